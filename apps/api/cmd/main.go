@@ -9,10 +9,12 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // 内嵌时区数据库，精简镜像中也能加载 IANA 时区
 
 	"github.com/go-chi/chi/v5"
 	"github.com/zeroicey/lifetrack-api/internal/app"
 	"github.com/zeroicey/lifetrack-api/internal/middleware"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
 )
 
 func main() {
@@ -25,9 +27,17 @@ func main() {
 	logger := APP.Logger
 	defer APP.Close()
 
+	trustedProxies, err := pkg.ParseTrustedProxies(APP.Config.TrustedProxies)
+	if err != nil {
+		logger.Sugar().Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+
 	// Initialize router
 	r := chi.NewRouter()
 
+	// 先解析客户端 IP，日志、限流和会话记录都使用解析结果
+	r.Use(middleware.RealIP(trustedProxies))
+
 	// Initialize middleware logger
 	r.Use(middleware.Logger(logger))
 	r.Use(middleware.Cors())
@@ -7,6 +7,7 @@ CREATE TABLE
         birthday DATE NOT NULL,
         avatar_base64 TEXT NOT NULL,
         bio TEXT NOT NULL,
+        timezone TEXT NOT NULL DEFAULT 'UTC',
         created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
         updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
     );
@@ -32,6 +33,8 @@ COMMENT ON COLUMN users.avatar_base64 IS '头像Base64编码数据';
 
 COMMENT ON COLUMN users.bio IS '用户简介';
 
+COMMENT ON COLUMN users.timezone IS '用户的 IANA 时区，用于日期边界和邮件中的时间显示';
+
 COMMENT ON COLUMN users.created_at IS '创建时间';
 
 COMMENT ON COLUMN users.updated_at IS '更新时间';
\ No newline at end of file
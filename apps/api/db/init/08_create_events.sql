@@ -6,10 +6,18 @@ CREATE TABLE
         description TEXT NOT NULL,
         start_time timestamptz NOT NULL,
         end_time timestamptz NOT NULL,
+        rrule TEXT NOT NULL DEFAULT '',
+        exdates timestamptz[] NOT NULL DEFAULT '{}',
+        uid TEXT NOT NULL DEFAULT '',
+        timezone TEXT NOT NULL DEFAULT 'UTC',
         created_at timestamptz NOT NULL DEFAULT NOW (),
         updated_at timestamptz NOT NULL DEFAULT NOW ()
     );
 
+-- 只对非空 UID 保持唯一，用于 iCalendar 导入去重
+CREATE UNIQUE INDEX idx_events_uid ON events (uid)
+WHERE uid <> '';
+
 CREATE TRIGGER events_updated_at_trigger BEFORE
 UPDATE ON events FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
 
@@ -25,6 +33,14 @@ COMMENT ON COLUMN events.start_time IS '事件的开始时间';
 
 COMMENT ON COLUMN events.end_time IS '事件的结束时间';
 
+COMMENT ON COLUMN events.rrule IS '重复规则 (RFC 5545 RRULE)，为空表示不重复';
+
+COMMENT ON COLUMN events.exdates IS '重复事件中被排除的实例开始时间 (EXDATE)';
+
+COMMENT ON COLUMN events.uid IS 'iCalendar UID，导入的事件保留来源 UID，为空时导出使用默认 UID';
+
+COMMENT ON COLUMN events.timezone IS '事件所在的 IANA 时区，重复规则按该时区展开';
+
 COMMENT ON COLUMN events.created_at IS '记录创建时间';
 
 COMMENT ON COLUMN events.updated_at IS '记录最后更新时间';
\ No newline at end of file
@@ -3,7 +3,9 @@ CREATE TABLE
         id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
         event_id BIGINT NOT NULL REFERENCES events (id) ON DELETE CASCADE,
         remind_before INTEGER NOT NULL,
+        channel TEXT NOT NULL DEFAULT 'email',
         notified BOOLEAN NOT NULL DEFAULT FALSE,
+        last_notified_occurrence TIMESTAMPTZ,
         created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
     );
 
@@ -15,6 +17,10 @@ COMMENT ON COLUMN event_reminders.event_id IS '关联的事件ID';
 
 COMMENT ON COLUMN event_reminders.remind_before IS '提醒前的时间间隔（单位：分）';
 
+COMMENT ON COLUMN event_reminders.channel IS '提醒渠道，如 email、webhook、push、telegram';
+
 COMMENT ON COLUMN event_reminders.notified IS '是否已通知';
 
+COMMENT ON COLUMN event_reminders.last_notified_occurrence IS '重复事件中最近一次已提醒实例的原始开始时间';
+
 COMMENT ON COLUMN event_reminders.created_at IS '创建时间';
\ No newline at end of file
@@ -0,0 +1,39 @@
+CREATE TABLE
+    IF NOT EXISTS event_overrides (
+        id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+        event_id BIGINT NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+        recurrence_id timestamptz NOT NULL,
+        name TEXT NOT NULL,
+        place TEXT NOT NULL,
+        description TEXT NOT NULL,
+        start_time timestamptz NOT NULL,
+        end_time timestamptz NOT NULL,
+        created_at timestamptz NOT NULL DEFAULT NOW (),
+        updated_at timestamptz NOT NULL DEFAULT NOW (),
+        UNIQUE (event_id, recurrence_id)
+    );
+
+CREATE TRIGGER event_overrides_updated_at_trigger BEFORE
+UPDATE ON event_overrides FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+COMMENT ON TABLE event_overrides IS '重复事件的单次实例修改 (RECURRENCE-ID)';
+
+COMMENT ON COLUMN event_overrides.id IS '主键，自增ID';
+
+COMMENT ON COLUMN event_overrides.event_id IS '关联的重复事件ID';
+
+COMMENT ON COLUMN event_overrides.recurrence_id IS '被修改实例的原始开始时间';
+
+COMMENT ON COLUMN event_overrides.name IS '该实例的名称';
+
+COMMENT ON COLUMN event_overrides.place IS '该实例的地点';
+
+COMMENT ON COLUMN event_overrides.description IS '该实例的描述';
+
+COMMENT ON COLUMN event_overrides.start_time IS '该实例的开始时间';
+
+COMMENT ON COLUMN event_overrides.end_time IS '该实例的结束时间';
+
+COMMENT ON COLUMN event_overrides.created_at IS '记录创建时间';
+
+COMMENT ON COLUMN event_overrides.updated_at IS '记录最后更新时间';
@@ -0,0 +1,60 @@
+CREATE TABLE
+    IF NOT EXISTS reminder_deliveries (
+        id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+        reminder_id BIGINT NOT NULL REFERENCES event_reminders (id) ON DELETE CASCADE,
+        event_id BIGINT NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+        occurrence timestamptz NOT NULL,
+        channel TEXT NOT NULL,
+        title TEXT NOT NULL,
+        body TEXT NOT NULL,
+        html TEXT NOT NULL DEFAULT '',
+        status VARCHAR(20) NOT NULL DEFAULT 'pending',
+        CONSTRAINT chk_reminder_delivery_status CHECK (status IN ('pending', 'sent', 'failed')),
+        attempts INTEGER NOT NULL DEFAULT 0,
+        next_attempt_at timestamptz NOT NULL DEFAULT NOW (),
+        last_error TEXT NOT NULL DEFAULT '',
+        sent_at timestamptz,
+        created_at timestamptz NOT NULL DEFAULT NOW (),
+        updated_at timestamptz NOT NULL DEFAULT NOW (),
+        UNIQUE (reminder_id, occurrence)
+    );
+
+-- 投递任务按下次尝试时间扫描 pending 记录
+CREATE INDEX idx_reminder_deliveries_pending
+ON reminder_deliveries (next_attempt_at)
+WHERE status = 'pending';
+
+CREATE TRIGGER reminder_deliveries_updated_at_trigger BEFORE
+UPDATE ON reminder_deliveries FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+COMMENT ON TABLE reminder_deliveries IS '提醒投递发件箱，每条记录对应一次提醒（重复事件为每次实例）';
+
+COMMENT ON COLUMN reminder_deliveries.id IS '主键，自增ID';
+
+COMMENT ON COLUMN reminder_deliveries.reminder_id IS '关联的提醒ID';
+
+COMMENT ON COLUMN reminder_deliveries.event_id IS '关联的事件ID';
+
+COMMENT ON COLUMN reminder_deliveries.occurrence IS '被提醒实例的原始开始时间';
+
+COMMENT ON COLUMN reminder_deliveries.channel IS '投递渠道';
+
+COMMENT ON COLUMN reminder_deliveries.title IS '通知标题';
+
+COMMENT ON COLUMN reminder_deliveries.body IS '通知正文';
+
+COMMENT ON COLUMN reminder_deliveries.html IS '通知的 HTML 正文，仅邮件渠道使用，为空时只发送纯文本';
+
+COMMENT ON COLUMN reminder_deliveries.status IS '投递状态 (pending, sent, failed)，failed 表示超过最大重试次数';
+
+COMMENT ON COLUMN reminder_deliveries.attempts IS '已尝试次数';
+
+COMMENT ON COLUMN reminder_deliveries.next_attempt_at IS '下次尝试时间';
+
+COMMENT ON COLUMN reminder_deliveries.last_error IS '最近一次失败的错误信息';
+
+COMMENT ON COLUMN reminder_deliveries.sent_at IS '投递成功时间';
+
+COMMENT ON COLUMN reminder_deliveries.created_at IS '记录创建时间';
+
+COMMENT ON COLUMN reminder_deliveries.updated_at IS '记录最后更新时间';
@@ -0,0 +1,25 @@
+CREATE TABLE
+    IF NOT EXISTS reminder_delivery_attempts (
+        id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+        delivery_id BIGINT NOT NULL REFERENCES reminder_deliveries (id) ON DELETE CASCADE,
+        attempt INTEGER NOT NULL,
+        success BOOLEAN NOT NULL,
+        error TEXT NOT NULL DEFAULT '',
+        attempted_at timestamptz NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX idx_reminder_delivery_attempts_delivery_id ON reminder_delivery_attempts (delivery_id);
+
+COMMENT ON TABLE reminder_delivery_attempts IS '提醒投递的每次尝试记录';
+
+COMMENT ON COLUMN reminder_delivery_attempts.id IS '主键，自增ID';
+
+COMMENT ON COLUMN reminder_delivery_attempts.delivery_id IS '关联的投递记录ID';
+
+COMMENT ON COLUMN reminder_delivery_attempts.attempt IS '第几次尝试，手动重试后重新计数';
+
+COMMENT ON COLUMN reminder_delivery_attempts.success IS '是否投递成功';
+
+COMMENT ON COLUMN reminder_delivery_attempts.error IS '失败时的错误信息';
+
+COMMENT ON COLUMN reminder_delivery_attempts.attempted_at IS '尝试时间';
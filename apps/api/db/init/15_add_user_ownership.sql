@@ -0,0 +1,87 @@
+-- 为业务数据增加所有者，实现多用户之间的数据隔离
+-- users 表在 moments 之后创建，因此所有者列统一在这里追加
+-- 已有数据归属于最早注册的用户（此前为单用户系统）
+
+ALTER TABLE moments ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users (id) ON DELETE CASCADE;
+
+UPDATE moments SET user_id = (SELECT MIN(id) FROM users) WHERE user_id IS NULL;
+
+ALTER TABLE moments ALTER COLUMN user_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_moments_user_id ON moments (user_id, created_at DESC);
+
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users (id) ON DELETE CASCADE;
+
+UPDATE attachments SET user_id = (SELECT MIN(id) FROM users) WHERE user_id IS NULL;
+
+ALTER TABLE attachments ALTER COLUMN user_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_attachments_user_id ON attachments (user_id);
+
+ALTER TABLE task_groups ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users (id) ON DELETE CASCADE;
+
+UPDATE task_groups SET user_id = (SELECT MIN(id) FROM users) WHERE user_id IS NULL;
+
+ALTER TABLE task_groups ALTER COLUMN user_id SET NOT NULL;
+
+-- 任务组名称只在同一用户内唯一
+ALTER TABLE task_groups DROP CONSTRAINT IF EXISTS task_groups_name_key;
+
+ALTER TABLE task_groups ADD CONSTRAINT task_groups_user_id_name_key UNIQUE (user_id, name);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users (id) ON DELETE CASCADE;
+
+UPDATE tasks SET user_id = (SELECT MIN(id) FROM users) WHERE user_id IS NULL;
+
+ALTER TABLE tasks ALTER COLUMN user_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_id ON tasks (user_id);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users (id) ON DELETE CASCADE;
+
+UPDATE events SET user_id = (SELECT MIN(id) FROM users) WHERE user_id IS NULL;
+
+ALTER TABLE events ALTER COLUMN user_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_events_user_id ON events (user_id, start_time);
+
+-- iCalendar UID 只在同一用户内唯一，不同用户可以导入同一个日历
+DROP INDEX IF EXISTS idx_events_uid;
+
+CREATE UNIQUE INDEX idx_events_user_id_uid ON events (user_id, uid)
+WHERE uid <> '';
+
+ALTER TABLE habits ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users (id) ON DELETE CASCADE;
+
+UPDATE habits SET user_id = (SELECT MIN(id) FROM users) WHERE user_id IS NULL;
+
+ALTER TABLE habits ALTER COLUMN user_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_habits_user_id ON habits (user_id);
+
+ALTER TABLE habit_logs ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users (id) ON DELETE CASCADE;
+
+UPDATE habit_logs SET user_id = (SELECT MIN(id) FROM users) WHERE user_id IS NULL;
+
+ALTER TABLE habit_logs ALTER COLUMN user_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_habit_logs_user_id ON habit_logs (user_id, happened_at DESC);
+
+-- 提醒投递按事件所有者的邮箱发送，入队时记录收件人
+ALTER TABLE reminder_deliveries ADD COLUMN IF NOT EXISTS recipient TEXT NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN moments.user_id IS '所有者用户ID';
+
+COMMENT ON COLUMN attachments.user_id IS '上传者用户ID';
+
+COMMENT ON COLUMN task_groups.user_id IS '所有者用户ID';
+
+COMMENT ON COLUMN tasks.user_id IS '所有者用户ID';
+
+COMMENT ON COLUMN events.user_id IS '所有者用户ID';
+
+COMMENT ON COLUMN habits.user_id IS '所有者用户ID';
+
+COMMENT ON COLUMN habit_logs.user_id IS '所有者用户ID';
+
+COMMENT ON COLUMN reminder_deliveries.recipient IS '收件人地址，仅邮件渠道使用，为空时使用默认收件人';
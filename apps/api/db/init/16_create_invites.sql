@@ -0,0 +1,31 @@
+CREATE TABLE
+    IF NOT EXISTS invites (
+        id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+        code_hash TEXT NOT NULL UNIQUE,
+        email TEXT NOT NULL DEFAULT '',
+        created_by BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        used_by BIGINT REFERENCES users (id) ON DELETE SET NULL,
+        expires_at TIMESTAMPTZ NOT NULL,
+        used_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX idx_invites_created_by ON invites (created_by, created_at DESC);
+
+COMMENT ON TABLE invites IS '注册邀请码，邀请制注册模式下新用户需要持有未使用且未过期的邀请码';
+
+COMMENT ON COLUMN invites.id IS '主键，自增ID';
+
+COMMENT ON COLUMN invites.code_hash IS '邀请码的 SHA-256 哈希，明文只在创建时返回一次';
+
+COMMENT ON COLUMN invites.email IS '限定使用该邀请码的邮箱，为空表示不限';
+
+COMMENT ON COLUMN invites.created_by IS '创建邀请的用户ID';
+
+COMMENT ON COLUMN invites.used_by IS '使用邀请注册的用户ID';
+
+COMMENT ON COLUMN invites.expires_at IS '过期时间';
+
+COMMENT ON COLUMN invites.used_at IS '使用时间';
+
+COMMENT ON COLUMN invites.created_at IS '创建时间';
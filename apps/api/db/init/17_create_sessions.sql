@@ -0,0 +1,41 @@
+CREATE TABLE
+    IF NOT EXISTS sessions (
+        id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+        user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        refresh_token_hash TEXT NOT NULL UNIQUE,
+        previous_refresh_token_hash TEXT NOT NULL DEFAULT '',
+        user_agent TEXT NOT NULL DEFAULT '',
+        ip_address TEXT NOT NULL DEFAULT '',
+        expires_at timestamptz NOT NULL,
+        revoked_at timestamptz,
+        last_used_at timestamptz NOT NULL DEFAULT NOW (),
+        created_at timestamptz NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX idx_sessions_user_id ON sessions (user_id, last_used_at DESC);
+
+-- 用于检测已轮换的 refresh token 被再次使用
+CREATE INDEX idx_sessions_previous_refresh_token_hash ON sessions (previous_refresh_token_hash)
+WHERE previous_refresh_token_hash <> '';
+
+COMMENT ON TABLE sessions IS '登录会话，每次登录创建一条，access token 的 jti 为会话ID，撤销后该会话的 token 全部失效';
+
+COMMENT ON COLUMN sessions.id IS '会话ID (UUID)，同时作为 access token 的 jti';
+
+COMMENT ON COLUMN sessions.user_id IS '所属用户ID';
+
+COMMENT ON COLUMN sessions.refresh_token_hash IS '当前 refresh token 的 SHA-256 哈希，每次刷新都会轮换';
+
+COMMENT ON COLUMN sessions.previous_refresh_token_hash IS '上一个 refresh token 的哈希，再次出现时视为泄露并撤销会话';
+
+COMMENT ON COLUMN sessions.user_agent IS '登录时的 User-Agent';
+
+COMMENT ON COLUMN sessions.ip_address IS '最近一次登录或刷新的客户端 IP';
+
+COMMENT ON COLUMN sessions.expires_at IS 'refresh token 过期时间，每次刷新顺延';
+
+COMMENT ON COLUMN sessions.revoked_at IS '撤销时间，为空表示会话有效';
+
+COMMENT ON COLUMN sessions.last_used_at IS '最近一次刷新时间';
+
+COMMENT ON COLUMN sessions.created_at IS '创建时间';
@@ -0,0 +1,34 @@
+CREATE TABLE
+    IF NOT EXISTS api_tokens (
+        id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+        user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        name TEXT NOT NULL,
+        token_prefix TEXT NOT NULL,
+        token_hash TEXT NOT NULL UNIQUE,
+        scopes TEXT[] NOT NULL DEFAULT '{}',
+        expires_at timestamptz,
+        last_used_at timestamptz,
+        created_at timestamptz NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX idx_api_tokens_user_id ON api_tokens (user_id, created_at DESC);
+
+COMMENT ON TABLE api_tokens IS '个人访问令牌，供脚本和第三方集成调用 API，按 scope 限制可访问的资源';
+
+COMMENT ON COLUMN api_tokens.id IS '主键，自增ID';
+
+COMMENT ON COLUMN api_tokens.user_id IS '所属用户ID';
+
+COMMENT ON COLUMN api_tokens.name IS '令牌名称，便于用户区分用途';
+
+COMMENT ON COLUMN api_tokens.token_prefix IS '令牌明文的前几位，用于在列表中识别令牌';
+
+COMMENT ON COLUMN api_tokens.token_hash IS '令牌的 SHA-256 哈希，明文只在创建时返回一次';
+
+COMMENT ON COLUMN api_tokens.scopes IS '授权范围，例如 read、write、habits:write';
+
+COMMENT ON COLUMN api_tokens.expires_at IS '过期时间，为空表示永不过期';
+
+COMMENT ON COLUMN api_tokens.last_used_at IS '最近一次使用时间';
+
+COMMENT ON COLUMN api_tokens.created_at IS '创建时间';
@@ -0,0 +1,45 @@
+CREATE TABLE
+    IF NOT EXISTS user_totp (
+        user_id BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+        secret TEXT NOT NULL,
+        enabled BOOLEAN NOT NULL DEFAULT FALSE,
+        last_used_step BIGINT NOT NULL DEFAULT 0,
+        enabled_at timestamptz,
+        created_at timestamptz NOT NULL DEFAULT NOW ()
+    );
+
+COMMENT ON TABLE user_totp IS '用户的 TOTP 两步验证配置，enabled 为 FALSE 表示正在绑定、尚未验证';
+
+COMMENT ON COLUMN user_totp.user_id IS '用户ID';
+
+COMMENT ON COLUMN user_totp.secret IS 'Base32 编码的 TOTP 密钥';
+
+COMMENT ON COLUMN user_totp.enabled IS '是否已通过验证并启用';
+
+COMMENT ON COLUMN user_totp.last_used_step IS '最近一次成功验证的时间步，同一时间步的验证码不能重复使用';
+
+COMMENT ON COLUMN user_totp.enabled_at IS '启用时间';
+
+COMMENT ON COLUMN user_totp.created_at IS '创建时间';
+
+CREATE TABLE
+    IF NOT EXISTS user_recovery_codes (
+        id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+        user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        code_hash TEXT NOT NULL,
+        used_at timestamptz,
+        created_at timestamptz NOT NULL DEFAULT NOW (),
+        UNIQUE (user_id, code_hash)
+    );
+
+COMMENT ON TABLE user_recovery_codes IS '两步验证的一次性恢复码，无法使用验证器时代替 TOTP 验证码登录';
+
+COMMENT ON COLUMN user_recovery_codes.id IS '主键，自增ID';
+
+COMMENT ON COLUMN user_recovery_codes.user_id IS '用户ID';
+
+COMMENT ON COLUMN user_recovery_codes.code_hash IS '恢复码的 SHA-256 哈希，明文只在生成时返回一次';
+
+COMMENT ON COLUMN user_recovery_codes.used_at IS '使用时间，为空表示未使用';
+
+COMMENT ON COLUMN user_recovery_codes.created_at IS '创建时间';
@@ -0,0 +1,39 @@
+-- 邮箱验证状态，已有用户视为已验证
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at timestamptz;
+
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+COMMENT ON COLUMN users.email_verified_at IS '邮箱验证时间，为空表示尚未验证';
+
+CREATE TABLE
+    IF NOT EXISTS account_tokens (
+        id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+        user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        purpose VARCHAR(32) NOT NULL,
+        CONSTRAINT chk_account_token_purpose CHECK (purpose IN ('password_reset', 'email_verification')),
+        token_hash TEXT NOT NULL UNIQUE,
+        email TEXT NOT NULL DEFAULT '',
+        expires_at timestamptz NOT NULL,
+        used_at timestamptz,
+        created_at timestamptz NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX idx_account_tokens_user_id ON account_tokens (user_id, purpose);
+
+COMMENT ON TABLE account_tokens IS '通过邮件发送的一次性账户令牌，用于重置密码和验证邮箱';
+
+COMMENT ON COLUMN account_tokens.id IS '主键，自增ID';
+
+COMMENT ON COLUMN account_tokens.user_id IS '所属用户ID';
+
+COMMENT ON COLUMN account_tokens.purpose IS '用途 (password_reset, email_verification)';
+
+COMMENT ON COLUMN account_tokens.token_hash IS '令牌的 SHA-256 哈希，明文只出现在邮件链接中';
+
+COMMENT ON COLUMN account_tokens.email IS '待验证的邮箱地址，仅邮箱验证使用，修改邮箱时为新地址';
+
+COMMENT ON COLUMN account_tokens.expires_at IS '过期时间';
+
+COMMENT ON COLUMN account_tokens.used_at IS '使用时间，为空表示未使用';
+
+COMMENT ON COLUMN account_tokens.created_at IS '创建时间';
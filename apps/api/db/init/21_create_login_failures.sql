@@ -0,0 +1,17 @@
+CREATE TABLE
+    IF NOT EXISTS login_failures (
+        email TEXT PRIMARY KEY,
+        failed_count INT NOT NULL DEFAULT 0,
+        locked_until timestamptz,
+        last_failed_at timestamptz NOT NULL DEFAULT NOW ()
+    );
+
+COMMENT ON TABLE login_failures IS '登录失败记录，按邮箱统计连续失败次数，超过阈值后逐步延长锁定时间，登录成功后删除';
+
+COMMENT ON COLUMN login_failures.email IS '登录邮箱（小写），不要求对应的用户存在';
+
+COMMENT ON COLUMN login_failures.failed_count IS '连续失败次数，距上次失败超过统计窗口后重新计数';
+
+COMMENT ON COLUMN login_failures.locked_until IS '锁定截止时间，为空或已过期表示未锁定';
+
+COMMENT ON COLUMN login_failures.last_failed_at IS '最近一次失败时间';
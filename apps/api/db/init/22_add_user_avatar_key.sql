@@ -0,0 +1,8 @@
+-- 头像改为存储在对象存储中，avatar_base64 只保留给启动时迁移旧数据使用
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_key TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE users ALTER COLUMN avatar_base64 SET DEFAULT '';
+
+COMMENT ON COLUMN users.avatar_base64 IS '已废弃：旧版内联头像的 Base64 数据，启动时迁移到对象存储后清空';
+
+COMMENT ON COLUMN users.avatar_key IS '头像在对象存储中的前缀，各尺寸的对象为 <avatar_key>/<尺寸>.jpg，为空表示未设置头像';
@@ -0,0 +1,8 @@
+-- 全文搜索：使用 simple 配置，不做词干提取，只按空白和标点分词
+-- 中日韩文本没有空格，连续的文字会成为一个词，只能整段或按前缀匹配
+ALTER TABLE moments
+ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_moments_search_vector ON moments USING GIN (search_vector);
+
+COMMENT ON COLUMN moments.search_vector IS '由 content 生成的全文搜索向量';
@@ -0,0 +1,22 @@
+CREATE TABLE
+    IF NOT EXISTS moment_revisions (
+        id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+        moment_id BIGINT NOT NULL REFERENCES moments (id) ON DELETE CASCADE,
+        content TEXT NOT NULL,
+        attachments JSONB NOT NULL DEFAULT '[]',
+        created_at timestamptz NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX idx_moment_revisions_moment_id ON moment_revisions (moment_id, id DESC);
+
+COMMENT ON TABLE moment_revisions IS 'moment 的历史版本，每次编辑或恢复前保存修改前的内容和附件';
+
+COMMENT ON COLUMN moment_revisions.id IS '主键，自增ID';
+
+COMMENT ON COLUMN moment_revisions.moment_id IS '所属 moment ID';
+
+COMMENT ON COLUMN moment_revisions.content IS '修改前的文本内容';
+
+COMMENT ON COLUMN moment_revisions.attachments IS '修改前的附件列表，格式为 [{"attachment_id": "...", "position": 0}]';
+
+COMMENT ON COLUMN moment_revisions.created_at IS '保存时间，即这次修改的时间';
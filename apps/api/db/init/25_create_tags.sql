@@ -0,0 +1,59 @@
+CREATE TABLE
+    IF NOT EXISTS tags (
+        id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+        user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        name TEXT NOT NULL,
+        created_at timestamptz NOT NULL DEFAULT NOW (),
+        UNIQUE (user_id, name)
+    );
+
+COMMENT ON TABLE tags IS '从 moment 内容中解析出的话题标签（#reading），每个用户独立';
+
+COMMENT ON COLUMN tags.id IS '主键，自增ID';
+
+COMMENT ON COLUMN tags.user_id IS '所属用户ID';
+
+COMMENT ON COLUMN tags.name IS '标签名，小写且不含 #';
+
+COMMENT ON COLUMN tags.created_at IS '首次使用时间';
+
+CREATE TABLE
+    IF NOT EXISTS moment_tags (
+        moment_id BIGINT NOT NULL REFERENCES moments (id) ON DELETE CASCADE,
+        tag_id BIGINT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+        PRIMARY KEY (moment_id, tag_id)
+    );
+
+CREATE INDEX idx_moment_tags_tag_id ON moment_tags (tag_id);
+
+COMMENT ON TABLE moment_tags IS '连接 moments 和 tags 的多对多联结表，moment 创建、编辑时按内容重新生成';
+
+COMMENT ON COLUMN moment_tags.moment_id IS '关联的 Moment ID';
+
+COMMENT ON COLUMN moment_tags.tag_id IS '关联的标签ID';
+
+-- 为已有的 moment 生成标签，规则与 moment.ExtractHashtags 一致
+WITH
+    parsed AS (
+        SELECT DISTINCT
+            m.id AS moment_id,
+            m.user_id,
+            lower(match[1]) AS name
+        FROM
+            moments m,
+            regexp_matches(m.content, '(?:^|[^[:alnum:]_/])#([[:alnum:]_]+)', 'g') AS match
+        WHERE
+            length(match[1]) <= 64
+            AND match[1] ~ '[[:alpha:]]'
+    ),
+    inserted AS (
+        INSERT INTO tags (user_id, name)
+        SELECT DISTINCT user_id, name FROM parsed
+        ON CONFLICT (user_id, name) DO NOTHING
+        RETURNING id, user_id, name
+    )
+INSERT INTO moment_tags (moment_id, tag_id)
+SELECT p.moment_id, i.id
+FROM parsed p
+JOIN inserted i ON i.user_id = p.user_id AND i.name = p.name
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,20 @@
+CREATE TABLE
+    IF NOT EXISTS memory_digest_settings (
+        user_id BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+        enabled BOOLEAN NOT NULL DEFAULT FALSE,
+        send_hour SMALLINT NOT NULL DEFAULT 8 CHECK (send_hour BETWEEN 0 AND 23),
+        last_sent_on DATE,
+        updated_at timestamptz NOT NULL DEFAULT NOW ()
+    );
+
+COMMENT ON TABLE memory_digest_settings IS '那年今日晨间邮件的订阅设置，没有记录表示未订阅';
+
+COMMENT ON COLUMN memory_digest_settings.user_id IS '所属用户ID';
+
+COMMENT ON COLUMN memory_digest_settings.enabled IS '是否发送那年今日邮件';
+
+COMMENT ON COLUMN memory_digest_settings.send_hour IS '发送时间（用户时区的小时，0-23）';
+
+COMMENT ON COLUMN memory_digest_settings.last_sent_on IS '最近一次发送对应的用户本地日期，用于保证每天最多发送一次';
+
+COMMENT ON COLUMN memory_digest_settings.updated_at IS '设置最后修改时间';
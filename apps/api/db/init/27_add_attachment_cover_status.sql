@@ -0,0 +1,23 @@
+-- 封面改为由服务端在上传完成后生成，客户端仍可自行上传封面（此时直接为 ready）
+-- 已有记录的封面都由客户端上传，默认为 ready
+ALTER TABLE attachments
+ADD COLUMN IF NOT EXISTS cover_status VARCHAR(20) NOT NULL DEFAULT 'ready',
+ADD COLUMN IF NOT EXISTS cover_sizes INT[] NOT NULL DEFAULT '{}',
+ADD COLUMN IF NOT EXISTS cover_attempts INT NOT NULL DEFAULT 0;
+
+ALTER TABLE attachments
+ADD CONSTRAINT chk_cover_status CHECK (cover_status IN ('pending', 'processing', 'ready', 'failed', 'unsupported'));
+
+-- 后台任务按创建时间领取待生成封面的附件
+CREATE INDEX IF NOT EXISTS idx_attachments_cover_pending ON attachments (created_at)
+WHERE cover_status IN ('pending', 'processing');
+
+COMMENT ON COLUMN attachments.cover_object_key IS '封面在对象存储中的键，服务端生成时为默认尺寸的封面，封面未就绪时为空';
+
+COMMENT ON COLUMN attachments.cover_md5 IS '封面内容的 MD5 哈希值，客户端上传封面时用于校验';
+
+COMMENT ON COLUMN attachments.cover_status IS '封面状态：pending 等待生成、processing 生成中、ready 可用、failed 生成失败、unsupported 不支持的文件类型';
+
+COMMENT ON COLUMN attachments.cover_sizes IS '服务端生成的封面尺寸（长边像素），各尺寸的对象为 <object_key 去掉扩展名>.cover-<尺寸>.jpg，客户端上传的封面为空';
+
+COMMENT ON COLUMN attachments.cover_attempts IS '封面生成的尝试次数，超过上限后标记为 failed';
@@ -0,0 +1,12 @@
+-- 过期的上传记录改由定时回收任务连同对象一起删除，原触发器只删除记录，对象会永远留在存储桶中
+DROP TRIGGER IF EXISTS auto_cleanup_expired_uploads ON attachments;
+
+DROP FUNCTION IF EXISTS trigger_cleanup_expired_uploads ();
+
+DROP FUNCTION IF EXISTS cleanup_expired_uploads ();
+
+-- 回收附件时按最后更新时间查找超过宽限期的记录
+CREATE INDEX IF NOT EXISTS idx_attachments_updated_at ON attachments (updated_at);
+
+-- 回收附件时检查历史版本是否仍引用该附件
+CREATE INDEX IF NOT EXISTS idx_moment_revisions_attachments ON moment_revisions USING GIN (attachments jsonb_path_ops);
@@ -0,0 +1,15 @@
+-- 大文件改为分片上传，分片上传的对象 ETag 不是 MD5，完整性改用客户端提供的 SHA-256 校验
+ALTER TABLE attachments
+ADD COLUMN IF NOT EXISTS sha256 VARCHAR(64) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS upload_id VARCHAR(1024) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS part_size BIGINT NOT NULL DEFAULT 0;
+
+-- 分片上传前按 SHA-256 检查是否已经上传过相同的文件
+CREATE INDEX IF NOT EXISTS idx_attachments_sha256_completed ON attachments (user_id, sha256)
+WHERE status = 'completed' AND sha256 <> '';
+
+COMMENT ON COLUMN attachments.sha256 IS '文件内容的 SHA-256 哈希值，分片上传完成时用于校验，单次上传的附件为空';
+
+COMMENT ON COLUMN attachments.upload_id IS '对象存储的分片上传 ID，分片合并后清空，单次上传的附件为空';
+
+COMMENT ON COLUMN attachments.part_size IS '分片大小（字节），最后一个分片可以更小，单次上传的附件为 0';
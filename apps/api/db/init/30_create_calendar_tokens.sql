@@ -0,0 +1,20 @@
+CREATE TABLE
+    IF NOT EXISTS calendar_tokens (
+        user_id BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+        token_prefix TEXT NOT NULL,
+        token_hash TEXT NOT NULL UNIQUE,
+        last_used_at timestamptz,
+        created_at timestamptz NOT NULL DEFAULT NOW ()
+    );
+
+COMMENT ON TABLE calendar_tokens IS '日历订阅令牌，每个用户最多一个，只能读取 .ics 订阅源，重新生成或删除后旧地址立即失效';
+
+COMMENT ON COLUMN calendar_tokens.user_id IS '所属用户ID';
+
+COMMENT ON COLUMN calendar_tokens.token_prefix IS '令牌明文的前几位，用于识别当前订阅地址';
+
+COMMENT ON COLUMN calendar_tokens.token_hash IS '令牌的 SHA-256 哈希，明文只在生成时返回一次';
+
+COMMENT ON COLUMN calendar_tokens.last_used_at IS '日历客户端最近一次拉取订阅源的时间';
+
+COMMENT ON COLUMN calendar_tokens.created_at IS '生成时间';
@@ -0,0 +1,12 @@
+-- 投递前先把记录领取为 sending，调度器和手动重试不会同时投递同一条记录
+ALTER TABLE reminder_deliveries
+DROP CONSTRAINT IF EXISTS chk_reminder_delivery_status;
+
+ALTER TABLE reminder_deliveries
+ADD CONSTRAINT chk_reminder_delivery_status CHECK (status IN ('pending', 'sending', 'sent', 'failed'));
+
+-- 调度器按领取时间找回实例退出后遗留的 sending 记录
+CREATE INDEX IF NOT EXISTS idx_reminder_deliveries_sending ON reminder_deliveries (updated_at)
+WHERE status = 'sending';
+
+COMMENT ON COLUMN reminder_deliveries.status IS '投递状态 (pending, sending, sent, failed)，sending 表示已被领取正在投递，failed 表示超过最大重试次数';
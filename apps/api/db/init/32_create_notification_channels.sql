@@ -0,0 +1,31 @@
+CREATE TABLE
+    IF NOT EXISTS notification_channels (
+        user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        channel VARCHAR(20) NOT NULL,
+        CONSTRAINT chk_notification_channel CHECK (channel IN ('webhook', 'push', 'telegram')),
+        provider TEXT NOT NULL DEFAULT '',
+        target TEXT NOT NULL,
+        secret TEXT NOT NULL DEFAULT '',
+        created_at timestamptz NOT NULL DEFAULT NOW (),
+        updated_at timestamptz NOT NULL DEFAULT NOW (),
+        PRIMARY KEY (user_id, channel)
+    );
+
+CREATE TRIGGER notification_channels_updated_at_trigger BEFORE
+UPDATE ON notification_channels FOR EACH ROW EXECUTE FUNCTION update_updated_at_column ();
+
+COMMENT ON TABLE notification_channels IS '用户自己配置的提醒渠道，提醒只会投递到事件所有者配置的地址，邮件渠道不需要配置';
+
+COMMENT ON COLUMN notification_channels.user_id IS '所属用户ID';
+
+COMMENT ON COLUMN notification_channels.channel IS '渠道名称 (webhook, push, telegram)';
+
+COMMENT ON COLUMN notification_channels.provider IS '推送服务 (ntfy, gotify)，仅 push 渠道使用';
+
+COMMENT ON COLUMN notification_channels.target IS '投递目标，webhook 和 push 为请求地址，telegram 为 chat id';
+
+COMMENT ON COLUMN notification_channels.secret IS 'webhook 签名密钥、push 访问令牌或 telegram bot token，不会通过接口返回';
+
+COMMENT ON COLUMN notification_channels.created_at IS '创建时间';
+
+COMMENT ON COLUMN notification_channels.updated_at IS '最后修改时间';
@@ -0,0 +1,25 @@
+CREATE TABLE
+    IF NOT EXISTS two_factor_challenges (
+        id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+        user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        attempts INT NOT NULL DEFAULT 0,
+        used_at timestamptz,
+        expires_at timestamptz NOT NULL,
+        created_at timestamptz NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX idx_two_factor_challenges_user_id ON two_factor_challenges (user_id, expires_at);
+
+COMMENT ON TABLE two_factor_challenges IS '两步验证登录的 challenge，密码验证通过后创建一条，验证码通过后标记为已使用，不能重复使用';
+
+COMMENT ON COLUMN two_factor_challenges.id IS 'challenge ID (UUID)，同时作为 challenge token 的 jti';
+
+COMMENT ON COLUMN two_factor_challenges.user_id IS '所属用户ID';
+
+COMMENT ON COLUMN two_factor_challenges.attempts IS '已提交验证码的次数，达到上限后 challenge 作废，需要重新输入密码登录';
+
+COMMENT ON COLUMN two_factor_challenges.used_at IS '验证通过的时间，为空表示尚未使用';
+
+COMMENT ON COLUMN two_factor_challenges.expires_at IS '过期时间，与 challenge token 的有效期一致';
+
+COMMENT ON COLUMN two_factor_challenges.created_at IS '创建时间';
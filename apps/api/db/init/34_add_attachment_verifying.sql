@@ -0,0 +1,17 @@
+-- 分片合并后不再在请求中读取整个对象校验 SHA-256，改为标记为 verifying 由后台任务校验，校验不通过标记为 failed
+ALTER TABLE attachments
+DROP CONSTRAINT IF EXISTS chk_status;
+
+ALTER TABLE attachments
+ADD CONSTRAINT chk_status CHECK (status IN ('uploading', 'verifying', 'completed', 'failed'));
+
+ALTER TABLE attachments
+ADD COLUMN IF NOT EXISTS verify_started_at timestamptz;
+
+-- 后台任务领取等待校验的附件
+CREATE INDEX IF NOT EXISTS idx_attachments_verifying ON attachments (created_at)
+WHERE status = 'verifying';
+
+COMMENT ON COLUMN attachments.status IS '文件上传状态 (uploading, verifying, completed, failed)，verifying 表示分片已合并、等待后台校验 SHA-256，failed 表示校验不通过';
+
+COMMENT ON COLUMN attachments.verify_started_at IS '后台任务领取校验的时间，为空表示等待领取，超时未完成（例如实例退出）时重新领取';
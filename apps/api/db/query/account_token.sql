@@ -0,0 +1,19 @@
+-- name: CreateAccountToken :one
+INSERT INTO account_tokens (user_id, purpose, token_hash, email, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- 原子地使用令牌，返回 ErrNoRows 表示令牌无效、已使用或已过期
+-- name: ConsumeAccountToken :one
+UPDATE account_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+    AND purpose = $2
+    AND used_at IS NULL
+    AND expires_at > NOW()
+RETURNING *;
+
+-- 签发新令牌或完成操作后，使同一用途的其他未使用令牌失效
+-- name: DeleteUnusedAccountTokens :exec
+DELETE FROM account_tokens
+WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL;
@@ -0,0 +1,28 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (user_id, name, token_prefix, token_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListAPITokensByUser :many
+SELECT * FROM api_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteAPIToken :execrows
+DELETE FROM api_tokens
+WHERE id = $1 AND user_id = $2;
+
+-- 修改或重置密码时撤销用户的所有个人访问令牌
+-- name: DeleteAPITokensByUser :exec
+DELETE FROM api_tokens
+WHERE user_id = $1;
+
+-- 校验令牌并记录使用时间，返回 ErrNoRows 表示令牌不存在或已过期
+-- name: TouchAPIToken :one
+UPDATE api_tokens
+SET last_used_at = NOW()
+FROM users
+WHERE api_tokens.token_hash = $1
+    AND (api_tokens.expires_at IS NULL OR api_tokens.expires_at > NOW())
+    AND users.id = api_tokens.user_id
+RETURNING api_tokens.id, api_tokens.user_id, api_tokens.scopes, users.email;
@@ -0,0 +1,25 @@
+-- 生成新令牌，已有令牌时替换，旧的订阅地址随之失效
+-- name: UpsertCalendarToken :one
+INSERT INTO calendar_tokens (user_id, token_prefix, token_hash)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET token_prefix = EXCLUDED.token_prefix,
+    token_hash = EXCLUDED.token_hash,
+    last_used_at = NULL,
+    created_at = NOW()
+RETURNING *;
+
+-- name: GetCalendarToken :one
+SELECT * FROM calendar_tokens
+WHERE user_id = $1;
+
+-- name: DeleteCalendarToken :execrows
+DELETE FROM calendar_tokens
+WHERE user_id = $1;
+
+-- 校验令牌并记录使用时间，返回 ErrNoRows 表示令牌不存在或已被撤销
+-- name: TouchCalendarToken :one
+UPDATE calendar_tokens
+SET last_used_at = NOW()
+WHERE token_hash = $1
+RETURNING user_id;
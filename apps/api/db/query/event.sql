@@ -6,14 +6,20 @@ SELECT
     e.description,
     e.start_time,
     e.end_time,
+    e.rrule,
+    e.exdates,
+    e.uid,
+    e.timezone,
     e.created_at,
     e.updated_at,
     er.id as reminder_id,
     er.remind_before,
+    er.channel as reminder_channel,
     er.notified,
     er.created_at as reminder_created_at
 FROM events e
 LEFT JOIN event_reminders er ON e.id = er.event_id
+WHERE e.user_id = $1
 ORDER BY e.start_time ASC, er.remind_before ASC;
 
 -- name: GetEventByID :one
@@ -24,15 +30,20 @@ SELECT
     e.description,
     e.start_time,
     e.end_time,
+    e.rrule,
+    e.exdates,
+    e.uid,
+    e.timezone,
     e.created_at,
     e.updated_at,
     er.id as reminder_id,
     er.remind_before,
+    er.channel as reminder_channel,
     er.notified,
     er.created_at as reminder_created_at
 FROM events e
 LEFT JOIN event_reminders er ON e.id = er.event_id
-WHERE e.id = $1
+WHERE e.id = $1 AND e.user_id = $2
 ORDER BY er.remind_before ASC;
 
 -- name: CreateEvent :one
@@ -41,11 +52,16 @@ INSERT INTO events (
     place,
     description,
     start_time,
-    end_time
+    end_time,
+    rrule,
+    exdates,
+    uid,
+    timezone,
+    user_id
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 )
-RETURNING id, name, place, description, start_time, end_time, created_at, updated_at;
+RETURNING id, name, place, description, start_time, end_time, rrule, exdates, uid, timezone, created_at, updated_at, user_id;
 
 -- name: UpdateEvent :one
 UPDATE events
@@ -54,13 +70,26 @@ SET
     place = $3,
     description = $4,
     start_time = $5,
-    end_time = $6
-WHERE id = $1
-RETURNING id, name, place, description, start_time, end_time, created_at, updated_at;
+    end_time = $6,
+    rrule = $7,
+    exdates = $8,
+    timezone = $9
+WHERE id = $1 AND user_id = $10
+RETURNING id, name, place, description, start_time, end_time, rrule, exdates, uid, timezone, created_at, updated_at, user_id;
+
+-- name: EventUIDExists :one
+SELECT EXISTS(
+    SELECT 1 FROM events WHERE uid = $1 AND user_id = $2
+) AS exists;
+
+-- name: EventExists :one
+SELECT EXISTS(
+    SELECT 1 FROM events WHERE id = $1 AND user_id = $2
+) AS exists;
 
 -- name: DeleteEvent :exec
 DELETE FROM events
-WHERE id = $1;
+WHERE id = $1 AND user_id = $2;
 
 -- name: GetEventsByDateRange :many
 SELECT 
@@ -70,50 +99,75 @@ SELECT
     e.description,
     e.start_time,
     e.end_time,
+    e.rrule,
+    e.exdates,
+    e.uid,
+    e.timezone,
     e.created_at,
     e.updated_at,
     er.id as reminder_id,
     er.remind_before,
+    er.channel as reminder_channel,
     er.notified,
     er.created_at as reminder_created_at
 FROM events e
 LEFT JOIN event_reminders er ON e.id = er.event_id
-WHERE e.start_time >= $1 AND e.end_time <= $2
+WHERE e.user_id = $3
+    AND ((e.rrule = '' AND e.start_time < $2 AND (e.end_time > $1 OR e.start_time >= $1))
+        OR (e.rrule <> '' AND e.start_time < $2))
 ORDER BY e.start_time ASC, er.remind_before ASC;
 
+-- name: GetEventsOverlappingRange :many
+SELECT id, name, place, description, start_time, end_time, rrule, exdates, uid, timezone, created_at, updated_at, user_id
+FROM events
+WHERE ((rrule = '' AND start_time < @range_end AND end_time > @range_start)
+        OR (rrule <> '' AND start_time < @range_end))
+    AND user_id = @user_id
+ORDER BY start_time ASC;
+
 -- name: CreateEventReminder :one
 INSERT INTO event_reminders (
     event_id,
-    remind_before
+    remind_before,
+    channel
 ) VALUES (
-    $1, $2
+    $1, $2, $3
 )
-RETURNING id, event_id, remind_before, notified, created_at;
+RETURNING id, event_id, remind_before, channel, notified, last_notified_occurrence, created_at;
 
 -- name: UpdateEventReminderNotified :exec
 UPDATE event_reminders
 SET notified = $2
 WHERE id = $1;
 
+-- name: UpdateEventReminderChannel :one
+UPDATE event_reminders
+SET channel = $2
+WHERE id = $1 AND event_id IN (SELECT id FROM events WHERE user_id = $3)
+RETURNING id, event_id, remind_before, channel, notified, last_notified_occurrence, created_at;
+
 -- name: DeleteEventReminder :exec
 DELETE FROM event_reminders
-WHERE id = $1;
+WHERE id = $1 AND event_id IN (SELECT id FROM events WHERE user_id = $2);
 
 -- name: GetEventRemindersToNotify :many
 SELECT 
     er.id,
     er.event_id,
     er.remind_before,
+    er.channel,
     er.notified,
     er.created_at,
     e.name,
     e.place,
     e.description,
     e.start_time,
-    e.end_time
+    e.end_time,
+    e.user_id
 FROM event_reminders er
 JOIN events e ON er.event_id = e.id
 WHERE er.notified = false
+    AND e.rrule = ''
     AND e.start_time <= NOW() + INTERVAL '1 minute' * er.remind_before
 ORDER BY e.start_time ASC;
 
@@ -122,8 +176,74 @@ SELECT
     id,
     event_id,
     remind_before,
+    channel,
     notified,
+    last_notified_occurrence,
     created_at
 FROM event_reminders
 WHERE event_id = $1
-ORDER BY remind_before ASC;
\ No newline at end of file
+ORDER BY remind_before ASC;
+
+-- name: GetRecurringEventReminders :many
+SELECT 
+    er.id,
+    er.event_id,
+    er.remind_before,
+    er.channel,
+    er.last_notified_occurrence,
+    er.created_at,
+    e.name,
+    e.place,
+    e.description,
+    e.start_time,
+    e.end_time,
+    e.rrule,
+    e.exdates,
+    e.timezone,
+    e.user_id
+FROM event_reminders er
+JOIN events e ON er.event_id = e.id
+WHERE e.rrule <> ''
+ORDER BY e.start_time ASC;
+
+-- name: RearmEventReminders :exec
+UPDATE event_reminders er
+SET notified = e.start_time <= NOW(), last_notified_occurrence = NULL
+FROM events e
+WHERE e.id = er.event_id AND er.event_id = $1;
+
+-- name: UpdateEventReminderLastNotifiedOccurrence :exec
+UPDATE event_reminders
+SET last_notified_occurrence = $2
+WHERE id = $1;
+
+-- name: UpsertEventOverride :one
+INSERT INTO event_overrides (
+    event_id,
+    recurrence_id,
+    name,
+    place,
+    description,
+    start_time,
+    end_time
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (event_id, recurrence_id) DO UPDATE
+SET 
+    name = EXCLUDED.name,
+    place = EXCLUDED.place,
+    description = EXCLUDED.description,
+    start_time = EXCLUDED.start_time,
+    end_time = EXCLUDED.end_time
+RETURNING *;
+
+-- name: GetEventOverridesByEventIDs :many
+SELECT * FROM event_overrides
+WHERE event_id = ANY(@event_ids::bigint[])
+ORDER BY recurrence_id ASC;
+
+-- name: DeleteEventOverride :one
+DELETE FROM event_overrides
+WHERE id = $1 AND event_id IN (SELECT id FROM events WHERE user_id = $2)
+RETURNING *;
\ No newline at end of file
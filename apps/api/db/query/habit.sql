@@ -1,6 +1,6 @@
 -- name: CreateHabit :one
-INSERT INTO habits (name, description)
-VALUES ($1, $2)
+INSERT INTO habits (name, description, user_id)
+VALUES ($1, $2, $3)
 RETURNING *;
 
 -- name: GetHabitById :one
@@ -14,11 +14,11 @@ SELECT
     MAX(hl.happened_at)::timestamptz as last_log_time
 FROM habits h
 LEFT JOIN habit_logs hl ON h.id = hl.habit_id
-WHERE h.id = $1
+WHERE h.id = $1 AND h.user_id = $2
 GROUP BY h.id, h.name, h.description, h.created_at, h.updated_at;
 
 -- name: GetHabitByName :one
-SELECT * FROM habits WHERE name = $1;
+SELECT * FROM habits WHERE name = $1 AND user_id = $2;
 
 -- name: GetAllHabits :many
 SELECT 
@@ -31,6 +31,7 @@ SELECT
     MAX(hl.happened_at)::timestamptz as last_log_time
 FROM habits h
 LEFT JOIN habit_logs hl ON h.id = hl.habit_id
+WHERE h.user_id = $1
 GROUP BY h.id, h.name, h.description, h.created_at, h.updated_at
 ORDER BY h.updated_at DESC;
 
@@ -40,14 +41,14 @@ SET
     name = $1,
     description = $2
 WHERE
-    id = $3
+    id = $3 AND user_id = $4
 RETURNING *;
 
 -- name: DeleteHabitById :exec
 DELETE FROM habits
-WHERE id = $1;
+WHERE id = $1 AND user_id = $2;
 
 -- name: HabitExists :one
 SELECT EXISTS(
-    SELECT 1 FROM habits WHERE id = $1
+    SELECT 1 FROM habits WHERE id = $1 AND user_id = $2
 ) AS exists;
\ No newline at end of file
@@ -1,65 +1,67 @@
 -- name: CreateHabitLog :one
-INSERT INTO habit_logs (habit_id, happened_at)
-VALUES ($1, $2)
+INSERT INTO habit_logs (habit_id, happened_at, user_id)
+VALUES ($1, $2, $3)
 RETURNING *;
 
 -- name: CreateHabitLogNow :one
-INSERT INTO habit_logs (habit_id)
-VALUES ($1)
+INSERT INTO habit_logs (habit_id, user_id)
+VALUES ($1, $2)
 RETURNING *;
 
 -- name: GetHabitLogById :one
 SELECT hl.*, h.name as habit_name 
 FROM habit_logs hl
 JOIN habits h ON hl.habit_id = h.id
-WHERE hl.id = $1;
+WHERE hl.id = $1 AND hl.user_id = $2;
 
 -- name: GetHabitLogsByHabitId :many
 SELECT hl.*, h.name as habit_name 
 FROM habit_logs hl
 JOIN habits h ON hl.habit_id = h.id
-WHERE hl.habit_id = $1
+WHERE hl.habit_id = $1 AND hl.user_id = $2
 ORDER BY hl.happened_at DESC;
 
 -- name: GetHabitLogsByHabitIdWithLimit :many
 SELECT * FROM habit_logs
-WHERE habit_id = $1
+WHERE habit_id = $1 AND user_id = $3
 ORDER BY happened_at DESC
 LIMIT $2;
 
 -- name: GetHabitLogsCountByHabitId :one
 SELECT COUNT(*) as count FROM habit_logs
-WHERE habit_id = $1;
+WHERE habit_id = $1 AND user_id = $2;
 
 -- name: GetHabitLogsCountByHabitIdInDateRange :one
 SELECT COUNT(*) as count FROM habit_logs
 WHERE habit_id = $1
   AND happened_at >= $2
-  AND happened_at <= $3;
+  AND happened_at <= $3
+  AND user_id = $4;
 
 -- name: GetAllHabitLogs :many
 SELECT hl.*, h.name as habit_name 
 FROM habit_logs hl
 JOIN habits h ON hl.habit_id = h.id
+WHERE hl.user_id = $1
 ORDER BY hl.happened_at DESC;
 
 -- name: DeleteHabitLogById :exec
 DELETE FROM habit_logs
-WHERE id = $1;
+WHERE id = $1 AND user_id = $2;
 
 -- name: DeleteHabitLogsByHabitId :exec
 DELETE FROM habit_logs
-WHERE habit_id = $1;
+WHERE habit_id = $1 AND user_id = $2;
 
 -- name: UpdateHabitLogById :one
 UPDATE habit_logs
 SET happened_at = $2
-WHERE id = $1
+WHERE id = $1 AND user_id = $3
 RETURNING *;
 
 -- name: HabitLogExists :one
 SELECT EXISTS(
-    SELECT 1 FROM habit_logs WHERE id = $1
+    SELECT 1 FROM habit_logs WHERE id = $1 AND user_id = $2
 ) AS exists;
 
 -- 获取习惯及其最近的日志记录
@@ -74,24 +76,22 @@ SELECT
     hl.happened_at as log_happened_at
 FROM habits h
 LEFT JOIN habit_logs hl ON h.id = hl.habit_id
-WHERE h.id = $1
+WHERE h.id = $1 AND h.user_id = $3
 ORDER BY hl.happened_at DESC
 LIMIT $2;
 
--- 获取今天的习惯日志
--- name: GetTodayHabitLogs :many
-SELECT * FROM habit_logs
-WHERE DATE(happened_at) = CURRENT_DATE
-ORDER BY happened_at DESC;
-
--- 获取指定日期的习惯日志
+-- 获取指定日期的习惯日志，日期边界由调用方按用户时区计算
 -- name: GetHabitLogsByDate :many
-SELECT * FROM habit_logs
-WHERE DATE(happened_at) = $1
-ORDER BY happened_at DESC;
+SELECT hl.*, h.name as habit_name
+FROM habit_logs hl
+JOIN habits h ON hl.habit_id = h.id
+WHERE hl.user_id = @user_id AND hl.happened_at >= @day_start AND hl.happened_at < @day_end
+ORDER BY hl.happened_at DESC;
 
--- 获取指定习惯在指定日期的日志
+-- 获取指定习惯在指定日期的日志，日期边界由调用方按用户时区计算
 -- name: GetHabitLogsByHabitIdAndDate :many
-SELECT * FROM habit_logs
-WHERE habit_id = $1 AND DATE(happened_at) = $2
-ORDER BY happened_at DESC;
\ No newline at end of file
+SELECT hl.*, h.name as habit_name
+FROM habit_logs hl
+JOIN habits h ON hl.habit_id = h.id
+WHERE hl.habit_id = @habit_id AND hl.user_id = @user_id AND hl.happened_at >= @day_start AND hl.happened_at < @day_end
+ORDER BY hl.happened_at DESC;
@@ -0,0 +1,24 @@
+-- name: CreateInvite :one
+INSERT INTO invites (code_hash, email, created_by, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListInvitesByCreator :many
+SELECT * FROM invites
+WHERE created_by = $1
+ORDER BY created_at DESC;
+
+-- 只能删除自己创建且尚未使用的邀请
+-- name: DeleteInvite :execrows
+DELETE FROM invites
+WHERE id = $1 AND created_by = $2 AND used_by IS NULL;
+
+-- 原子地占用邀请码，返回 ErrNoRows 表示邀请码无效、已使用、已过期或邮箱不匹配
+-- name: ClaimInvite :one
+UPDATE invites
+SET used_by = @user_id, used_at = NOW()
+WHERE code_hash = @code_hash
+    AND used_by IS NULL
+    AND expires_at > NOW()
+    AND (email = '' OR email = @email)
+RETURNING *;
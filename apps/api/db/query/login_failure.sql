@@ -0,0 +1,20 @@
+-- name: GetLoginFailure :one
+SELECT * FROM login_failures WHERE email = $1;
+
+-- 记录一次登录失败，上次失败早于 reset_before 时重新计数
+-- name: RecordLoginFailure :one
+INSERT INTO login_failures (email, failed_count, last_failed_at)
+VALUES (@email, 1, NOW())
+ON CONFLICT (email) DO UPDATE
+SET failed_count = CASE
+        WHEN login_failures.last_failed_at < @reset_before THEN 1
+        ELSE login_failures.failed_count + 1
+    END,
+    last_failed_at = NOW()
+RETURNING *;
+
+-- name: LockLogin :exec
+UPDATE login_failures SET locked_until = @locked_until WHERE email = @email;
+
+-- name: ClearLoginFailures :exec
+DELETE FROM login_failures WHERE email = $1;
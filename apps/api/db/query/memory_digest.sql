@@ -0,0 +1,32 @@
+-- name: GetMemoryDigestSettings :one
+SELECT * FROM memory_digest_settings WHERE user_id = $1;
+
+-- name: UpsertMemoryDigestSettings :one
+INSERT INTO memory_digest_settings (user_id, enabled, send_hour)
+VALUES (@user_id, @enabled, @send_hour)
+ON CONFLICT (user_id) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    send_hour = EXCLUDED.send_hour,
+    updated_at = NOW()
+RETURNING *;
+
+-- 列出已到发送时间且用户本地日期今天还没有发送过的订阅
+-- name: ListDueMemoryDigests :many
+SELECT
+    s.user_id,
+    u.email,
+    u.name,
+    u.timezone,
+    (NOW() AT TIME ZONE u.timezone)::date AS local_date
+FROM memory_digest_settings s
+JOIN users u ON u.id = s.user_id
+WHERE s.enabled
+    AND EXTRACT(HOUR FROM NOW() AT TIME ZONE u.timezone) >= s.send_hour
+    AND (s.last_sent_on IS NULL OR s.last_sent_on < (NOW() AT TIME ZONE u.timezone)::date);
+
+-- 记录某天已发送，返回 0 表示这一天已经发送过
+-- name: MarkMemoryDigestSent :execrows
+UPDATE memory_digest_settings
+SET last_sent_on = @sent_on
+WHERE user_id = @user_id
+    AND (last_sent_on IS NULL OR last_sent_on < @sent_on);
@@ -1,42 +1,107 @@
 -- name: GetMomentsPaginated :many
 SELECT * FROM moments
-WHERE ($1::timestamp IS NULL OR created_at < $1::timestamp)
+WHERE user_id = $3
+    AND ($1::timestamp IS NULL OR created_at < $1::timestamp)
 ORDER BY created_at DESC
 LIMIT $2;
 
 
 -- name: GetMomentByID :one
 SELECT * FROM moments
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- 锁定 moment 行，编辑和恢复历史版本时防止并发修改
+-- name: GetMomentForUpdate :one
+SELECT * FROM moments
+WHERE id = $1 AND user_id = $2
+FOR UPDATE;
+
+-- name: UpdateMomentContent :one
+UPDATE moments
+SET content = $3
+WHERE id = $1 AND user_id = $2
+RETURNING *;
 
 -- name: CreateMoment :one
 INSERT INTO moments
-(content)
-VALUES ($1)
+(content, user_id)
+VALUES ($1, $2)
 RETURNING *;
 
--- name: AddAttachmentToMoment :exec
+-- 只能添加属于同一用户且已上传完成的附件，返回 0 表示附件不存在、不属于该用户或尚未完成
+-- 未完成、校验中或校验失败的上传被引用后既不会显示，也无法取消或回收
+-- name: AddAttachmentToMoment :execrows
 INSERT INTO moment_attachments (moment_id, attachment_id, position)
-VALUES ($1, $2, $3);
+SELECT $1, a.id, $3
+FROM attachments a
+WHERE a.id = $2 AND a.user_id = $4 AND a.status = 'completed';
 
 -- name: RemoveAttachmentFromMoment :exec
 DELETE FROM moment_attachments
 WHERE moment_id = $1 AND attachment_id = $2;
 
--- name: GetMomentAttachmentsByID :many
-SELECT 
+-- name: ClearMomentAttachments :exec
+DELETE FROM moment_attachments
+WHERE moment_id = $1;
+
+-- 包括未完成上传的附件，用于保存历史版本
+-- name: ListMomentAttachmentRefs :many
+SELECT attachment_id, position FROM moment_attachments
+WHERE moment_id = $1
+ORDER BY position;
+
+-- 一次取出多条 moment 的附件，按 moment 和位置排序
+-- name: GetAttachmentsByMomentIDs :many
+SELECT
+    ma.moment_id,
     a.*,
     ma.position
 FROM attachments a
 INNER JOIN moment_attachments ma ON a.id = ma.attachment_id
-WHERE ma.moment_id = $1 AND a.status = 'completed'
-ORDER BY ma.position;
+WHERE ma.moment_id = ANY(@moment_ids::bigint[]) AND a.status = 'completed'
+ORDER BY ma.moment_id, ma.position;
 
 -- name: DeleteMomentByID :exec
 DELETE FROM moments
-WHERE id = $1;
+WHERE id = $1 AND user_id = $2;
 
 -- name: MomentExists :one
 SELECT EXISTS(
-    SELECT 1 FROM moments WHERE id = $1
+    SELECT 1 FROM moments WHERE id = $1 AND user_id = $2
 ) AS exists;
+
+-- 按相关度排序的全文搜索，query 为 to_tsquery 语法，offset 用于翻页
+-- snippet 中命中的词由 chr(2)、chr(3) 包裹，由服务层转义 HTML 后再替换为 <mark>
+-- name: SearchMoments :many
+SELECT
+    m.*,
+    ts_rank_cd(m.search_vector, q.query)::float8 AS rank,
+    ts_headline('simple', m.content, q.query, 'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=2, MaxWords=20, MinWords=5') AS snippet
+FROM moments m, to_tsquery('simple', @query) AS q(query)
+WHERE m.user_id = @user_id
+    AND m.search_vector @@ q.query
+ORDER BY rank DESC, m.created_at DESC, m.id DESC
+LIMIT @row_limit OFFSET @row_offset;
+
+-- 按创建时间倒序的全文搜索，游标与 GetMomentsPaginated 相同
+-- snippet 中命中的词由 chr(2)、chr(3) 包裹，由服务层转义 HTML 后再替换为 <mark>
+-- name: SearchMomentsByCreatedAt :many
+SELECT
+    m.*,
+    ts_rank_cd(m.search_vector, q.query)::float8 AS rank,
+    ts_headline('simple', m.content, q.query, 'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=2, MaxWords=20, MinWords=5') AS snippet
+FROM moments m, to_tsquery('simple', @query) AS q(query)
+WHERE m.user_id = @user_id
+    AND m.search_vector @@ q.query
+    AND (@cursor::timestamp IS NULL OR m.created_at < @cursor::timestamp)
+ORDER BY m.created_at DESC
+LIMIT @row_limit;
+
+-- 那年今日：按用户时区取往年同月同日的 moment，day_end 用于在非闰年的 2 月 28 日一并返回 2 月 29 日
+-- name: ListMomentsOnThisDay :many
+SELECT * FROM moments
+WHERE user_id = @user_id
+    AND EXTRACT(MONTH FROM created_at AT TIME ZONE @timezone::text) = @month::int
+    AND EXTRACT(DAY FROM created_at AT TIME ZONE @timezone::text) BETWEEN @day::int AND @day_end::int
+    AND EXTRACT(YEAR FROM created_at AT TIME ZONE @timezone::text) < @year::int
+ORDER BY created_at DESC;
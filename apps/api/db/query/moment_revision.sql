@@ -0,0 +1,13 @@
+-- name: CreateMomentRevision :one
+INSERT INTO moment_revisions (moment_id, content, attachments)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListMomentRevisions :many
+SELECT * FROM moment_revisions
+WHERE moment_id = $1
+ORDER BY id DESC;
+
+-- name: GetMomentRevision :one
+SELECT * FROM moment_revisions
+WHERE id = $1 AND moment_id = $2;
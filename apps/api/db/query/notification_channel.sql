@@ -0,0 +1,27 @@
+-- name: UpsertNotificationChannel :one
+INSERT INTO notification_channels (user_id, channel, provider, target, secret)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, channel) DO UPDATE
+SET provider = EXCLUDED.provider,
+    target = EXCLUDED.target,
+    secret = EXCLUDED.secret
+RETURNING *;
+
+-- name: GetNotificationChannel :one
+SELECT * FROM notification_channels
+WHERE user_id = $1 AND channel = $2;
+
+-- name: ListNotificationChannels :many
+SELECT * FROM notification_channels
+WHERE user_id = $1
+ORDER BY channel ASC;
+
+-- name: DeleteNotificationChannel :execrows
+DELETE FROM notification_channels
+WHERE user_id = $1 AND channel = $2;
+
+-- 投递提醒时按事件所有者查找渠道配置
+-- name: GetEventOwnerNotificationChannel :one
+SELECT nc.* FROM notification_channels nc
+JOIN events e ON e.user_id = nc.user_id
+WHERE e.id = $1 AND nc.channel = $2;
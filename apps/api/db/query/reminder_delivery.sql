@@ -0,0 +1,98 @@
+-- name: EnqueueReminderDelivery :exec
+INSERT INTO reminder_deliveries (
+    reminder_id,
+    event_id,
+    occurrence,
+    channel,
+    title,
+    body,
+    html,
+    recipient,
+    next_attempt_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (reminder_id, occurrence) DO UPDATE
+SET
+    channel = EXCLUDED.channel,
+    title = EXCLUDED.title,
+    body = EXCLUDED.body,
+    html = EXCLUDED.html,
+    recipient = EXCLUDED.recipient,
+    status = 'pending',
+    attempts = 0,
+    last_error = '',
+    next_attempt_at = EXCLUDED.next_attempt_at,
+    sent_at = NULL
+WHERE reminder_deliveries.status NOT IN ('pending', 'sending');
+
+-- name: DeletePendingReminderDeliveriesByEventID :exec
+DELETE FROM reminder_deliveries
+WHERE event_id = $1 AND status = 'pending';
+
+-- name: DeletePendingReminderDeliveriesByOccurrence :exec
+DELETE FROM reminder_deliveries
+WHERE event_id = $1 AND occurrence = $2 AND status = 'pending';
+
+-- 领取一批到期的投递记录并标记为 sending，避免与手动重试重复投递
+-- 发送中但超过 stale_before 仍未结束的（实例退出）重新领取
+-- name: ClaimDueReminderDeliveries :many
+UPDATE reminder_deliveries
+SET status = 'sending'
+WHERE id IN (
+    SELECT id FROM reminder_deliveries
+    WHERE (status = 'pending' AND next_attempt_at <= NOW())
+        OR (status = 'sending' AND updated_at < @stale_before)
+    ORDER BY next_attempt_at ASC
+    LIMIT @batch_size
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkReminderDeliverySent :exec
+UPDATE reminder_deliveries
+SET status = 'sent', attempts = attempts + 1, last_error = '', sent_at = NOW()
+WHERE id = $1 AND status = 'sending';
+
+-- name: MarkReminderDeliveryRetry :exec
+UPDATE reminder_deliveries
+SET status = 'pending', attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+WHERE id = $1 AND status = 'sending';
+
+-- name: MarkReminderDeliveryFailed :exec
+UPDATE reminder_deliveries
+SET status = 'failed', attempts = attempts + 1, last_error = $2
+WHERE id = $1 AND status = 'sending';
+
+-- name: CreateReminderDeliveryAttempt :exec
+INSERT INTO reminder_delivery_attempts (
+    delivery_id,
+    attempt,
+    success,
+    error
+) VALUES (
+    $1, $2, $3, $4
+);
+
+-- name: ListReminderDeliveriesByStatus :many
+SELECT * FROM reminder_deliveries
+WHERE status = $1 AND event_id IN (SELECT id FROM events WHERE user_id = $3)
+ORDER BY updated_at DESC
+LIMIT $2;
+
+-- name: GetReminderDeliveryByID :one
+SELECT * FROM reminder_deliveries
+WHERE id = $1 AND event_id IN (SELECT id FROM events WHERE user_id = $2);
+
+-- name: GetReminderDeliveryAttempts :many
+SELECT * FROM reminder_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY attempted_at ASC;
+
+-- 将 failed 的记录直接领取为 sending，由调用方立即投递，调度器不会同时领取
+-- name: RetryReminderDelivery :one
+UPDATE reminder_deliveries
+SET status = 'sending', attempts = 0, last_error = '', next_attempt_at = NOW()
+WHERE id = $1 AND status = 'failed'
+    AND event_id IN (SELECT id FROM events WHERE user_id = $2)
+RETURNING *;
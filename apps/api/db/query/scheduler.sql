@@ -0,0 +1,19 @@
+-- name: TryAdvisoryLock :one
+SELECT pg_try_advisory_lock(@key::bigint) AS locked;
+
+-- name: ReleaseAdvisoryLock :one
+SELECT pg_advisory_unlock(@key::bigint) AS released;
+
+-- name: SetApplicationName :exec
+SELECT set_config('application_name', @name::text, false);
+
+-- name: GetAdvisoryLockHolder :one
+SELECT COALESCE(a.application_name, '')::text AS holder
+FROM pg_locks l
+JOIN pg_stat_activity a ON a.pid = l.pid
+WHERE l.locktype = 'advisory'
+    AND l.classid::bigint = 0
+    AND l.objid::bigint = @key::bigint
+    AND l.objsubid = 1
+    AND l.granted
+LIMIT 1;
@@ -0,0 +1,56 @@
+-- name: CreateSession :one
+INSERT INTO sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- 轮换 refresh token，旧 token 记录到 previous_refresh_token_hash，返回 ErrNoRows 表示 token 无效、已过期或会话已撤销
+-- name: RotateSessionRefreshToken :one
+UPDATE sessions
+SET previous_refresh_token_hash = refresh_token_hash,
+    refresh_token_hash = @new_refresh_token_hash,
+    ip_address = @ip_address,
+    expires_at = @expires_at,
+    last_used_at = NOW()
+WHERE refresh_token_hash = @refresh_token_hash
+    AND revoked_at IS NULL
+    AND expires_at > NOW()
+RETURNING *;
+
+-- 已轮换的 refresh token 被再次使用，说明 token 可能已泄露，撤销整个会话
+-- name: RevokeSessionByPreviousRefreshTokenHash :execrows
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE previous_refresh_token_hash = $1 AND revoked_at IS NULL;
+
+-- name: RevokeSessionByRefreshTokenHash :execrows
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE refresh_token_hash = $1 AND revoked_at IS NULL;
+
+-- name: RevokeSession :execrows
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: IsSessionActive :one
+SELECT EXISTS (
+    SELECT 1 FROM sessions
+    WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+) AS active;
+
+-- name: ListActiveSessionsByUser :many
+SELECT * FROM sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY last_used_at DESC;
+
+-- 修改密码后撤销用户的其他会话
+-- name: RevokeOtherUserSessions :exec
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL;
+
+-- 重置密码后撤销用户的所有会话
+-- name: RevokeAllUserSessions :exec
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
@@ -7,29 +7,185 @@ INSERT INTO attachments (
     md5,
     cover_md5,
     file_size,
-    status
+    status,
+    user_id,
+    cover_status
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, 'uploading'
+    $1, $2, $3, $4, $5, $6, $7, 'uploading', $8, $9
 ) RETURNING *;
 
 -- name: FindCompletedAttachmentByMD5 :one
 SELECT * FROM attachments
-WHERE md5 = $1 AND status = 'completed'
+WHERE md5 = $1 AND user_id = $2 AND status = 'completed'
 LIMIT 1;
 
 -- name: UpdateAttachmentStatus :exec
 UPDATE attachments
 SET status = $1
-WHERE id = $2;
+WHERE id = $2 AND user_id = $3;
 
 -- name: GetCompletedAttachmentObjectKey :one
 SELECT object_key FROM attachments
-WHERE id = $1 AND status = 'completed';
+WHERE id = $1 AND user_id = $2 AND status = 'completed';
 
 -- name: GetCompletedAttachmentCoverObjectKey :one
 SELECT cover_object_key FROM attachments
-WHERE id = $1 AND status = 'completed';
+WHERE id = $1 AND user_id = $2 AND status = 'completed';
 
 -- name: GetAttachmentById :one
 SELECT * FROM attachments
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1 AND user_id = $2;
+
+-- 领取一批待生成封面的附件，处理中但超过 stale_before 仍未完成的（实例退出）重新领取
+-- name: ClaimPendingCovers :many
+UPDATE attachments
+SET cover_status = 'processing',
+    cover_attempts = cover_attempts + 1
+WHERE id IN (
+    SELECT id FROM attachments
+    WHERE status = 'completed'
+        AND (cover_status = 'pending' OR (cover_status = 'processing' AND updated_at < @stale_before))
+    ORDER BY created_at
+    LIMIT @batch_size
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkCoverReady :exec
+UPDATE attachments
+SET cover_status = 'ready',
+    cover_object_key = @cover_object_key,
+    cover_md5 = @cover_md5,
+    cover_sizes = @cover_sizes
+WHERE id = @id;
+
+-- name: SetCoverStatus :exec
+UPDATE attachments
+SET cover_status = @cover_status
+WHERE id = @id;
+
+-- 列出超过宽限期且引用计数为 0 的附件：既没有被 moment 引用，也不在任何历史版本中
+-- 包括未完成的上传和已完成但不再使用的附件，正在生成封面的附件留到下次
+-- name: ListGarbageAttachments :many
+SELECT * FROM attachments a
+WHERE a.updated_at < @updated_before
+    AND a.cover_status <> 'processing'
+    AND NOT EXISTS (SELECT 1 FROM moment_attachments ma WHERE ma.attachment_id = a.id)
+    AND NOT EXISTS (
+        SELECT 1 FROM moment_revisions mr
+        WHERE mr.attachments @> jsonb_build_array(jsonb_build_object('attachment_id', a.id::text))
+    )
+ORDER BY a.updated_at
+LIMIT @batch_size;
+
+-- 与 ListGarbageAttachments 条件相同，只列出一位用户的附件，用于预演报告
+-- name: ListUserGarbageAttachments :many
+SELECT * FROM attachments a
+WHERE a.user_id = @user_id
+    AND a.updated_at < @updated_before
+    AND a.cover_status <> 'processing'
+    AND NOT EXISTS (SELECT 1 FROM moment_attachments ma WHERE ma.attachment_id = a.id)
+    AND NOT EXISTS (
+        SELECT 1 FROM moment_revisions mr
+        WHERE mr.attachments @> jsonb_build_array(jsonb_build_object('attachment_id', a.id::text))
+    )
+ORDER BY a.updated_at;
+
+-- 删除前再次检查引用计数，期间被引用或更新过的附件不删除（不返回记录）
+-- name: DeleteUnreferencedAttachment :one
+DELETE FROM attachments a
+WHERE a.id = @id
+    AND a.updated_at < @updated_before
+    AND a.cover_status <> 'processing'
+    AND NOT EXISTS (SELECT 1 FROM moment_attachments ma WHERE ma.attachment_id = a.id)
+    AND NOT EXISTS (
+        SELECT 1 FROM moment_revisions mr
+        WHERE mr.attachments @> jsonb_build_array(jsonb_build_object('attachment_id', a.id::text))
+    )
+RETURNING *;
+
+-- 所有附件使用的对象键，服务端生成的封面键由 object_key 和 cover_sizes 推导
+-- name: ListAttachmentObjectKeys :many
+SELECT object_key, cover_object_key, cover_sizes FROM attachments;
+
+-- name: ListUserAvatarKeys :many
+SELECT avatar_key FROM users
+WHERE avatar_key <> '';
+
+-- name: CreateMultipartAttachment :one
+INSERT INTO attachments (
+    object_key,
+    cover_object_key,
+    original_name,
+    mime_type,
+    md5,
+    cover_md5,
+    file_size,
+    status,
+    user_id,
+    cover_status,
+    sha256,
+    upload_id,
+    part_size
+) VALUES (
+    @object_key, '', @original_name, @mime_type, '', '', @file_size, 'uploading', @user_id, @cover_status, @sha256, @upload_id, @part_size
+) RETURNING *;
+
+-- name: FindCompletedAttachmentBySHA256 :one
+SELECT * FROM attachments
+WHERE user_id = @user_id AND sha256 = @sha256 AND status = 'completed'
+LIMIT 1;
+
+-- 分片合并后清空 upload_id 并等待后台任务校验
+-- name: MarkAttachmentVerifying :exec
+UPDATE attachments
+SET status = 'verifying',
+    upload_id = '',
+    verify_started_at = NULL
+WHERE id = @id AND status = 'uploading';
+
+-- 领取一批等待校验的附件，校验中但超过 stale_before 仍未完成的（实例退出）重新领取
+-- name: ClaimVerifyingAttachments :many
+UPDATE attachments
+SET verify_started_at = NOW()
+WHERE id IN (
+    SELECT id FROM attachments
+    WHERE status = 'verifying'
+        AND (verify_started_at IS NULL OR verify_started_at < @stale_before)
+    ORDER BY created_at
+    LIMIT @batch_size
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- 读取对象失败时放回等待领取，下次轮询重试
+-- name: ReleaseVerifyingAttachment :exec
+UPDATE attachments
+SET verify_started_at = NULL
+WHERE id = @id AND status = 'verifying';
+
+-- 校验通过后写入服务端计算的 MD5，使分片上传的附件同样可以按 MD5 去重
+-- name: CompleteMultipartAttachment :exec
+UPDATE attachments
+SET status = 'completed',
+    md5 = @md5
+WHERE id = @id AND status = 'verifying';
+
+-- 合并后的文件与声明的 SHA-256 或大小不一致，需要取消后重新上传
+-- name: FailAttachmentVerification :exec
+UPDATE attachments
+SET status = 'failed'
+WHERE id = @id AND status = 'verifying';
+
+-- 取消未完成或校验失败的上传
+-- name: DeleteUploadingAttachment :one
+DELETE FROM attachments
+WHERE id = @id AND user_id = @user_id AND status IN ('uploading', 'failed')
+    AND NOT EXISTS (SELECT 1 FROM moment_attachments ma WHERE ma.attachment_id = attachments.id)
+RETURNING *;
+
+-- 刷新 updated_at，推迟垃圾回收：去重命中的附件即将被新的 moment 引用，分片上传仍在进行
+-- name: TouchAttachment :exec
+UPDATE attachments
+SET updated_at = NOW()
+WHERE id = @id;
@@ -0,0 +1,43 @@
+-- name: UpsertTag :one
+INSERT INTO tags (user_id, name)
+VALUES ($1, $2)
+ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id;
+
+-- name: AddMomentTag :exec
+INSERT INTO moment_tags (moment_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: ClearMomentTags :exec
+DELETE FROM moment_tags
+WHERE moment_id = $1;
+
+-- 删除已没有 moment 使用的标签
+-- name: DeleteUnusedTags :exec
+DELETE FROM tags t
+WHERE t.user_id = $1
+    AND NOT EXISTS (SELECT 1 FROM moment_tags mt WHERE mt.tag_id = t.id);
+
+-- name: ListTagsWithCounts :many
+SELECT
+    t.name,
+    COUNT(*) AS moment_count,
+    MAX(m.created_at)::timestamptz AS last_used_at
+FROM tags t
+INNER JOIN moment_tags mt ON mt.tag_id = t.id
+INNER JOIN moments m ON m.id = mt.moment_id
+WHERE t.user_id = $1
+GROUP BY t.id, t.name
+ORDER BY moment_count DESC, t.name;
+
+-- name: GetMomentsPaginatedByTag :many
+SELECT m.* FROM moments m
+INNER JOIN moment_tags mt ON mt.moment_id = m.id
+INNER JOIN tags t ON t.id = mt.tag_id
+WHERE m.user_id = @user_id
+    AND t.user_id = @user_id
+    AND t.name = @tag
+    AND (@cursor::timestamp IS NULL OR m.created_at < @cursor::timestamp)
+ORDER BY m.created_at DESC
+LIMIT @row_limit;
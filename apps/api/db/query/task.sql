@@ -1,6 +1,6 @@
 -- name: GetTasksByGroupId :many
 SELECT * FROM tasks
-WHERE group_id = $1
+WHERE group_id = $1 AND user_id = $2
 ORDER BY
     CASE WHEN deadline IS NULL THEN 1 ELSE 0 END,
     CASE WHEN deadline < NOW() THEN 0 ELSE 1 END,
@@ -8,11 +8,11 @@ ORDER BY
 
 
 -- name: GetTaskById :one
-SELECT * FROM tasks WHERE id = $1;
+SELECT * FROM tasks WHERE id = $1 AND user_id = $2;
 
 -- name: CreateTask :one
-INSERT INTO tasks (group_id, content, deadline)
-VALUES ($1, $2, $3)
+INSERT INTO tasks (group_id, content, deadline, user_id)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: UpdateTaskById :one
@@ -22,14 +22,14 @@ SET
     deadline = $3,
     status = $4
 WHERE
-    id = $1
+    id = $1 AND user_id = $5
 RETURNING *;
 
 -- name: DeleteTaskById :exec
 DELETE FROM tasks
-WHERE id = $1;
+WHERE id = $1 AND user_id = $2;
 
 -- name: TaskExists :one
 SELECT EXISTS(
-    SELECT 1 FROM tasks WHERE id = $1
+    SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2
 ) AS exists;
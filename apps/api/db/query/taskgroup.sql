@@ -1,19 +1,19 @@
 -- name: CreateTaskGroup :one
-INSERT INTO task_groups (name, description, type)
-VALUES ($1, $2, $3)
+INSERT INTO task_groups (name, description, type, user_id)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: GetTaskGroupById :one
-SELECT * FROM task_groups WHERE id = $1;
+SELECT * FROM task_groups WHERE id = $1 AND user_id = $2;
 
 -- name: GetTaskGroupByName :one
-SELECT * FROM task_groups WHERE name = $1;
+SELECT * FROM task_groups WHERE name = $1 AND user_id = $2;
 
 -- name: GetAllTaskGroups :many
-SELECT * FROM task_groups ORDER BY updated_at DESC;
+SELECT * FROM task_groups WHERE user_id = $1 ORDER BY updated_at DESC;
 
 -- name: GetTaskGroupsByType :many
-SELECT * FROM task_groups WHERE type = $1 ORDER BY updated_at DESC;
+SELECT * FROM task_groups WHERE type = $1 AND user_id = $2 ORDER BY updated_at DESC;
 
 -- name: UpdateTaskGroupById :one
 UPDATE task_groups
@@ -22,14 +22,14 @@ SET
     description = $2,
     type = $3
 WHERE
-    id = $4
+    id = $4 AND user_id = $5
 RETURNING *;
 
 -- name: DeleteTaskGroupById :exec
 DELETE FROM task_groups
-WHERE id = $1;
+WHERE id = $1 AND user_id = $2;
 
 -- name: TaskGroupExists :one
 SELECT EXISTS(
-    SELECT 1 FROM task_groups WHERE id = $1
-) AS exists;
\ No newline at end of file
+    SELECT 1 FROM task_groups WHERE id = $1 AND user_id = $2
+) AS exists;
@@ -0,0 +1,27 @@
+-- name: CreateTwoFactorChallenge :one
+INSERT INTO two_factor_challenges (user_id, expires_at)
+VALUES ($1, $2)
+RETURNING *;
+
+-- 记录一次验证码提交，challenge 已使用、已过期或提交次数已达上限时返回 ErrNoRows
+-- 先计数再校验验证码，并发提交也不能超过上限
+-- name: BeginTwoFactorChallengeAttempt :one
+UPDATE two_factor_challenges
+SET attempts = attempts + 1
+WHERE id = @id
+    AND user_id = @user_id
+    AND used_at IS NULL
+    AND expires_at > NOW()
+    AND attempts < @max_attempts
+RETURNING *;
+
+-- 验证码通过后标记 challenge 已使用，返回 0 表示已被并发请求使用
+-- name: ConsumeTwoFactorChallenge :execrows
+UPDATE two_factor_challenges
+SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL;
+
+-- 创建 challenge 时顺带清理该用户已过期的记录
+-- name: DeleteExpiredTwoFactorChallenges :exec
+DELETE FROM two_factor_challenges
+WHERE user_id = $1 AND expires_at < NOW();
@@ -1,29 +1,50 @@
 -- name: CheckUserExists :one
 SELECT COUNT(*) > 0 as exists FROM users;
 
--- name: GetUser :one
-SELECT * FROM users LIMIT 1;
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1 LIMIT 1;
 
 -- name: GetUserByEmail :one
 SELECT * FROM users WHERE email = $1 LIMIT 1;
 
 -- name: CreateUser :one
-INSERT INTO users (email, name, password_hash, birthday, avatar_base64, bio)
+INSERT INTO users (email, name, password_hash, birthday, bio, timezone)
 VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
--- name: UpdateUser :one
+-- name: UpdateUserProfile :one
 UPDATE users
 SET
-    email = $2,
-    name = $3,
-    birthday = $4,
-    avatar_base64 = $5,
-    bio = $6
+    name = $2,
+    birthday = $3,
+    bio = $4
 WHERE
     id = $1
 RETURNING *;
 
+-- 设置头像前缀并清空旧版内联头像，返回原来的前缀用于删除旧头像对象
+-- name: UpdateUserAvatar :one
+UPDATE users AS u
+SET avatar_key = $2, avatar_base64 = ''
+FROM users AS old
+WHERE u.id = $1 AND old.id = u.id
+RETURNING old.avatar_key;
+
+-- 仍使用内联头像、需要迁移到对象存储的用户
+-- name: ListUsersWithInlineAvatar :many
+SELECT id, avatar_base64 FROM users
+WHERE avatar_base64 <> '' AND avatar_key = ''
+ORDER BY id;
+
+-- name: UpdateUserTimezone :one
+UPDATE users
+SET timezone = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserTimezone :one
+SELECT timezone FROM users WHERE id = $1;
+
 -- name: UpdateUserPassword :exec
 UPDATE users
 SET password_hash = $2
@@ -33,4 +54,11 @@ WHERE id = $1;
 DELETE FROM users WHERE id = $1;
 
 -- name: GetUserCount :one
-SELECT COUNT(*) as count FROM users;
\ No newline at end of file
+SELECT COUNT(*) as count FROM users;
+
+-- 邮箱验证通过，修改邮箱时同时更新为新地址
+-- name: VerifyUserEmail :one
+UPDATE users
+SET email = $2, email_verified_at = NOW()
+WHERE id = $1
+RETURNING *;
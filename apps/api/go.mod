@@ -10,6 +10,7 @@ require (
 	github.com/minio/minio-go/v7 v7.0.95
 	github.com/spf13/viper v1.20.1
 	go.uber.org/zap v1.27.0
+	golang.org/x/image v0.25.0
 )
 
 require (
@@ -4,11 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/wneessen/go-mail"
 	"github.com/zeroicey/lifetrack-api/internal/config"
 	"github.com/zeroicey/lifetrack-api/internal/middleware"
@@ -18,6 +17,7 @@ import (
 	"github.com/zeroicey/lifetrack-api/internal/modules/moment"
 	"github.com/zeroicey/lifetrack-api/internal/modules/notification"
 	"github.com/zeroicey/lifetrack-api/internal/modules/storage"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage/driver"
 	"github.com/zeroicey/lifetrack-api/internal/modules/task"
 	"github.com/zeroicey/lifetrack-api/internal/modules/taskgroup"
 	"github.com/zeroicey/lifetrack-api/internal/modules/user"
@@ -36,6 +36,8 @@ type App struct {
 
 	// Scheduled tasks
 	EventScheduler *event.Scheduler
+	CoverWorker    *storage.CoverWorker
+	VerifyWorker   *storage.VerifyWorker
 
 	// Services
 	MomentService       *moment.Service
@@ -69,14 +71,10 @@ func NewApp(ctx context.Context) (*App, error) {
 		return nil, fmt.Errorf("failed to initialize database connection: %w", err)
 	}
 
-	// Initialize MinIO client
-	minioClient, err := minio.New(cfg.Storage.Endpoint, &minio.Options{
-		Creds:        credentials.NewStaticV4(cfg.Storage.AccessKey, cfg.Storage.SecretKey, ""),
-		BucketLookup: minio.BucketLookupDNS,
-		Secure:       cfg.Storage.UseSSL,
-	})
+	// Initialize storage driver
+	storageDriver, err := driver.New(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create MinIO client: %w", err)
+		return nil, fmt.Errorf("failed to initialize storage driver: %w", err)
 	}
 
 	// Initialize mailer
@@ -87,26 +85,34 @@ func NewApp(ctx context.Context) (*App, error) {
 	}
 	smtpMailer := notification.NewSMTPMailer(mailClient, cfg.Mail, logger)
 
-	notificationService := notification.NewService(logger, smtpMailer)
+	// Webhook、推送和 Telegram 由用户各自配置，系统渠道只有邮件
+	notificationService := notification.NewService(logger, smtpMailer, notification.NewRenderer(cfg.Mail.TemplatesDir), cfg.Notify,
+		notification.NewEmailChannel(smtpMailer, cfg.Mail.To))
 
 	// Initialize validator
 	validate := validator.New(validator.WithRequiredStructEnabled())
 
 	// Initialize JWT manager
-	jwtManager := pkg.NewJWTManager(cfg.JWT.JWTSecret)
+	jwtManager := pkg.NewJWTManager(cfg.JWT.JWTSecret, time.Duration(cfg.JWT.AccessTokenTTL)*time.Minute)
 
 	// Initialize repositories
 	queries := repository.New(dbConn)
 
 	// Initialize services
-	eventService := event.NewService(queries, logger, cfg, notificationService)
-	momentService := moment.NewService(dbConn, queries, logger)
+	eventService := event.NewService(dbConn, queries, logger, cfg, notificationService)
 	taskGroupService := taskgroup.NewService(queries)
 	taskService := task.NewService(queries)
-	storageService := storage.NewService(dbConn, queries, minioClient, logger, cfg)
-	userService := user.NewService(queries)
+	storageService := storage.NewService(dbConn, queries, storageDriver, logger, cfg)
+	momentService := moment.NewService(dbConn, queries, logger, cfg, notificationService, storageService)
+	userService := user.NewService(dbConn, queries, logger, cfg, notificationService, storageService)
 	habitLogService := habitlog.NewService(queries)
-	eventScheduler := event.NewScheduler(eventService, logger)
+	eventScheduler := event.NewScheduler(eventService, dbConn, cfg.InstanceID, logger)
+	// 每 5 分钟检查一次到达发送时间的那年今日邮件
+	eventScheduler.AddJob("memory digest", "0 */5 * * * *", momentService.SendMemoryDigests)
+	// 每天凌晨回收未引用的附件和孤立对象
+	if cfg.Storage.GCEnabled {
+		eventScheduler.AddJob("storage gc", "0 30 3 * * *", storageService.CollectGarbage)
+	}
 	habitService := habit.NewService(queries)
 
 	app := &App{
@@ -117,6 +123,8 @@ func NewApp(ctx context.Context) (*App, error) {
 		JWTManager: jwtManager,
 
 		EventScheduler: eventScheduler,
+		CoverWorker:    storage.NewCoverWorker(storageService),
+		VerifyWorker:   storage.NewVerifyWorker(storageService),
 
 		MomentService:       momentService,
 		TaskGroupService:    taskGroupService,
@@ -134,6 +142,11 @@ func NewApp(ctx context.Context) (*App, error) {
 		return nil, fmt.Errorf("failed to ensure bucket exists: %w", err)
 	}
 
+	// 将旧版内联头像迁移到对象存储
+	if err := app.StorageService.MigrateInlineAvatars(ctx); err != nil {
+		logger.Error("Failed to migrate inline avatars", zap.Error(err))
+	}
+
 	return app, nil
 }
 
@@ -159,10 +172,14 @@ func (a *App) StartSchedulers() error {
 	if err := a.EventScheduler.Start(); err != nil {
 		return fmt.Errorf("failed to start event scheduler: %w", err)
 	}
+	a.CoverWorker.Start()
+	a.VerifyWorker.Start()
 	return nil
 }
 
 func (a *App) StopSchedulers() {
 	a.Logger.Info("Stopping schedulers...")
 	a.EventScheduler.Stop()
+	a.CoverWorker.Stop()
+	a.VerifyWorker.Stop()
 }
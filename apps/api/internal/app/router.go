@@ -12,6 +12,7 @@ import (
 	"github.com/zeroicey/lifetrack-api/internal/modules/habitlog"
 	"github.com/zeroicey/lifetrack-api/internal/modules/moment"
 	"github.com/zeroicey/lifetrack-api/internal/modules/storage"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage/driver"
 	"github.com/zeroicey/lifetrack-api/internal/modules/task"
 	"github.com/zeroicey/lifetrack-api/internal/modules/taskgroup"
 	"github.com/zeroicey/lifetrack-api/internal/modules/user"
@@ -35,11 +36,20 @@ func DetailedHealthCheckHandler(app *App) http.HandlerFunc {
 			dbStatus = "error: " + err.Error()
 		}
 
+		// 检查调度器 leader
+		var scheduler any
+		if status, err := app.EventScheduler.Status(ctx); err != nil {
+			scheduler = "error: " + err.Error()
+		} else {
+			scheduler = status
+		}
+
 		response.Success("Detailed health check").SetData(map[string]any{
 			"status":    "ok",
 			"timestamp": time.Now().Unix(),
 			"service":   "lifetrack-api",
 			"database":  dbStatus,
+			"scheduler": scheduler,
 		}).Build(w)
 	}
 }
@@ -47,17 +57,33 @@ func DetailedHealthCheckHandler(app *App) http.HandlerFunc {
 func RegisterRoutes(r chi.Router, app *App) {
 	r.NotFound(NotFoundHandler())
 
+	// 登录、注册和重置密码按 IP 限流，其余接口认证后按用户限流
+	var authLimiter, apiLimiter *middleware.RateLimiter
+	if rl := app.Config.RateLimit; rl.Enabled {
+		authLimiter = middleware.NewRateLimiter(rl.AuthRate, rl.AuthBurst)
+		apiLimiter = middleware.NewRateLimiter(rl.APIRate, rl.APIBurst)
+	}
+
 	r.Route("/api", func(api chi.Router) {
 		// 详细的健康检查（无需认证）
 		api.Get("/health", DetailedHealthCheckHandler(app))
 
-		// 用户相关路由（包含登录注册，部分无需认证）
-		api.Mount("/user", user.UserRouter(app.UserService, app.JWTManager))
+		// 用户相关路由（包含登录注册，部分无需认证），限流在路由内按接口分别设置
+		api.Mount("/user", user.UserRouter(app.UserService, app.JWTManager, authLimiter, apiLimiter))
+
+		// 日历订阅源（通过 token 查询参数认证，供日历客户端订阅）
+		api.With(middleware.RateLimit(apiLimiter)).Get("/events/calendar.ics", event.CalendarFeedHandler(app.EventService))
+
+		// 本地存储的签名上传和下载地址（通过 URL 签名认证），路径与 driver.LocalRoutePrefix 一致
+		if local, ok := app.StorageService.Driver().(*driver.Local); ok {
+			api.Mount("/storage/files", storage.LocalFileRouter(local, app.Logger))
+		}
 
 		// 受保护的API路由组（需要JWT认证）
 		api.Group(func(protected chi.Router) {
 			// 应用JWT认证中间件
-			protected.Use(middleware.JWTAuth(app.JWTManager))
+			protected.Use(middleware.JWTAuth(app.JWTManager, app.UserService, app.UserService))
+			protected.Use(middleware.RateLimit(apiLimiter))
 
 			// 所有需要认证的API
 			protected.Mount("/moments", moment.MomentRouter(app.MomentService))
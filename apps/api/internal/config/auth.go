@@ -0,0 +1,38 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// 注册模式
+const (
+	RegistrationModeOpen   = "open"   // 任何人都可以注册
+	RegistrationModeInvite = "invite" // 除第一个用户外，需要已有用户发出的邀请码
+)
+
+type AuthConfig struct {
+	RegistrationMode        string // "open", "invite"
+	InviteExpiry            int    // 邀请码有效期（小时）
+	AppURL                  string // 前端地址，用于生成邮件中的重置密码和验证邮箱链接
+	PasswordResetExpiry     int    // 重置密码链接有效期（分钟）
+	EmailVerificationExpiry int    // 验证邮箱链接有效期（小时）
+}
+
+func NewAuthConfig() *AuthConfig {
+	config := &AuthConfig{}
+
+	// 设置默认值
+	viper.SetDefault("REGISTRATION_MODE", RegistrationModeInvite)
+	viper.SetDefault("INVITE_EXPIRY", 168)
+	viper.SetDefault("APP_URL", "http://localhost:3000")
+	viper.SetDefault("PASSWORD_RESET_EXPIRY", 60)
+	viper.SetDefault("EMAIL_VERIFICATION_EXPIRY", 48)
+
+	config.RegistrationMode = viper.GetString("REGISTRATION_MODE")
+	config.InviteExpiry = viper.GetInt("INVITE_EXPIRY")
+	config.AppURL = viper.GetString("APP_URL")
+	config.PasswordResetExpiry = viper.GetInt("PASSWORD_RESET_EXPIRY")
+	config.EmailVerificationExpiry = viper.GetInt("EMAIL_VERIFICATION_EXPIRY")
+
+	return config
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -9,12 +10,18 @@ import (
 )
 
 type Config struct {
-	Port    string
-	APPMODE string
-	DB      *DBConfig
-	JWT     *JWTConfig
-	Storage *StorageConfig
-	Mail    *MailConfig
+	Port       string
+	APPMODE    string
+	InstanceID string // 实例标识，多副本部署时用于区分调度器 leader
+	// 可信反向代理的 CIDR 或 IP，只有来自这些地址的请求才读取 X-Forwarded-For 和 X-Real-IP，为空时使用直连地址
+	TrustedProxies []string
+	DB             *DBConfig
+	JWT            *JWTConfig
+	Auth           *AuthConfig
+	Storage        *StorageConfig
+	Mail           *MailConfig
+	Notify         *NotifyConfig
+	RateLimit      *RateLimitConfig
 }
 
 func NewConfig() (*Config, error) {
@@ -28,12 +35,23 @@ func NewConfig() (*Config, error) {
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	viper.SetDefault("APPMODE", "dev")
+	hostname, _ := os.Hostname()
+	viper.SetDefault("INSTANCE_ID", hostname)
 	config.Port = viper.GetString("APPPORT")
 	config.APPMODE = viper.GetString("APPMODE")
+	config.InstanceID = viper.GetString("INSTANCE_ID")
+	for _, proxy := range strings.Split(viper.GetString("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			config.TrustedProxies = append(config.TrustedProxies, proxy)
+		}
+	}
 
 	config.DB = NewDBConfig()
 	config.JWT = NewJWTConfig()
+	config.Auth = NewAuthConfig()
 	config.Storage = NewStorageConfig()
 	config.Mail = NewMailConfig()
+	config.Notify = NewNotifyConfig()
+	config.RateLimit = NewRateLimitConfig()
 	return config, nil
 }
@@ -5,12 +5,18 @@ import (
 )
 
 type JWTConfig struct {
-	JWTSecret string
+	JWTSecret       string
+	AccessTokenTTL  int // access token 有效期（分钟）
+	RefreshTokenTTL int // refresh token 有效期（天），每次刷新顺延
 }
 
 func NewJWTConfig() *JWTConfig {
 	config := &JWTConfig{}
 	viper.SetDefault("JWT_SECRET", "lifetrack-secret")
+	viper.SetDefault("JWT_ACCESS_TOKEN_TTL", 15)
+	viper.SetDefault("JWT_REFRESH_TOKEN_TTL", 30)
 	config.JWTSecret = viper.GetString("JWT_SECRET")
+	config.AccessTokenTTL = viper.GetInt("JWT_ACCESS_TOKEN_TTL")
+	config.RefreshTokenTTL = viper.GetInt("JWT_REFRESH_TOKEN_TTL")
 	return config
 }
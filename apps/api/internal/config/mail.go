@@ -11,6 +11,8 @@ type MailConfig struct {
 	Password string
 	From     string
 	To       string
+	// 邮件模板目录，目录中的同名模板会覆盖内置模板，为空时只使用内置模板
+	TemplatesDir string
 }
 
 func NewMailConfig() *MailConfig {
@@ -23,6 +25,7 @@ func NewMailConfig() *MailConfig {
 	viper.SetDefault("MAIL_PASSWORD", "123")
 	viper.SetDefault("MAIL_FROM", "123@qq.com")
 	viper.SetDefault("MAIL_TO", "123@qq.com")
+	viper.SetDefault("MAIL_TEMPLATES_DIR", "")
 
 	config.Host = viper.GetString("MAIL_HOST")
 	config.Port = viper.GetInt("MAIL_PORT")
@@ -30,6 +33,7 @@ func NewMailConfig() *MailConfig {
 	config.Password = viper.GetString("MAIL_PASSWORD")
 	config.From = viper.GetString("MAIL_FROM")
 	config.To = viper.GetString("MAIL_TO")
+	config.TemplatesDir = viper.GetString("MAIL_TEMPLATES_DIR")
 
 	return config
 }
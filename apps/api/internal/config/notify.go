@@ -0,0 +1,37 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+type NotifyConfig struct {
+	DefaultChannel      string // 新建提醒默认使用的渠道，用户未配置该渠道时使用邮件
+	Timeout             int    // HTTP 渠道请求超时时间（秒）
+	MaxAttempts         int    // 单条提醒的最大投递次数，超过后进入 failed 状态
+	RetryBaseDelay      int    // 首次重试的等待时间（秒），之后按指数递增
+	RetryMaxDelay       int    // 重试等待时间上限（秒）
+	TelegramAPIURL      string // Telegram Bot API 地址，可指向自建的 Bot API 服务
+	AllowPrivateTargets bool   // 是否允许用户渠道连接回环和内网地址，仅用于自建部署
+}
+
+func NewNotifyConfig() *NotifyConfig {
+	config := &NotifyConfig{}
+
+	// 设置默认值
+	viper.SetDefault("NOTIFY_DEFAULT_CHANNEL", "email")
+	viper.SetDefault("NOTIFY_TIMEOUT", 10)
+	viper.SetDefault("NOTIFY_MAX_ATTEMPTS", 5)
+	viper.SetDefault("NOTIFY_RETRY_BASE_DELAY", 60)
+	viper.SetDefault("NOTIFY_RETRY_MAX_DELAY", 3600)
+	viper.SetDefault("NOTIFY_TELEGRAM_API_URL", "https://api.telegram.org")
+
+	config.DefaultChannel = viper.GetString("NOTIFY_DEFAULT_CHANNEL")
+	config.Timeout = viper.GetInt("NOTIFY_TIMEOUT")
+	config.MaxAttempts = viper.GetInt("NOTIFY_MAX_ATTEMPTS")
+	config.RetryBaseDelay = viper.GetInt("NOTIFY_RETRY_BASE_DELAY")
+	config.RetryMaxDelay = viper.GetInt("NOTIFY_RETRY_MAX_DELAY")
+	config.TelegramAPIURL = viper.GetString("NOTIFY_TELEGRAM_API_URL")
+	config.AllowPrivateTargets = viper.GetBool("NOTIFY_ALLOW_PRIVATE_TARGETS")
+
+	return config
+}
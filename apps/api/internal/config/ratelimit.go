@@ -0,0 +1,46 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// RateLimitConfig 请求限流配置，按路由组分别设置速率和突发量
+type RateLimitConfig struct {
+	Enabled   bool
+	AuthRate  int // 用户路由组（登录、注册、重置密码等）每个 IP 每分钟的请求数
+	AuthBurst int // 用户路由组允许的突发请求数
+	APIRate   int // 其余需要认证的路由每个用户每分钟的请求数
+	APIBurst  int // 需要认证的路由允许的突发请求数
+
+	LoginMaxAttempts   int // 同一邮箱连续登录失败多少次后开始锁定
+	LoginLockoutBase   int // 首次锁定时长（秒），之后每次失败翻倍
+	LoginLockoutMax    int // 最长锁定时长（秒）
+	LoginFailureWindow int // 失败次数的统计窗口（分钟），超过后重新计数
+}
+
+func NewRateLimitConfig() *RateLimitConfig {
+	config := &RateLimitConfig{}
+
+	// 设置默认值
+	viper.SetDefault("RATE_LIMIT_ENABLED", true)
+	viper.SetDefault("RATE_LIMIT_AUTH_RATE", 20)
+	viper.SetDefault("RATE_LIMIT_AUTH_BURST", 10)
+	viper.SetDefault("RATE_LIMIT_API_RATE", 300)
+	viper.SetDefault("RATE_LIMIT_API_BURST", 60)
+	viper.SetDefault("LOGIN_MAX_ATTEMPTS", 5)
+	viper.SetDefault("LOGIN_LOCKOUT_BASE", 60)
+	viper.SetDefault("LOGIN_LOCKOUT_MAX", 3600)
+	viper.SetDefault("LOGIN_FAILURE_WINDOW", 1440)
+
+	config.Enabled = viper.GetBool("RATE_LIMIT_ENABLED")
+	config.AuthRate = viper.GetInt("RATE_LIMIT_AUTH_RATE")
+	config.AuthBurst = viper.GetInt("RATE_LIMIT_AUTH_BURST")
+	config.APIRate = viper.GetInt("RATE_LIMIT_API_RATE")
+	config.APIBurst = viper.GetInt("RATE_LIMIT_API_BURST")
+	config.LoginMaxAttempts = viper.GetInt("LOGIN_MAX_ATTEMPTS")
+	config.LoginLockoutBase = viper.GetInt("LOGIN_LOCKOUT_BASE")
+	config.LoginLockoutMax = viper.GetInt("LOGIN_LOCKOUT_MAX")
+	config.LoginFailureWindow = viper.GetInt("LOGIN_FAILURE_WINDOW")
+
+	return config
+}
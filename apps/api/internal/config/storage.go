@@ -1,18 +1,28 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/spf13/viper"
 )
 
 type StorageConfig struct {
-	Provider        string // "minio", "aws", "tencent", "aliyun"
+	Provider        string // "local", "minio", "aws", "tencent", "aliyun"
 	Endpoint        string
 	AccessKey       string
 	SecretKey       string
 	BucketName      string
 	Region          string
 	UseSSL          bool
-	PresignedExpiry int // 预签名URL过期时间（秒）
+	PresignedExpiry    int   // 预签名URL过期时间（秒）
+	AvatarMaxSize      int64 // 头像原图的最大字节数
+	CoverMaxSourceSize int64 // 服务端生成封面时原图的最大字节数，更大的图片标记为生成失败
+	CoverPollInterval  int   // 封面生成任务轮询待处理附件的间隔（秒）
+	LocalDir           string // local 存储保存对象的目录
+	PublicURL          string // API 对外的访问地址，local 存储用它生成签名的上传和下载地址
+	SigningKey         string // local 存储签名地址的密钥，为空时使用 JWT_SECRET
+	GCEnabled          bool   // 是否定时回收未引用的附件和孤立对象
+	GCGracePeriod      int    // 附件和对象超过该时间（小时）未被引用才会被回收，需大于预签名地址的有效期
 }
 
 func NewStorageConfig() *StorageConfig {
@@ -27,6 +37,13 @@ func NewStorageConfig() *StorageConfig {
 	viper.SetDefault("STORAGE_REGION", "us-east-1")
 	viper.SetDefault("STORAGE_USE_SSL", false)
 	viper.SetDefault("STORAGE_PRESIGNED_EXPIRY", 10*60)
+	viper.SetDefault("STORAGE_AVATAR_MAX_SIZE", 5<<20)
+	viper.SetDefault("STORAGE_COVER_MAX_SOURCE_SIZE", 32<<20)
+	viper.SetDefault("STORAGE_COVER_POLL_INTERVAL", 30)
+	viper.SetDefault("STORAGE_LOCAL_DIR", "data/storage")
+	viper.SetDefault("STORAGE_PUBLIC_URL", "http://localhost:5000")
+	viper.SetDefault("STORAGE_GC_ENABLED", true)
+	viper.SetDefault("STORAGE_GC_GRACE_PERIOD", 24)
 
 	config.Provider = viper.GetString("STORAGE_PROVIDER")
 	config.Endpoint = viper.GetString("STORAGE_ENDPOINT")
@@ -36,6 +53,14 @@ func NewStorageConfig() *StorageConfig {
 	config.Region = viper.GetString("STORAGE_REGION")
 	config.UseSSL = viper.GetBool("STORAGE_USE_SSL")
 	config.PresignedExpiry = viper.GetInt("STORAGE_PRESIGNED_EXPIRY")
+	config.AvatarMaxSize = viper.GetInt64("STORAGE_AVATAR_MAX_SIZE")
+	config.CoverMaxSourceSize = viper.GetInt64("STORAGE_COVER_MAX_SOURCE_SIZE")
+	config.CoverPollInterval = viper.GetInt("STORAGE_COVER_POLL_INTERVAL")
+	config.LocalDir = viper.GetString("STORAGE_LOCAL_DIR")
+	config.PublicURL = strings.TrimRight(viper.GetString("STORAGE_PUBLIC_URL"), "/")
+	config.SigningKey = viper.GetString("STORAGE_SIGNING_KEY")
+	config.GCEnabled = viper.GetBool("STORAGE_GC_ENABLED")
+	config.GCGracePeriod = viper.GetInt("STORAGE_GC_GRACE_PERIOD")
 
 	return config
 }
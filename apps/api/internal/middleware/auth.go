@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -16,6 +17,8 @@ const (
 	UserIDKey ContextKey = "user_id"
 	// UserEmailKey 用户邮箱在context中的键
 	UserEmailKey ContextKey = "user_email"
+	// SessionIDKey 会话ID在context中的键
+	SessionIDKey ContextKey = "session_id"
 )
 
 // JWTManager 接口定义
@@ -23,8 +26,30 @@ type JWTManager interface {
 	ValidateToken(token string) (*pkg.JWTClaims, error)
 }
 
-// JWTAuth JWT认证中间件
-func JWTAuth(jwtManager JWTManager) func(http.Handler) http.Handler {
+// SessionValidator 检查会话是否仍然有效（未撤销、未过期）
+type SessionValidator interface {
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+// ErrInvalidAPIToken 是个人访问令牌不存在或已过期时返回的哨兵错误
+var ErrInvalidAPIToken = errors.New("invalid or expired api token")
+
+// APIToken 是通过校验的个人访问令牌
+type APIToken struct {
+	ID     int64
+	UserID int64
+	Email  string
+	Scopes []string
+}
+
+// APITokenValidator 校验个人访问令牌，令牌无效或已过期时返回错误
+type APITokenValidator interface {
+	ValidateAPIToken(ctx context.Context, token string) (APIToken, error)
+}
+
+// JWTAuth JWT认证中间件，token 的 jti 对应的会话被撤销后立即拒绝
+// 同时接受以 APITokenPrefix 开头的个人访问令牌，按令牌的授权范围限制可访问的路由
+func JWTAuth(jwtManager JWTManager, sessions SessionValidator, apiTokens APITokenValidator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// 从请求头获取Authorization
@@ -47,6 +72,28 @@ func JWTAuth(jwtManager JWTManager) func(http.Handler) http.Handler {
 				return
 			}
 
+			// 个人访问令牌
+			if strings.HasPrefix(token, APITokenPrefix) {
+				apiToken, err := apiTokens.ValidateAPIToken(r.Context(), token)
+				if err != nil {
+					if errors.Is(err, ErrInvalidAPIToken) {
+						pkg.Error("Invalid or expired token").SetStatusCode(http.StatusUnauthorized).Build(w)
+						return
+					}
+					pkg.Error("Failed to validate token").SetStatusCode(http.StatusInternalServerError).Build(w)
+					return
+				}
+				if !scopeAllows(apiToken.Scopes, r) {
+					pkg.Error("Token scope does not allow this request").SetStatusCode(http.StatusForbidden).Build(w)
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), UserIDKey, apiToken.UserID)
+				ctx = context.WithValue(ctx, UserEmailKey, apiToken.Email)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			// 验证token
 			claims, err := jwtManager.ValidateToken(token)
 			if err != nil {
@@ -54,9 +101,21 @@ func JWTAuth(jwtManager JWTManager) func(http.Handler) http.Handler {
 				return
 			}
 
+			// 检查会话是否已被撤销
+			active, err := sessions.IsSessionActive(r.Context(), claims.ID)
+			if err != nil {
+				pkg.Error("Failed to validate session").SetStatusCode(http.StatusInternalServerError).Build(w)
+				return
+			}
+			if !active {
+				pkg.Error("Session has been revoked").SetStatusCode(http.StatusUnauthorized).Build(w)
+				return
+			}
+
 			// 将用户信息添加到上下文
 			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
 			ctx = context.WithValue(ctx, UserEmailKey, claims.Email)
+			ctx = context.WithValue(ctx, SessionIDKey, claims.ID)
 
 			// 继续处理请求
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -75,3 +134,9 @@ func GetUserEmailFromContext(ctx context.Context) (string, bool) {
 	email, ok := ctx.Value(UserEmailKey).(string)
 	return email, ok
 }
+
+// GetSessionIDFromContext 从context中获取当前会话ID
+func GetSessionIDFromContext(ctx context.Context) (string, bool) {
+	sessionID, ok := ctx.Value(SessionIDKey).(string)
+	return sessionID, ok
+}
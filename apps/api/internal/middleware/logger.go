@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/zeroicey/lifetrack-api/internal/config"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -37,7 +38,7 @@ func Logger(logger *zap.Logger) func(http.Handler) http.Handler {
 				statusColor, ww.statusCode, ColorReset,
 				ColorBlue, r.Method, ColorReset,
 				r.URL.Path,
-				ColorGray, r.RemoteAddr, ColorReset,
+				ColorGray, pkg.ClientIP(r), ColorReset,
 				ColorCyan+duration.String()+ColorReset,
 			)
 		})
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
+)
+
+// RealIP 按可信代理列表解析客户端 IP，之后 pkg.ClientIP 返回解析结果
+// 只有直连地址属于 trusted 时才读取 X-Forwarded-For 和 X-Real-IP，trusted 为空时始终使用 RemoteAddr
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := pkg.ResolveClientIP(r, trusted)
+			next.ServeHTTP(w, r.WithContext(pkg.WithClientIP(r.Context(), ip)))
+		})
+	}
+}
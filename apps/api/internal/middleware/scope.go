@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// APITokenPrefix 个人访问令牌的前缀，用于和 JWT 区分
+const APITokenPrefix = "lt_"
+
+// 个人访问令牌的全局授权范围，write 包含 read
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// scopeResources 路由前缀到授权资源的映射，未列出的路由（如用户账户相关）不允许使用个人访问令牌
+var scopeResources = map[string]string{
+	"moments":     "moments",
+	"storage":     "moments",
+	"tasks":       "tasks",
+	"task-groups": "tasks",
+	"events":      "events",
+	"habits":      "habits",
+	"habit-logs":  "habits",
+}
+
+// scopeExcludedPaths 映射到某个资源但仍然不允许个人访问令牌访问的路由前缀
+// 头像属于账户资料，不能通过 moments 授权修改
+var scopeExcludedPaths = []string{
+	"storage/avatar",
+}
+
+// ValidScope 判断是否为合法的授权范围：read、write 或 <资源>:read、<资源>:write
+func ValidScope(scope string) bool {
+	if scope == ScopeRead || scope == ScopeWrite {
+		return true
+	}
+	resource, action, ok := strings.Cut(scope, ":")
+	if !ok || (action != ScopeRead && action != ScopeWrite) {
+		return false
+	}
+	for _, r := range scopeResources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeAllows 判断令牌的授权范围是否允许访问该请求，GET/HEAD 需要 read，其他方法需要 write
+func scopeAllows(scopes []string, r *http.Request) bool {
+	path := strings.TrimPrefix(r.URL.Path, "/api/")
+	segment, _, _ := strings.Cut(path, "/")
+	resource, ok := scopeResources[segment]
+	if !ok {
+		return false
+	}
+	for _, excluded := range scopeExcludedPaths {
+		if path == excluded || strings.HasPrefix(path, excluded+"/") {
+			return false
+		}
+	}
+
+	action := ScopeWrite
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		action = ScopeRead
+	}
+
+	if slices.Contains(scopes, ScopeWrite) || slices.Contains(scopes, resource+":"+ScopeWrite) {
+		return true
+	}
+	return action == ScopeRead && (slices.Contains(scopes, ScopeRead) || slices.Contains(scopes, resource+":"+ScopeRead))
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestScopeAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		method string
+		path   string
+		want   bool
+	}{
+		{"resource write allows uploads", []string{"moments:write"}, http.MethodPost, "/api/storage/multipart", true},
+		{"resource read allows get", []string{"moments:read"}, http.MethodGet, "/api/moments", true},
+		{"resource read rejects write", []string{"moments:read"}, http.MethodPost, "/api/moments", false},
+		{"other resource is rejected", []string{"tasks:write"}, http.MethodPost, "/api/moments", false},
+		{"account routes are rejected", []string{"write"}, http.MethodGet, "/api/user/me", false},
+		{"avatar upload is rejected", []string{"moments:write"}, http.MethodPost, "/api/storage/avatar/presigned", false},
+		{"avatar delete is rejected", []string{"write"}, http.MethodDelete, "/api/storage/avatar", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if got := scopeAllows(tt.scopes, r); got != tt.want {
+				t.Errorf("scopeAllows(%v, %s %s) = %v, want %v", tt.scopes, tt.method, tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package event
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zeroicey/lifetrack-api/internal/modules/event/types"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+)
+
+const (
+	// calendarTokenPrefix 日历订阅令牌的前缀，与个人访问令牌区分
+	calendarTokenPrefix = "cal_"
+	// calendarTokenBytes 日历订阅令牌的随机字节数
+	calendarTokenBytes = 24
+	// calendarTokenPrefixLen 展示的令牌前缀长度（包含 calendarTokenPrefix）
+	calendarTokenPrefixLen = 10
+	// calendarFeedPath 订阅源地址，令牌通过 token 查询参数传递
+	calendarFeedPath = "/api/events/calendar.ics"
+)
+
+var (
+	// ErrCalendarTokenNotFound 是用户尚未生成或已撤销日历订阅令牌时返回的哨兵错误
+	ErrCalendarTokenNotFound = errors.New("calendar token not found")
+)
+
+// RotateCalendarToken 生成新的日历订阅令牌并替换旧令牌，明文只在此时返回一次
+func (s *Service) RotateCalendarToken(ctx context.Context, userID int64) (types.CalendarTokenResponse, error) {
+	buf := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return types.CalendarTokenResponse{}, err
+	}
+	token := calendarTokenPrefix + hex.EncodeToString(buf)
+
+	calendarToken, err := s.Q.UpsertCalendarToken(ctx, repository.UpsertCalendarTokenParams{
+		UserID:      userID,
+		TokenPrefix: token[:calendarTokenPrefixLen],
+		TokenHash:   hashCalendarToken(token),
+	})
+	if err != nil {
+		return types.CalendarTokenResponse{}, err
+	}
+
+	result := convertToCalendarTokenResponse(calendarToken)
+	result.Token = token
+	result.URL = calendarFeedPath + "?token=" + url.QueryEscape(token)
+	return result, nil
+}
+
+// GetCalendarToken 获取当前日历订阅令牌的信息，不包含明文
+func (s *Service) GetCalendarToken(ctx context.Context, userID int64) (types.CalendarTokenResponse, error) {
+	calendarToken, err := s.Q.GetCalendarToken(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.CalendarTokenResponse{}, ErrCalendarTokenNotFound
+		}
+		return types.CalendarTokenResponse{}, err
+	}
+	return convertToCalendarTokenResponse(calendarToken), nil
+}
+
+// RevokeCalendarToken 撤销日历订阅令牌，已订阅的日历客户端随即无法拉取
+func (s *Service) RevokeCalendarToken(ctx context.Context, userID int64) error {
+	rows, err := s.Q.DeleteCalendarToken(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrCalendarTokenNotFound
+	}
+	return nil
+}
+
+// ValidateCalendarToken 校验日历订阅令牌并记录使用时间，返回令牌所属用户
+func (s *Service) ValidateCalendarToken(ctx context.Context, token string) (int64, error) {
+	userID, err := s.Q.TouchCalendarToken(ctx, hashCalendarToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrCalendarTokenNotFound
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+// hashCalendarToken 计算令牌的 SHA-256 哈希，数据库中只保存哈希
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func convertToCalendarTokenResponse(calendarToken repository.CalendarToken) types.CalendarTokenResponse {
+	var lastUsedAt *time.Time
+	if calendarToken.LastUsedAt.Valid {
+		lastUsedAt = &calendarToken.LastUsedAt.Time
+	}
+	return types.CalendarTokenResponse{
+		Prefix:     calendarToken.TokenPrefix,
+		LastUsedAt: lastUsedAt,
+		CreatedAt:  calendarToken.CreatedAt.Time,
+	}
+}
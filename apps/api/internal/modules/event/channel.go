@@ -0,0 +1,188 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zeroicey/lifetrack-api/internal/modules/event/types"
+	"github.com/zeroicey/lifetrack-api/internal/modules/notification"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+)
+
+// ErrReminderChannelNotFound 是用户没有配置该提醒渠道时返回的哨兵错误
+var ErrReminderChannelNotFound = errors.New("reminder channel not configured")
+
+// ReminderChannels 返回用户可用的提醒渠道：系统渠道（邮件）和用户自己配置的渠道
+func (s *Service) ReminderChannels(ctx context.Context, userID int64) ([]string, error) {
+	configured, err := s.Q.ListNotificationChannels(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := s.notificationService.Channels()
+	for _, c := range configured {
+		if !slices.Contains(channels, c.Channel) {
+			channels = append(channels, c.Channel)
+		}
+	}
+	slices.Sort(channels)
+	return channels, nil
+}
+
+// GetReminderChannel 获取用户某个提醒渠道的配置
+func (s *Service) GetReminderChannel(ctx context.Context, userID int64, channel string) (types.ReminderChannelResponse, error) {
+	settings, err := s.Q.GetNotificationChannel(ctx, repository.GetNotificationChannelParams{
+		UserID:  userID,
+		Channel: channel,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.ReminderChannelResponse{}, ErrReminderChannelNotFound
+		}
+		return types.ReminderChannelResponse{}, err
+	}
+	return convertReminderChannel(settings), nil
+}
+
+// UpsertReminderChannel 保存用户自己的 Webhook、推送或 Telegram 配置，保存前先校验能否创建渠道
+func (s *Service) UpsertReminderChannel(ctx context.Context, userID int64, channel string, body types.UpsertReminderChannelBody) (types.ReminderChannelResponse, error) {
+	if !slices.Contains(notification.UserChannels, channel) {
+		return types.ReminderChannelResponse{}, fmt.Errorf("%w: %s", notification.ErrUnknownChannel, channel)
+	}
+
+	settings := notification.ChannelSettings{Channel: channel}
+	switch channel {
+	case notification.ChannelWebhook:
+		settings.Target = strings.TrimSpace(body.URL)
+		settings.Secret = body.Secret
+	case notification.ChannelPush:
+		settings.Provider = strings.ToLower(strings.TrimSpace(body.Provider))
+		settings.Target = strings.TrimSpace(body.URL)
+		settings.Secret = body.Token
+	case notification.ChannelTelegram:
+		settings.Target = strings.TrimSpace(body.ChatID)
+		settings.Secret = strings.TrimSpace(body.Token)
+	}
+	if _, err := s.notificationService.NewChannel(settings); err != nil {
+		return types.ReminderChannelResponse{}, err
+	}
+
+	saved, err := s.Q.UpsertNotificationChannel(ctx, repository.UpsertNotificationChannelParams{
+		UserID:   userID,
+		Channel:  settings.Channel,
+		Provider: settings.Provider,
+		Target:   settings.Target,
+		Secret:   settings.Secret,
+	})
+	if err != nil {
+		return types.ReminderChannelResponse{}, err
+	}
+	return convertReminderChannel(saved), nil
+}
+
+// DeleteReminderChannel 删除用户的渠道配置，仍使用该渠道的提醒会投递失败，可在投递记录中看到
+func (s *Service) DeleteReminderChannel(ctx context.Context, userID int64, channel string) error {
+	rows, err := s.Q.DeleteNotificationChannel(ctx, repository.DeleteNotificationChannelParams{
+		UserID:  userID,
+		Channel: channel,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrReminderChannelNotFound
+	}
+	return nil
+}
+
+// resolveReminderChannel 校验提醒渠道是系统渠道或用户已配置的渠道
+// 为空时使用配置的默认渠道，用户没有配置默认渠道时使用邮件
+func (s *Service) resolveReminderChannel(ctx context.Context, userID int64, channel string) (string, error) {
+	if channel == "" {
+		channel = s.config.Notify.DefaultChannel
+		if ok, err := s.hasReminderChannel(ctx, userID, channel); err != nil || !ok {
+			return notification.ChannelEmail, err
+		}
+		return channel, nil
+	}
+
+	ok, err := s.hasReminderChannel(ctx, userID, channel)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %s", notification.ErrUnknownChannel, channel)
+	}
+	return channel, nil
+}
+
+// hasReminderChannel 判断渠道对该用户是否可用
+func (s *Service) hasReminderChannel(ctx context.Context, userID int64, channel string) (bool, error) {
+	if s.notificationService.HasChannel(channel) {
+		return true, nil
+	}
+	_, err := s.Q.GetNotificationChannel(ctx, repository.GetNotificationChannelParams{
+		UserID:  userID,
+		Channel: channel,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// sendDelivery 邮件通过系统渠道发送到事件所有者的邮箱，其他渠道使用事件所有者自己保存的配置
+func (s *Service) sendDelivery(ctx context.Context, delivery repository.ReminderDelivery) error {
+	msg := notification.Message{
+		To:    delivery.Recipient,
+		Title: delivery.Title,
+		Body:  delivery.Body,
+		HTML:  delivery.Html,
+	}
+	if s.notificationService.HasChannel(delivery.Channel) {
+		return s.notificationService.Send(ctx, delivery.Channel, msg)
+	}
+
+	settings, err := s.Q.GetEventOwnerNotificationChannel(ctx, repository.GetEventOwnerNotificationChannelParams{
+		ID:      delivery.EventID,
+		Channel: delivery.Channel,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: %s", notification.ErrUnknownChannel, delivery.Channel)
+		}
+		return err
+	}
+	ch, err := s.notificationService.NewChannel(notification.ChannelSettings{
+		Channel:  settings.Channel,
+		Provider: settings.Provider,
+		Target:   settings.Target,
+		Secret:   settings.Secret,
+	})
+	if err != nil {
+		return err
+	}
+	return s.notificationService.SendWith(ctx, ch, msg)
+}
+
+func convertReminderChannel(c repository.NotificationChannel) types.ReminderChannelResponse {
+	result := types.ReminderChannelResponse{
+		Channel:   c.Channel,
+		Provider:  c.Provider,
+		HasSecret: c.Secret != "",
+		UpdatedAt: c.UpdatedAt.Time,
+	}
+	if c.Channel == notification.ChannelTelegram {
+		result.ChatID = c.Target
+	} else {
+		result.URL = c.Target
+	}
+	return result
+}
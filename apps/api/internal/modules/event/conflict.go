@@ -0,0 +1,287 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/modules/event/types"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+// conflictHorizon 重复事件只检查这段时间内的实例是否冲突
+const conflictHorizon = 90 * 24 * time.Hour
+
+// maxFreeBusyRange 空闲/忙碌查询允许的最大范围
+const maxFreeBusyRange = 366 * 24 * time.Hour
+
+// defaultFreeSlotMinutes 未指定时空闲时段的默认最短时长（分钟）
+const defaultFreeSlotMinutes = 30
+
+var (
+	// ErrEventConflict 是事件与已有事件时间重叠时返回的哨兵错误
+	ErrEventConflict = errors.New("event conflicts with existing events")
+	// ErrInvalidFreeBusyRange 是空闲/忙碌查询的范围或最短时长不合法时返回的哨兵错误
+	ErrInvalidFreeBusyRange = errors.New("invalid free/busy range")
+)
+
+// ConflictError 携带与事件时间重叠的已有事件，errors.Is(err, ErrEventConflict) 为 true
+type ConflictError struct {
+	Conflicts []types.EventConflict
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: %d overlapping event(s)", ErrEventConflict, len(e.Conflicts))
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrEventConflict
+}
+
+// checkConflicts 检查候选事件是否与其他事件重叠，有重叠时返回 *ConflictError
+// 只与同一用户的事件比较，excludeID 为正在更新的事件ID，新建事件时为 0
+func (s *Service) checkConflicts(ctx context.Context, userID int64, excludeID int64, candidate types.EventResponse) error {
+	conflicts, err := s.findConflicts(ctx, userID, excludeID, candidate)
+	if err != nil {
+		s.logger.Error("Failed to check event conflicts", zap.Error(err))
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// findConflicts 返回与候选事件时间重叠的已有事件实例
+// 重复事件只检查 conflictHorizon 内的实例
+func (s *Service) findConflicts(ctx context.Context, userID int64, excludeID int64, candidate types.EventResponse) ([]types.EventConflict, error) {
+	slots := []types.EventResponse{candidate}
+	if candidate.RRule != "" {
+		slots = s.expandOccurrences(candidate, nil, candidate.StartTime, candidate.StartTime.Add(conflictHorizon))
+	}
+	if len(slots) == 0 {
+		return nil, nil
+	}
+
+	from, to := slots[0].StartTime, slots[0].EndTime
+	for _, slot := range slots[1:] {
+		if slot.StartTime.Before(from) {
+			from = slot.StartTime
+		}
+		if slot.EndTime.After(to) {
+			to = slot.EndTime
+		}
+	}
+
+	occurrences, err := s.occurrencesInRange(ctx, userID, from, to, excludeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []types.EventConflict
+	for _, occurrence := range occurrences {
+		for _, slot := range slots {
+			if !overlaps(occurrence.StartTime, occurrence.EndTime, slot.StartTime, slot.EndTime) {
+				continue
+			}
+			conflicts = append(conflicts, types.EventConflict{
+				EventID:      occurrence.ID,
+				Name:         occurrence.Name,
+				StartTime:    occurrence.StartTime,
+				EndTime:      occurrence.EndTime,
+				RecurrenceID: occurrence.RecurrenceID,
+			})
+			break
+		}
+	}
+	return conflicts, nil
+}
+
+// GetFreeBusy 返回 [start, end) 内的忙碌时段和不短于 minMinutes 分钟的空闲时段
+// start、end 可以是 RFC 3339 时间或 YYYY-MM-DD 日期，日期按 timezone（为空时为用户时区）解析，结束日期包含当天
+func (s *Service) GetFreeBusy(ctx context.Context, userID int64, start, end, timezone string, minMinutes int) (types.FreeBusyResponse, error) {
+	loc, err := pkg.ResolveLocation(ctx, s.Q, userID, timezone)
+	if err != nil {
+		return types.FreeBusyResponse{}, err
+	}
+	if minMinutes == 0 {
+		minMinutes = defaultFreeSlotMinutes
+	}
+	if minMinutes < 0 {
+		return types.FreeBusyResponse{}, fmt.Errorf("%w: min_duration must be positive", ErrInvalidFreeBusyRange)
+	}
+
+	from, err := parseRangeBound(start, loc, false)
+	if err != nil {
+		return types.FreeBusyResponse{}, fmt.Errorf("%w: invalid start", ErrInvalidFreeBusyRange)
+	}
+	to, err := parseRangeBound(end, loc, true)
+	if err != nil {
+		return types.FreeBusyResponse{}, fmt.Errorf("%w: invalid end", ErrInvalidFreeBusyRange)
+	}
+	if !to.After(from) {
+		return types.FreeBusyResponse{}, fmt.Errorf("%w: end must be after start", ErrInvalidFreeBusyRange)
+	}
+	if to.Sub(from) > maxFreeBusyRange {
+		return types.FreeBusyResponse{}, fmt.Errorf("%w: range must not exceed 366 days", ErrInvalidFreeBusyRange)
+	}
+
+	occurrences, err := s.occurrencesInRange(ctx, userID, from, to, 0)
+	if err != nil {
+		s.logger.Error("Failed to get events for free/busy", zap.Error(err))
+		return types.FreeBusyResponse{}, err
+	}
+
+	busy := mergeBusyBlocks(occurrences, from, to)
+	minDuration := time.Duration(minMinutes) * time.Minute
+	free := []types.TimeBlock{}
+	cursor := from
+	for _, block := range append(busy, types.TimeBlock{Start: to, End: to}) {
+		if block.Start.Sub(cursor) >= minDuration {
+			free = append(free, types.TimeBlock{Start: cursor.In(loc), End: block.Start.In(loc)})
+		}
+		if block.End.After(cursor) {
+			cursor = block.End
+		}
+	}
+	for i := range busy {
+		busy[i] = types.TimeBlock{Start: busy[i].Start.In(loc), End: busy[i].End.In(loc)}
+	}
+
+	return types.FreeBusyResponse{
+		Start:       from.In(loc),
+		End:         to.In(loc),
+		Timezone:    loc.String(),
+		MinDuration: minMinutes,
+		Busy:        busy,
+		Free:        free,
+	}, nil
+}
+
+// occurrencesInRange 返回用户与 [from, to) 重叠的所有事件实例，重复事件会被展开
+func (s *Service) occurrencesInRange(ctx context.Context, userID int64, from, to time.Time, excludeID int64) ([]types.EventResponse, error) {
+	rangeStart := pgtype.Timestamptz{}
+	rangeStart.Scan(from)
+	rangeEnd := pgtype.Timestamptz{}
+	rangeEnd.Scan(to)
+
+	rows, err := s.Q.GetEventsOverlappingRange(ctx, repository.GetEventsOverlappingRangeParams{
+		RangeStart: rangeStart,
+		RangeEnd:   rangeEnd,
+		UserID:     userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var events []types.EventResponse
+	var recurringIDs []int64
+	for _, row := range rows {
+		if row.ID == excludeID {
+			continue
+		}
+		events = append(events, convertEvent(row))
+		if row.Rrule != "" {
+			recurringIDs = append(recurringIDs, row.ID)
+		}
+	}
+
+	overridesByEvent := make(map[int64][]repository.EventOverride)
+	if len(recurringIDs) > 0 {
+		overrides, err := s.Q.GetEventOverridesByEventIDs(ctx, recurringIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range overrides {
+			overridesByEvent[o.EventID] = append(overridesByEvent[o.EventID], o)
+		}
+	}
+
+	var result []types.EventResponse
+	for _, event := range events {
+		if event.RRule == "" {
+			result = append(result, event)
+			continue
+		}
+		for _, occurrence := range s.expandOccurrences(event, overridesByEvent[event.ID], from, to) {
+			if overlaps(occurrence.StartTime, occurrence.EndTime, from, to) {
+				result = append(result, occurrence)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].StartTime.Before(result[j].StartTime)
+	})
+	return result, nil
+}
+
+// mergeBusyBlocks 将已按开始时间排序的实例裁剪到 [from, to) 并合并重叠或相接的时段
+func mergeBusyBlocks(occurrences []types.EventResponse, from, to time.Time) []types.TimeBlock {
+	busy := []types.TimeBlock{}
+	for _, occurrence := range occurrences {
+		start, end := occurrence.StartTime, occurrence.EndTime
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			continue
+		}
+		if n := len(busy); n > 0 && !start.After(busy[n-1].End) {
+			if end.After(busy[n-1].End) {
+				busy[n-1].End = end
+			}
+			continue
+		}
+		busy = append(busy, types.TimeBlock{Start: start, End: end})
+	}
+	return busy
+}
+
+// parseRangeBound 解析 RFC 3339 时间或 YYYY-MM-DD 日期，作为结束边界的日期取次日零点
+func parseRangeBound(value string, loc *time.Location, isEnd bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := pkg.ParseDate(value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if isEnd {
+		_, next := pkg.DayRange(day)
+		return next, nil
+	}
+	return day, nil
+}
+
+// overlaps 判断 [aStart, aEnd) 与 [bStart, bEnd) 是否重叠，首尾相接不算重叠
+func overlaps(aStart, aEnd, bStart, bEnd time.Time) bool {
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+// convertEvent 将不含提醒的事件记录转换为响应模型
+func convertEvent(e repository.Event) types.EventResponse {
+	return types.EventResponse{
+		ID:          e.ID,
+		Name:        e.Name,
+		Place:       e.Place,
+		Description: e.Description,
+		StartTime:   e.StartTime.Time,
+		EndTime:     e.EndTime.Time,
+		UID:         e.Uid,
+		Timezone:    e.Timezone,
+		RRule:       e.Rrule,
+		ExDates:     fromPgTimestamps(e.Exdates),
+		Reminders:   []types.EventReminder{},
+		CreatedAt:   e.CreatedAt.Time,
+		UpdatedAt:   e.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,233 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/modules/event/types"
+	"github.com/zeroicey/lifetrack-api/internal/modules/notification"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+// 提醒投递状态
+const (
+	DeliveryStatusPending = "pending"
+	DeliveryStatusSending = "sending"
+	DeliveryStatusSent    = "sent"
+	DeliveryStatusFailed  = "failed"
+)
+
+const (
+	// deliveryBatchSize 每轮最多投递的发件箱记录数
+	deliveryBatchSize = 100
+	// deliveryStaleAfter sending 状态的记录超过该时间仍未结束时（例如实例退出）重新领取
+	// 需要大于一整批记录按渠道超时依次发送的耗时
+	deliveryStaleAfter = 30 * time.Minute
+)
+
+var (
+	// ErrDeliveryNotFound 是投递记录不存在时返回的哨兵错误
+	ErrDeliveryNotFound = errors.New("reminder delivery not found")
+	// ErrDeliveryNotFailed 是重试非 failed 状态的投递记录时返回的哨兵错误
+	ErrDeliveryNotFailed = errors.New("only failed deliveries can be retried")
+	// ErrInvalidDeliveryStatus 是按未知状态查询投递记录时返回的哨兵错误
+	ErrInvalidDeliveryStatus = errors.New("invalid delivery status")
+)
+
+// dispatchReminderDeliveries 领取并投递发件箱中到期的记录
+func (s *Service) dispatchReminderDeliveries(ctx context.Context) {
+	staleBefore := pgtype.Timestamptz{}
+	staleBefore.Scan(time.Now().Add(-deliveryStaleAfter))
+	deliveries, err := s.Q.ClaimDueReminderDeliveries(ctx, repository.ClaimDueReminderDeliveriesParams{
+		StaleBefore: staleBefore,
+		BatchSize:   deliveryBatchSize,
+	})
+	if err != nil {
+		s.logger.Error("Failed to get due reminder deliveries", zap.Error(err))
+		return
+	}
+
+	for _, delivery := range deliveries {
+		s.deliver(ctx, delivery)
+	}
+}
+
+// deliver 投递一条已领取（sending）的记录并记录结果：成功标记为 sent，失败按指数退避重新排入 pending，
+// 超过最大次数或渠道不可用时标记为 failed
+func (s *Service) deliver(ctx context.Context, delivery repository.ReminderDelivery) {
+	attempt := delivery.Attempts + 1
+	sendErr := s.sendDelivery(ctx, delivery)
+
+	errMsg := ""
+	if sendErr != nil {
+		errMsg = sendErr.Error()
+	}
+	err := s.Q.CreateReminderDeliveryAttempt(ctx, repository.CreateReminderDeliveryAttemptParams{
+		DeliveryID: delivery.ID,
+		Attempt:    attempt,
+		Success:    sendErr == nil,
+		Error:      errMsg,
+	})
+	if err != nil {
+		s.logger.Error("Failed to record reminder delivery attempt", zap.Int64("delivery_id", delivery.ID), zap.Error(err))
+	}
+
+	switch {
+	case sendErr == nil:
+		err = s.Q.MarkReminderDeliverySent(ctx, delivery.ID)
+	case isPermanentDeliveryError(sendErr) || int(attempt) >= s.config.Notify.MaxAttempts:
+		s.logger.Warn("Reminder delivery moved to failed state",
+			zap.Int64("delivery_id", delivery.ID),
+			zap.Int64("reminder_id", delivery.ReminderID),
+			zap.Int32("attempts", attempt),
+			zap.Error(sendErr),
+		)
+		err = s.Q.MarkReminderDeliveryFailed(ctx, repository.MarkReminderDeliveryFailedParams{
+			ID:        delivery.ID,
+			LastError: errMsg,
+		})
+	default:
+		nextAttemptAt := pgtype.Timestamptz{}
+		nextAttemptAt.Scan(time.Now().Add(s.retryBackoff(attempt)))
+		err = s.Q.MarkReminderDeliveryRetry(ctx, repository.MarkReminderDeliveryRetryParams{
+			ID:            delivery.ID,
+			LastError:     errMsg,
+			NextAttemptAt: nextAttemptAt,
+		})
+	}
+	if err != nil {
+		s.logger.Error("Failed to update reminder delivery", zap.Int64("delivery_id", delivery.ID), zap.Error(err))
+	}
+}
+
+// isPermanentDeliveryError 判断重试也无法成功的错误：渠道未配置、配置不合法或地址被禁止
+func isPermanentDeliveryError(err error) bool {
+	return errors.Is(err, notification.ErrUnknownChannel) ||
+		errors.Is(err, notification.ErrInvalidChannelSettings) ||
+		errors.Is(err, notification.ErrForbiddenTarget)
+}
+
+// retryBackoff 计算第 attempt 次失败后的等待时间：base * 2^(attempt-1)，不超过上限
+func (s *Service) retryBackoff(attempt int32) time.Duration {
+	base := time.Duration(s.config.Notify.RetryBaseDelay) * time.Second
+	limit := time.Duration(s.config.Notify.RetryMaxDelay) * time.Second
+
+	delay := base
+	for i := int32(1); i < attempt; i++ {
+		delay *= 2
+		if delay >= limit {
+			return limit
+		}
+	}
+	return min(delay, limit)
+}
+
+// ListReminderDeliveries 按状态列出用户事件的投递记录，默认列出 failed
+func (s *Service) ListReminderDeliveries(ctx context.Context, userID int64, status string, limit int) ([]types.ReminderDelivery, error) {
+	if status == "" {
+		status = DeliveryStatusFailed
+	}
+	if status != DeliveryStatusPending && status != DeliveryStatusSending && status != DeliveryStatusSent && status != DeliveryStatusFailed {
+		return nil, ErrInvalidDeliveryStatus
+	}
+
+	deliveries, err := s.Q.ListReminderDeliveriesByStatus(ctx, repository.ListReminderDeliveriesByStatusParams{
+		Status: status,
+		Limit:  int32(limit),
+		UserID: userID,
+	})
+	if err != nil {
+		s.logger.Error("Failed to list reminder deliveries", zap.String("status", status), zap.Error(err))
+		return nil, err
+	}
+
+	result := make([]types.ReminderDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		result = append(result, convertReminderDelivery(delivery))
+	}
+	return result, nil
+}
+
+// GetReminderDelivery 获取投递记录及其每次尝试
+func (s *Service) GetReminderDelivery(ctx context.Context, userID int64, id int64) (types.ReminderDelivery, error) {
+	delivery, err := s.Q.GetReminderDeliveryByID(ctx, repository.GetReminderDeliveryByIDParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.ReminderDelivery{}, ErrDeliveryNotFound
+		}
+		s.logger.Error("Failed to get reminder delivery", zap.Int64("id", id), zap.Error(err))
+		return types.ReminderDelivery{}, err
+	}
+
+	attempts, err := s.Q.GetReminderDeliveryAttempts(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to get reminder delivery attempts", zap.Int64("id", id), zap.Error(err))
+		return types.ReminderDelivery{}, err
+	}
+
+	result := convertReminderDelivery(delivery)
+	for _, a := range attempts {
+		result.AttemptLog = append(result.AttemptLog, types.ReminderDeliveryAttempt{
+			Attempt:     int(a.Attempt),
+			Success:     a.Success,
+			Error:       a.Error,
+			AttemptedAt: a.AttemptedAt.Time,
+		})
+	}
+	return result, nil
+}
+
+// RetryReminderDelivery 将 failed 的投递记录领取为 sending 并立即尝试投递一次
+// 领取和状态转换在同一条 UPDATE 中完成，调度器不会同时投递这条记录
+func (s *Service) RetryReminderDelivery(ctx context.Context, userID int64, id int64) (types.ReminderDelivery, error) {
+	delivery, err := s.Q.RetryReminderDelivery(ctx, repository.RetryReminderDeliveryParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Error("Failed to retry reminder delivery", zap.Int64("id", id), zap.Error(err))
+			return types.ReminderDelivery{}, err
+		}
+		if _, err := s.Q.GetReminderDeliveryByID(ctx, repository.GetReminderDeliveryByIDParams{
+			ID:     id,
+			UserID: userID,
+		}); err != nil {
+			return types.ReminderDelivery{}, ErrDeliveryNotFound
+		}
+		return types.ReminderDelivery{}, ErrDeliveryNotFailed
+	}
+
+	s.deliver(ctx, delivery)
+	return s.GetReminderDelivery(ctx, userID, id)
+}
+
+func convertReminderDelivery(d repository.ReminderDelivery) types.ReminderDelivery {
+	var sentAt *time.Time
+	if d.SentAt.Valid {
+		sentAt = &d.SentAt.Time
+	}
+	return types.ReminderDelivery{
+		ID:            d.ID,
+		ReminderID:    d.ReminderID,
+		EventID:       d.EventID,
+		Occurrence:    d.Occurrence.Time,
+		Channel:       d.Channel,
+		Title:         d.Title,
+		Body:          d.Body,
+		Status:        d.Status,
+		Attempts:      int(d.Attempts),
+		NextAttemptAt: d.NextAttemptAt.Time,
+		LastError:     d.LastError,
+		SentAt:        sentAt,
+		CreatedAt:     d.CreatedAt.Time,
+		UpdatedAt:     d.UpdatedAt.Time,
+	}
+}
@@ -2,16 +2,25 @@ package event
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
+	"github.com/zeroicey/lifetrack-api/internal/middleware"
 	"github.com/zeroicey/lifetrack-api/internal/modules/event/types"
+	"github.com/zeroicey/lifetrack-api/internal/modules/notification"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
 	response "github.com/zeroicey/lifetrack-api/internal/pkg"
 )
 
+// maxCalendarImportSize 导入 .ics 文件的大小上限
+const maxCalendarImportSize = 5 << 20
+
 type Handler struct {
 	S         *Service
 	validator *validator.Validate
@@ -35,17 +44,44 @@ func EventRouter(s *Service, validator *validator.Validate) chi.Router {
 	r.Put("/{id}", h.UpdateEvent)
 	r.Delete("/{id}", h.DeleteEvent)
 	r.Get("/date-range", h.GetEventsByDateRange)
+	r.Get("/free-busy", h.GetFreeBusy)
+
+	// iCalendar 订阅与导入相关路由
+	r.Get("/calendar-token", h.GetCalendarToken)
+	r.Post("/calendar-token", h.RotateCalendarToken)
+	r.Delete("/calendar-token", h.RevokeCalendarToken)
+	r.Post("/import", h.ImportCalendar)
 
 	// 事件提醒相关路由
+	r.Get("/reminder-channels", h.ListReminderChannels)
+	r.Get("/reminder-channels/{channel}", h.GetReminderChannel)
+	r.Put("/reminder-channels/{channel}", h.UpsertReminderChannel)
+	r.Delete("/reminder-channels/{channel}", h.DeleteReminderChannel)
 	r.Post("/{id}/reminders", h.CreateEventReminder)
+	r.Put("/reminders/{reminder_id}", h.UpdateEventReminder)
 	r.Delete("/reminders/{reminder_id}", h.DeleteEventReminder)
 
+	// 提醒投递（发件箱）相关路由
+	r.Get("/deliveries", h.ListReminderDeliveries)
+	r.Get("/deliveries/{delivery_id}", h.GetReminderDelivery)
+	r.Post("/deliveries/{delivery_id}/retry", h.RetryReminderDelivery)
+
+	// 重复事件单次实例修改相关路由
+	r.Post("/{id}/overrides", h.UpsertEventOverride)
+	r.Delete("/overrides/{override_id}", h.DeleteEventOverride)
+
 	return r
 }
 
 // ListEvents 获取所有事件列表
 func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
-	events, err := h.S.GetAllEvents(r.Context())
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	events, err := h.S.GetAllEvents(r.Context(), userID)
 	if err != nil {
 		response.Error("Failed to get events").SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
@@ -57,6 +93,12 @@ func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
 
 // CreateEvent 创建新事件
 func (h *Handler) CreateEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	var body types.CreateEventBody
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		response.Error("Invalid request body").SetStatusCode(http.StatusBadRequest).Build(w)
@@ -68,8 +110,17 @@ func (h *Handler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	event, err := h.S.CreateEvent(r.Context(), body)
+	event, err := h.S.CreateEvent(r.Context(), userID, body)
 	if err != nil {
+		var conflictErr *ConflictError
+		if errors.As(err, &conflictErr) {
+			response.Error(err.Error()).SetStatusCode(http.StatusConflict).SetData(conflictErr.Conflicts).Build(w)
+			return
+		}
+		if errors.Is(err, ErrInvalidTimeRange) || errors.Is(err, pkg.ErrInvalidRRule) || errors.Is(err, notification.ErrUnknownChannel) || errors.Is(err, ErrInvalidTimezone) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
 		response.Error("Failed to create event").SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
 	}
@@ -79,6 +130,12 @@ func (h *Handler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 
 // GetEvent 根据ID获取事件
 func (h *Handler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -86,7 +143,7 @@ func (h *Handler) GetEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	event, err := h.S.GetEventByID(r.Context(), id)
+	event, err := h.S.GetEventByID(r.Context(), userID, id)
 	if err != nil {
 		response.Error("Event not found").SetStatusCode(http.StatusNotFound).Build(w)
 		return
@@ -97,6 +154,12 @@ func (h *Handler) GetEvent(w http.ResponseWriter, r *http.Request) {
 
 // UpdateEvent 更新事件
 func (h *Handler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -115,8 +178,21 @@ func (h *Handler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	event, err := h.S.UpdateEvent(r.Context(), id, body)
+	event, err := h.S.UpdateEvent(r.Context(), userID, id, body)
 	if err != nil {
+		var conflictErr *ConflictError
+		if errors.As(err, &conflictErr) {
+			response.Error(err.Error()).SetStatusCode(http.StatusConflict).SetData(conflictErr.Conflicts).Build(w)
+			return
+		}
+		if errors.Is(err, ErrEventNotFound) {
+			response.Error("Event not found").SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		if errors.Is(err, ErrInvalidTimeRange) || errors.Is(err, pkg.ErrInvalidRRule) || errors.Is(err, notification.ErrUnknownChannel) || errors.Is(err, ErrInvalidTimezone) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
 		response.Error("Failed to update event").SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
 	}
@@ -126,6 +202,12 @@ func (h *Handler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 
 // DeleteEvent 删除事件
 func (h *Handler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -133,7 +215,7 @@ func (h *Handler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.S.DeleteEvent(r.Context(), id)
+	err = h.S.DeleteEvent(r.Context(), userID, id)
 	if err != nil {
 		response.Error("Failed to delete event").SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
@@ -142,8 +224,53 @@ func (h *Handler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	response.Success("Event deleted successfully").Build(w)
 }
 
+// GetFreeBusy 获取指定范围内的忙碌时段和空闲时段
+// start、end 为 RFC 3339 时间或 YYYY-MM-DD 日期，min_duration 为空闲时段最短分钟数
+func (h *Handler) GetFreeBusy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	query := r.URL.Query()
+	start, end := query.Get("start"), query.Get("end")
+	if start == "" || end == "" {
+		response.Error("start and end are required").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	minMinutes := 0
+	if value := query.Get("min_duration"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			response.Error("min_duration must be a positive number of minutes").SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		minMinutes = n
+	}
+
+	freeBusy, err := h.S.GetFreeBusy(r.Context(), userID, start, end, query.Get(pkg.TimezoneQueryParam), minMinutes)
+	if err != nil {
+		if errors.Is(err, ErrInvalidFreeBusyRange) || errors.Is(err, ErrInvalidTimezone) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		response.Error("Failed to get free/busy information").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Free/busy information retrieved successfully").SetData(freeBusy).Build(w)
+}
+
 // GetEventsByDateRange 根据日期范围获取事件
 func (h *Handler) GetEventsByDateRange(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	startDateStr := r.URL.Query().Get("start_date")
 	endDateStr := r.URL.Query().Get("end_date")
 
@@ -153,20 +280,24 @@ func (h *Handler) GetEventsByDateRange(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 验证日期格式
-	_, err := time.Parse("2006-01-02", startDateStr)
+	_, err := time.Parse(pkg.DateLayout, startDateStr)
 	if err != nil {
 		response.Error("Invalid start_date format, expected YYYY-MM-DD").SetStatusCode(http.StatusBadRequest).Build(w)
 		return
 	}
 
-	_, err = time.Parse("2006-01-02", endDateStr)
+	_, err = time.Parse(pkg.DateLayout, endDateStr)
 	if err != nil {
 		response.Error("Invalid end_date format, expected YYYY-MM-DD").SetStatusCode(http.StatusBadRequest).Build(w)
 		return
 	}
 
-	events, err := h.S.GetEventsByDateRange(r.Context(), startDateStr, endDateStr)
+	events, err := h.S.GetEventsByDateRange(r.Context(), userID, startDateStr, endDateStr, r.URL.Query().Get(pkg.TimezoneQueryParam))
 	if err != nil {
+		if errors.Is(err, ErrInvalidTimezone) {
+			response.Error("Invalid tz, expected an IANA name such as Asia/Shanghai").SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
 		response.Error("Failed to get events by date range").SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
 	}
@@ -176,6 +307,12 @@ func (h *Handler) GetEventsByDateRange(w http.ResponseWriter, r *http.Request) {
 
 // CreateEventReminder 为事件创建提醒
 func (h *Handler) CreateEventReminder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	eventID, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -194,8 +331,16 @@ func (h *Handler) CreateEventReminder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	reminder, err := h.S.CreateEventReminder(r.Context(), eventID, body)
+	reminder, err := h.S.CreateEventReminder(r.Context(), userID, eventID, body)
 	if err != nil {
+		if errors.Is(err, ErrEventNotFound) {
+			response.Error("Event not found").SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		if errors.Is(err, notification.ErrUnknownChannel) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
 		response.Error("Failed to create event reminder").SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
 	}
@@ -203,8 +348,142 @@ func (h *Handler) CreateEventReminder(w http.ResponseWriter, r *http.Request) {
 	response.Success("Event reminder created successfully").SetStatusCode(http.StatusCreated).SetData(reminder).Build(w)
 }
 
+// UpdateEventReminder 修改事件提醒的通知渠道
+func (h *Handler) UpdateEventReminder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	reminderIDStr := chi.URLParam(r, "reminder_id")
+	reminderID, err := strconv.ParseInt(reminderIDStr, 10, 64)
+	if err != nil {
+		response.Error("Invalid reminder ID").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	var body types.UpdateEventReminderBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Invalid request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	if err := h.validator.Struct(body); err != nil {
+		response.Error("Validation failed: " + err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	reminder, err := h.S.UpdateEventReminderChannel(r.Context(), userID, reminderID, body)
+	if err != nil {
+		if errors.Is(err, ErrReminderNotFound) {
+			response.Error("Event reminder not found").SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		if errors.Is(err, notification.ErrUnknownChannel) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		response.Error("Failed to update event reminder").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Event reminder updated successfully").SetData(reminder).Build(w)
+}
+
+// ListReminderChannels 获取当前用户可用的提醒渠道
+func (h *Handler) ListReminderChannels(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	channels, err := h.S.ReminderChannels(r.Context(), userID)
+	if err != nil {
+		response.Error("Failed to get reminder channels").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Reminder channels retrieved successfully").SetData(channels).Build(w)
+}
+
+// GetReminderChannel 获取当前用户某个提醒渠道的配置
+func (h *Handler) GetReminderChannel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	channel, err := h.S.GetReminderChannel(r.Context(), userID, chi.URLParam(r, "channel"))
+	if err != nil {
+		if errors.Is(err, ErrReminderChannelNotFound) {
+			response.Error(err.Error()).SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		response.Error("Failed to get reminder channel").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Reminder channel retrieved successfully").SetData(channel).Build(w)
+}
+
+// UpsertReminderChannel 保存当前用户的 Webhook、推送或 Telegram 渠道配置
+func (h *Handler) UpsertReminderChannel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body types.UpsertReminderChannelBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Invalid request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	channel, err := h.S.UpsertReminderChannel(r.Context(), userID, chi.URLParam(r, "channel"), body)
+	if err != nil {
+		if errors.Is(err, notification.ErrUnknownChannel) || errors.Is(err, notification.ErrInvalidChannelSettings) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		response.Error("Failed to save reminder channel").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Reminder channel saved successfully").SetData(channel).Build(w)
+}
+
+// DeleteReminderChannel 删除当前用户的渠道配置
+func (h *Handler) DeleteReminderChannel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	if err := h.S.DeleteReminderChannel(r.Context(), userID, chi.URLParam(r, "channel")); err != nil {
+		if errors.Is(err, ErrReminderChannelNotFound) {
+			response.Error(err.Error()).SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		response.Error("Failed to delete reminder channel").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Reminder channel deleted successfully").Build(w)
+}
+
 // DeleteEventReminder 删除事件提醒
 func (h *Handler) DeleteEventReminder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	reminderIDStr := chi.URLParam(r, "reminder_id")
 	reminderID, err := strconv.ParseInt(reminderIDStr, 10, 64)
 	if err != nil {
@@ -212,7 +491,7 @@ func (h *Handler) DeleteEventReminder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.S.DeleteEventReminder(r.Context(), reminderID)
+	err = h.S.DeleteEventReminder(r.Context(), userID, reminderID)
 	if err != nil {
 		response.Error("Failed to delete event reminder").SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
@@ -220,3 +499,296 @@ func (h *Handler) DeleteEventReminder(w http.ResponseWriter, r *http.Request) {
 
 	response.Success("Event reminder deleted successfully").Build(w)
 }
+
+// UpsertEventOverride 修改重复事件中的某一次实例
+func (h *Handler) UpsertEventOverride(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	eventID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		response.Error("Invalid event ID").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	var body types.UpsertEventOverrideBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Invalid request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	if err := h.validator.Struct(body); err != nil {
+		response.Error("Validation failed: " + err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	override, err := h.S.UpsertEventOverride(r.Context(), userID, eventID, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrEventNotFound):
+			response.Error("Event not found").SetStatusCode(http.StatusNotFound).Build(w)
+		case errors.Is(err, ErrInvalidTimeRange), errors.Is(err, ErrNotRecurring), errors.Is(err, ErrInvalidOccurrence):
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		default:
+			response.Error("Failed to save event override").SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+
+	response.Success("Event override saved successfully").SetData(override).Build(w)
+}
+
+// DeleteEventOverride 删除重复事件的单次实例修改
+func (h *Handler) DeleteEventOverride(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	overrideIDStr := chi.URLParam(r, "override_id")
+	overrideID, err := strconv.ParseInt(overrideIDStr, 10, 64)
+	if err != nil {
+		response.Error("Invalid override ID").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	err = h.S.DeleteEventOverride(r.Context(), userID, overrideID)
+	if err != nil {
+		response.Error("Failed to delete event override").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Event override deleted successfully").Build(w)
+}
+
+// GetCalendarToken 获取当前日历订阅令牌的信息
+func (h *Handler) GetCalendarToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("User not authenticated").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	calendarToken, err := h.S.GetCalendarToken(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, ErrCalendarTokenNotFound) {
+			response.Error(err.Error()).SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		response.Error("Failed to get calendar token").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Calendar token retrieved successfully").SetData(calendarToken).Build(w)
+}
+
+// RotateCalendarToken 生成新的日历订阅令牌和订阅地址，旧地址随即失效
+// 个人访问令牌不能生成订阅令牌，否则令牌被撤销后仍能通过订阅地址读取日历
+func (h *Handler) RotateCalendarToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("User not authenticated").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+	if _, ok := middleware.GetSessionIDFromContext(r.Context()); !ok {
+		response.Error("Calendar token requires a login session").SetStatusCode(http.StatusForbidden).Build(w)
+		return
+	}
+
+	calendarToken, err := h.S.RotateCalendarToken(r.Context(), userID)
+	if err != nil {
+		response.Error("Failed to generate calendar token").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Calendar token generated successfully").SetData(calendarToken).Build(w)
+}
+
+// RevokeCalendarToken 撤销日历订阅令牌
+func (h *Handler) RevokeCalendarToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("User not authenticated").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+	if _, ok := middleware.GetSessionIDFromContext(r.Context()); !ok {
+		response.Error("Calendar token requires a login session").SetStatusCode(http.StatusForbidden).Build(w)
+		return
+	}
+
+	if err := h.S.RevokeCalendarToken(r.Context(), userID); err != nil {
+		if errors.Is(err, ErrCalendarTokenNotFound) {
+			response.Error(err.Error()).SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		response.Error("Failed to revoke calendar token").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Calendar token revoked successfully").Build(w)
+}
+
+// ImportCalendar 导入 .ics 文件，支持 multipart 的 file 字段或直接上传原始内容
+func (h *Handler) ImportCalendar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxCalendarImportSize)
+
+	var reader io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			response.Error("File is required").SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	result, err := h.S.ImportCalendar(r.Context(), userID, reader)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			response.Error("Calendar file is too large").SetStatusCode(http.StatusRequestEntityTooLarge).Build(w)
+		case errors.Is(err, ErrInvalidCalendar):
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		default:
+			response.Error("Failed to import calendar").SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+
+	response.Success("Calendar imported successfully").SetData(result).Build(w)
+}
+
+// CalendarFeedHandler 提供 .ics 订阅源，通过 token 查询参数认证，供日历客户端订阅
+func CalendarFeedHandler(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			response.Error("Token is required").SetStatusCode(http.StatusUnauthorized).Build(w)
+			return
+		}
+		userID, err := s.ValidateCalendarToken(r.Context(), token)
+		if err != nil {
+			if errors.Is(err, ErrCalendarTokenNotFound) {
+				response.Error("Invalid or revoked token").SetStatusCode(http.StatusUnauthorized).Build(w)
+				return
+			}
+			response.Error("Failed to validate token").SetStatusCode(http.StatusInternalServerError).Build(w)
+			return
+		}
+
+		calendar, err := s.ExportCalendar(r.Context(), userID)
+		if err != nil {
+			response.Error("Failed to export calendar").SetStatusCode(http.StatusInternalServerError).Build(w)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="lifetrack.ics"`)
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, calendar)
+	}
+}
+
+// ListReminderDeliveries 按状态获取提醒投递记录，默认返回 failed
+func (h *Handler) ListReminderDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	limit := func() int {
+		limitStr := r.URL.Query().Get("limit")
+		if strings.TrimSpace(limitStr) == "" {
+			return 50
+		}
+		if n, parseErr := strconv.Atoi(limitStr); parseErr == nil && n > 0 {
+			return min(n, 200)
+		}
+		return 50
+	}()
+
+	deliveries, err := h.S.ListReminderDeliveries(r.Context(), userID, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		if errors.Is(err, ErrInvalidDeliveryStatus) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		response.Error("Failed to get reminder deliveries").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Reminder deliveries retrieved successfully").SetData(deliveries).Build(w)
+}
+
+// GetReminderDelivery 获取提醒投递记录及每次尝试
+func (h *Handler) GetReminderDelivery(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	deliveryIDStr := chi.URLParam(r, "delivery_id")
+	deliveryID, err := strconv.ParseInt(deliveryIDStr, 10, 64)
+	if err != nil {
+		response.Error("Invalid delivery ID").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	delivery, err := h.S.GetReminderDelivery(r.Context(), userID, deliveryID)
+	if err != nil {
+		if errors.Is(err, ErrDeliveryNotFound) {
+			response.Error("Reminder delivery not found").SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		response.Error("Failed to get reminder delivery").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Reminder delivery details").SetData(delivery).Build(w)
+}
+
+// RetryReminderDelivery 手动重试 failed 状态的提醒投递
+func (h *Handler) RetryReminderDelivery(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	deliveryIDStr := chi.URLParam(r, "delivery_id")
+	deliveryID, err := strconv.ParseInt(deliveryIDStr, 10, 64)
+	if err != nil {
+		response.Error("Invalid delivery ID").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	delivery, err := h.S.RetryReminderDelivery(r.Context(), userID, deliveryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDeliveryNotFound):
+			response.Error("Reminder delivery not found").SetStatusCode(http.StatusNotFound).Build(w)
+		case errors.Is(err, ErrDeliveryNotFailed):
+			response.Error(err.Error()).SetStatusCode(http.StatusConflict).Build(w)
+		default:
+			response.Error("Failed to retry reminder delivery").SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+
+	response.Success("Reminder delivery retried").SetData(delivery).Build(w)
+}
@@ -0,0 +1,565 @@
+package event
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zeroicey/lifetrack-api/internal/modules/event/types"
+)
+
+const (
+	icalDateTimeUTC = "20060102T150405Z"
+	icalDateTime    = "20060102T150405"
+	icalDate        = "20060102"
+	icalLineLimit   = 75
+	// icalTimezoneYears VTIMEZONE 中列出的时区切换至少覆盖到当前时间之后的年数
+	icalTimezoneYears = 10
+)
+
+// ErrInvalidCalendar 是上传的 .ics 文件无法解析时返回的哨兵错误
+var ErrInvalidCalendar = errors.New("invalid iCalendar data")
+
+// icalEvent 是从 .ics 文件中解析出的单个 VEVENT
+type icalEvent struct {
+	UID          string
+	Summary      string
+	Location     string
+	Description  string
+	Start        time.Time
+	End          time.Time
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID *time.Time
+	Alarms       []int  // 提前提醒的分钟数
+	Timezone     string // DTSTART 的 TZID，为空表示 UTC 或浮动时间
+}
+
+// defaultEventUID 为没有来源 UID 的事件生成稳定的 UID
+func defaultEventUID(id int64) string {
+	return fmt.Sprintf("lifetrack-event-%d@lifetrack", id)
+}
+
+// parseDefaultEventUID 从 defaultEventUID 生成的 UID 中取回事件ID
+func parseDefaultEventUID(uid string) (int64, bool) {
+	rest, ok := strings.CutPrefix(uid, "lifetrack-event-")
+	if !ok {
+		return 0, false
+	}
+	idStr, ok := strings.CutSuffix(rest, "@lifetrack")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	return id, err == nil
+}
+
+// EncodeCalendar 将事件及其提醒序列化为 iCalendar (RFC 5545) 文本
+func EncodeCalendar(events []types.EventResponse, now time.Time) string {
+	w := &icalWriter{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//LifeTrack//LifeTrack API//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.line("X-WR-CALNAME", "LifeTrack")
+
+	// DTSTART/DTEND 引用的每个 TZID 都需要对应的 VTIMEZONE，客户端不一定认识 IANA 时区名
+	for _, zone := range calendarTimezones(events, now) {
+		w.timezone(zone)
+	}
+
+	stamp := now.UTC().Format(icalDateTimeUTC)
+	for _, event := range events {
+		uid := event.UID
+		if uid == "" {
+			uid = defaultEventUID(event.ID)
+		}
+
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", uid)
+		w.line("DTSTAMP", stamp)
+		// 非 UTC 事件带 TZID 输出，客户端才能按本地时间展开跨夏令时的重复规则
+		w.time("DTSTART", event.StartTime, event.Timezone)
+		w.time("DTEND", event.EndTime, event.Timezone)
+		w.line("SUMMARY", escapeICalText(event.Name))
+		if event.Place != "" {
+			w.line("LOCATION", escapeICalText(event.Place))
+		}
+		if event.Description != "" {
+			w.line("DESCRIPTION", escapeICalText(event.Description))
+		}
+		if event.RRule != "" {
+			w.line("RRULE", event.RRule)
+		}
+		if len(event.ExDates) > 0 {
+			exdates := make([]string, len(event.ExDates))
+			for i, exdate := range event.ExDates {
+				exdates[i] = exdate.UTC().Format(icalDateTimeUTC)
+			}
+			w.line("EXDATE", strings.Join(exdates, ","))
+		}
+		w.line("CREATED", event.CreatedAt.UTC().Format(icalDateTimeUTC))
+		w.line("LAST-MODIFIED", event.UpdatedAt.UTC().Format(icalDateTimeUTC))
+		for _, reminder := range event.Reminders {
+			w.line("BEGIN", "VALARM")
+			w.line("ACTION", "DISPLAY")
+			w.line("DESCRIPTION", escapeICalText(event.Name))
+			w.line("TRIGGER", fmt.Sprintf("-PT%dM", reminder.RemindBefore))
+			w.line("END", "VALARM")
+		}
+		w.line("END", "VEVENT")
+
+		// 单次实例修改以带 RECURRENCE-ID 的 VEVENT 输出
+		for _, o := range event.Overrides {
+			w.line("BEGIN", "VEVENT")
+			w.line("UID", uid)
+			w.line("DTSTAMP", stamp)
+			w.line("RECURRENCE-ID", o.RecurrenceID.UTC().Format(icalDateTimeUTC))
+			w.line("DTSTART", o.StartTime.UTC().Format(icalDateTimeUTC))
+			w.line("DTEND", o.EndTime.UTC().Format(icalDateTimeUTC))
+			w.line("SUMMARY", escapeICalText(o.Name))
+			if o.Place != "" {
+				w.line("LOCATION", escapeICalText(o.Place))
+			}
+			if o.Description != "" {
+				w.line("DESCRIPTION", escapeICalText(o.Description))
+			}
+			w.line("END", "VEVENT")
+		}
+	}
+
+	w.line("END", "VCALENDAR")
+	return w.buf.String()
+}
+
+// icalTimezone 导出时需要输出 VTIMEZONE 的时区及其覆盖的时间范围
+type icalTimezone struct {
+	loc      *time.Location
+	from, to time.Time
+}
+
+// calendarTimezones 收集事件使用的非 UTC 时区，按首次出现的顺序返回
+// 范围从最早的事件所在年份开始，至少覆盖到 now 之后 icalTimezoneYears 年，重复事件没有结束时间时以此为限
+func calendarTimezones(events []types.EventResponse, now time.Time) []icalTimezone {
+	var zones []icalTimezone
+	index := make(map[string]int)
+	for _, event := range events {
+		loc := icalLocation(event.Timezone)
+		if loc == nil {
+			continue
+		}
+		i, ok := index[event.Timezone]
+		if !ok {
+			i = len(zones)
+			index[event.Timezone] = i
+			zones = append(zones, icalTimezone{loc: loc, from: event.StartTime, to: now.AddDate(icalTimezoneYears, 0, 0)})
+		}
+		if event.StartTime.Before(zones[i].from) {
+			zones[i].from = event.StartTime
+		}
+		if event.EndTime.After(zones[i].to) {
+			zones[i].to = event.EndTime
+		}
+	}
+	for i := range zones {
+		from := zones[i].from.In(zones[i].loc)
+		zones[i].from = time.Date(from.Year(), time.January, 1, 0, 0, 0, 0, zones[i].loc)
+	}
+	return zones
+}
+
+// icalLocation 返回需要以 TZID 输出的时区，UTC、空值和无法识别的时区返回 nil
+func icalLocation(timezone string) *time.Location {
+	if timezone == "" || timezone == "UTC" {
+		return nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// icalWriter 负责按 75 字节折行输出内容行
+type icalWriter struct {
+	buf bytes.Buffer
+}
+
+// time 输出 DATE-TIME 属性，时区为空或 UTC 时使用 UTC 格式，否则使用 TZID 参数
+func (w *icalWriter) time(name string, t time.Time, timezone string) {
+	loc := icalLocation(timezone)
+	if loc == nil {
+		w.line(name, t.UTC().Format(icalDateTimeUTC))
+		return
+	}
+	w.line(name+";TZID="+timezone, t.In(loc).Format(icalDateTime))
+}
+
+// timezone 输出 VTIMEZONE，范围起点一个观测段，之后每次偏移切换一个观测段
+// 观测段的 DTSTART 是切换前偏移下的本地时间，与 RFC 5545 §3.6.5 一致
+func (w *icalWriter) timezone(zone icalTimezone) {
+	w.line("BEGIN", "VTIMEZONE")
+	w.line("TZID", zone.loc.String())
+
+	t := zone.from
+	_, offset := t.Zone()
+	w.observance(t, offset)
+	for {
+		_, end := t.ZoneBounds()
+		if end.IsZero() || end.After(zone.to) {
+			break
+		}
+		w.observance(end, offset)
+		_, offset = end.Zone()
+		t = end
+	}
+
+	w.line("END", "VTIMEZONE")
+}
+
+// observance 输出 t 时刻开始生效的 STANDARD 或 DAYLIGHT 观测段，offsetFrom 为切换前的偏移秒数
+func (w *icalWriter) observance(t time.Time, offsetFrom int) {
+	name, offsetTo := t.Zone()
+	kind := "STANDARD"
+	if t.IsDST() {
+		kind = "DAYLIGHT"
+	}
+	w.line("BEGIN", kind)
+	w.line("DTSTART", t.UTC().Add(time.Duration(offsetFrom)*time.Second).Format(icalDateTime))
+	w.line("TZOFFSETFROM", formatUTCOffset(offsetFrom))
+	w.line("TZOFFSETTO", formatUTCOffset(offsetTo))
+	w.line("TZNAME", name)
+	w.line("END", kind)
+}
+
+// formatUTCOffset 将偏移秒数格式化为 UTC-OFFSET，如 +0800、-0430，非整分钟时附带秒
+func formatUTCOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	result := fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset/60%60)
+	if offset%60 != 0 {
+		result += fmt.Sprintf("%02d", offset%60)
+	}
+	return result
+}
+
+func (w *icalWriter) line(name, value string) {
+	content := name + ":" + value
+	// 续行以空格开头，空格也计入 75 字节
+	limit := icalLineLimit
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		w.buf.WriteString(content[:cut])
+		w.buf.WriteString("\r\n ")
+		content = content[cut:]
+		limit = icalLineLimit - 1
+	}
+	w.buf.WriteString(content)
+	w.buf.WriteString("\r\n")
+}
+
+// decodeCalendar 解析 .ics 文件中的所有 VEVENT
+func decodeCalendar(r io.Reader) ([]icalEvent, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := unfoldICalLines(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("%w: missing BEGIN:VCALENDAR", ErrInvalidCalendar)
+	}
+
+	var (
+		events   []icalEvent
+		current  *icalEvent
+		inAlarm  bool
+		duration *time.Duration
+		allDay   bool
+		stack    []string
+	)
+
+	for _, raw := range lines {
+		name, params, value, ok := parseICalLine(raw)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "BEGIN":
+			component := strings.ToUpper(value)
+			stack = append(stack, component)
+			switch {
+			case component == "VEVENT" && current == nil:
+				current = &icalEvent{}
+				duration = nil
+				allDay = false
+			case component == "VALARM" && current != nil:
+				inAlarm = true
+			}
+			continue
+		case "END":
+			component := strings.ToUpper(value)
+			if len(stack) == 0 || stack[len(stack)-1] != component {
+				return nil, fmt.Errorf("%w: unexpected END:%s", ErrInvalidCalendar, value)
+			}
+			stack = stack[:len(stack)-1]
+			switch {
+			case component == "VALARM":
+				inAlarm = false
+			case component == "VEVENT" && current != nil:
+				if current.Start.IsZero() {
+					return nil, fmt.Errorf("%w: VEVENT %q has no DTSTART", ErrInvalidCalendar, current.UID)
+				}
+				if current.End.IsZero() {
+					switch {
+					case duration != nil:
+						current.End = current.Start.Add(*duration)
+					case allDay:
+						current.End = current.Start.AddDate(0, 0, 1)
+					default:
+						current.End = current.Start
+					}
+				}
+				events = append(events, *current)
+				current = nil
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if inAlarm {
+			if name == "TRIGGER" {
+				if minutes, ok := parseICalTrigger(params, value, current.Start); ok {
+					current.Alarms = append(current.Alarms, minutes)
+				}
+			}
+			continue
+		}
+
+		switch name {
+		case "UID":
+			current.UID = value
+		case "SUMMARY":
+			current.Summary = unescapeICalText(value)
+		case "LOCATION":
+			current.Location = unescapeICalText(value)
+		case "DESCRIPTION":
+			current.Description = unescapeICalText(value)
+		case "DTSTART":
+			current.Start, err = parseICalTime(value, params)
+			allDay = strings.EqualFold(params["VALUE"], "DATE")
+			if tzid := params["TZID"]; tzid != "" {
+				if _, loadErr := time.LoadLocation(tzid); loadErr == nil {
+					current.Timezone = tzid
+				}
+			}
+		case "DTEND":
+			current.End, err = parseICalTime(value, params)
+		case "DURATION":
+			var d time.Duration
+			d, err = parseICalDuration(value)
+			duration = &d
+		case "RRULE":
+			current.RRule = value
+		case "EXDATE":
+			for _, item := range strings.Split(value, ",") {
+				var t time.Time
+				if t, err = parseICalTime(item, params); err != nil {
+					break
+				}
+				current.ExDates = append(current.ExDates, t)
+			}
+		case "RECURRENCE-ID":
+			var t time.Time
+			t, err = parseICalTime(value, params)
+			current.RecurrenceID = &t
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidCalendar, name, err)
+		}
+	}
+
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("%w: unterminated %s", ErrInvalidCalendar, stack[len(stack)-1])
+	}
+	return events, nil
+}
+
+// unfoldICalLines 还原被折行的内容行，兼容 CRLF 与 LF
+func unfoldICalLines(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseICalLine 将内容行拆分为属性名、参数和值
+func parseICalLine(line string) (string, map[string]string, string, bool) {
+	inQuotes := false
+	sep := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 {
+		return "", nil, "", false
+	}
+
+	head, value := line[:sep], line[sep+1:]
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+// parseICalTime 解析 DATE-TIME / DATE 值，支持 UTC、TZID 和浮动时间（按 UTC 处理）
+func parseICalTime(value string, params map[string]string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == len(icalDate) {
+		return time.ParseInLocation(icalDate, value, time.UTC)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(icalDateTimeUTC, value)
+	}
+
+	loc := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation(icalDateTime, value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
+
+// parseICalDuration 解析 RFC 5545 DURATION，例如 -PT15M、P1DT2H、P1W
+func parseICalDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(strings.ToUpper(value))
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign = -1
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	if !strings.HasPrefix(value, "P") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	value = value[1:]
+
+	var (
+		total  time.Duration
+		num    strings.Builder
+		inTime bool
+	)
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			num.WriteRune(r)
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(num.String())
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		num.Reset()
+		switch {
+		case r == 'W' && !inTime:
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			total += time.Duration(n) * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += time.Duration(n) * time.Hour
+		case r == 'M' && inTime:
+			total += time.Duration(n) * time.Minute
+		case r == 'S' && inTime:
+			total += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+	}
+	if num.Len() > 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return sign * total, nil
+}
+
+// parseICalTrigger 将 VALARM TRIGGER 转换为开始前的分钟数，只支持相对开始时间的提醒
+func parseICalTrigger(params map[string]string, value string, start time.Time) (int, bool) {
+	if strings.EqualFold(params["RELATED"], "END") {
+		return 0, false
+	}
+	if strings.EqualFold(params["VALUE"], "DATE-TIME") {
+		t, err := parseICalTime(value, params)
+		if err != nil || start.IsZero() || t.After(start) {
+			return 0, false
+		}
+		return int(start.Sub(t).Minutes()), true
+	}
+	d, err := parseICalDuration(value)
+	if err != nil || d > 0 {
+		return 0, false
+	}
+	minutes := int(-d.Minutes())
+	if minutes < 1 {
+		return 0, false
+	}
+	return minutes, true
+}
+
+var (
+	icalEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	icalUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escapeICalText(value string) string {
+	return icalEscaper.Replace(value)
+}
+
+func unescapeICalText(value string) string {
+	return icalUnescaper.Replace(value)
+}
@@ -0,0 +1,98 @@
+package event
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zeroicey/lifetrack-api/internal/modules/event/types"
+)
+
+func TestEncodeCalendarTimezones(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone America/New_York not available: %v", err)
+	}
+	now := time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC)
+	events := []types.EventResponse{
+		{
+			ID:        1,
+			Name:      "Standup",
+			StartTime: time.Date(2025, time.March, 3, 9, 0, 0, 0, ny),
+			EndTime:   time.Date(2025, time.March, 3, 9, 15, 0, 0, ny),
+			RRule:     "FREQ=WEEKLY",
+			Timezone:  "America/New_York",
+		},
+		{
+			ID:        2,
+			Name:      "Review",
+			StartTime: time.Date(2025, time.June, 2, 14, 0, 0, 0, ny),
+			EndTime:   time.Date(2025, time.June, 2, 15, 0, 0, 0, ny),
+			Timezone:  "America/New_York",
+		},
+		{
+			ID:        3,
+			Name:      "Call",
+			StartTime: time.Date(2025, time.June, 3, 8, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2025, time.June, 3, 9, 0, 0, 0, time.UTC),
+			Timezone:  "UTC",
+		},
+	}
+	ics := EncodeCalendar(events, now)
+
+	// 同一时区只输出一次，UTC 不需要 VTIMEZONE
+	if n := strings.Count(ics, "BEGIN:VTIMEZONE\r\n"); n != 1 {
+		t.Fatalf("VTIMEZONE count = %d, want 1\n%s", n, ics)
+	}
+	if !strings.Contains(ics, "TZID:America/New_York\r\n") {
+		t.Fatalf("missing TZID:America/New_York\n%s", ics)
+	}
+	if strings.Index(ics, "BEGIN:VTIMEZONE") > strings.Index(ics, "BEGIN:VEVENT") {
+		t.Fatalf("VTIMEZONE should precede VEVENT\n%s", ics)
+	}
+
+	for _, want := range []string{
+		"BEGIN:STANDARD\r\nDTSTART:20250101T000000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0500\r\nTZNAME:EST\r\nEND:STANDARD\r\n",
+		"BEGIN:DAYLIGHT\r\nDTSTART:20250309T020000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0400\r\nTZNAME:EDT\r\nEND:DAYLIGHT\r\n",
+		"BEGIN:STANDARD\r\nDTSTART:20251102T020000\r\nTZOFFSETFROM:-0400\r\nTZOFFSETTO:-0500\r\nTZNAME:EST\r\nEND:STANDARD\r\n",
+		// 重复事件没有结束时间，切换至少列到 now 之后 icalTimezoneYears 年
+		"BEGIN:DAYLIGHT\r\nDTSTART:20340312T020000\r\n",
+		"DTSTART;TZID=America/New_York:20250303T090000\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Fatalf("missing %q\n%s", want, ics)
+		}
+	}
+	if strings.Contains(ics, "DTSTART:20360309T020000") {
+		t.Fatalf("transitions beyond the covered range\n%s", ics)
+	}
+
+	parsed, err := decodeCalendar(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("decode exported calendar: %v", err)
+	}
+	if len(parsed) != len(events) {
+		t.Fatalf("decoded %d events, want %d", len(parsed), len(events))
+	}
+	if !parsed[0].Start.Equal(events[0].StartTime) || parsed[0].Timezone != "America/New_York" {
+		t.Fatalf("decoded start = %v %q, want %v America/New_York", parsed[0].Start, parsed[0].Timezone, events[0].StartTime)
+	}
+}
+
+func TestFormatUTCOffset(t *testing.T) {
+	tests := []struct {
+		offset int
+		want   string
+	}{
+		{0, "+0000"},
+		{8 * 3600, "+0800"},
+		{-(4*3600 + 30*60), "-0430"},
+		{5*3600 + 45*60, "+0545"},
+		{-(17*60 + 32), "-001732"},
+	}
+	for _, tt := range tests {
+		if got := formatUTCOffset(tt.offset); got != tt.want {
+			t.Errorf("formatUTCOffset(%d) = %q, want %q", tt.offset, got, tt.want)
+		}
+	}
+}
@@ -2,33 +2,83 @@ package event
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/robfig/cron/v3"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
 	"go.uber.org/zap"
 )
 
+// schedulerLockKey 调度器 leader 使用的 Postgres advisory lock 键
+const schedulerLockKey int64 = 7310001
+
+// reminderCheckInterval 事件提醒的检查周期，与 Start 中的 cron 表达式一致
+const reminderCheckInterval = time.Minute
+
+// schedulerAppNamePrefix 持有锁的连接的 application_name 前缀，用于查询当前 leader
+const schedulerAppNamePrefix = "lifetrack-scheduler:"
+
 // Scheduler 定时任务调度器
+// 多个实例同时运行时，只有持有 advisory lock 的实例（leader）会处理提醒
 type Scheduler struct {
 	cron         *cron.Cron
 	eventService *Service
+	db           *pgxpool.Pool
+	instanceID   string
 	logger       *zap.Logger
+
+	jobs []scheduledJob // 通过 AddJob 注册的其他定时任务
+
+	mu       sync.Mutex
+	lockConn *pgxpool.Conn // 持有 advisory lock 的专用连接，锁随会话存在
+	isLeader atomic.Bool
+}
+
+// scheduledJob 其他模块注册的定时任务，和事件提醒一样只在 leader 上执行
+type scheduledJob struct {
+	name string
+	spec string
+	run  func(ctx context.Context)
+}
+
+// SchedulerStatus 调度器 leader 状态
+type SchedulerStatus struct {
+	InstanceID string `json:"instance_id"`
+	IsLeader   bool   `json:"is_leader"`
+	Leader     string `json:"leader"` // 当前持有锁的实例，为空表示暂无 leader
 }
 
 // NewScheduler 创建新的调度器实例
-func NewScheduler(eventService *Service, logger *zap.Logger) *Scheduler {
+func NewScheduler(eventService *Service, db *pgxpool.Pool, instanceID string, logger *zap.Logger) *Scheduler {
 	return &Scheduler{
-		cron:         cron.New(cron.WithSeconds()),
+		cron:         cron.New(cron.WithSeconds(), cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger))),
 		eventService: eventService,
+		db:           db,
+		instanceID:   instanceID,
 		logger:       logger,
 	}
 }
 
+// AddJob 注册一个只在 leader 上执行的定时任务，spec 为带秒的 cron 表达式，需要在 Start 之前调用
+func (s *Scheduler) AddJob(name, spec string, run func(ctx context.Context)) {
+	s.jobs = append(s.jobs, scheduledJob{name: name, spec: spec, run: run})
+}
+
 // Start 启动调度器
 func (s *Scheduler) Start() error {
 	// 每分钟检查一次事件提醒
 	_, err := s.cron.AddFunc("0 * * * * *", func() {
 		ctx := context.Background()
+		if !s.ensureLeadership(ctx) {
+			s.logger.Debug("Skipping event reminder check, not the leader", zap.String("instance_id", s.instanceID))
+			return
+		}
 		s.logger.Debug("Running event reminder check", zap.Time("timestamp", time.Now()))
 		s.eventService.CheckAndSendReminders(ctx)
 	})
@@ -37,8 +87,24 @@ func (s *Scheduler) Start() error {
 		return err
 	}
 
+	for _, job := range s.jobs {
+		_, err := s.cron.AddFunc(job.spec, func() {
+			ctx := context.Background()
+			if !s.ensureLeadership(ctx) {
+				s.logger.Debug("Skipping scheduled job, not the leader", zap.String("job", job.name), zap.String("instance_id", s.instanceID))
+				return
+			}
+			s.logger.Debug("Running scheduled job", zap.String("job", job.name), zap.Time("timestamp", time.Now()))
+			job.run(ctx)
+		})
+		if err != nil {
+			s.logger.Error("Failed to add cron job", zap.String("job", job.name), zap.Error(err))
+			return err
+		}
+	}
+
 	s.cron.Start()
-	s.logger.Info("Event reminder scheduler started")
+	s.logger.Info("Event reminder scheduler started", zap.String("instance_id", s.instanceID))
 	return nil
 }
 
@@ -46,5 +112,95 @@ func (s *Scheduler) Start() error {
 func (s *Scheduler) Stop() {
 	ctx := s.cron.Stop()
 	<-ctx.Done()
+	s.releaseLeadership(context.Background())
 	s.logger.Info("Event reminder scheduler stopped")
 }
+
+// IsLeader 当前实例是否持有调度锁
+func (s *Scheduler) IsLeader() bool {
+	return s.isLeader.Load()
+}
+
+// Status 返回当前实例和持有调度锁的实例
+func (s *Scheduler) Status(ctx context.Context) (SchedulerStatus, error) {
+	status := SchedulerStatus{
+		InstanceID: s.instanceID,
+		IsLeader:   s.IsLeader(),
+	}
+
+	holder, err := repository.New(s.db).GetAdvisoryLockHolder(ctx, schedulerLockKey)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return status, nil
+		}
+		return status, err
+	}
+	status.Leader = strings.TrimPrefix(holder, schedulerAppNamePrefix)
+	return status, nil
+}
+
+// ensureLeadership 确认或尝试获取调度锁，返回当前实例是否为 leader
+func (s *Scheduler) ensureLeadership(ctx context.Context) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 已持有锁时确认连接仍然存活，连接断开意味着锁已被数据库释放
+	if s.lockConn != nil {
+		if err := s.lockConn.Ping(ctx); err == nil {
+			return true
+		}
+		s.logger.Warn("Lost scheduler lock connection", zap.String("instance_id", s.instanceID))
+		s.lockConn.Conn().Close(ctx)
+		s.lockConn.Release()
+		s.lockConn = nil
+		s.isLeader.Store(false)
+	}
+
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		s.logger.Error("Failed to acquire connection for scheduler lock", zap.Error(err))
+		return false
+	}
+
+	q := repository.New(conn)
+	locked, err := q.TryAdvisoryLock(ctx, schedulerLockKey)
+	if err != nil || !locked {
+		if err != nil {
+			s.logger.Error("Failed to try scheduler lock", zap.Error(err))
+		}
+		conn.Release()
+		return false
+	}
+
+	if err := q.SetApplicationName(ctx, schedulerAppNamePrefix+s.instanceID); err != nil {
+		s.logger.Warn("Failed to set scheduler application name", zap.Error(err))
+	}
+
+	s.lockConn = conn
+	s.isLeader.Store(true)
+	s.logger.Info("Acquired scheduler leadership", zap.String("instance_id", s.instanceID))
+	return true
+}
+
+// releaseLeadership 释放调度锁，让其他实例接管
+func (s *Scheduler) releaseLeadership(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lockConn == nil {
+		return
+	}
+
+	q := repository.New(s.lockConn)
+	if _, err := q.ReleaseAdvisoryLock(ctx, schedulerLockKey); err != nil {
+		s.logger.Warn("Failed to release scheduler lock", zap.Error(err))
+	}
+	// 恢复默认 application_name，避免连接回到连接池后被误认为 leader
+	if err := q.SetApplicationName(ctx, ""); err != nil {
+		s.lockConn.Conn().Close(ctx)
+	}
+	s.lockConn.Release()
+	s.lockConn = nil
+	s.isLeader.Store(false)
+	s.logger.Info("Released scheduler leadership", zap.String("instance_id", s.instanceID))
+}
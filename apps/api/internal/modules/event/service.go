@@ -4,33 +4,52 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/zeroicey/lifetrack-api/internal/config"
 	"github.com/zeroicey/lifetrack-api/internal/modules/event/types"
 	"github.com/zeroicey/lifetrack-api/internal/modules/notification"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
 	"github.com/zeroicey/lifetrack-api/internal/repository"
 	"go.uber.org/zap"
 )
 
 type Service struct {
 	Q                   *repository.Queries
+	DB                  *pgxpool.Pool
 	logger              *zap.Logger
 	config              *config.Config
 	notificationService *notification.Service
 }
 
-// ErrEventNotFound 是当事件不存在时返回的哨兵错误
-var ErrEventNotFound = errors.New("event not found")
+// Sentinel errors for event domain
+var (
+	// ErrEventNotFound 是当事件不存在时返回的哨兵错误
+	ErrEventNotFound = errors.New("event not found")
+	// ErrInvalidTimeRange 是结束时间早于开始时间时返回的哨兵错误
+	ErrInvalidTimeRange = errors.New("end time must be after start time")
+	// ErrNotRecurring 是对非重复事件执行实例操作时返回的哨兵错误
+	ErrNotRecurring = errors.New("event is not recurring")
+	// ErrInvalidOccurrence 是 recurrence_id 不是该事件的某次实例时返回的哨兵错误
+	ErrInvalidOccurrence = errors.New("recurrence_id does not match any occurrence")
+	// ErrReminderNotFound 是当事件提醒不存在时返回的哨兵错误
+	ErrReminderNotFound = errors.New("event reminder not found")
+	// ErrInvalidTimezone 是时区不是合法 IANA 名称时返回的哨兵错误
+	ErrInvalidTimezone = pkg.ErrInvalidTimezone
+)
 
-func NewService(q *repository.Queries, logger *zap.Logger, config *config.Config, notificationService *notification.Service) *Service {
-	return &Service{Q: q, logger: logger, config: config, notificationService: notificationService}
+func NewService(db *pgxpool.Pool, q *repository.Queries, logger *zap.Logger, config *config.Config, notificationService *notification.Service) *Service {
+	return &Service{Q: q, DB: db, logger: logger, config: config, notificationService: notificationService}
 }
 
 // GetAllEvents 获取所有事件及其提醒
-func (s *Service) GetAllEvents(ctx context.Context) ([]types.EventResponse, error) {
-	rows, err := s.Q.GetAllEvents(ctx)
+func (s *Service) GetAllEvents(ctx context.Context, userID int64) ([]types.EventResponse, error) {
+	rows, err := s.Q.GetAllEvents(ctx, userID)
 	if err != nil {
 		s.logger.Error("Failed to get all events", zap.Error(err))
 		return nil, err
@@ -40,8 +59,11 @@ func (s *Service) GetAllEvents(ctx context.Context) ([]types.EventResponse, erro
 }
 
 // GetEventByID 根据ID获取事件及其提醒
-func (s *Service) GetEventByID(ctx context.Context, id int64) (types.EventResponse, error) {
-	rows, err := s.Q.GetEventByID(ctx, id)
+func (s *Service) GetEventByID(ctx context.Context, userID int64, id int64) (types.EventResponse, error) {
+	rows, err := s.Q.GetEventByID(ctx, repository.GetEventByIDParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		s.logger.Error("Failed to get event by ID", zap.Int64("id", id), zap.Error(err))
 		return types.EventResponse{}, ErrEventNotFound
@@ -56,10 +78,15 @@ func (s *Service) GetEventByID(ctx context.Context, id int64) (types.EventRespon
 			Description:       rows.Description,
 			StartTime:         rows.StartTime,
 			EndTime:           rows.EndTime,
+			Rrule:             rows.Rrule,
+			Exdates:           rows.Exdates,
+			Uid:               rows.Uid,
+			Timezone:          rows.Timezone,
 			CreatedAt:         rows.CreatedAt,
 			UpdatedAt:         rows.UpdatedAt,
 			ReminderID:        rows.ReminderID,
 			RemindBefore:      rows.RemindBefore,
+			ReminderChannel:   rows.ReminderChannel,
 			Notified:          rows.Notified,
 			ReminderCreatedAt: rows.ReminderCreatedAt,
 		},
@@ -70,14 +97,58 @@ func (s *Service) GetEventByID(ctx context.Context, id int64) (types.EventRespon
 		return types.EventResponse{}, ErrEventNotFound
 	}
 
-	return grouped[0], nil
+	event := grouped[0]
+	if event.RRule != "" {
+		overrides, err := s.Q.GetEventOverridesByEventIDs(ctx, []int64{id})
+		if err != nil {
+			s.logger.Error("Failed to get event overrides", zap.Int64("id", id), zap.Error(err))
+			return types.EventResponse{}, err
+		}
+		for _, o := range overrides {
+			event.Overrides = append(event.Overrides, convertEventOverride(o))
+		}
+	}
+
+	return event, nil
 }
 
 // CreateEvent 创建新事件
-func (s *Service) CreateEvent(ctx context.Context, body types.CreateEventBody) (types.EventResponse, error) {
+func (s *Service) CreateEvent(ctx context.Context, userID int64, body types.CreateEventBody) (types.EventResponse, error) {
 	// 验证时间
 	if body.EndTime.Before(body.StartTime) {
-		return types.EventResponse{}, errors.New("end time must be after start time")
+		return types.EventResponse{}, ErrInvalidTimeRange
+	}
+
+	// 验证并规范化重复规则
+	rrule, err := normalizeRRule(body.RRule)
+	if err != nil {
+		return types.EventResponse{}, err
+	}
+
+	// 验证提醒渠道
+	channel, err := s.resolveReminderChannel(ctx, userID, body.ReminderChannel)
+	if err != nil {
+		return types.EventResponse{}, err
+	}
+
+	// 未指定时区时使用用户时区
+	loc, err := pkg.ResolveLocation(ctx, s.Q, userID, body.Timezone)
+	if err != nil {
+		return types.EventResponse{}, err
+	}
+
+	// 检查与已有事件的时间冲突
+	if !body.AllowConflict {
+		err := s.checkConflicts(ctx, userID, 0, types.EventResponse{
+			StartTime: body.StartTime,
+			EndTime:   body.EndTime,
+			RRule:     rrule,
+			ExDates:   body.ExDates,
+			Timezone:  loc.String(),
+		})
+		if err != nil {
+			return types.EventResponse{}, err
+		}
 	}
 
 	// 转换时间格式
@@ -93,6 +164,11 @@ func (s *Service) CreateEvent(ctx context.Context, body types.CreateEventBody) (
 		Description: body.Description,
 		StartTime:   startTime,
 		EndTime:     endTime,
+		Rrule:       rrule,
+		Exdates:     toPgTimestamps(body.ExDates),
+		Uid:         body.UID,
+		Timezone:    loc.String(),
+		UserID:      userID,
 	})
 	if err != nil {
 		s.logger.Error("Failed to create event", zap.Error(err))
@@ -105,6 +181,7 @@ func (s *Service) CreateEvent(ctx context.Context, body types.CreateEventBody) (
 		reminder, err := s.Q.CreateEventReminder(ctx, repository.CreateEventReminderParams{
 			EventID:      event.ID,
 			RemindBefore: int32(reminderMinutes),
+			Channel:      channel,
 		})
 		if err != nil {
 			s.logger.Error("Failed to create event reminder", zap.Error(err))
@@ -115,6 +192,7 @@ func (s *Service) CreateEvent(ctx context.Context, body types.CreateEventBody) (
 			ID:           reminder.ID,
 			EventID:      reminder.EventID,
 			RemindBefore: int(reminder.RemindBefore),
+			Channel:      reminder.Channel,
 			Notified:     reminder.Notified,
 			CreatedAt:    reminder.CreatedAt.Time,
 		})
@@ -127,6 +205,10 @@ func (s *Service) CreateEvent(ctx context.Context, body types.CreateEventBody) (
 		Description: event.Description,
 		StartTime:   event.StartTime.Time,
 		EndTime:     event.EndTime.Time,
+		UID:         event.Uid,
+		Timezone:    event.Timezone,
+		RRule:       event.Rrule,
+		ExDates:     fromPgTimestamps(event.Exdates),
 		Reminders:   reminders,
 		CreatedAt:   event.CreatedAt.Time,
 		UpdatedAt:   event.UpdatedAt.Time,
@@ -134,10 +216,64 @@ func (s *Service) CreateEvent(ctx context.Context, body types.CreateEventBody) (
 }
 
 // UpdateEvent 更新事件
-func (s *Service) UpdateEvent(ctx context.Context, id int64, body types.UpdateEventBody) (types.EventResponse, error) {
+func (s *Service) UpdateEvent(ctx context.Context, userID int64, id int64, body types.UpdateEventBody) (types.EventResponse, error) {
 	// 验证时间
 	if body.EndTime.Before(body.StartTime) {
-		return types.EventResponse{}, errors.New("end time must be after start time")
+		return types.EventResponse{}, ErrInvalidTimeRange
+	}
+
+	// 验证并规范化重复规则
+	rrule, err := normalizeRRule(body.RRule)
+	if err != nil {
+		return types.EventResponse{}, err
+	}
+
+	// 验证完整提醒列表中的渠道
+	var reminderInputs []types.ReminderInput
+	if body.Reminders != nil {
+		for _, input := range *body.Reminders {
+			input.Channel, err = s.resolveReminderChannel(ctx, userID, input.Channel)
+			if err != nil {
+				return types.EventResponse{}, err
+			}
+			reminderInputs = append(reminderInputs, input)
+		}
+	}
+
+	existing, err := s.Q.GetEventByID(ctx, repository.GetEventByIDParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.EventResponse{}, ErrEventNotFound
+		}
+		s.logger.Error("Failed to get event by ID", zap.Int64("id", id), zap.Error(err))
+		return types.EventResponse{}, err
+	}
+
+	// 未指定时区时保持原有时区
+	timezone := existing.Timezone
+	if body.Timezone != "" {
+		loc, err := pkg.LoadLocation(body.Timezone)
+		if err != nil {
+			return types.EventResponse{}, err
+		}
+		timezone = loc.String()
+	}
+
+	// 检查与其他事件的时间冲突
+	if !body.AllowConflict {
+		err := s.checkConflicts(ctx, userID, id, types.EventResponse{
+			StartTime: body.StartTime,
+			EndTime:   body.EndTime,
+			RRule:     rrule,
+			ExDates:   body.ExDates,
+			Timezone:  timezone,
+		})
+		if err != nil {
+			return types.EventResponse{}, err
+		}
 	}
 
 	// 转换时间格式
@@ -146,20 +282,56 @@ func (s *Service) UpdateEvent(ctx context.Context, id int64, body types.UpdateEv
 	endTime := pgtype.Timestamptz{}
 	endTime.Scan(body.EndTime)
 
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return types.EventResponse{}, err
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.Q.WithTx(tx)
+
 	// 更新事件
-	event, err := s.Q.UpdateEvent(ctx, repository.UpdateEventParams{
+	event, err := qtx.UpdateEvent(ctx, repository.UpdateEventParams{
 		ID:          id,
 		Name:        body.Name,
 		Place:       body.Place,
 		Description: body.Description,
 		StartTime:   startTime,
 		EndTime:     endTime,
+		Rrule:       rrule,
+		Exdates:     toPgTimestamps(body.ExDates),
+		Timezone:    timezone,
+		UserID:      userID,
 	})
 	if err != nil {
 		s.logger.Error("Failed to update event", zap.Int64("id", id), zap.Error(err))
 		return types.EventResponse{}, err
 	}
 
+	// 传入完整提醒列表时整体替换
+	if body.Reminders != nil {
+		if err := s.replaceReminders(ctx, qtx, userID, id, reminderInputs); err != nil {
+			s.logger.Error("Failed to replace event reminders", zap.Int64("id", id), zap.Error(err))
+			return types.EventResponse{}, err
+		}
+	}
+
+	// 时间或重复规则变化后重新启用提醒，并取消按旧时间排队的投递
+	if isRescheduled(existing, event) {
+		if err := qtx.RearmEventReminders(ctx, id); err != nil {
+			s.logger.Error("Failed to re-arm event reminders", zap.Int64("id", id), zap.Error(err))
+			return types.EventResponse{}, err
+		}
+		if err := qtx.DeletePendingReminderDeliveriesByEventID(ctx, id); err != nil {
+			s.logger.Error("Failed to cancel pending reminder deliveries", zap.Int64("id", id), zap.Error(err))
+			return types.EventResponse{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.logger.Error("Failed to commit event update", zap.Int64("id", id), zap.Error(err))
+		return types.EventResponse{}, err
+	}
+
 	// 获取现有提醒
 	reminders, err := s.Q.GetEventRemindersByEventID(ctx, id)
 	if err != nil {
@@ -172,6 +344,7 @@ func (s *Service) UpdateEvent(ctx context.Context, id int64, body types.UpdateEv
 			ID:           reminder.ID,
 			EventID:      reminder.EventID,
 			RemindBefore: int(reminder.RemindBefore),
+			Channel:      reminder.Channel,
 			Notified:     reminder.Notified,
 			CreatedAt:    reminder.CreatedAt.Time,
 		})
@@ -184,15 +357,70 @@ func (s *Service) UpdateEvent(ctx context.Context, id int64, body types.UpdateEv
 		Description: event.Description,
 		StartTime:   event.StartTime.Time,
 		EndTime:     event.EndTime.Time,
+		UID:         event.Uid,
+		Timezone:    event.Timezone,
+		RRule:       event.Rrule,
+		ExDates:     fromPgTimestamps(event.Exdates),
 		Reminders:   reminderResponses,
 		CreatedAt:   event.CreatedAt.Time,
 		UpdatedAt:   event.UpdatedAt.Time,
 	}, nil
 }
 
+// replaceReminders 将事件的提醒替换为给定列表
+// 提前时间和渠道都相同的提醒原样保留（包括已通知状态），其余删除或新建
+func (s *Service) replaceReminders(ctx context.Context, q *repository.Queries, userID int64, eventID int64, inputs []types.ReminderInput) error {
+	current, err := q.GetEventRemindersByEventID(ctx, eventID)
+	if err != nil {
+		return err
+	}
+
+	type reminderKey struct {
+		remindBefore int32
+		channel      string
+	}
+	wanted := make(map[reminderKey]bool, len(inputs))
+	for _, input := range inputs {
+		wanted[reminderKey{int32(input.RemindBefore), input.Channel}] = true
+	}
+
+	for _, reminder := range current {
+		key := reminderKey{reminder.RemindBefore, reminder.Channel}
+		if wanted[key] {
+			delete(wanted, key)
+			continue
+		}
+		if err := q.DeleteEventReminder(ctx, repository.DeleteEventReminderParams{
+			ID:     reminder.ID,
+			UserID: userID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, input := range inputs {
+		key := reminderKey{int32(input.RemindBefore), input.Channel}
+		if !wanted[key] {
+			continue
+		}
+		delete(wanted, key)
+		if _, err := q.CreateEventReminder(ctx, repository.CreateEventReminderParams{
+			EventID:      eventID,
+			RemindBefore: key.remindBefore,
+			Channel:      key.channel,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // DeleteEvent 删除事件
-func (s *Service) DeleteEvent(ctx context.Context, id int64) error {
-	err := s.Q.DeleteEvent(ctx, id)
+func (s *Service) DeleteEvent(ctx context.Context, userID int64, id int64) error {
+	err := s.Q.DeleteEvent(ctx, repository.DeleteEventParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		s.logger.Error("Failed to delete event", zap.Int64("id", id), zap.Error(err))
 		return err
@@ -200,21 +428,25 @@ func (s *Service) DeleteEvent(ctx context.Context, id int64) error {
 	return nil
 }
 
-// GetEventsByDateRange 根据日期范围获取事件
-func (s *Service) GetEventsByDateRange(ctx context.Context, startDate, endDate string) ([]types.EventResponse, error) {
+// GetEventsByDateRange 根据日期范围获取事件，日期按 timezone 解析，为空时使用用户时区
+func (s *Service) GetEventsByDateRange(ctx context.Context, userID int64, startDate, endDate, timezone string) ([]types.EventResponse, error) {
+	loc, err := pkg.ResolveLocation(ctx, s.Q, userID, timezone)
+	if err != nil {
+		return nil, err
+	}
+
 	// 解析日期
-	startTime, err := time.Parse("2006-01-02", startDate)
+	startTime, err := pkg.ParseDate(startDate, loc)
 	if err != nil {
 		return nil, fmt.Errorf("invalid start date format: %w", err)
 	}
-	endTime, err := time.Parse("2006-01-02", endDate)
+	endTime, err := pkg.ParseDate(endDate, loc)
 	if err != nil {
 		return nil, fmt.Errorf("invalid end date format: %w", err)
 	}
 
-	// 设置时间范围（开始日期的00:00:00到结束日期的23:59:59）
-	startTime = startTime.UTC()
-	endTime = endTime.Add(24*time.Hour - time.Second).UTC()
+	// 设置时间范围（开始日期的00:00:00到结束日期次日的00:00:00，按所选时区计算）
+	_, endTime = pkg.DayRange(endTime)
 
 	// 转换为pgtype
 	startTimePg := pgtype.Timestamptz{}
@@ -225,6 +457,7 @@ func (s *Service) GetEventsByDateRange(ctx context.Context, startDate, endDate s
 	rows, err := s.Q.GetEventsByDateRange(ctx, repository.GetEventsByDateRangeParams{
 		StartTime: startTimePg,
 		EndTime:   endTimePg,
+		UserID:    userID,
 	})
 	if err != nil {
 		s.logger.Error("Failed to get events by date range", zap.String("start", startDate), zap.String("end", endDate), zap.Error(err))
@@ -241,23 +474,379 @@ func (s *Service) GetEventsByDateRange(ctx context.Context, startDate, endDate s
 			Description:       row.Description,
 			StartTime:         row.StartTime,
 			EndTime:           row.EndTime,
+			Rrule:             row.Rrule,
+			Exdates:           row.Exdates,
+			Uid:               row.Uid,
+			Timezone:          row.Timezone,
 			CreatedAt:         row.CreatedAt,
 			UpdatedAt:         row.UpdatedAt,
 			ReminderID:        row.ReminderID,
 			RemindBefore:      row.RemindBefore,
+			ReminderChannel:   row.ReminderChannel,
 			Notified:          row.Notified,
 			ReminderCreatedAt: row.ReminderCreatedAt,
 		})
 	}
 
-	return s.groupEventRows(allEventsRows), nil
+	return s.expandEvents(ctx, s.groupEventRows(allEventsRows), startTime, endTime)
+}
+
+// expandEvents 将重复事件展开为与 [from, to) 重叠的各次实例，普通事件原样保留，结果按开始时间排序
+func (s *Service) expandEvents(ctx context.Context, events []types.EventResponse, from, to time.Time) ([]types.EventResponse, error) {
+	var recurringIDs []int64
+	for _, event := range events {
+		if event.RRule != "" {
+			recurringIDs = append(recurringIDs, event.ID)
+		}
+	}
+
+	overridesByEvent := make(map[int64][]repository.EventOverride)
+	if len(recurringIDs) > 0 {
+		overrides, err := s.Q.GetEventOverridesByEventIDs(ctx, recurringIDs)
+		if err != nil {
+			s.logger.Error("Failed to get event overrides", zap.Error(err))
+			return nil, err
+		}
+		for _, o := range overrides {
+			overridesByEvent[o.EventID] = append(overridesByEvent[o.EventID], o)
+		}
+	}
+
+	result := []types.EventResponse{}
+	for _, event := range events {
+		if event.RRule == "" {
+			result = append(result, event)
+			continue
+		}
+		result = append(result, s.expandOccurrences(event, overridesByEvent[event.ID], from, to)...)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].StartTime.Before(result[j].StartTime)
+	})
+	return result, nil
+}
+
+// expandOccurrences 计算单个重复事件与 [from, to) 重叠的实例，应用 EXDATE 和单次修改
+// 开始时间早于 from 但尚未结束的实例（例如跨越零点的实例）也会返回
+func (s *Service) expandOccurrences(event types.EventResponse, overrides []repository.EventOverride, from, to time.Time) []types.EventResponse {
+	rule, err := pkg.ParseRRule(event.RRule)
+	if err != nil {
+		s.logger.Warn("Skipping event with invalid rrule", zap.Int64("id", event.ID), zap.String("rrule", event.RRule), zap.Error(err))
+		return nil
+	}
+
+	excluded := make(map[int64]bool, len(event.ExDates))
+	for _, exdate := range event.ExDates {
+		excluded[exdate.Unix()] = true
+	}
+	overrideByRecurrence := make(map[int64]repository.EventOverride, len(overrides))
+	for _, o := range overrides {
+		overrideByRecurrence[o.RecurrenceID.Time.Unix()] = o
+	}
+
+	duration := event.EndTime.Sub(event.StartTime)
+	seen := make(map[int64]bool)
+	var occurrences []types.EventResponse
+	// 按事件时区展开，保证跨夏令时的实例保持相同的本地时间
+	dtstart := event.StartTime.In(eventLocation(event.Timezone))
+	for _, start := range rule.Between(dtstart, from.Add(-duration), to) {
+		key := start.Unix()
+		seen[key] = true
+		if excluded[key] {
+			continue
+		}
+		occurrence := newOccurrence(event, start, start.Add(duration))
+		if o, ok := overrideByRecurrence[key]; ok {
+			occurrence = applyOverride(occurrence, o)
+		}
+		if !occursWithin(occurrence.StartTime, occurrence.EndTime, from, to) {
+			continue
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+
+	// 原始时间不在范围内、但被改期或延长到范围内的实例
+	for key, o := range overrideByRecurrence {
+		if seen[key] || excluded[key] {
+			continue
+		}
+		if !occursWithin(o.StartTime.Time, o.EndTime.Time, from, to) {
+			continue
+		}
+		occurrences = append(occurrences, applyOverride(newOccurrence(event, o.RecurrenceID.Time, o.RecurrenceID.Time.Add(duration)), o))
+	}
+
+	return occurrences
+}
+
+// occursWithin 判断 [start, end) 是否与 [from, to) 重叠，时长为 0 的实例开始时间落在范围内即可
+func occursWithin(start, end, from, to time.Time) bool {
+	if !start.Before(to) {
+		return false
+	}
+	return end.After(from) || (end.Equal(start) && !start.Before(from))
+}
+
+// UpsertEventOverride 创建或更新重复事件中某一次实例的修改
+func (s *Service) UpsertEventOverride(ctx context.Context, userID int64, eventID int64, body types.UpsertEventOverrideBody) (types.EventOverride, error) {
+	if body.EndTime.Before(body.StartTime) {
+		return types.EventOverride{}, ErrInvalidTimeRange
+	}
+
+	event, err := s.GetEventByID(ctx, userID, eventID)
+	if err != nil {
+		return types.EventOverride{}, err
+	}
+	if event.RRule == "" {
+		return types.EventOverride{}, ErrNotRecurring
+	}
+
+	rule, err := pkg.ParseRRule(event.RRule)
+	if err != nil {
+		return types.EventOverride{}, err
+	}
+	recurrenceID := body.RecurrenceID.UTC()
+	dtstart := event.StartTime.In(eventLocation(event.Timezone))
+	if len(rule.Between(dtstart, recurrenceID, recurrenceID.Add(time.Second))) == 0 {
+		return types.EventOverride{}, ErrInvalidOccurrence
+	}
+
+	recurrenceIDPg := pgtype.Timestamptz{}
+	recurrenceIDPg.Scan(recurrenceID)
+	startTime := pgtype.Timestamptz{}
+	startTime.Scan(body.StartTime)
+	endTime := pgtype.Timestamptz{}
+	endTime.Scan(body.EndTime)
+
+	override, err := s.Q.UpsertEventOverride(ctx, repository.UpsertEventOverrideParams{
+		EventID:      eventID,
+		RecurrenceID: recurrenceIDPg,
+		Name:         body.Name,
+		Place:        body.Place,
+		Description:  body.Description,
+		StartTime:    startTime,
+		EndTime:      endTime,
+	})
+	if err != nil {
+		s.logger.Error("Failed to upsert event override", zap.Int64("eventID", eventID), zap.Error(err))
+		return types.EventOverride{}, err
+	}
+
+	duration := event.EndTime.Sub(event.StartTime)
+	occurrence := applyOverride(newOccurrence(event, recurrenceID, recurrenceID.Add(duration)), override)
+	if err := s.rearmOccurrenceReminders(ctx, userID, occurrence); err != nil {
+		s.logger.Error("Failed to re-arm occurrence reminders", zap.Int64("eventID", eventID), zap.Error(err))
+		return types.EventOverride{}, err
+	}
+
+	return convertEventOverride(override), nil
+}
+
+// DeleteEventOverride 删除单次实例修改，该实例恢复为规则生成的默认值
+func (s *Service) DeleteEventOverride(ctx context.Context, userID int64, overrideID int64) error {
+	override, err := s.Q.DeleteEventOverride(ctx, repository.DeleteEventOverrideParams{
+		ID:     overrideID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		s.logger.Error("Failed to delete event override", zap.Int64("overrideID", overrideID), zap.Error(err))
+		return err
+	}
+
+	event, err := s.GetEventByID(ctx, userID, override.EventID)
+	if err != nil {
+		return err
+	}
+	recurrenceID := override.RecurrenceID.Time
+	occurrence := newOccurrence(event, recurrenceID, recurrenceID.Add(event.EndTime.Sub(event.StartTime)))
+	if err := s.rearmOccurrenceReminders(ctx, userID, occurrence); err != nil {
+		s.logger.Error("Failed to re-arm occurrence reminders", zap.Int64("overrideID", overrideID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// rearmOccurrenceReminders 单次实例被修改或恢复后，取消该实例按旧时间排队的投递并按新的时间重新提醒
+// 提醒记录还没到这个实例时由 checkRecurringReminders 按新时间处理；已经越过它时（按旧时间提醒过或正在重试）
+// checkRecurringReminders 不会再处理这个实例，直接以新的提醒时间为 next_attempt_at 写入发件箱
+func (s *Service) rearmOccurrenceReminders(ctx context.Context, userID int64, occurrence types.EventResponse) error {
+	recurrenceID := *occurrence.RecurrenceID
+	recurrenceIDPg := pgtype.Timestamptz{}
+	recurrenceIDPg.Scan(recurrenceID)
+	err := s.Q.DeletePendingReminderDeliveriesByOccurrence(ctx, repository.DeletePendingReminderDeliveriesByOccurrenceParams{
+		EventID:    occurrence.ID,
+		Occurrence: recurrenceIDPg,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 已经开始的实例不再提醒
+	now := time.Now()
+	if !occurrence.StartTime.After(now) {
+		return nil
+	}
+
+	reminders, err := s.Q.GetEventRemindersByEventID(ctx, occurrence.ID)
+	if err != nil {
+		return err
+	}
+	for _, reminder := range reminders {
+		if !reminder.LastNotifiedOccurrence.Valid || reminder.LastNotifiedOccurrence.Time.Before(recurrenceID) {
+			continue
+		}
+		sendAt := occurrence.StartTime.Add(-time.Duration(reminder.RemindBefore) * time.Minute)
+		if sendAt.Before(now) {
+			sendAt = now
+		}
+		err := s.enqueueReminder(ctx, userID, reminder.ID, occurrence.ID, reminder.Channel, occurrence.Name, occurrence.Place, occurrence.Description,
+			occurrence.StartTime, occurrence.EndTime, recurrenceID, reminder.RemindBefore, sendAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportCalendar 导出用户的所有事件为 iCalendar 文本，重复事件附带单次实例修改
+func (s *Service) ExportCalendar(ctx context.Context, userID int64) (string, error) {
+	events, err := s.GetAllEvents(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	var recurringIDs []int64
+	for _, event := range events {
+		if event.RRule != "" {
+			recurringIDs = append(recurringIDs, event.ID)
+		}
+	}
+	if len(recurringIDs) > 0 {
+		overrides, err := s.Q.GetEventOverridesByEventIDs(ctx, recurringIDs)
+		if err != nil {
+			s.logger.Error("Failed to get event overrides", zap.Error(err))
+			return "", err
+		}
+		overridesByEvent := make(map[int64][]types.EventOverride)
+		for _, o := range overrides {
+			overridesByEvent[o.EventID] = append(overridesByEvent[o.EventID], convertEventOverride(o))
+		}
+		for i := range events {
+			events[i].Overrides = overridesByEvent[events[i].ID]
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+	return EncodeCalendar(events, time.Now()), nil
+}
+
+// ImportCalendar 从 .ics 文件导入事件，UID 已存在的事件会被跳过
+func (s *Service) ImportCalendar(ctx context.Context, userID int64, r io.Reader) (types.ImportEventsResponse, error) {
+	result := types.ImportEventsResponse{
+		Created: []types.EventResponse{},
+		Skipped: []string{},
+		Errors:  []string{},
+	}
+
+	icalEvents, err := decodeCalendar(r)
+	if err != nil {
+		return result, err
+	}
+
+	// 先导入主事件，再把带 RECURRENCE-ID 的实例挂到本次新建的主事件上
+	createdByUID := make(map[string]int64)
+	for _, e := range icalEvents {
+		if e.RecurrenceID != nil {
+			continue
+		}
+		if e.UID != "" {
+			exists, err := s.eventUIDExists(ctx, userID, e.UID)
+			if err != nil {
+				s.logger.Error("Failed to check event uid", zap.String("uid", e.UID), zap.Error(err))
+				return result, err
+			}
+			if exists {
+				result.Skipped = append(result.Skipped, e.UID)
+				continue
+			}
+		}
+
+		event, err := s.CreateEvent(ctx, userID, types.CreateEventBody{
+			Name:        e.Summary,
+			Place:       e.Location,
+			Description: e.Description,
+			StartTime:   e.Start,
+			EndTime:     e.End,
+			Reminders:   e.Alarms,
+			RRule:       e.RRule,
+			ExDates:     e.ExDates,
+			UID:         e.UID,
+			Timezone:    e.Timezone,
+			// 导入的日历本身可能包含重叠事件，不做冲突检查
+			AllowConflict: true,
+		})
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", importLabel(e), err))
+			continue
+		}
+		if e.UID != "" {
+			createdByUID[e.UID] = event.ID
+		}
+		result.Created = append(result.Created, event)
+	}
+
+	for _, e := range icalEvents {
+		if e.RecurrenceID == nil {
+			continue
+		}
+		eventID, ok := createdByUID[e.UID]
+		if !ok {
+			continue
+		}
+		_, err := s.UpsertEventOverride(ctx, userID, eventID, types.UpsertEventOverrideBody{
+			RecurrenceID: *e.RecurrenceID,
+			Name:         e.Summary,
+			Place:        e.Location,
+			Description:  e.Description,
+			StartTime:    e.Start,
+			EndTime:      e.End,
+		})
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s (%s): %v", importLabel(e), e.RecurrenceID.Format(time.RFC3339), err))
+		}
+	}
+
+	return result, nil
 }
 
 // CreateEventReminder 为事件创建提醒
-func (s *Service) CreateEventReminder(ctx context.Context, eventID int64, body types.CreateEventReminderBody) (types.EventReminderResponse, error) {
+func (s *Service) CreateEventReminder(ctx context.Context, userID int64, eventID int64, body types.CreateEventReminderBody) (types.EventReminderResponse, error) {
+	channel, err := s.resolveReminderChannel(ctx, userID, body.Channel)
+	if err != nil {
+		return types.EventReminderResponse{}, err
+	}
+
+	exists, err := s.Q.EventExists(ctx, repository.EventExistsParams{
+		ID:     eventID,
+		UserID: userID,
+	})
+	if err != nil {
+		return types.EventReminderResponse{}, err
+	}
+	if !exists {
+		return types.EventReminderResponse{}, ErrEventNotFound
+	}
+
 	reminder, err := s.Q.CreateEventReminder(ctx, repository.CreateEventReminderParams{
 		EventID:      eventID,
 		RemindBefore: int32(body.RemindBefore),
+		Channel:      channel,
 	})
 	if err != nil {
 		s.logger.Error("Failed to create event reminder", zap.Int64("eventID", eventID), zap.Error(err))
@@ -268,14 +857,48 @@ func (s *Service) CreateEventReminder(ctx context.Context, eventID int64, body t
 		ID:           reminder.ID,
 		EventID:      reminder.EventID,
 		RemindBefore: int(reminder.RemindBefore),
+		Channel:      reminder.Channel,
+		Notified:     reminder.Notified,
+		CreatedAt:    reminder.CreatedAt.Time,
+	}, nil
+}
+
+// UpdateEventReminderChannel 修改提醒的通知渠道
+func (s *Service) UpdateEventReminderChannel(ctx context.Context, userID int64, reminderID int64, body types.UpdateEventReminderBody) (types.EventReminderResponse, error) {
+	channel, err := s.resolveReminderChannel(ctx, userID, body.Channel)
+	if err != nil {
+		return types.EventReminderResponse{}, err
+	}
+
+	reminder, err := s.Q.UpdateEventReminderChannel(ctx, repository.UpdateEventReminderChannelParams{
+		ID:      reminderID,
+		Channel: channel,
+		UserID:  userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.EventReminderResponse{}, ErrReminderNotFound
+		}
+		s.logger.Error("Failed to update event reminder channel", zap.Int64("reminderID", reminderID), zap.Error(err))
+		return types.EventReminderResponse{}, err
+	}
+
+	return types.EventReminderResponse{
+		ID:           reminder.ID,
+		EventID:      reminder.EventID,
+		RemindBefore: int(reminder.RemindBefore),
+		Channel:      reminder.Channel,
 		Notified:     reminder.Notified,
 		CreatedAt:    reminder.CreatedAt.Time,
 	}, nil
 }
 
 // DeleteEventReminder 删除事件提醒
-func (s *Service) DeleteEventReminder(ctx context.Context, reminderID int64) error {
-	err := s.Q.DeleteEventReminder(ctx, reminderID)
+func (s *Service) DeleteEventReminder(ctx context.Context, userID int64, reminderID int64) error {
+	err := s.Q.DeleteEventReminder(ctx, repository.DeleteEventReminderParams{
+		ID:     reminderID,
+		UserID: userID,
+	})
 	if err != nil {
 		s.logger.Error("Failed to delete event reminder", zap.Int64("reminderID", reminderID), zap.Error(err))
 		return err
@@ -283,7 +906,15 @@ func (s *Service) DeleteEventReminder(ctx context.Context, reminderID int64) err
 	return nil
 }
 
+// CheckAndSendReminders 将到期的提醒写入发件箱，再投递发件箱中到期的记录，重复事件按每次实例分别提醒
 func (s *Service) CheckAndSendReminders(ctx context.Context) {
+	s.enqueueDueReminders(ctx)
+	s.checkRecurringReminders(ctx)
+	s.dispatchReminderDeliveries(ctx)
+}
+
+// enqueueDueReminders 将到期的非重复事件提醒写入发件箱
+func (s *Service) enqueueDueReminders(ctx context.Context) {
 	reminders, err := s.Q.GetEventRemindersToNotify(ctx)
 	if err != nil {
 		s.logger.Error("Failed to get event reminders to notify", zap.Error(err))
@@ -291,46 +922,9 @@ func (s *Service) CheckAndSendReminders(ctx context.Context) {
 	}
 
 	for _, reminder := range reminders {
-		// 在日志中输出提醒信息
-		s.logger.Info("Event Reminder",
-			zap.Int64("reminder_id", reminder.ID),
-			zap.Int64("event_id", reminder.EventID),
-			zap.String("event_name", reminder.Name),
-			zap.String("event_place", reminder.Place),
-			zap.String("event_description", reminder.Description),
-			zap.Time("event_start_time", reminder.StartTime.Time),
-			zap.Int32("remind_before_minutes", reminder.RemindBefore),
-		)
-		subject := fmt.Sprintf("🔔 Gentle Reminder: %s", reminder.Name)
-		body := fmt.Sprintf(`🌟 Event Reminder 🌟
-
-Hi there! 👋
-
-I hope this message finds you well! I wanted to gently remind you about your upcoming event:
-
-📅 Event: %s
-📍 Location: %s
-📝 Description: %s
-⏰ Start Time: %s
-⏰ End Time: %s
-⏱️ Reminder: %d minutes before
-
-I hope you have a wonderful time! 😊✨
-
-Warm regards! 💕`,
-			reminder.Name,
-			reminder.Place,
-			reminder.Description,
-			reminder.StartTime.Time.Format("2006-01-02 15:04:05"),
-			reminder.EndTime.Time.Format("2006-01-02 15:04:05"),
-			reminder.RemindBefore,
-		)
-		err := s.notificationService.SendEmail(s.config.Mail.To, subject, body)
+		err := s.enqueueReminder(ctx, reminder.UserID, reminder.ID, reminder.EventID, reminder.Channel, reminder.Name, reminder.Place, reminder.Description,
+			reminder.StartTime.Time, reminder.EndTime.Time, reminder.StartTime.Time, reminder.RemindBefore, time.Now())
 		if err != nil {
-			s.logger.Error("Failed to send reminder email via notification service",
-				zap.Int64("reminder_id", reminder.ID),
-				zap.Error(err),
-			)
 			continue
 		}
 		err = s.Q.UpdateEventReminderNotified(ctx, repository.UpdateEventReminderNotifiedParams{
@@ -346,6 +940,162 @@ Warm regards! 💕`,
 	}
 }
 
+// checkRecurringReminders 将重复事件到达提醒时间（开始时间 - remind_before）的实例写入发件箱
+// 每个提醒记录最近一次已入队实例的原始开始时间，从该实例起展开，避免同一实例重复入队；
+// 没有记录时（新建或重新启用的提醒）只回看一个检查周期，不为更早开始的实例补发提醒
+func (s *Service) checkRecurringReminders(ctx context.Context) {
+	reminders, err := s.Q.GetRecurringEventReminders(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get recurring event reminders", zap.Error(err))
+		return
+	}
+	if len(reminders) == 0 {
+		return
+	}
+
+	eventIDs := make([]int64, 0, len(reminders))
+	for _, reminder := range reminders {
+		eventIDs = append(eventIDs, reminder.EventID)
+	}
+	overrides, err := s.Q.GetEventOverridesByEventIDs(ctx, eventIDs)
+	if err != nil {
+		s.logger.Error("Failed to get event overrides", zap.Error(err))
+		return
+	}
+	overridesByEvent := make(map[int64][]repository.EventOverride)
+	for _, o := range overrides {
+		overridesByEvent[o.EventID] = append(overridesByEvent[o.EventID], o)
+	}
+
+	now := time.Now()
+	for _, reminder := range reminders {
+		event := types.EventResponse{
+			ID:          reminder.EventID,
+			Name:        reminder.Name,
+			Place:       reminder.Place,
+			Description: reminder.Description,
+			StartTime:   reminder.StartTime.Time,
+			EndTime:     reminder.EndTime.Time,
+			RRule:       reminder.Rrule,
+			ExDates:     fromPgTimestamps(reminder.Exdates),
+			Timezone:    reminder.Timezone,
+		}
+		remindBefore := time.Duration(reminder.RemindBefore) * time.Minute
+		from := now.Add(-reminderCheckInterval)
+		if reminder.LastNotifiedOccurrence.Valid {
+			from = reminder.LastNotifiedOccurrence.Time
+		}
+		occurrences := s.expandOccurrences(event, overridesByEvent[reminder.EventID], from, now.Add(remindBefore).Add(time.Nanosecond))
+
+		due := dueOccurrence(occurrences, from, now, remindBefore, reminder.LastNotifiedOccurrence)
+		if due == nil {
+			continue
+		}
+
+		// 调度中断期间错过、已经结束的实例不再提醒，只推进记录
+		if !due.EndTime.Before(now.Add(-reminderCheckInterval)) {
+			err := s.enqueueReminder(ctx, reminder.UserID, reminder.ID, reminder.EventID, reminder.Channel, due.Name, due.Place, due.Description,
+				due.StartTime, due.EndTime, *due.RecurrenceID, reminder.RemindBefore, now)
+			if err != nil {
+				continue
+			}
+		}
+
+		lastNotified := pgtype.Timestamptz{}
+		lastNotified.Scan(*due.RecurrenceID)
+		err := s.Q.UpdateEventReminderLastNotifiedOccurrence(ctx, repository.UpdateEventReminderLastNotifiedOccurrenceParams{
+			ID:                     reminder.ID,
+			LastNotifiedOccurrence: lastNotified,
+		})
+		if err != nil {
+			s.logger.Error("Failed to update reminder last notified occurrence",
+				zap.Int64("reminder_id", reminder.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// dueOccurrence 返回开始时间不早于 from、已到提醒时间且晚于 lastNotified 的实例
+// 多个实例同时到期时（例如调度中断后恢复）只返回最近的一次，更早的跳过
+func dueOccurrence(occurrences []types.EventResponse, from, now time.Time, remindBefore time.Duration, lastNotified pgtype.Timestamptz) *types.EventResponse {
+	var due *types.EventResponse
+	for i, occurrence := range occurrences {
+		if occurrence.StartTime.Before(from) || occurrence.StartTime.Add(-remindBefore).After(now) {
+			continue
+		}
+		if lastNotified.Valid && !occurrence.RecurrenceID.After(lastNotified.Time) {
+			continue
+		}
+		if due == nil || occurrence.RecurrenceID.After(*due.RecurrenceID) {
+			due = &occurrences[i]
+		}
+	}
+	return due
+}
+
+// enqueueReminder 组装单条事件提醒并写入发件箱，由 dispatchReminderDeliveries 在 sendAt 之后投递
+// 提醒发送给事件所有者 userID，邮件中的时间按其时区显示
+func (s *Service) enqueueReminder(ctx context.Context, userID, reminderID, eventID int64, channel, name, place, description string, startTime, endTime, occurrence time.Time, remindBefore int32, sendAt time.Time) error {
+	// 在日志中输出提醒信息
+	s.logger.Info("Event Reminder",
+		zap.Int64("reminder_id", reminderID),
+		zap.Int64("event_id", eventID),
+		zap.String("channel", channel),
+		zap.String("event_name", name),
+		zap.String("event_place", place),
+		zap.String("event_description", description),
+		zap.Time("event_start_time", startTime),
+		zap.Int32("remind_before_minutes", remindBefore),
+	)
+	owner, err := s.Q.GetUserByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get event owner",
+			zap.Int64("reminder_id", reminderID),
+			zap.Int64("user_id", userID),
+			zap.Error(err),
+		)
+		return err
+	}
+	loc := eventLocation(owner.Timezone)
+
+	msg, err := s.notificationService.Render(notification.TemplateReminder, notification.ReminderData{
+		Name:         name,
+		Place:        place,
+		Description:  description,
+		StartTime:    startTime.In(loc),
+		EndTime:      endTime.In(loc),
+		RemindBefore: remindBefore,
+	})
+	if err != nil {
+		return err
+	}
+
+	occurrencePg := pgtype.Timestamptz{}
+	occurrencePg.Scan(occurrence)
+	nextAttemptAt := pgtype.Timestamptz{}
+	nextAttemptAt.Scan(sendAt)
+	err = s.Q.EnqueueReminderDelivery(ctx, repository.EnqueueReminderDeliveryParams{
+		ReminderID:    reminderID,
+		EventID:       eventID,
+		Occurrence:    occurrencePg,
+		Channel:       channel,
+		Title:         msg.Title,
+		Body:          msg.Body,
+		Html:          msg.HTML,
+		Recipient:     owner.Email,
+		NextAttemptAt: nextAttemptAt,
+	})
+	if err != nil {
+		s.logger.Error("Failed to enqueue reminder delivery",
+			zap.Int64("reminder_id", reminderID),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
 // groupEventRows 将数据库查询结果按事件分组，合并提醒信息
 func (s *Service) groupEventRows(rows []repository.GetAllEventsRow) []types.EventResponse {
 	eventMap := make(map[int64]*types.EventResponse)
@@ -360,6 +1110,10 @@ func (s *Service) groupEventRows(rows []repository.GetAllEventsRow) []types.Even
 				Description: row.Description,
 				StartTime:   row.StartTime.Time,
 				EndTime:     row.EndTime.Time,
+				UID:         row.Uid,
+				Timezone:    row.Timezone,
+				RRule:       row.Rrule,
+				ExDates:     fromPgTimestamps(row.Exdates),
 				Reminders:   []types.EventReminder{},
 				CreatedAt:   row.CreatedAt.Time,
 				UpdatedAt:   row.UpdatedAt.Time,
@@ -372,6 +1126,7 @@ func (s *Service) groupEventRows(rows []repository.GetAllEventsRow) []types.Even
 				ID:           row.ReminderID.Int64,
 				EventID:      row.ID,
 				RemindBefore: int(row.RemindBefore.Int32),
+				Channel:      row.ReminderChannel.String,
 				Notified:     row.Notified.Bool,
 				CreatedAt:    row.ReminderCreatedAt.Time,
 			}
@@ -387,3 +1142,122 @@ func (s *Service) groupEventRows(rows []repository.GetAllEventsRow) []types.Even
 
 	return events
 }
+
+// isRescheduled 判断更新是否改变了事件的提醒时间点
+func isRescheduled(before repository.GetEventByIDRow, after repository.Event) bool {
+	if !before.StartTime.Time.Equal(after.StartTime.Time) || before.Rrule != after.Rrule || before.Timezone != after.Timezone {
+		return true
+	}
+	if len(before.Exdates) != len(after.Exdates) {
+		return true
+	}
+	for i := range before.Exdates {
+		if !before.Exdates[i].Time.Equal(after.Exdates[i].Time) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRRule 校验重复规则并返回规范化后的字符串，空字符串表示不重复
+func normalizeRRule(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	rule, err := pkg.ParseRRule(value)
+	if err != nil {
+		return "", err
+	}
+	return rule.String(), nil
+}
+
+// eventLocation 返回事件存储的时区，无法加载时按 UTC 处理
+func eventLocation(name string) *time.Location {
+	loc, err := pkg.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// newOccurrence 基于主事件构造一次实例
+func newOccurrence(event types.EventResponse, start, end time.Time) types.EventResponse {
+	recurrenceID := start
+	occurrence := event
+	occurrence.StartTime = start
+	occurrence.EndTime = end
+	occurrence.RecurrenceID = &recurrenceID
+	occurrence.Overrides = nil
+	return occurrence
+}
+
+// applyOverride 用单次修改覆盖实例的字段
+func applyOverride(occurrence types.EventResponse, o repository.EventOverride) types.EventResponse {
+	occurrence.Name = o.Name
+	occurrence.Place = o.Place
+	occurrence.Description = o.Description
+	occurrence.StartTime = o.StartTime.Time
+	occurrence.EndTime = o.EndTime.Time
+	return occurrence
+}
+
+// eventUIDExists 判断用户是否已有 UID 对应的事件，包括本系统导出的默认 UID
+func (s *Service) eventUIDExists(ctx context.Context, userID int64, uid string) (bool, error) {
+	if id, ok := parseDefaultEventUID(uid); ok {
+		exists, err := s.Q.EventExists(ctx, repository.EventExistsParams{
+			ID:     id,
+			UserID: userID,
+		})
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			return true, nil
+		}
+	}
+	return s.Q.EventUIDExists(ctx, repository.EventUIDExistsParams{
+		Uid:    uid,
+		UserID: userID,
+	})
+}
+
+// importLabel 返回导入错误信息中用于标识事件的名称
+func importLabel(e icalEvent) string {
+	if e.UID != "" {
+		return e.UID
+	}
+	return e.Summary
+}
+
+func convertEventOverride(o repository.EventOverride) types.EventOverride {
+	return types.EventOverride{
+		ID:           o.ID,
+		EventID:      o.EventID,
+		RecurrenceID: o.RecurrenceID.Time,
+		Name:         o.Name,
+		Place:        o.Place,
+		Description:  o.Description,
+		StartTime:    o.StartTime.Time,
+		EndTime:      o.EndTime.Time,
+		CreatedAt:    o.CreatedAt.Time,
+		UpdatedAt:    o.UpdatedAt.Time,
+	}
+}
+
+func toPgTimestamps(times []time.Time) []pgtype.Timestamptz {
+	result := make([]pgtype.Timestamptz, 0, len(times))
+	for _, t := range times {
+		result = append(result, pgtype.Timestamptz{Time: t, Valid: true})
+	}
+	return result
+}
+
+func fromPgTimestamps(values []pgtype.Timestamptz) []time.Time {
+	var result []time.Time
+	for _, v := range values {
+		if v.Valid {
+			result = append(result, v.Time)
+		}
+	}
+	return result
+}
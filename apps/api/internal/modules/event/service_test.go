@@ -0,0 +1,159 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/modules/event/types"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+func mustTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("parse %q: %v", value, err)
+	}
+	return parsed
+}
+
+func occurrenceStarts(occurrences []types.EventResponse) []string {
+	starts := make([]string, len(occurrences))
+	for i, o := range occurrences {
+		starts[i] = o.StartTime.UTC().Format(time.RFC3339)
+	}
+	return starts
+}
+
+func TestExpandOccurrencesOverlap(t *testing.T) {
+	s := &Service{logger: zap.NewNop()}
+
+	// 每天 22:00-02:00，跨越零点
+	nightly := types.EventResponse{
+		ID:        1,
+		StartTime: mustTime(t, "2025-03-01T22:00:00Z"),
+		EndTime:   mustTime(t, "2025-03-02T02:00:00Z"),
+		RRule:     "FREQ=DAILY;COUNT=5",
+		Timezone:  "UTC",
+	}
+	// 2025-03-02 当天：前一晚延续到凌晨的实例和当晚开始的实例都应返回
+	from, to := mustTime(t, "2025-03-02T00:00:00Z"), mustTime(t, "2025-03-03T00:00:00Z")
+	got := occurrenceStarts(s.expandOccurrences(nightly, nil, from, to))
+	want := []string{"2025-03-01T22:00:00Z", "2025-03-02T22:00:00Z"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("cross-midnight occurrences = %v, want %v", got, want)
+	}
+
+	// 首尾相接不算重叠
+	got = occurrenceStarts(s.expandOccurrences(nightly, nil, mustTime(t, "2025-03-02T02:00:00Z"), mustTime(t, "2025-03-02T22:00:00Z")))
+	if len(got) != 0 {
+		t.Fatalf("adjacent occurrences = %v, want none", got)
+	}
+
+	// 单次修改把实例延长到范围内
+	override := repository.EventOverride{
+		EventID:      1,
+		RecurrenceID: pgtype.Timestamptz{Time: mustTime(t, "2025-03-01T22:00:00Z"), Valid: true},
+		StartTime:    pgtype.Timestamptz{Time: mustTime(t, "2025-03-01T22:00:00Z"), Valid: true},
+		EndTime:      pgtype.Timestamptz{Time: mustTime(t, "2025-03-02T12:00:00Z"), Valid: true},
+	}
+	got = occurrenceStarts(s.expandOccurrences(nightly, []repository.EventOverride{override}, mustTime(t, "2025-03-02T10:00:00Z"), mustTime(t, "2025-03-02T11:00:00Z")))
+	if len(got) != 1 || got[0] != "2025-03-01T22:00:00Z" {
+		t.Fatalf("lengthened override = %v, want [2025-03-01T22:00:00Z]", got)
+	}
+
+	// 时长为 0 的实例开始时间落在范围内即返回
+	instant := nightly
+	instant.EndTime = instant.StartTime
+	got = occurrenceStarts(s.expandOccurrences(instant, nil, mustTime(t, "2025-03-02T22:00:00Z"), mustTime(t, "2025-03-02T23:00:00Z")))
+	if len(got) != 1 || got[0] != "2025-03-02T22:00:00Z" {
+		t.Fatalf("zero-length occurrences = %v, want [2025-03-02T22:00:00Z]", got)
+	}
+}
+
+func TestDueOccurrence(t *testing.T) {
+	s := &Service{logger: zap.NewNop()}
+	daily := types.EventResponse{
+		ID:        1,
+		StartTime: mustTime(t, "2025-03-01T09:00:00Z"),
+		EndTime:   mustTime(t, "2025-03-01T09:30:00Z"),
+		RRule:     "FREQ=DAILY",
+		Timezone:  "UTC",
+	}
+
+	due := func(now time.Time, remindBefore time.Duration, last pgtype.Timestamptz, overrides ...repository.EventOverride) string {
+		from := now.Add(-reminderCheckInterval)
+		if last.Valid {
+			from = last.Time
+		}
+		occurrences := s.expandOccurrences(daily, overrides, from, now.Add(remindBefore).Add(time.Nanosecond))
+		if o := dueOccurrence(occurrences, from, now, remindBefore, last); o != nil {
+			return o.RecurrenceID.UTC().Format(time.RFC3339)
+		}
+		return ""
+	}
+	none := pgtype.Timestamptz{}
+
+	tests := []struct {
+		name         string
+		now          string
+		remindBefore time.Duration
+		last         pgtype.Timestamptz
+		overrides    []repository.EventOverride
+		want         string
+	}{
+		{"remind_before=0 fires on the tick after start", "2025-03-02T09:00:20Z", 0, none, nil, "2025-03-02T09:00:00Z"},
+		{"remind_before=0 fires exactly at start", "2025-03-02T09:00:00Z", 0, none, nil, "2025-03-02T09:00:00Z"},
+		{"not yet due", "2025-03-02T08:49:59Z", 10 * time.Minute, none, nil, ""},
+		{"due at the reminder time", "2025-03-02T08:50:00Z", 10 * time.Minute, none, nil, "2025-03-02T09:00:00Z"},
+		{"started between ticks after last notified", "2025-03-02T09:00:40Z", 0,
+			pgtype.Timestamptz{Time: mustTime(t, "2025-03-01T09:00:00Z"), Valid: true}, nil, "2025-03-02T09:00:00Z"},
+		{"already notified", "2025-03-02T09:00:40Z", 0,
+			pgtype.Timestamptz{Time: mustTime(t, "2025-03-02T09:00:00Z"), Valid: true}, nil, ""},
+		{"catch-up reminds the latest missed occurrence only", "2025-03-04T08:55:00Z", 10 * time.Minute,
+			pgtype.Timestamptz{Time: mustTime(t, "2025-03-01T09:00:00Z"), Valid: true}, nil, "2025-03-04T09:00:00Z"},
+		{"lengthened override is still reminded", "2025-03-02T09:00:30Z", 0, none, []repository.EventOverride{{
+			EventID:      1,
+			RecurrenceID: pgtype.Timestamptz{Time: mustTime(t, "2025-03-02T09:00:00Z"), Valid: true},
+			StartTime:    pgtype.Timestamptz{Time: mustTime(t, "2025-03-02T09:00:00Z"), Valid: true},
+			EndTime:      pgtype.Timestamptz{Time: mustTime(t, "2025-03-02T20:00:00Z"), Valid: true},
+		}}, "2025-03-02T09:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := due(mustTime(t, tt.now), tt.remindBefore, tt.last, tt.overrides...); got != tt.want {
+				t.Fatalf("due occurrence = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandOccurrencesCountExdate(t *testing.T) {
+	s := &Service{logger: zap.NewNop()}
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone America/New_York not available: %v", err)
+	}
+
+	// RFC 5545：被 EXDATE 排除的实例仍然计入 COUNT，不会顺延出第 6 次
+	event := types.EventResponse{
+		ID:        1,
+		StartTime: time.Date(2025, time.March, 7, 9, 0, 0, 0, ny),
+		EndTime:   time.Date(2025, time.March, 7, 10, 0, 0, 0, ny),
+		RRule:     "FREQ=DAILY;COUNT=5",
+		ExDates:   []time.Time{time.Date(2025, time.March, 9, 13, 0, 0, 0, time.UTC)},
+		Timezone:  "America/New_York",
+	}
+	got := occurrenceStarts(s.expandOccurrences(event, nil, event.StartTime, event.StartTime.AddDate(0, 1, 0)))
+	want := []string{"2025-03-07T14:00:00Z", "2025-03-08T14:00:00Z", "2025-03-10T13:00:00Z", "2025-03-11T13:00:00Z"}
+	if len(got) != len(want) {
+		t.Fatalf("occurrences = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("occurrences = %v, want %v", got, want)
+		}
+	}
+}
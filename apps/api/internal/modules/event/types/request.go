@@ -3,24 +3,66 @@ package types
 import "time"
 
 type CreateEventBody struct {
-	Name        string    `json:"name" validate:"required"`
-	Place       string    `json:"place" validate:"required"`
-	Description string    `json:"description" validate:"required"`
-	StartTime   time.Time `json:"start_time" validate:"required"`
-	EndTime     time.Time `json:"end_time" validate:"required"`
-	Reminders   []int     `json:"reminders,omitempty"` // 提醒时间（分钟）
+	Name            string      `json:"name" validate:"required"`
+	Place           string      `json:"place" validate:"required"`
+	Description     string      `json:"description" validate:"required"`
+	StartTime       time.Time   `json:"start_time" validate:"required"`
+	EndTime         time.Time   `json:"end_time" validate:"required"`
+	Reminders       []int       `json:"reminders,omitempty"`                              // 提醒时间（分钟）
+	ReminderChannel string      `json:"reminder_channel,omitempty"`                       // 提醒渠道，为空时使用默认渠道
+	RRule           string      `json:"rrule,omitempty"`                                  // 重复规则 (RFC 5545)，例如 FREQ=WEEKLY;BYDAY=MO
+	ExDates         []time.Time `json:"exdates,omitempty"`                                // 排除的实例开始时间
+	UID             string      `json:"uid,omitempty"`                                    // iCalendar UID，导入时用于去重
+	Timezone        string      `json:"timezone,omitempty" validate:"omitempty,timezone"` // 事件所在的 IANA 时区，为空时使用用户时区
+	AllowConflict   bool        `json:"allow_conflict,omitempty"`                         // 为 true 时允许与已有事件时间重叠
 }
 
 type UpdateEventBody struct {
-	Name        string    `json:"name" validate:"required"`
-	Place       string    `json:"place" validate:"required"`
-	Description string    `json:"description" validate:"required"`
-	StartTime   time.Time `json:"start_time" validate:"required"`
-	EndTime     time.Time `json:"end_time" validate:"required"`
+	Name          string           `json:"name" validate:"required"`
+	Place         string           `json:"place" validate:"required"`
+	Description   string           `json:"description" validate:"required"`
+	StartTime     time.Time        `json:"start_time" validate:"required"`
+	EndTime       time.Time        `json:"end_time" validate:"required"`
+	RRule         string           `json:"rrule,omitempty"`
+	ExDates       []time.Time      `json:"exdates,omitempty"`
+	Reminders     *[]ReminderInput `json:"reminders,omitempty" validate:"omitnil,dive"`      // 完整提醒列表，传入时替换现有提醒，不传则保持不变
+	Timezone      string           `json:"timezone,omitempty" validate:"omitempty,timezone"` // 事件所在的 IANA 时区，为空时保持不变
+	AllowConflict bool             `json:"allow_conflict,omitempty"`                         // 为 true 时允许与已有事件时间重叠
+}
+
+// ReminderInput 完整提醒列表中的一项
+type ReminderInput struct {
+	RemindBefore int    `json:"remind_before" validate:"required,min=1"` // 提醒前的分钟数
+	Channel      string `json:"channel,omitempty"`                       // 提醒渠道，为空时使用默认渠道
+}
+
+// UpsertEventOverrideBody 修改重复事件中的某一次实例
+type UpsertEventOverrideBody struct {
+	RecurrenceID time.Time `json:"recurrence_id" validate:"required"` // 被修改实例的原始开始时间
+	Name         string    `json:"name" validate:"required"`
+	Place        string    `json:"place" validate:"required"`
+	Description  string    `json:"description" validate:"required"`
+	StartTime    time.Time `json:"start_time" validate:"required"`
+	EndTime      time.Time `json:"end_time" validate:"required"`
 }
 
 type CreateEventReminderBody struct {
-	RemindBefore int `json:"remind_before" validate:"required,min=1"` // 提醒前的分钟数
+	RemindBefore int    `json:"remind_before" validate:"required,min=1"` // 提醒前的分钟数
+	Channel      string `json:"channel,omitempty"`                       // 提醒渠道，为空时使用默认渠道
+}
+
+// UpdateEventReminderBody 修改提醒的通知渠道
+type UpdateEventReminderBody struct {
+	Channel string `json:"channel" validate:"required"`
+}
+
+// UpsertReminderChannelBody 保存用户自己的提醒渠道配置，按渠道填写对应字段
+type UpsertReminderChannelBody struct {
+	URL      string `json:"url,omitempty"`      // webhook 和 push 的请求地址
+	Secret   string `json:"secret,omitempty"`   // webhook 签名密钥，可选
+	Provider string `json:"provider,omitempty"` // push 的推送服务：ntfy、gotify
+	Token    string `json:"token,omitempty"`    // push 的访问令牌（可选）或 telegram 的 bot token
+	ChatID   string `json:"chat_id,omitempty"`  // telegram 的 chat id
 }
 
 type DateRangeQuery struct {
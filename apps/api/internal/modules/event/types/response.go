@@ -3,21 +3,41 @@ package types
 import "time"
 
 type EventResponse struct {
-	ID          int64           `json:"id"`
-	Name        string          `json:"name"`
-	Place       string          `json:"place"`
-	Description string          `json:"description"`
-	StartTime   time.Time       `json:"start_time"`
-	EndTime     time.Time       `json:"end_time"`
-	Reminders   []EventReminder `json:"reminders"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
+	ID           int64           `json:"id"`
+	Name         string          `json:"name"`
+	Place        string          `json:"place"`
+	Description  string          `json:"description"`
+	StartTime    time.Time       `json:"start_time"`
+	EndTime      time.Time       `json:"end_time"`
+	UID          string          `json:"uid,omitempty"`
+	Timezone     string          `json:"timezone"`
+	RRule        string          `json:"rrule,omitempty"`
+	ExDates      []time.Time     `json:"exdates,omitempty"`
+	RecurrenceID *time.Time      `json:"recurrence_id,omitempty"` // 展开后的实例对应的原始开始时间
+	Overrides    []EventOverride `json:"overrides,omitempty"`
+	Reminders    []EventReminder `json:"reminders"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+type EventOverride struct {
+	ID           int64     `json:"id"`
+	EventID      int64     `json:"event_id"`
+	RecurrenceID time.Time `json:"recurrence_id"`
+	Name         string    `json:"name"`
+	Place        string    `json:"place"`
+	Description  string    `json:"description"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 type EventReminder struct {
 	ID           int64     `json:"id"`
 	EventID      int64     `json:"event_id"`
 	RemindBefore int       `json:"remind_before"` // 提醒前的分钟数
+	Channel      string    `json:"channel"`
 	Notified     bool      `json:"notified"`
 	CreatedAt    time.Time `json:"created_at"`
 }
@@ -26,6 +46,85 @@ type EventReminderResponse struct {
 	ID           int64     `json:"id"`
 	EventID      int64     `json:"event_id"`
 	RemindBefore int       `json:"remind_before"`
+	Channel      string    `json:"channel"`
 	Notified     bool      `json:"notified"`
 	CreatedAt    time.Time `json:"created_at"`
 }
+
+// CalendarTokenResponse 日历订阅令牌，明文和订阅地址只在生成时返回一次
+type CalendarTokenResponse struct {
+	Token      string     `json:"token,omitempty"`
+	URL        string     `json:"url,omitempty"`
+	Prefix     string     `json:"prefix"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ReminderChannelResponse 用户的提醒渠道配置，不返回密钥和令牌
+type ReminderChannelResponse struct {
+	Channel   string    `json:"channel"`
+	URL       string    `json:"url,omitempty"`
+	Provider  string    `json:"provider,omitempty"`
+	ChatID    string    `json:"chat_id,omitempty"`
+	HasSecret bool      `json:"has_secret"` // 是否保存了签名密钥或令牌
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ImportEventsResponse .ics 导入结果
+type ImportEventsResponse struct {
+	Created []EventResponse `json:"created"`
+	Skipped []string        `json:"skipped"` // 已存在的事件 UID
+	Errors  []string        `json:"errors"`
+}
+
+// ReminderDelivery 提醒投递记录
+type ReminderDelivery struct {
+	ID            int64                     `json:"id"`
+	ReminderID    int64                     `json:"reminder_id"`
+	EventID       int64                     `json:"event_id"`
+	Occurrence    time.Time                 `json:"occurrence"`
+	Channel       string                    `json:"channel"`
+	Title         string                    `json:"title"`
+	Body          string                    `json:"body"`
+	Status        string                    `json:"status"`
+	Attempts      int                       `json:"attempts"`
+	NextAttemptAt time.Time                 `json:"next_attempt_at"`
+	LastError     string                    `json:"last_error"`
+	SentAt        *time.Time                `json:"sent_at"`
+	AttemptLog    []ReminderDeliveryAttempt `json:"attempt_log,omitempty"`
+	CreatedAt     time.Time                 `json:"created_at"`
+	UpdatedAt     time.Time                 `json:"updated_at"`
+}
+
+// ReminderDeliveryAttempt 单次投递尝试
+type ReminderDeliveryAttempt struct {
+	Attempt     int       `json:"attempt"`
+	Success     bool      `json:"success"`
+	Error       string    `json:"error"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// EventConflict 与新事件时间重叠的已有事件（重复事件为具体的某次实例）
+type EventConflict struct {
+	EventID      int64      `json:"event_id"`
+	Name         string     `json:"name"`
+	StartTime    time.Time  `json:"start_time"`
+	EndTime      time.Time  `json:"end_time"`
+	RecurrenceID *time.Time `json:"recurrence_id,omitempty"`
+}
+
+// TimeBlock 一段 [start, end) 时间区间
+type TimeBlock struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// FreeBusyResponse 指定范围内的忙碌时段和满足最短时长的空闲时段
+type FreeBusyResponse struct {
+	Start       time.Time   `json:"start"`
+	End         time.Time   `json:"end"`
+	Timezone    string      `json:"timezone"`
+	MinDuration int         `json:"min_duration"` // 空闲时段的最短时长（分钟）
+	Busy        []TimeBlock `json:"busy"`
+	Free        []TimeBlock `json:"free"`
+}
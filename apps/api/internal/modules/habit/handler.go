@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/zeroicey/lifetrack-api/internal/middleware"
 	"github.com/zeroicey/lifetrack-api/internal/modules/habit/types"
 	response "github.com/zeroicey/lifetrack-api/internal/pkg"
 )
@@ -32,13 +33,19 @@ func HabitRouter(s *Service) chi.Router {
 }
 
 func (h *Handler) CreateHabit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	var body types.CreateHabitBody
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		response.Error("Invalid request body").SetStatusCode(http.StatusBadRequest).Build(w)
 		return
 	}
 
-	habit, err := h.S.CreateHabit(r.Context(), body)
+	habit, err := h.S.CreateHabit(r.Context(), userID, body)
 	if err != nil {
 		response.Error("Failed to create habit").SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
@@ -48,6 +55,12 @@ func (h *Handler) CreateHabit(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetHabitById(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -55,7 +68,7 @@ func (h *Handler) GetHabitById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	habit, err := h.S.GetHabitById(r.Context(), id)
+	habit, err := h.S.GetHabitById(r.Context(), userID, id)
 	if err != nil {
 		if errors.Is(err, ErrHabitNotFound) {
 			response.Error("Habit not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -69,7 +82,13 @@ func (h *Handler) GetHabitById(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetAllHabits(w http.ResponseWriter, r *http.Request) {
-	habits, err := h.S.GetAllHabits(r.Context())
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	habits, err := h.S.GetAllHabits(r.Context(), userID)
 	if err != nil {
 		response.Error("Failed to get habits").SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
@@ -79,6 +98,12 @@ func (h *Handler) GetAllHabits(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UpdateHabit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -92,7 +117,7 @@ func (h *Handler) UpdateHabit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedHabit, err := h.S.UpdateHabitById(r.Context(), id, body)
+	updatedHabit, err := h.S.UpdateHabitById(r.Context(), userID, id, body)
 	if err != nil {
 		if errors.Is(err, ErrHabitNotFound) {
 			response.Error("Habit not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -106,6 +131,12 @@ func (h *Handler) UpdateHabit(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeleteHabit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -113,7 +144,7 @@ func (h *Handler) DeleteHabit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.S.DeleteHabitById(r.Context(), id)
+	err = h.S.DeleteHabitById(r.Context(), userID, id)
 	if err != nil {
 		if errors.Is(err, ErrHabitNotFound) {
 			response.Error("Habit not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -22,14 +22,21 @@ func NewService(repo *repository.Queries) *Service {
 	return &Service{Q: repo}
 }
 
-func (s *Service) CreateHabit(ctx context.Context, body types.CreateHabitBody) (*types.HabitResponse, error) {
+func (s *Service) CreateHabit(ctx context.Context, userID int64, body types.CreateHabitBody) (*types.HabitResponse, error) {
 	// 检查习惯名称是否已存在
-	_, err := s.Q.GetHabitByName(ctx, body.Name)
+	_, err := s.Q.GetHabitByName(ctx, repository.GetHabitByNameParams{
+		Name:   body.Name,
+		UserID: userID,
+	})
 	if err == nil {
 		return nil, ErrHabitAlreadyExists
 	}
 
-	habit, err := s.Q.CreateHabit(ctx, (repository.CreateHabitParams)(body))
+	habit, err := s.Q.CreateHabit(ctx, repository.CreateHabitParams{
+		Name:        body.Name,
+		Description: body.Description,
+		UserID:      userID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -43,8 +50,11 @@ func (s *Service) CreateHabit(ctx context.Context, body types.CreateHabitBody) (
 	}, nil
 }
 
-func (s *Service) GetHabitById(ctx context.Context, id int64) (*types.HabitStatsResponse, error) {
-	habit, err := s.Q.GetHabitById(ctx, id)
+func (s *Service) GetHabitById(ctx context.Context, userID int64, id int64) (*types.HabitStatsResponse, error) {
+	habit, err := s.Q.GetHabitById(ctx, repository.GetHabitByIdParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		return nil, ErrHabitNotFound
 	}
@@ -65,8 +75,8 @@ func (s *Service) GetHabitById(ctx context.Context, id int64) (*types.HabitStats
 	return response, nil
 }
 
-func (s *Service) GetAllHabits(ctx context.Context) ([]*types.HabitStatsResponse, error) {
-	habits, err := s.Q.GetAllHabits(ctx)
+func (s *Service) GetAllHabits(ctx context.Context, userID int64) ([]*types.HabitStatsResponse, error) {
+	habits, err := s.Q.GetAllHabits(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -92,9 +102,12 @@ func (s *Service) GetAllHabits(ctx context.Context) ([]*types.HabitStatsResponse
 	return response, nil
 }
 
-func (s *Service) UpdateHabitById(ctx context.Context, id int64, body types.UpdateHabitBody) (*types.HabitResponse, error) {
+func (s *Service) UpdateHabitById(ctx context.Context, userID int64, id int64, body types.UpdateHabitBody) (*types.HabitResponse, error) {
 	// 检查习惯是否存在
-	exists, err := s.Q.HabitExists(ctx, id)
+	exists, err := s.Q.HabitExists(ctx, repository.HabitExistsParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +117,10 @@ func (s *Service) UpdateHabitById(ctx context.Context, id int64, body types.Upda
 
 	// 如果名称发生变化，检查新名称是否已存在
 	if body.Name != "" {
-		existingHabit, err := s.Q.GetHabitByName(ctx, body.Name)
+		existingHabit, err := s.Q.GetHabitByName(ctx, repository.GetHabitByNameParams{
+			Name:   body.Name,
+			UserID: userID,
+		})
 		if err == nil && existingHabit.ID != id {
 			return nil, ErrHabitAlreadyExists
 		}
@@ -114,6 +130,7 @@ func (s *Service) UpdateHabitById(ctx context.Context, id int64, body types.Upda
 		ID:          id,
 		Name:        body.Name,
 		Description: body.Description,
+		UserID:      userID,
 	})
 	if err != nil {
 		return nil, err
@@ -128,9 +145,12 @@ func (s *Service) UpdateHabitById(ctx context.Context, id int64, body types.Upda
 	}, nil
 }
 
-func (s *Service) DeleteHabitById(ctx context.Context, id int64) error {
+func (s *Service) DeleteHabitById(ctx context.Context, userID int64, id int64) error {
 	// 检查习惯是否存在
-	exists, err := s.Q.HabitExists(ctx, id)
+	exists, err := s.Q.HabitExists(ctx, repository.HabitExistsParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		return err
 	}
@@ -138,5 +158,8 @@ func (s *Service) DeleteHabitById(ctx context.Context, id int64) error {
 		return ErrHabitNotFound
 	}
 
-	return s.Q.DeleteHabitById(ctx, id)
+	return s.Q.DeleteHabitById(ctx, repository.DeleteHabitByIdParams{
+		ID:     id,
+		UserID: userID,
+	})
 }
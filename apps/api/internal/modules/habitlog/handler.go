@@ -7,7 +7,9 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/zeroicey/lifetrack-api/internal/middleware"
 	"github.com/zeroicey/lifetrack-api/internal/modules/habitlog/types"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
 	response "github.com/zeroicey/lifetrack-api/internal/pkg"
 )
 
@@ -25,6 +27,7 @@ func HabitLogRouter(s *Service) chi.Router {
 	r.Post("/", h.CreateHabitLog)
 	r.Post("/now", h.CreateHabitLogNow)
 	r.Get("/", h.GetAllHabitLogs)
+	r.Get("/day", h.GetHabitLogsByDay)
 	r.Get("/{id}", h.GetHabitLogById)
 	r.Put("/{id}", h.UpdateHabitLog)
 	r.Delete("/{id}", h.DeleteHabitLog)
@@ -36,13 +39,19 @@ func HabitLogRouter(s *Service) chi.Router {
 }
 
 func (h *Handler) CreateHabitLog(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	var body types.CreateHabitLogBody
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		response.Error("Invalid request body").SetStatusCode(http.StatusBadRequest).Build(w)
 		return
 	}
 
-	habitLog, err := h.S.CreateHabitLog(r.Context(), body)
+	habitLog, err := h.S.CreateHabitLog(r.Context(), userID, body)
 	if err != nil {
 		if errors.Is(err, ErrHabitNotFound) {
 			response.Error("Habit not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -56,6 +65,12 @@ func (h *Handler) CreateHabitLog(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) CreateHabitLogNow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	habitIdStr := r.URL.Query().Get("habit_id")
 	if habitIdStr == "" {
 		response.Error("habit_id is required").SetStatusCode(http.StatusBadRequest).Build(w)
@@ -68,7 +83,7 @@ func (h *Handler) CreateHabitLogNow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	habitLog, err := h.S.CreateHabitLogNow(r.Context(), habitId)
+	habitLog, err := h.S.CreateHabitLogNow(r.Context(), userID, habitId)
 	if err != nil {
 		if errors.Is(err, ErrHabitNotFound) {
 			response.Error("Habit not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -82,6 +97,12 @@ func (h *Handler) CreateHabitLogNow(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetHabitLogById(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -89,7 +110,7 @@ func (h *Handler) GetHabitLogById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	habitLog, err := h.S.GetHabitLogById(r.Context(), id)
+	habitLog, err := h.S.GetHabitLogById(r.Context(), userID, id)
 	if err != nil {
 		if errors.Is(err, ErrHabitLogNotFound) {
 			response.Error("Habit log not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -103,7 +124,13 @@ func (h *Handler) GetHabitLogById(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetAllHabitLogs(w http.ResponseWriter, r *http.Request) {
-	habitLogs, err := h.S.GetAllHabitLogs(r.Context())
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	habitLogs, err := h.S.GetAllHabitLogs(r.Context(), userID)
 	if err != nil {
 		response.Error("Failed to get habit logs").SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
@@ -112,14 +139,57 @@ func (h *Handler) GetAllHabitLogs(w http.ResponseWriter, r *http.Request) {
 	response.Success("Habit logs retrieved successfully").SetStatusCode(http.StatusOK).SetData(habitLogs).Build(w)
 }
 
+// GetHabitLogsByDay 按用户时区获取某一天的习惯日志，支持 date、habit_id 和 tz 查询参数
+func (h *Handler) GetHabitLogsByDay(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	query := r.URL.Query()
+
+	var habitID *int64
+	if habitIDStr := query.Get("habit_id"); habitIDStr != "" {
+		id, err := strconv.ParseInt(habitIDStr, 10, 64)
+		if err != nil {
+			response.Error("Invalid habit_id").SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		habitID = &id
+	}
+
+	habitLogs, err := h.S.GetHabitLogsByDay(r.Context(), userID, query.Get("date"), habitID, query.Get(pkg.TimezoneQueryParam))
+	if err != nil {
+		if errors.Is(err, ErrInvalidDate) || errors.Is(err, ErrInvalidTimezone) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		if errors.Is(err, ErrHabitNotFound) {
+			response.Error("Habit not found").SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		response.Error("Failed to get habit logs").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Habit logs retrieved successfully").SetStatusCode(http.StatusOK).SetData(habitLogs).Build(w)
+}
+
 func (h *Handler) GetHabitLogsByHabitId(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	habitIdStr := chi.URLParam(r, "habit_id")
 	habitId, err := strconv.ParseInt(habitIdStr, 10, 64)
 	if err != nil {
 		response.Error("Invalid habit ID").SetStatusCode(http.StatusBadRequest).Build(w)
 		return
 	}
-	habitLogs, err := h.S.GetHabitLogsByHabitId(r.Context(), habitId)
+	habitLogs, err := h.S.GetHabitLogsByHabitId(r.Context(), userID, habitId)
 	if err != nil {
 		if errors.Is(err, ErrHabitNotFound) {
 			response.Error("Habit not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -133,6 +203,12 @@ func (h *Handler) GetHabitLogsByHabitId(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *Handler) UpdateHabitLog(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -146,7 +222,7 @@ func (h *Handler) UpdateHabitLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	habitLog, err := h.S.UpdateHabitLogById(r.Context(), id, body.HappenedAt)
+	habitLog, err := h.S.UpdateHabitLogById(r.Context(), userID, id, body.HappenedAt)
 	if err != nil {
 		if errors.Is(err, ErrHabitLogNotFound) {
 			response.Error("Habit log not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -160,6 +236,12 @@ func (h *Handler) UpdateHabitLog(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeleteHabitLog(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -167,7 +249,7 @@ func (h *Handler) DeleteHabitLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.S.DeleteHabitLogById(r.Context(), id)
+	err = h.S.DeleteHabitLogById(r.Context(), userID, id)
 	if err != nil {
 		if errors.Is(err, ErrHabitLogNotFound) {
 			response.Error("Habit log not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -181,6 +263,12 @@ func (h *Handler) DeleteHabitLog(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeleteHabitLogsByHabitId(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	habitIDStr := chi.URLParam(r, "habit_id")
 	habitID, err := strconv.ParseInt(habitIDStr, 10, 64)
 	if err != nil {
@@ -188,7 +276,7 @@ func (h *Handler) DeleteHabitLogsByHabitId(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = h.S.DeleteHabitLogsByHabitId(r.Context(), habitID)
+	err = h.S.DeleteHabitLogsByHabitId(r.Context(), userID, habitID)
 	if err != nil {
 		if errors.Is(err, ErrHabitNotFound) {
 			response.Error("Habit not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -202,6 +290,12 @@ func (h *Handler) DeleteHabitLogsByHabitId(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *Handler) GetHabitLogsCountByHabitId(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	habitIDStr := chi.URLParam(r, "habit_id")
 	habitID, err := strconv.ParseInt(habitIDStr, 10, 64)
 	if err != nil {
@@ -209,7 +303,7 @@ func (h *Handler) GetHabitLogsCountByHabitId(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	count, err := h.S.GetHabitLogsCountByHabitId(r.Context(), habitID)
+	count, err := h.S.GetHabitLogsCountByHabitId(r.Context(), userID, habitID)
 	if err != nil {
 		if errors.Is(err, ErrHabitNotFound) {
 			response.Error("Habit not found").SetStatusCode(http.StatusNotFound).Build(w)
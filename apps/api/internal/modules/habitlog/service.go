@@ -7,12 +7,15 @@ import (
 
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/zeroicey/lifetrack-api/internal/modules/habitlog/types"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
 	"github.com/zeroicey/lifetrack-api/internal/repository"
 )
 
 var (
 	ErrHabitLogNotFound = errors.New("habit log not found")
 	ErrHabitNotFound    = errors.New("habit not found")
+	ErrInvalidDate      = errors.New("invalid date, expected YYYY-MM-DD")
+	ErrInvalidTimezone  = pkg.ErrInvalidTimezone
 )
 
 type Service struct {
@@ -23,9 +26,12 @@ func NewService(repo *repository.Queries) *Service {
 	return &Service{Q: repo}
 }
 
-func (s *Service) CreateHabitLog(ctx context.Context, body types.CreateHabitLogBody) (*types.HabitLogResponse, error) {
+func (s *Service) CreateHabitLog(ctx context.Context, userID int64, body types.CreateHabitLogBody) (*types.HabitLogResponse, error) {
 	// 检查习惯是否存在并获取习惯信息
-	habit, err := s.Q.GetHabitById(ctx, body.HabitID)
+	habit, err := s.Q.GetHabitById(ctx, repository.GetHabitByIdParams{
+		ID:     body.HabitID,
+		UserID: userID,
+	})
 	if err != nil {
 		return nil, ErrHabitNotFound
 	}
@@ -33,6 +39,7 @@ func (s *Service) CreateHabitLog(ctx context.Context, body types.CreateHabitLogB
 	habitLog, err := s.Q.CreateHabitLog(ctx, repository.CreateHabitLogParams{
 		HabitID:    body.HabitID,
 		HappenedAt: body.HappenedAt,
+		UserID:     userID,
 	})
 	if err != nil {
 		return nil, err
@@ -46,14 +53,20 @@ func (s *Service) CreateHabitLog(ctx context.Context, body types.CreateHabitLogB
 	}, nil
 }
 
-func (s *Service) CreateHabitLogNow(ctx context.Context, habitID int64) (*types.HabitLogResponse, error) {
+func (s *Service) CreateHabitLogNow(ctx context.Context, userID int64, habitID int64) (*types.HabitLogResponse, error) {
 	// 检查习惯是否存在并获取习惯信息
-	habit, err := s.Q.GetHabitById(ctx, habitID)
+	habit, err := s.Q.GetHabitById(ctx, repository.GetHabitByIdParams{
+		ID:     habitID,
+		UserID: userID,
+	})
 	if err != nil {
 		return nil, ErrHabitNotFound
 	}
 
-	habitLog, err := s.Q.CreateHabitLogNow(ctx, habitID)
+	habitLog, err := s.Q.CreateHabitLogNow(ctx, repository.CreateHabitLogNowParams{
+		HabitID: habitID,
+		UserID:  userID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -66,8 +79,11 @@ func (s *Service) CreateHabitLogNow(ctx context.Context, habitID int64) (*types.
 	}, nil
 }
 
-func (s *Service) GetHabitLogById(ctx context.Context, id int64) (*types.HabitLogResponse, error) {
-	habitLog, err := s.Q.GetHabitLogById(ctx, id)
+func (s *Service) GetHabitLogById(ctx context.Context, userID int64, id int64) (*types.HabitLogResponse, error) {
+	habitLog, err := s.Q.GetHabitLogById(ctx, repository.GetHabitLogByIdParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		return nil, ErrHabitLogNotFound
 	}
@@ -80,8 +96,8 @@ func (s *Service) GetHabitLogById(ctx context.Context, id int64) (*types.HabitLo
 	}, nil
 }
 
-func (s *Service) GetAllHabitLogs(ctx context.Context) ([]*types.HabitLogResponse, error) {
-	habitLogs, err := s.Q.GetAllHabitLogs(ctx)
+func (s *Service) GetAllHabitLogs(ctx context.Context, userID int64) ([]*types.HabitLogResponse, error) {
+	habitLogs, err := s.Q.GetAllHabitLogs(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -99,9 +115,12 @@ func (s *Service) GetAllHabitLogs(ctx context.Context) ([]*types.HabitLogRespons
 	return response, nil
 }
 
-func (s *Service) GetHabitLogsByHabitId(ctx context.Context, habitID int64) ([]*types.HabitLogResponse, error) {
+func (s *Service) GetHabitLogsByHabitId(ctx context.Context, userID int64, habitID int64) ([]*types.HabitLogResponse, error) {
 	// 检查习惯是否存在
-	exists, err := s.Q.HabitExists(ctx, habitID)
+	exists, err := s.Q.HabitExists(ctx, repository.HabitExistsParams{
+		ID:     habitID,
+		UserID: userID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +128,10 @@ func (s *Service) GetHabitLogsByHabitId(ctx context.Context, habitID int64) ([]*
 		return nil, ErrHabitNotFound
 	}
 
-	habitLogs, err := s.Q.GetHabitLogsByHabitId(ctx, habitID)
+	habitLogs, err := s.Q.GetHabitLogsByHabitId(ctx, repository.GetHabitLogsByHabitIdParams{
+		HabitID: habitID,
+		UserID:  userID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -127,9 +149,85 @@ func (s *Service) GetHabitLogsByHabitId(ctx context.Context, habitID int64) ([]*
 	return response, nil
 }
 
-func (s *Service) GetHabitLogsByHabitIdWithLimit(ctx context.Context, habitID int64, limit int32) ([]*types.HabitLogResponse, error) {
+// GetHabitLogsByDay 获取某一天的习惯日志，日期为空时表示今天，日期边界按 timezone（为空时为用户时区）计算
+func (s *Service) GetHabitLogsByDay(ctx context.Context, userID int64, date string, habitID *int64, timezone string) ([]*types.HabitLogResponse, error) {
+	loc, err := pkg.ResolveLocation(ctx, s.Q, userID, timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	day := time.Now().In(loc)
+	if date != "" {
+		day, err = pkg.ParseDate(date, loc)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+	}
+	start, end := pkg.DayRange(day)
+	dayStart := pgtype.Timestamptz{}
+	dayStart.Scan(start)
+	dayEnd := pgtype.Timestamptz{}
+	dayEnd.Scan(end)
+
+	response := []*types.HabitLogResponse{}
+	if habitID != nil {
+		exists, err := s.Q.HabitExists(ctx, repository.HabitExistsParams{
+			ID:     *habitID,
+			UserID: userID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrHabitNotFound
+		}
+
+		habitLogs, err := s.Q.GetHabitLogsByHabitIdAndDate(ctx, repository.GetHabitLogsByHabitIdAndDateParams{
+			HabitID:  *habitID,
+			UserID:   userID,
+			DayStart: dayStart,
+			DayEnd:   dayEnd,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, habitLog := range habitLogs {
+			response = append(response, &types.HabitLogResponse{
+				ID:         habitLog.ID,
+				HabitID:    habitLog.HabitID,
+				HabitName:  habitLog.HabitName,
+				HappenedAt: habitLog.HappenedAt.Time.In(loc).Format(time.RFC3339),
+			})
+		}
+		return response, nil
+	}
+
+	habitLogs, err := s.Q.GetHabitLogsByDate(ctx, repository.GetHabitLogsByDateParams{
+		UserID:   userID,
+		DayStart: dayStart,
+		DayEnd:   dayEnd,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, habitLog := range habitLogs {
+		response = append(response, &types.HabitLogResponse{
+			ID:         habitLog.ID,
+			HabitID:    habitLog.HabitID,
+			HabitName:  habitLog.HabitName,
+			HappenedAt: habitLog.HappenedAt.Time.In(loc).Format(time.RFC3339),
+		})
+	}
+
+	return response, nil
+}
+
+func (s *Service) GetHabitLogsByHabitIdWithLimit(ctx context.Context, userID int64, habitID int64, limit int32) ([]*types.HabitLogResponse, error) {
 	// 检查习惯是否存在
-	exists, err := s.Q.HabitExists(ctx, habitID)
+	exists, err := s.Q.HabitExists(ctx, repository.HabitExistsParams{
+		ID:     habitID,
+		UserID: userID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -140,6 +238,7 @@ func (s *Service) GetHabitLogsByHabitIdWithLimit(ctx context.Context, habitID in
 	habitLogs, err := s.Q.GetHabitLogsByHabitIdWithLimit(ctx, repository.GetHabitLogsByHabitIdWithLimitParams{
 		HabitID: habitID,
 		Limit:   limit,
+		UserID:  userID,
 	})
 	if err != nil {
 		return nil, err
@@ -157,9 +256,12 @@ func (s *Service) GetHabitLogsByHabitIdWithLimit(ctx context.Context, habitID in
 	return response, nil
 }
 
-func (s *Service) UpdateHabitLogById(ctx context.Context, id int64, happenedAt pgtype.Timestamptz) (*types.HabitLogResponse, error) {
+func (s *Service) UpdateHabitLogById(ctx context.Context, userID int64, id int64, happenedAt pgtype.Timestamptz) (*types.HabitLogResponse, error) {
 	// 检查习惯日志是否存在
-	exists, err := s.Q.HabitLogExists(ctx, id)
+	exists, err := s.Q.HabitLogExists(ctx, repository.HabitLogExistsParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -170,6 +272,7 @@ func (s *Service) UpdateHabitLogById(ctx context.Context, id int64, happenedAt p
 	habitLog, err := s.Q.UpdateHabitLogById(ctx, repository.UpdateHabitLogByIdParams{
 		ID:         id,
 		HappenedAt: happenedAt,
+		UserID:     userID,
 	})
 	if err != nil {
 		return nil, err
@@ -182,9 +285,12 @@ func (s *Service) UpdateHabitLogById(ctx context.Context, id int64, happenedAt p
 	}, nil
 }
 
-func (s *Service) DeleteHabitLogById(ctx context.Context, id int64) error {
+func (s *Service) DeleteHabitLogById(ctx context.Context, userID int64, id int64) error {
 	// 检查习惯日志是否存在
-	exists, err := s.Q.HabitLogExists(ctx, id)
+	exists, err := s.Q.HabitLogExists(ctx, repository.HabitLogExistsParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		return err
 	}
@@ -192,12 +298,18 @@ func (s *Service) DeleteHabitLogById(ctx context.Context, id int64) error {
 		return ErrHabitLogNotFound
 	}
 
-	return s.Q.DeleteHabitLogById(ctx, id)
+	return s.Q.DeleteHabitLogById(ctx, repository.DeleteHabitLogByIdParams{
+		ID:     id,
+		UserID: userID,
+	})
 }
 
-func (s *Service) DeleteHabitLogsByHabitId(ctx context.Context, habitID int64) error {
+func (s *Service) DeleteHabitLogsByHabitId(ctx context.Context, userID int64, habitID int64) error {
 	// 检查习惯是否存在
-	exists, err := s.Q.HabitExists(ctx, habitID)
+	exists, err := s.Q.HabitExists(ctx, repository.HabitExistsParams{
+		ID:     habitID,
+		UserID: userID,
+	})
 	if err != nil {
 		return err
 	}
@@ -205,12 +317,18 @@ func (s *Service) DeleteHabitLogsByHabitId(ctx context.Context, habitID int64) e
 		return ErrHabitNotFound
 	}
 
-	return s.Q.DeleteHabitLogsByHabitId(ctx, habitID)
+	return s.Q.DeleteHabitLogsByHabitId(ctx, repository.DeleteHabitLogsByHabitIdParams{
+		HabitID: habitID,
+		UserID:  userID,
+	})
 }
 
-func (s *Service) GetHabitLogsCountByHabitId(ctx context.Context, habitID int64) (int64, error) {
+func (s *Service) GetHabitLogsCountByHabitId(ctx context.Context, userID int64, habitID int64) (int64, error) {
 	// 检查习惯是否存在
-	exists, err := s.Q.HabitExists(ctx, habitID)
+	exists, err := s.Q.HabitExists(ctx, repository.HabitExistsParams{
+		ID:     habitID,
+		UserID: userID,
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -218,5 +336,8 @@ func (s *Service) GetHabitLogsCountByHabitId(ctx context.Context, habitID int64)
 		return 0, ErrHabitNotFound
 	}
 
-	return s.Q.GetHabitLogsCountByHabitId(ctx, habitID)
+	return s.Q.GetHabitLogsCountByHabitId(ctx, repository.GetHabitLogsCountByHabitIdParams{
+		HabitID: habitID,
+		UserID:  userID,
+	})
 }
@@ -7,73 +7,123 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/zeroicey/lifetrack-api/internal/modules/moment/types"
 	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
 )
 
+// CoverPresigner 为附件封面生成预签名链接，由 storage.Service 实现
+type CoverPresigner interface {
+	PresignedCoverURL(ctx context.Context, objectKey string) (string, error)
+	PresignedCoverURLs(ctx context.Context, objectKey string, sizes []int32) (map[string]string, error)
+}
+
 // Converter 负责数据转换逻辑
 type Converter struct {
-	Q *repository.Queries
+	Q         *repository.Queries
+	presigner CoverPresigner
+	logger    *zap.Logger
 }
 
 // NewConverter 创建一个新的转换器实例
-func NewConverter(q *repository.Queries) *Converter {
-	return &Converter{q}
+func NewConverter(q *repository.Queries, presigner CoverPresigner, logger *zap.Logger) *Converter {
+	return &Converter{Q: q, presigner: presigner, logger: logger}
 }
 
 // ToMomentResponse 将数据库模型转换为响应模型
 func (c *Converter) ToMomentResponse(ctx context.Context, moment repository.Moment) (types.MomentResponse, error) {
-	// 获取附件信息
-	attachments, err := c.getMomentAttachments(ctx, moment.ID)
+	responses, err := c.ToMomentResponses(ctx, []repository.Moment{moment})
 	if err != nil {
 		return types.MomentResponse{}, err
 	}
-
-	return types.MomentResponse{
-		ID:          moment.ID,
-		Content:     moment.Content,
-		Attachments: attachments,
-		UpdatedAt:   moment.UpdatedAt.Time.Format(time.RFC3339),
-		CreatedAt:   moment.CreatedAt.Time.Format(time.RFC3339),
-	}, nil
+	return responses[0], nil
 }
 
 // ToMomentResponses 批量转换数据库模型为响应模型
+// 所有 moment 的附件用一次查询取出，封面链接在同一遍中签名，查询次数与 moment 数量无关
 func (c *Converter) ToMomentResponses(ctx context.Context, moments []repository.Moment) ([]types.MomentResponse, error) {
-	var responses []types.MomentResponse
+	if len(moments) == 0 {
+		return nil, nil
+	}
+
+	momentIDs := make([]int64, len(moments))
+	for i, m := range moments {
+		momentIDs[i] = m.ID
+	}
+	attachments, err := c.getMomentAttachments(ctx, momentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]types.MomentResponse, 0, len(moments))
 	for _, m := range moments {
-		resp, err := c.ToMomentResponse(ctx, m)
-		if err != nil {
-			return nil, err
+		// 标签由内容决定，直接从内容解析，无需查询 moment_tags
+		tags := ExtractHashtags(m.Content)
+		if tags == nil {
+			tags = []string{}
 		}
-		responses = append(responses, resp)
+
+		responses = append(responses, types.MomentResponse{
+			ID:          m.ID,
+			Content:     m.Content,
+			Attachments: attachments[m.ID],
+			Tags:        tags,
+			UpdatedAt:   m.UpdatedAt.Time.Format(time.RFC3339),
+			CreatedAt:   m.CreatedAt.Time.Format(time.RFC3339),
+		})
 	}
 	return responses, nil
 }
 
-// getMomentAttachments 获取指定 moment 的所有附件信息
-func (c *Converter) getMomentAttachments(ctx context.Context, momentID int64) ([]types.Attachment, error) {
-	attachmentRows, err := c.Q.GetMomentAttachmentsByID(ctx, momentID)
+// getMomentAttachments 获取多条 moment 的附件信息，按 moment ID 分组，组内按位置排序
+func (c *Converter) getMomentAttachments(ctx context.Context, momentIDs []int64) (map[int64][]types.Attachment, error) {
+	attachmentRows, err := c.Q.GetAttachmentsByMomentIDs(ctx, momentIDs)
 	if err != nil {
 		return nil, err
 	}
 
-	var attachments []types.Attachment
+	attachments := make(map[int64][]types.Attachment)
 	for _, row := range attachmentRows {
-		// 将 pgtype.UUID 转换为字符串
-		idStr := row.ID.String()
-
-		attachments = append(attachments, types.Attachment{
-			ID:           idStr,
+		attachments[row.MomentID] = append(attachments[row.MomentID], types.Attachment{
+			ID:           row.ID.String(),
 			ObjectKey:    row.ObjectKey,
 			OriginalName: row.OriginalName,
 			MimeType:     row.MimeType,
 			FileSize:     row.FileSize,
 			Position:     row.Position,
+			CoverURL:     c.coverURL(ctx, row.CoverObjectKey),
+			CoverURLs:    c.coverURLs(ctx, row.ObjectKey, row.CoverSizes),
+			CoverStatus:  row.CoverStatus,
 		})
 	}
 
 	return attachments, nil
 }
 
+// coverURL 为封面生成预签名链接，签名失败时返回空字符串，客户端仍可单独请求封面
+func (c *Converter) coverURL(ctx context.Context, coverObjectKey string) string {
+	if coverObjectKey == "" {
+		return ""
+	}
+	url, err := c.presigner.PresignedCoverURL(ctx, coverObjectKey)
+	if err != nil {
+		c.logger.Warn("Failed to presign attachment cover", zap.String("cover_object_key", coverObjectKey), zap.Error(err))
+		return ""
+	}
+	return url
+}
+
+// coverURLs 为服务端生成的各尺寸封面生成预签名链接，客户端上传的封面没有多尺寸，返回 nil
+func (c *Converter) coverURLs(ctx context.Context, objectKey string, sizes []int32) map[string]string {
+	if len(sizes) == 0 {
+		return nil
+	}
+	urls, err := c.presigner.PresignedCoverURLs(ctx, objectKey, sizes)
+	if err != nil {
+		c.logger.Warn("Failed to presign attachment covers", zap.String("object_key", objectKey), zap.Error(err))
+		return nil
+	}
+	return urls
+}
+
 // CursorToTimestamp 将游标转换为 pgtype.Timestamp
 func (c *Converter) CursorToTimestamp(cursor int64) pgtype.Timestamp {
 	var cursorTs pgtype.Timestamp
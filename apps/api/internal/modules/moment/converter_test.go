@@ -0,0 +1,111 @@
+package moment
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+// momentPageSize 与列表接口一页的最大条数一致
+const momentPageSize = 100
+
+// fakePresigner 记录签名次数，返回可预测的链接
+type fakePresigner struct {
+	calls int
+}
+
+func (p *fakePresigner) PresignedCoverURL(_ context.Context, objectKey string) (string, error) {
+	p.calls++
+	return "https://storage.test/" + objectKey + "?signed", nil
+}
+
+func (p *fakePresigner) PresignedCoverURLs(ctx context.Context, objectKey string, sizes []int32) (map[string]string, error) {
+	urls := make(map[string]string, len(sizes))
+	for _, size := range sizes {
+		url, err := p.PresignedCoverURL(ctx, fmt.Sprintf("%s.cover-%d.jpg", objectKey, size))
+		if err != nil {
+			return nil, err
+		}
+		urls[fmt.Sprint(size)] = url
+	}
+	return urls, nil
+}
+
+// newMomentPage 生成一页 moment，每条带 attachmentsPerMoment 个附件
+func newMomentPage(attachmentsPerMoment int) ([]repository.Moment, []repository.GetAttachmentsByMomentIDsRow) {
+	now := pgtype.Timestamptz{Time: time.Date(2025, time.March, 1, 9, 0, 0, 0, time.UTC), Valid: true}
+	moments := make([]repository.Moment, momentPageSize)
+	var attachments []repository.GetAttachmentsByMomentIDsRow
+	for i := range moments {
+		id := int64(i + 1)
+		moments[i] = repository.Moment{ID: id, Content: fmt.Sprintf("moment %d #daily", id), CreatedAt: now, UpdatedAt: now}
+		for position := range attachmentsPerMoment {
+			attachments = append(attachments, repository.GetAttachmentsByMomentIDsRow{
+				MomentID:       id,
+				ID:             pgtype.UUID{Bytes: [16]byte{byte(id), byte(position)}, Valid: true},
+				ObjectKey:      fmt.Sprintf("moments/%d/%d.jpg", id, position),
+				CoverObjectKey: fmt.Sprintf("moments/%d/%d.jpg.cover-480.jpg", id, position),
+				CoverSizes:     []int32{240, 480},
+				OriginalName:   "photo.jpg",
+				MimeType:       "image/jpeg",
+				FileSize:       1024,
+				Status:         "completed",
+				CoverStatus:    "ready",
+				Position:       int16(position),
+			})
+		}
+	}
+	return moments, attachments
+}
+
+func TestToMomentResponsesQueriesAttachmentsOnce(t *testing.T) {
+	moments, attachments := newMomentPage(3)
+	db := &fakeDB{attachments: attachments}
+	presigner := &fakePresigner{}
+	c := NewConverter(repository.New(db), presigner, zap.NewNop())
+
+	responses, err := c.ToMomentResponses(context.Background(), moments)
+	if err != nil {
+		t.Fatalf("ToMomentResponses: %v", err)
+	}
+	if db.queries != 1 {
+		t.Fatalf("queries = %d for a page of %d moments, want 1", db.queries, len(moments))
+	}
+	if len(responses) != len(moments) {
+		t.Fatalf("responses = %d, want %d", len(responses), len(moments))
+	}
+	for _, r := range responses {
+		if len(r.Attachments) != 3 || r.Attachments[2].Position != 2 {
+			t.Fatalf("moment %d attachments = %+v, want 3 in position order", r.ID, r.Attachments)
+		}
+		for _, a := range r.Attachments {
+			if a.CoverURL == "" || len(a.CoverURLs) != 2 {
+				t.Fatalf("moment %d attachment %s covers = %q %v, want signed default and 2 sizes", r.ID, a.ID, a.CoverURL, a.CoverURLs)
+			}
+		}
+	}
+	// 每个附件签名默认封面和两个尺寸
+	if want := len(attachments) * 3; presigner.calls != want {
+		t.Fatalf("presign calls = %d, want %d", presigner.calls, want)
+	}
+}
+
+func BenchmarkToMomentResponses(b *testing.B) {
+	moments, attachments := newMomentPage(4)
+	db := &fakeDB{attachments: attachments}
+	c := NewConverter(repository.New(db), &fakePresigner{}, zap.NewNop())
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := c.ToMomentResponses(ctx, moments); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(db.queries)/float64(b.N), "queries/op")
+}
@@ -0,0 +1,85 @@
+package moment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+)
+
+// fakeDB 是记录查询次数的 repository.DBTX，只模拟测试用到的几条查询
+type fakeDB struct {
+	queries     int
+	attachments []repository.GetAttachmentsByMomentIDsRow // Query 返回的附件行
+	uploads     map[[16]byte]string                       // AddAttachmentToMoment 可以引用的附件及其上传状态
+}
+
+func (db *fakeDB) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	db.queries++
+	if strings.HasPrefix(sql, "-- name: AddAttachmentToMoment ") {
+		status, ok := db.uploads[args[1].(pgtype.UUID).Bytes]
+		// 与查询的 WHERE 条件一致：查询要求附件已完成时，未完成的附件不插入
+		if !ok || (strings.Contains(sql, "a.status = 'completed'") && status != "completed") {
+			return pgconn.NewCommandTag("INSERT 0 0"), nil
+		}
+		return pgconn.NewCommandTag("INSERT 0 1"), nil
+	}
+	return pgconn.CommandTag{}, nil
+}
+
+func (db *fakeDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	db.queries++
+	return &structRows{rows: db.attachments, index: -1}, nil
+}
+
+// QueryRow 只用于 MomentExists，始终返回 moment 存在
+func (db *fakeDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	db.queries++
+	return existsRow{}
+}
+
+type existsRow struct{}
+
+func (existsRow) Scan(dest ...any) error {
+	*dest[0].(*bool) = true
+	return nil
+}
+
+// structRows 按字段顺序把结构体切片扫描到 sqlc 生成代码传入的指针中
+type structRows struct {
+	rows  []repository.GetAttachmentsByMomentIDsRow
+	index int
+}
+
+func (r *structRows) Close()                                       {}
+func (r *structRows) Err() error                                   { return nil }
+func (r *structRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *structRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (r *structRows) Values() ([]any, error)                       { return nil, errors.New("not supported") }
+func (r *structRows) RawValues() [][]byte                          { return nil }
+func (r *structRows) Conn() *pgx.Conn                              { return nil }
+
+func (r *structRows) Next() bool {
+	r.index++
+	return r.index < len(r.rows)
+}
+
+func (r *structRows) Scan(dest ...any) error {
+	if r.index < 0 || r.index >= len(r.rows) {
+		return pgx.ErrNoRows
+	}
+	row := reflect.ValueOf(r.rows[r.index])
+	if row.NumField() != len(dest) {
+		return fmt.Errorf("scan %d columns into %d destinations", row.NumField(), len(dest))
+	}
+	for i := range dest {
+		reflect.ValueOf(dest[i]).Elem().Set(row.Field(i))
+	}
+	return nil
+}
@@ -9,7 +9,9 @@ import (
 	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/zeroicey/lifetrack-api/internal/middleware"
 	"github.com/zeroicey/lifetrack-api/internal/modules/moment/types"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
 	response "github.com/zeroicey/lifetrack-api/internal/pkg"
 )
 
@@ -31,17 +33,8 @@ func parseIDFromURL(r *http.Request, paramName string) (int64, error) {
 	return id, nil
 }
 
-func MomentRouter(s *Service) chi.Router {
-	h := NewHandler(s)
-	r := chi.NewRouter()
-	r.Get("/", h.ListMoments)
-	r.Post("/", h.CreateMoment)
-	r.Get("/{id}", h.GetMomentByID)
-	r.Delete("/{id}", h.DeleteMomentByID)
-	return r
-}
-
-func (h *Handler) ListMoments(w http.ResponseWriter, r *http.Request) {
+// parsePagination 解析 cursor 和 limit 查询参数，limit 缺省为 10，最大 100
+func parsePagination(r *http.Request) (int64, int, error) {
 	cursor, err := func() (int64, error) {
 		cursorStr := r.URL.Query().Get("cursor")
 		if strings.TrimSpace(cursorStr) == "" {
@@ -50,8 +43,7 @@ func (h *Handler) ListMoments(w http.ResponseWriter, r *http.Request) {
 		return strconv.ParseInt(cursorStr, 10, 64)
 	}()
 	if err != nil {
-		response.Error("Invalid cursor").SetStatusCode(http.StatusBadRequest).Build(w)
-		return
+		return 0, 0, err
 	}
 
 	limit := func() int {
@@ -65,7 +57,41 @@ func (h *Handler) ListMoments(w http.ResponseWriter, r *http.Request) {
 		return 10
 	}()
 
-	moments, nextCursor, err := h.S.ListMomentsPaginated(r.Context(), cursor, limit)
+	return cursor, limit, nil
+}
+
+func MomentRouter(s *Service) chi.Router {
+	h := NewHandler(s)
+	r := chi.NewRouter()
+	r.Get("/", h.ListMoments)
+	r.Post("/", h.CreateMoment)
+	r.Get("/search", h.SearchMoments)
+	r.Get("/tags", h.ListTags)
+	r.Get("/on-this-day", h.ListMomentsOnThisDay)
+	r.Get("/on-this-day/digest", h.GetMemoryDigestSettings)
+	r.Put("/on-this-day/digest", h.UpdateMemoryDigestSettings)
+	r.Get("/{id}", h.GetMomentByID)
+	r.Put("/{id}", h.UpdateMoment)
+	r.Get("/{id}/revisions", h.ListMomentRevisions)
+	r.Post("/{id}/revisions/{revisionID}/restore", h.RestoreMomentRevision)
+	r.Delete("/{id}", h.DeleteMomentByID)
+	return r
+}
+
+func (h *Handler) ListMoments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	cursor, limit, err := parsePagination(r)
+	if err != nil {
+		response.Error("Invalid cursor").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	moments, nextCursor, err := h.S.ListMomentsPaginated(r.Context(), userID, r.URL.Query().Get("tag"), cursor, limit)
 	if err != nil {
 		response.Error("Failed to list moments").SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
@@ -77,7 +103,123 @@ func (h *Handler) ListMoments(w http.ResponseWriter, r *http.Request) {
 	response.Success("Moments listed successfully").SetStatusCode(http.StatusOK).SetData(resp).Build(w)
 }
 
+// ListTags 列出当前用户的标签及使用次数
+func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	tags, err := h.S.ListTags(r.Context(), userID)
+	if err != nil {
+		response.Error("Failed to list tags").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+	response.Success("Tags listed successfully").SetStatusCode(http.StatusOK).SetData(tags).Build(w)
+}
+
+// ListMomentsOnThisDay 那年今日：返回往年同一天的 moment，date 为空时表示今天，tz 可覆盖用户时区
+func (h *Handler) ListMomentsOnThisDay(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	query := r.URL.Query()
+	memories, err := h.S.ListMomentsOnThisDay(r.Context(), userID, query.Get("date"), query.Get(pkg.TimezoneQueryParam))
+	if err != nil {
+		if errors.Is(err, ErrInvalidDate) || errors.Is(err, ErrInvalidTimezone) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		response.Error("Failed to list memories").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+	response.Success("Memories listed successfully").SetStatusCode(http.StatusOK).SetData(memories).Build(w)
+}
+
+// GetMemoryDigestSettings 获取那年今日邮件的订阅设置
+func (h *Handler) GetMemoryDigestSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	settings, err := h.S.GetMemoryDigestSettings(r.Context(), userID)
+	if err != nil {
+		response.Error("Failed to get memory digest settings").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+	response.Success("Memory digest settings retrieved successfully").SetStatusCode(http.StatusOK).SetData(settings).Build(w)
+}
+
+// UpdateMemoryDigestSettings 开启或关闭那年今日邮件，并设置发送时间
+func (h *Handler) UpdateMemoryDigestSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body types.UpdateMemoryDigestSettingsBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	settings, err := h.S.UpdateMemoryDigestSettings(r.Context(), userID, body)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSendHour) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		response.Error("Failed to update memory digest settings").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+	response.Success("Memory digest settings updated successfully").SetStatusCode(http.StatusOK).SetData(settings).Build(w)
+}
+
+// SearchMoments 全文搜索接口，q 支持 "短语"、前缀* 和 -排除词，sort 为 relevance（默认）或 recent
+func (h *Handler) SearchMoments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	cursor, limit, err := parsePagination(r)
+	if err != nil {
+		response.Error("Invalid cursor").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	query := r.URL.Query()
+	results, nextCursor, err := h.S.SearchMoments(r.Context(), userID, query.Get("q"), query.Get("sort"), cursor, limit)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSearchQuery) || errors.Is(err, ErrInvalidSearchSort) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		response.Error("Failed to search moments").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+	resp := map[string]any{
+		"items":      results,
+		"nextCursor": nextCursor,
+	}
+	response.Success("Moments searched successfully").SetStatusCode(http.StatusOK).SetData(resp).Build(w)
+}
+
 func (h *Handler) CreateMoment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	body, err := func() (types.CreateMomentBody, error) {
 		var body types.CreateMomentBody
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -96,7 +238,7 @@ func (h *Handler) CreateMoment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newMoment, err := h.S.CreateMoment(r.Context(), body)
+	newMoment, err := h.S.CreateMoment(r.Context(), userID, body)
 	if err != nil {
 		response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
 		return
@@ -106,13 +248,19 @@ func (h *Handler) CreateMoment(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetMomentByID(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	id, err := parseIDFromURL(r, "id")
 	if err != nil {
 		response.Error("Invalid moment ID").SetStatusCode(http.StatusBadRequest).Build(w)
 		return
 	}
 
-	moment, err := h.S.GetMomentByID(r.Context(), id)
+	moment, err := h.S.GetMomentByID(r.Context(), userID, id)
 	if err != nil {
 		if errors.Is(err, ErrMomentNotFound) {
 			response.Error("Moment not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -126,13 +274,19 @@ func (h *Handler) GetMomentByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeleteMomentByID(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	id, err := parseIDFromURL(r, "id")
 	if err != nil {
 		response.Error("Invalid moment ID").SetStatusCode(http.StatusBadRequest).Build(w)
 		return
 	}
 
-	err = h.S.DeleteMomentByID(r.Context(), id)
+	err = h.S.DeleteMomentByID(r.Context(), userID, id)
 	if err != nil {
 		if errors.Is(err, ErrMomentNotFound) {
 			response.Error("Moment not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -144,3 +298,100 @@ func (h *Handler) DeleteMomentByID(w http.ResponseWriter, r *http.Request) {
 
 	response.Success("Moment deleted successfully").SetStatusCode(http.StatusOK).Build(w)
 }
+
+// UpdateMoment 修改 moment 的内容和附件，修改前的版本保存为历史版本
+func (h *Handler) UpdateMoment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	id, err := parseIDFromURL(r, "id")
+	if err != nil {
+		response.Error("Invalid moment ID").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	var body types.UpdateMomentBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	moment, err := h.S.UpdateMoment(r.Context(), userID, id, body)
+	if err != nil {
+		writeMomentWriteError(w, err, "Failed to update moment")
+		return
+	}
+
+	response.Success("Moment updated successfully").SetStatusCode(http.StatusOK).SetData(moment).Build(w)
+}
+
+// ListMomentRevisions 列出 moment 的历史版本
+func (h *Handler) ListMomentRevisions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	id, err := parseIDFromURL(r, "id")
+	if err != nil {
+		response.Error("Invalid moment ID").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	revisions, err := h.S.ListMomentRevisions(r.Context(), userID, id)
+	if err != nil {
+		if errors.Is(err, ErrMomentNotFound) {
+			response.Error("Moment not found").SetStatusCode(http.StatusNotFound).Build(w)
+		} else {
+			response.Error("Failed to list moment revisions").SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+
+	response.Success("Moment revisions listed successfully").SetStatusCode(http.StatusOK).SetData(revisions).Build(w)
+}
+
+// RestoreMomentRevision 将 moment 恢复为指定的历史版本
+func (h *Handler) RestoreMomentRevision(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	id, err := parseIDFromURL(r, "id")
+	if err != nil {
+		response.Error("Invalid moment ID").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	revisionID, err := parseIDFromURL(r, "revisionID")
+	if err != nil {
+		response.Error("Invalid revision ID").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	moment, err := h.S.RestoreMomentRevision(r.Context(), userID, id, revisionID)
+	if err != nil {
+		writeMomentWriteError(w, err, "Failed to restore moment revision")
+		return
+	}
+
+	response.Success("Moment revision restored successfully").SetStatusCode(http.StatusOK).SetData(moment).Build(w)
+}
+
+// writeMomentWriteError 将修改 moment 时的错误映射为响应状态码
+func writeMomentWriteError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrMomentNotFound), errors.Is(err, ErrRevisionNotFound):
+		response.Error(err.Error()).SetStatusCode(http.StatusNotFound).Build(w)
+	case errors.Is(err, ErrEmptyContent), errors.Is(err, ErrInvalidAttachmentID),
+		errors.Is(err, ErrInvalidAttachmentPosition), errors.Is(err, ErrAttachmentNotFound):
+		response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+	default:
+		response.Error(fallback).SetStatusCode(http.StatusInternalServerError).Build(w)
+	}
+}
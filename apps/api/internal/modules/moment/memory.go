@@ -0,0 +1,241 @@
+package moment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zeroicey/lifetrack-api/internal/modules/moment/types"
+	"github.com/zeroicey/lifetrack-api/internal/modules/notification"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultMemoryDigestHour 未设置时那年今日邮件的发送时间（用户时区）
+	defaultMemoryDigestHour = 8
+	// maxDigestMemories 一封邮件中最多列出的 moment 数量
+	maxDigestMemories = 10
+	// maxDigestDetailLength 邮件中 moment 内容的最大长度（字符），超过的截断
+	maxDigestDetailLength = 280
+)
+
+var (
+	// ErrInvalidDate 是日期参数不是 YYYY-MM-DD 时返回的哨兵错误
+	ErrInvalidDate = errors.New("invalid date, expected YYYY-MM-DD")
+	// ErrInvalidTimezone 是时区参数不是合法 IANA 名称时返回的哨兵错误
+	ErrInvalidTimezone = pkg.ErrInvalidTimezone
+	// ErrInvalidSendHour 是发送时间不在 0-23 之间时返回的哨兵错误
+	ErrInvalidSendHour = errors.New("send_hour must be between 0 and 23")
+)
+
+// ListMomentsOnThisDay 返回往年同月同日的 moment，按创建时间倒序
+// 日期为空时表示今天，日期按 timezone（为空时为用户时区）计算
+func (s *Service) ListMomentsOnThisDay(ctx context.Context, userID int64, date string, timezone string) ([]types.MemoryResponse, error) {
+	loc, err := pkg.ResolveLocation(ctx, s.Q, userID, timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	day := time.Now().In(loc)
+	if date != "" {
+		day, err = pkg.ParseDate(date, loc)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+	}
+	return s.listMemories(ctx, userID, day)
+}
+
+// listMemories 查询 day 所在时区中往年同一天的 moment
+// 非闰年的 2 月 28 日同时返回往年 2 月 29 日的 moment，避免它们每四年才出现一次
+func (s *Service) listMemories(ctx context.Context, userID int64, day time.Time) ([]types.MemoryResponse, error) {
+	dayEnd := day.Day()
+	if day.Month() == time.February && day.Day() == 28 && !isLeapYear(day.Year()) {
+		dayEnd = 29
+	}
+
+	moments, err := s.Q.ListMomentsOnThisDay(ctx, repository.ListMomentsOnThisDayParams{
+		UserID:   userID,
+		Timezone: day.Location().String(),
+		Month:    int32(day.Month()),
+		Day:      int32(day.Day()),
+		DayEnd:   int32(dayEnd),
+		Year:     int32(day.Year()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	responses, err := s.converter.ToMomentResponses(ctx, moments)
+	if err != nil {
+		return nil, err
+	}
+
+	memories := make([]types.MemoryResponse, 0, len(moments))
+	for i, m := range moments {
+		memories = append(memories, types.MemoryResponse{
+			MomentResponse: responses[i],
+			YearsAgo:       day.Year() - m.CreatedAt.Time.In(day.Location()).Year(),
+		})
+	}
+	return memories, nil
+}
+
+// isLeapYear 判断是否为闰年
+func isLeapYear(year int) bool {
+	return time.Date(year, time.February, 29, 0, 0, 0, 0, time.UTC).Day() == 29
+}
+
+// GetMemoryDigestSettings 获取那年今日邮件的订阅设置，没有设置过时返回默认值（未订阅）
+func (s *Service) GetMemoryDigestSettings(ctx context.Context, userID int64) (types.MemoryDigestSettingsResponse, error) {
+	settings, err := s.Q.GetMemoryDigestSettings(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.MemoryDigestSettingsResponse{SendHour: defaultMemoryDigestHour}, nil
+		}
+		return types.MemoryDigestSettingsResponse{}, err
+	}
+	return toMemoryDigestSettingsResponse(settings), nil
+}
+
+// UpdateMemoryDigestSettings 开启或关闭那年今日邮件，send_hour 为空时保留原来的发送时间
+func (s *Service) UpdateMemoryDigestSettings(ctx context.Context, userID int64, body types.UpdateMemoryDigestSettingsBody) (types.MemoryDigestSettingsResponse, error) {
+	current, err := s.GetMemoryDigestSettings(ctx, userID)
+	if err != nil {
+		return types.MemoryDigestSettingsResponse{}, err
+	}
+
+	sendHour := current.SendHour
+	if body.SendHour != nil {
+		if *body.SendHour < 0 || *body.SendHour > 23 {
+			return types.MemoryDigestSettingsResponse{}, ErrInvalidSendHour
+		}
+		sendHour = *body.SendHour
+	}
+
+	settings, err := s.Q.UpsertMemoryDigestSettings(ctx, repository.UpsertMemoryDigestSettingsParams{
+		UserID:   userID,
+		Enabled:  body.Enabled,
+		SendHour: sendHour,
+	})
+	if err != nil {
+		return types.MemoryDigestSettingsResponse{}, err
+	}
+	return toMemoryDigestSettingsResponse(settings), nil
+}
+
+func toMemoryDigestSettingsResponse(settings repository.MemoryDigestSetting) types.MemoryDigestSettingsResponse {
+	resp := types.MemoryDigestSettingsResponse{
+		Enabled:  settings.Enabled,
+		SendHour: settings.SendHour,
+	}
+	if settings.LastSentOn.Valid {
+		lastSentOn := settings.LastSentOn.Time.Format(pkg.DateLayout)
+		resp.LastSentOn = &lastSentOn
+	}
+	return resp
+}
+
+// SendMemoryDigests 给已到发送时间的订阅用户发送那年今日邮件，由调度器定时调用
+func (s *Service) SendMemoryDigests(ctx context.Context) {
+	due, err := s.Q.ListDueMemoryDigests(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list due memory digests", zap.Error(err))
+		return
+	}
+
+	for _, row := range due {
+		if err := s.sendMemoryDigest(ctx, row); err != nil {
+			s.logger.Warn("Failed to send memory digest", zap.Int64("user_id", row.UserID), zap.Error(err))
+		}
+	}
+}
+
+// sendMemoryDigest 发送一位用户当天的那年今日邮件
+// 发送前先记录当天已发送，发送失败时不会重试，保证每天最多一封；往年没有 moment 时不发送
+func (s *Service) sendMemoryDigest(ctx context.Context, row repository.ListDueMemoryDigestsRow) error {
+	claimed, err := s.Q.MarkMemoryDigestSent(ctx, repository.MarkMemoryDigestSentParams{
+		SentOn: row.LocalDate,
+		UserID: row.UserID,
+	})
+	if err != nil || claimed == 0 {
+		return err
+	}
+
+	loc, err := pkg.LoadLocation(row.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	date := row.LocalDate.Time
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+
+	memories, err := s.listMemories(ctx, row.UserID, day)
+	if err != nil {
+		return err
+	}
+	if len(memories) == 0 {
+		return nil
+	}
+
+	intro := fmt.Sprintf("Hi %s, here is what you recorded on %s in previous years.", row.Name, day.Format("January 2"))
+	if len(memories) > maxDigestMemories {
+		intro = fmt.Sprintf("Hi %s, you recorded %d moments on %s in previous years. Here are the latest %d.", row.Name, len(memories), day.Format("January 2"), maxDigestMemories)
+		memories = memories[:maxDigestMemories]
+	}
+
+	data := notification.DigestData{
+		Title: "On this day",
+		Intro: intro,
+	}
+	// 附件的预签名链接很快过期，邮件中不放图片，每一项都链接到应用的 moment 页面
+	var momentURL string
+	if appURL := strings.TrimRight(s.config.Auth.AppURL, "/"); appURL != "" {
+		momentURL = appURL + "/moment"
+		data.ActionURL = momentURL
+		data.ActionText = "Open LifeTrack"
+	}
+	for _, memory := range memories {
+		data.Items = append(data.Items, notification.DigestItem{
+			Title:  fmt.Sprintf("%s · %d", formatYearsAgo(memory.YearsAgo), day.Year()-memory.YearsAgo),
+			Detail: digestDetail(memory),
+			URL:    momentURL,
+		})
+	}
+
+	return s.notificationService.SendTemplateEmail(row.Email, notification.TemplateDigest, data)
+}
+
+// digestDetail 返回邮件中一项的正文，有附件时注明附件数量
+func digestDetail(memory types.MemoryResponse) string {
+	detail := truncateRunes(memory.Content, maxDigestDetailLength)
+	switch n := len(memory.Attachments); {
+	case n == 1:
+		detail = strings.TrimSpace(detail + "\n(1 attachment)")
+	case n > 1:
+		detail = strings.TrimSpace(fmt.Sprintf("%s\n(%d attachments)", detail, n))
+	}
+	return detail
+}
+
+// formatYearsAgo 返回 "1 year ago"、"3 years ago" 形式的描述
+func formatYearsAgo(years int) string {
+	if years == 1 {
+		return "1 year ago"
+	}
+	return fmt.Sprintf("%d years ago", years)
+}
+
+// truncateRunes 将内容截断到最多 n 个字符，截断时以省略号结尾
+func truncateRunes(content string, n int) string {
+	if utf8.RuneCountInString(content) <= n {
+		return content
+	}
+	runes := []rune(content)
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
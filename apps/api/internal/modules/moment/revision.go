@@ -0,0 +1,244 @@
+package moment
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zeroicey/lifetrack-api/internal/modules/moment/types"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+)
+
+var (
+	// ErrEmptyContent 是 moment 内容为空时返回的哨兵错误
+	ErrEmptyContent = errors.New("content cannot be empty")
+	// ErrInvalidAttachmentID 是附件ID不是合法 UUID 时返回的哨兵错误
+	ErrInvalidAttachmentID = errors.New("invalid attachment ID format")
+	// ErrInvalidAttachmentPosition 是附件位置超出范围或重复时返回的哨兵错误
+	ErrInvalidAttachmentPosition = errors.New("invalid attachment position")
+	// ErrRevisionNotFound 是历史版本不存在或不属于该 moment 时返回的哨兵错误
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+// UpdateMoment 修改 moment 的内容和附件，修改前的版本保存到 moment_revisions
+// 内容和附件都没有变化时不保存历史版本
+func (s *Service) UpdateMoment(ctx context.Context, userID int64, id int64, body types.UpdateMomentBody) (types.MomentResponse, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return types.MomentResponse{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Q.WithTx(tx)
+	current, currentAttachments, err := s.lockMoment(ctx, qtx, userID, id)
+	if err != nil {
+		return types.MomentResponse{}, err
+	}
+
+	content := current.Content
+	if body.Content != nil {
+		content = *body.Content
+	}
+	attachments := currentAttachments
+	if body.Attachments != nil {
+		attachments = *body.Attachments
+	}
+
+	if content == current.Content && slices.Equal(attachments, currentAttachments) {
+		return s.converter.ToMomentResponse(ctx, current)
+	}
+
+	moment, err := s.replaceMoment(ctx, qtx, userID, current, currentAttachments, content, attachments)
+	if err != nil {
+		return types.MomentResponse{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return types.MomentResponse{}, errors.New("failed to commit transaction")
+	}
+	return s.converter.ToMomentResponse(ctx, moment)
+}
+
+// ListMomentRevisions 列出 moment 的历史版本，最新的在前
+func (s *Service) ListMomentRevisions(ctx context.Context, userID int64, momentID int64) ([]types.MomentRevisionResponse, error) {
+	if err := s.checkMomentExists(ctx, userID, momentID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.Q.ListMomentRevisions(ctx, momentID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]types.MomentRevisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		resp, err := toMomentRevisionResponse(revision)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+// RestoreMomentRevision 将 moment 恢复为指定的历史版本，恢复前的版本同样保存为历史版本
+// 历史版本中的附件已被删除时返回 ErrAttachmentNotFound
+func (s *Service) RestoreMomentRevision(ctx context.Context, userID int64, momentID int64, revisionID int64) (types.MomentResponse, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return types.MomentResponse{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Q.WithTx(tx)
+	current, currentAttachments, err := s.lockMoment(ctx, qtx, userID, momentID)
+	if err != nil {
+		return types.MomentResponse{}, err
+	}
+
+	revision, err := qtx.GetMomentRevision(ctx, repository.GetMomentRevisionParams{
+		ID:       revisionID,
+		MomentID: momentID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.MomentResponse{}, ErrRevisionNotFound
+		}
+		return types.MomentResponse{}, err
+	}
+	attachments, err := pkg.UnmarshalJSONB[[]types.AttachmentIdWithPosition](revision.Attachments)
+	if err != nil {
+		return types.MomentResponse{}, err
+	}
+
+	moment, err := s.replaceMoment(ctx, qtx, userID, current, currentAttachments, revision.Content, attachments)
+	if err != nil {
+		return types.MomentResponse{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return types.MomentResponse{}, errors.New("failed to commit transaction")
+	}
+	return s.converter.ToMomentResponse(ctx, moment)
+}
+
+// lockMoment 锁定 moment 并读取当前的附件列表
+func (s *Service) lockMoment(ctx context.Context, qtx *repository.Queries, userID int64, id int64) (repository.Moment, []types.AttachmentIdWithPosition, error) {
+	moment, err := qtx.GetMomentForUpdate(ctx, repository.GetMomentForUpdateParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.Moment{}, nil, ErrMomentNotFound
+		}
+		return repository.Moment{}, nil, err
+	}
+
+	refs, err := qtx.ListMomentAttachmentRefs(ctx, id)
+	if err != nil {
+		return repository.Moment{}, nil, err
+	}
+	attachments := make([]types.AttachmentIdWithPosition, 0, len(refs))
+	for _, ref := range refs {
+		attachments = append(attachments, types.AttachmentIdWithPosition{
+			AttachmentID: ref.AttachmentID.String(),
+			Position:     ref.Position,
+		})
+	}
+	return moment, attachments, nil
+}
+
+// replaceMoment 保存当前版本为历史版本，然后写入新的内容并整体替换附件，标签按新内容重新生成
+func (s *Service) replaceMoment(ctx context.Context, qtx *repository.Queries, userID int64, current repository.Moment, currentAttachments []types.AttachmentIdWithPosition, content string, attachments []types.AttachmentIdWithPosition) (repository.Moment, error) {
+	if strings.TrimSpace(content) == "" {
+		return repository.Moment{}, ErrEmptyContent
+	}
+	if err := validateAttachments(attachments); err != nil {
+		return repository.Moment{}, err
+	}
+
+	snapshot, err := pkg.MarshalJSONB(currentAttachments)
+	if err != nil {
+		return repository.Moment{}, err
+	}
+	_, err = qtx.CreateMomentRevision(ctx, repository.CreateMomentRevisionParams{
+		MomentID:    current.ID,
+		Content:     current.Content,
+		Attachments: snapshot,
+	})
+	if err != nil {
+		return repository.Moment{}, err
+	}
+
+	// 即使内容不变也执行更新，让触发器刷新 updated_at
+	moment, err := qtx.UpdateMomentContent(ctx, repository.UpdateMomentContentParams{
+		ID:      current.ID,
+		UserID:  userID,
+		Content: content,
+	})
+	if err != nil {
+		return repository.Moment{}, err
+	}
+	if err := s.syncMomentTags(ctx, qtx, userID, current.ID, content); err != nil {
+		return repository.Moment{}, err
+	}
+
+	if err := qtx.ClearMomentAttachments(ctx, current.ID); err != nil {
+		return repository.Moment{}, err
+	}
+	for _, attachment := range attachments {
+		attachmentID, _ := pkg.StringToPgUUID(attachment.AttachmentID)
+		added, err := qtx.AddAttachmentToMoment(ctx, repository.AddAttachmentToMomentParams{
+			MomentID:     current.ID,
+			AttachmentID: attachmentID,
+			Position:     attachment.Position,
+			UserID:       userID,
+		})
+		if err != nil {
+			return repository.Moment{}, err
+		}
+		if added == 0 {
+			return repository.Moment{}, ErrAttachmentNotFound
+		}
+	}
+
+	return moment, nil
+}
+
+// validateAttachments 检查附件ID格式，位置需在 0-9 之间且不能重复
+func validateAttachments(attachments []types.AttachmentIdWithPosition) error {
+	seen := make(map[int16]bool, len(attachments))
+	for _, attachment := range attachments {
+		if _, err := pkg.StringToPgUUID(attachment.AttachmentID); err != nil {
+			return ErrInvalidAttachmentID
+		}
+		if attachment.Position < 0 || attachment.Position > 9 || seen[attachment.Position] {
+			return ErrInvalidAttachmentPosition
+		}
+		seen[attachment.Position] = true
+	}
+	return nil
+}
+
+// toMomentRevisionResponse 将历史版本转换为响应模型
+func toMomentRevisionResponse(revision repository.MomentRevision) (types.MomentRevisionResponse, error) {
+	attachments, err := pkg.UnmarshalJSONB[[]types.AttachmentIdWithPosition](revision.Attachments)
+	if err != nil {
+		return types.MomentRevisionResponse{}, err
+	}
+	if attachments == nil {
+		attachments = []types.AttachmentIdWithPosition{}
+	}
+	return types.MomentRevisionResponse{
+		ID:          revision.ID,
+		MomentID:    revision.MomentID,
+		Content:     revision.Content,
+		Attachments: attachments,
+		CreatedAt:   revision.CreatedAt.Time.Format(time.RFC3339),
+	}, nil
+}
@@ -0,0 +1,227 @@
+package moment
+
+import (
+	"context"
+	"errors"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/zeroicey/lifetrack-api/internal/modules/moment/types"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+)
+
+// 搜索结果的排序方式
+const (
+	SearchSortRelevance = "relevance" // 按相关度排序，游标为结果偏移量
+	SearchSortRecent    = "recent"    // 按创建时间倒序，游标与列表接口相同
+)
+
+// maxSearchQueryLength 搜索词的最大长度（字符）
+const maxSearchQueryLength = 256
+
+// maxSearchOffset 按相关度翻页时允许的最大偏移量
+const maxSearchOffset = 10000
+
+// ts_headline 用来包裹命中词的分隔符，与 moment.sql 中的 StartSel/StopSel 对应
+const (
+	snippetStartSel = '\x02'
+	snippetStopSel  = '\x03'
+)
+
+var (
+	// ErrInvalidSearchQuery 是搜索词为空、过长或只包含排除词时返回的哨兵错误
+	ErrInvalidSearchQuery = errors.New("invalid search query")
+	// ErrInvalidSearchSort 是排序方式不是 relevance 或 recent 时返回的哨兵错误
+	ErrInvalidSearchSort = errors.New("invalid search sort, expected relevance or recent")
+)
+
+// SearchMoments 全文搜索当前用户的 moment
+// 支持 "短语"、前缀* 和 -排除词，多个条件之间为 AND 关系
+// search_vector 使用 simple 配置，只按空白和标点分词：中日韩文本中连续的文字会成为一个词，
+// 只能匹配完整的一段或用前缀* 匹配开头，无法搜索句子中间的词
+// sort 为 relevance 时 cursor 是结果偏移量，为 recent 时 cursor 与 ListMomentsPaginated 相同，都通过 nextCursor 翻页
+func (s *Service) SearchMoments(ctx context.Context, userID int64, q string, sort string, cursor int64, limit int) ([]types.MomentSearchResult, *int64, error) {
+	query, err := buildSearchQuery(q)
+	if err != nil {
+		return nil, nil, err
+	}
+	limit = min(max(limit, 1), 100)
+
+	var rows []repository.SearchMomentsRow
+	switch sort {
+	case "", SearchSortRelevance:
+		if cursor < 0 || cursor > maxSearchOffset {
+			return nil, nil, ErrInvalidSearchQuery
+		}
+		rows, err = s.Q.SearchMoments(ctx, repository.SearchMomentsParams{
+			Query:     query,
+			UserID:    userID,
+			RowLimit:  int32(limit + 1),
+			RowOffset: int32(cursor),
+		})
+	case SearchSortRecent:
+		var recent []repository.SearchMomentsByCreatedAtRow
+		recent, err = s.Q.SearchMomentsByCreatedAt(ctx, repository.SearchMomentsByCreatedAtParams{
+			Query:    query,
+			UserID:   userID,
+			Cursor:   s.converter.CursorToTimestamp(cursor),
+			RowLimit: int32(limit + 1),
+		})
+		for _, row := range recent {
+			rows = append(rows, repository.SearchMomentsRow(row))
+		}
+	default:
+		return nil, nil, ErrInvalidSearchSort
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hasNext := len(rows) > limit
+	if hasNext {
+		rows = rows[:limit]
+	}
+
+	var nextCursor *int64
+	if hasNext && len(rows) > 0 {
+		next := cursor + int64(limit)
+		if sort == SearchSortRecent {
+			next = rows[len(rows)-1].CreatedAt.Time.UnixMilli()
+		}
+		nextCursor = &next
+	}
+
+	matched := make([]repository.Moment, len(rows))
+	for i, row := range rows {
+		matched[i] = repository.Moment{
+			ID:        row.ID,
+			Content:   row.Content,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+			UserID:    row.UserID,
+		}
+	}
+	moments, err := s.converter.ToMomentResponses(ctx, matched)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]types.MomentSearchResult, 0, len(rows))
+	for i, row := range rows {
+		results = append(results, types.MomentSearchResult{
+			MomentResponse: moments[i],
+			Rank:           row.Rank,
+			Snippet:        highlightSnippet(row.Snippet),
+		})
+	}
+
+	return results, nextCursor, nil
+}
+
+// highlightSnippet 转义 ts_headline 返回的片段，再把分隔符替换为 <mark></mark>
+// 内容本身包含分隔符时也只输出成对的标签
+func highlightSnippet(snippet string) string {
+	var b strings.Builder
+	open := false
+	start := 0
+	for i, r := range snippet {
+		if r != snippetStartSel && r != snippetStopSel {
+			continue
+		}
+		b.WriteString(html.EscapeString(snippet[start:i]))
+		start = i + 1
+		switch {
+		case r == snippetStartSel && !open:
+			b.WriteString("<mark>")
+			open = true
+		case r == snippetStopSel && open:
+			b.WriteString("</mark>")
+			open = false
+		}
+	}
+	b.WriteString(html.EscapeString(snippet[start:]))
+	if open {
+		b.WriteString("</mark>")
+	}
+	return b.String()
+}
+
+// buildSearchQuery 将用户输入转换为 to_tsquery 语法
+// 引号内为短语（词之间用 <-> 连接），以 * 结尾为前缀匹配，以 - 开头为排除
+// 词中的标点会被去掉，用户输入无法注入 tsquery 运算符
+func buildSearchQuery(q string) (string, error) {
+	q = strings.TrimSpace(q)
+	if q == "" || len([]rune(q)) > maxSearchQueryLength {
+		return "", ErrInvalidSearchQuery
+	}
+
+	var clauses []string
+	positive := false
+	for _, token := range splitSearchTokens(q) {
+		negate := strings.HasPrefix(token, "-") && len(token) > 1
+		if negate {
+			token = token[1:]
+		}
+		prefix := strings.HasSuffix(token, "*")
+
+		words := strings.FieldsFunc(token, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) == 0 {
+			continue
+		}
+		if prefix {
+			words[len(words)-1] += ":*"
+		}
+
+		clause := strings.Join(words, " <-> ")
+		if len(words) > 1 {
+			clause = "(" + clause + ")"
+		}
+		if negate {
+			clause = "!" + clause
+		} else {
+			positive = true
+		}
+		clauses = append(clauses, clause)
+	}
+
+	if !positive {
+		return "", ErrInvalidSearchQuery
+	}
+	return strings.Join(clauses, " & "), nil
+}
+
+// splitSearchTokens 按空白拆分搜索词，引号内的内容（可带前导 -）作为一个短语
+func splitSearchTokens(q string) []string {
+	var tokens []string
+	var current strings.Builder
+	inQuote := false
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range q {
+		switch {
+		case r == '"':
+			if inQuote {
+				flush()
+			} else if current.String() != "-" {
+				flush()
+			}
+			inQuote = !inQuote
+		case unicode.IsSpace(r) && !inQuote:
+			flush()
+		case unicode.IsSpace(r):
+			current.WriteRune(' ')
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,35 @@
+package moment
+
+import "testing"
+
+func TestHighlightSnippetEscapesContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{
+			name:    "highlights matched words",
+			snippet: "went \x02hiking\x03 today",
+			want:    "went <mark>hiking</mark> today",
+		},
+		{
+			name:    "escapes html in content",
+			snippet: "<img src=x onerror=alert(1)> \x02hiking\x03 & <mark>",
+			want:    "&lt;img src=x onerror=alert(1)&gt; <mark>hiking</mark> &amp; &lt;mark&gt;",
+		},
+		{
+			name:    "keeps tags balanced when content contains delimiters",
+			snippet: "\x03a \x02b \x02c",
+			want:    "a <mark>b c</mark>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightSnippet(tt.snippet); got != tt.want {
+				t.Errorf("highlightSnippet(%q) = %q, want %q", tt.snippet, got, tt.want)
+			}
+		})
+	}
+}
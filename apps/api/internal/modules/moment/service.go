@@ -5,31 +5,42 @@ import (
 	"errors"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/zeroicey/lifetrack-api/internal/config"
 	"github.com/zeroicey/lifetrack-api/internal/modules/moment/types"
+	"github.com/zeroicey/lifetrack-api/internal/modules/notification"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage"
 	"github.com/zeroicey/lifetrack-api/internal/pkg"
 	"github.com/zeroicey/lifetrack-api/internal/repository"
 	"go.uber.org/zap"
 )
 
 type Service struct {
-	Q         *repository.Queries
-	DB        *pgxpool.Pool
-	logger    *zap.Logger
-	converter *Converter
+	Q                   *repository.Queries
+	DB                  *pgxpool.Pool
+	logger              *zap.Logger
+	config              *config.Config
+	converter           *Converter
+	notificationService *notification.Service
 }
 
-var ErrMomentNotFound = errors.New("moment not found")
+var (
+	ErrMomentNotFound     = errors.New("moment not found")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+)
 
-func NewService(db *pgxpool.Pool, q *repository.Queries, logger *zap.Logger) *Service {
+func NewService(db *pgxpool.Pool, q *repository.Queries, logger *zap.Logger, config *config.Config, notificationService *notification.Service, storageService *storage.Service) *Service {
 	return &Service{
-		Q:         q,
-		logger:    logger,
-		converter: NewConverter(q),
-		DB:        db,
+		Q:                   q,
+		logger:              logger,
+		config:              config,
+		converter:           NewConverter(q, storageService, logger),
+		DB:                  db,
+		notificationService: notificationService,
 	}
 }
 
-func (s *Service) ListMomentsPaginated(ctx context.Context, cursor int64, limit int) ([]types.MomentResponse, *int64, error) {
+// ListMomentsPaginated 按创建时间倒序分页列出 moment，tag 不为空时只返回带有该标签的 moment
+func (s *Service) ListMomentsPaginated(ctx context.Context, userID int64, tag string, cursor int64, limit int) ([]types.MomentResponse, *int64, error) {
 	limit = func() int {
 		if limit <= 0 {
 			return 10
@@ -43,10 +54,22 @@ func (s *Service) ListMomentsPaginated(ctx context.Context, cursor int64, limit
 	cursorTs := s.converter.CursorToTimestamp(cursor)
 
 	// Get more one moment to check if there is a next page
-	_moments, err := s.Q.GetMomentsPaginated(ctx, repository.GetMomentsPaginatedParams{
-		Column1: cursorTs,
-		Limit:   int32(limit + 1),
-	})
+	var _moments []repository.Moment
+	var err error
+	if tag = normalizeTag(tag); tag != "" {
+		_moments, err = s.Q.GetMomentsPaginatedByTag(ctx, repository.GetMomentsPaginatedByTagParams{
+			UserID:   userID,
+			Tag:      tag,
+			Cursor:   cursorTs,
+			RowLimit: int32(limit + 1),
+		})
+	} else {
+		_moments, err = s.Q.GetMomentsPaginated(ctx, repository.GetMomentsPaginatedParams{
+			Column1: cursorTs,
+			Limit:   int32(limit + 1),
+			UserID:  userID,
+		})
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -77,7 +100,7 @@ func (s *Service) ListMomentsPaginated(ctx context.Context, cursor int64, limit
 	return moments, nextCursor, nil
 }
 
-func (s *Service) CreateMoment(ctx context.Context, body types.CreateMomentBody) (types.MomentResponse, error) {
+func (s *Service) CreateMoment(ctx context.Context, userID int64, body types.CreateMomentBody) (types.MomentResponse, error) {
 	tx, err := s.DB.Begin(ctx)
 	if err != nil {
 		return types.MomentResponse{}, err
@@ -85,11 +108,18 @@ func (s *Service) CreateMoment(ctx context.Context, body types.CreateMomentBody)
 	defer tx.Rollback(ctx)
 
 	qtx := s.Q.WithTx(tx)
-	moment, err := qtx.CreateMoment(ctx, body.Content)
+	moment, err := qtx.CreateMoment(ctx, repository.CreateMomentParams{
+		Content: body.Content,
+		UserID:  userID,
+	})
 	if err != nil {
 		return types.MomentResponse{}, errors.New("failed to create moment")
 	}
 
+	if err := s.syncMomentTags(ctx, qtx, userID, moment.ID, moment.Content); err != nil {
+		return types.MomentResponse{}, errors.New("failed to save moment tags")
+	}
+
 	for _, attachment := range body.Attachments {
 		attachmentID, err := pkg.StringToPgUUID(attachment.AttachmentID)
 		if err != nil {
@@ -100,14 +130,18 @@ func (s *Service) CreateMoment(ctx context.Context, body types.CreateMomentBody)
 			return types.MomentResponse{}, errors.New("invalid attachment position")
 		}
 
-		err = qtx.AddAttachmentToMoment(ctx, repository.AddAttachmentToMomentParams{
+		added, err := qtx.AddAttachmentToMoment(ctx, repository.AddAttachmentToMomentParams{
 			MomentID:     moment.ID,
 			AttachmentID: attachmentID,
 			Position:     attachment.Position,
+			UserID:       userID,
 		})
 		if err != nil {
 			return types.MomentResponse{}, errors.New("failed to add attachment to moment")
 		}
+		if added == 0 {
+			return types.MomentResponse{}, ErrAttachmentNotFound
+		}
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -117,11 +151,14 @@ func (s *Service) CreateMoment(ctx context.Context, body types.CreateMomentBody)
 	return s.converter.ToMomentResponse(ctx, moment)
 }
 
-func (s *Service) GetMomentByID(ctx context.Context, id int64) (types.MomentResponse, error) {
-	if err := s.checkMomentExists(ctx, id); err != nil {
+func (s *Service) GetMomentByID(ctx context.Context, userID int64, id int64) (types.MomentResponse, error) {
+	if err := s.checkMomentExists(ctx, userID, id); err != nil {
 		return types.MomentResponse{}, err
 	}
-	_moment, err := s.Q.GetMomentByID(ctx, id)
+	_moment, err := s.Q.GetMomentByID(ctx, repository.GetMomentByIDParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		return types.MomentResponse{}, err
 	}
@@ -130,15 +167,30 @@ func (s *Service) GetMomentByID(ctx context.Context, id int64) (types.MomentResp
 	return s.converter.ToMomentResponse(ctx, _moment)
 }
 
-func (s *Service) DeleteMomentByID(ctx context.Context, id int64) error {
-	if err := s.checkMomentExists(ctx, id); err != nil {
+func (s *Service) DeleteMomentByID(ctx context.Context, userID int64, id int64) error {
+	if err := s.checkMomentExists(ctx, userID, id); err != nil {
+		return err
+	}
+	err := s.Q.DeleteMomentByID(ctx, repository.DeleteMomentByIDParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
 		return err
 	}
-	return s.Q.DeleteMomentByID(ctx, id)
+
+	// moment_tags 随 moment 级联删除，这里清理不再使用的标签
+	if err := s.Q.DeleteUnusedTags(ctx, userID); err != nil {
+		s.logger.Warn("Failed to delete unused tags", zap.Int64("user_id", userID), zap.Error(err))
+	}
+	return nil
 }
 
-func (s *Service) checkMomentExists(ctx context.Context, id int64) error {
-	exists, err := s.Q.MomentExists(ctx, id)
+func (s *Service) checkMomentExists(ctx context.Context, userID int64, id int64) error {
+	exists, err := s.Q.MomentExists(ctx, repository.MomentExistsParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		return err
 	}
@@ -149,9 +201,9 @@ func (s *Service) checkMomentExists(ctx context.Context, id int64) error {
 }
 
 // AddAttachmentToMoment 向指定的 moment 添加附件
-func (s *Service) AddAttachmentToMoment(ctx context.Context, momentID int64, attachmentID string, position int16) error {
+func (s *Service) AddAttachmentToMoment(ctx context.Context, userID int64, momentID int64, attachmentID string, position int16) error {
 	// 检查 moment 是否存在
-	if err := s.checkMomentExists(ctx, momentID); err != nil {
+	if err := s.checkMomentExists(ctx, userID, momentID); err != nil {
 		return ErrMomentNotFound
 	}
 
@@ -162,22 +214,26 @@ func (s *Service) AddAttachmentToMoment(ctx context.Context, momentID int64, att
 		return errors.New("invalid attachment ID format")
 	}
 
-	err = s.Q.AddAttachmentToMoment(ctx, repository.AddAttachmentToMomentParams{
+	added, err := s.Q.AddAttachmentToMoment(ctx, repository.AddAttachmentToMomentParams{
 		MomentID:     momentID,
 		AttachmentID: attachmentUUID,
 		Position:     position,
+		UserID:       userID,
 	})
 	if err != nil {
 		s.logger.Sugar().Errorf("failed to add attachment to moment: %v", err)
 		return err
 	}
+	if added == 0 {
+		return ErrAttachmentNotFound
+	}
 	return nil
 }
 
 // RemoveAttachmentFromMoment 从指定的 moment 移除附件
-func (s *Service) RemoveAttachmentFromMoment(ctx context.Context, momentID int64, attachmentID string) error {
+func (s *Service) RemoveAttachmentFromMoment(ctx context.Context, userID int64, momentID int64, attachmentID string) error {
 	// 检查 moment 是否存在
-	if err := s.checkMomentExists(ctx, momentID); err != nil {
+	if err := s.checkMomentExists(ctx, userID, momentID); err != nil {
 		return err
 	}
 
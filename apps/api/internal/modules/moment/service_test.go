@@ -0,0 +1,32 @@
+package moment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+func TestAddAttachmentRequiresCompletedUpload(t *testing.T) {
+	completed, failed, verifying := uuid.New(), uuid.New(), uuid.New()
+	db := &fakeDB{uploads: map[[16]byte]string{
+		completed: "completed",
+		failed:    "failed",
+		verifying: "verifying",
+	}}
+	s := &Service{Q: repository.New(db), logger: zap.NewNop()}
+	ctx := context.Background()
+
+	if err := s.AddAttachmentToMoment(ctx, 1, 1, completed.String(), 0); err != nil {
+		t.Fatalf("AddAttachmentToMoment(completed): %v", err)
+	}
+	// 校验失败或仍在校验的分片上传被引用后无法取消或回收，必须拒绝
+	for name, id := range map[string]uuid.UUID{"failed": failed, "verifying": verifying, "missing": uuid.New()} {
+		if err := s.AddAttachmentToMoment(ctx, 1, 1, id.String(), 1); !errors.Is(err, ErrAttachmentNotFound) {
+			t.Errorf("AddAttachmentToMoment(%s) error = %v, want ErrAttachmentNotFound", name, err)
+		}
+	}
+}
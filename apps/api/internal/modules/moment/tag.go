@@ -0,0 +1,102 @@
+package moment
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zeroicey/lifetrack-api/internal/modules/moment/types"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+)
+
+// maxTagLength 标签名的最大长度（字符），超过的不视为标签
+const maxTagLength = 64
+
+// isTagRune 标签名允许的字符：字母、数字和下划线
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// ExtractHashtags 从内容中解析话题标签，返回去重后的小写标签名（不含 #），按首次出现的顺序排列
+// # 前面必须是开头或除 / 以外的非标签字符（排除 a#b、URL 中的锚点），标签名至少包含一个字母
+// 规则需要与 db/init/25_create_tags.sql 中的回填语句保持一致
+func ExtractHashtags(content string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	prev := rune(-1)
+	for i, r := range content {
+		if r != '#' || (prev != -1 && (isTagRune(prev) || prev == '/')) {
+			prev = r
+			continue
+		}
+		prev = r
+
+		end := i + 1
+		hasLetter := false
+		for end < len(content) {
+			next, size := utf8.DecodeRuneInString(content[end:])
+			if !isTagRune(next) {
+				break
+			}
+			hasLetter = hasLetter || unicode.IsLetter(next)
+			end += size
+		}
+
+		name := strings.ToLower(content[i+1 : end])
+		if !hasLetter || utf8.RuneCountInString(name) > maxTagLength || seen[name] {
+			continue
+		}
+		seen[name] = true
+		tags = append(tags, name)
+	}
+	return tags
+}
+
+// normalizeTag 将查询参数中的标签转换为存储的形式：去掉 # 并转为小写
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// syncMomentTags 按内容重新生成 moment 的标签，并删除不再使用的标签
+func (s *Service) syncMomentTags(ctx context.Context, qtx *repository.Queries, userID int64, momentID int64, content string) error {
+	if err := qtx.ClearMomentTags(ctx, momentID); err != nil {
+		return err
+	}
+	for _, name := range ExtractHashtags(content) {
+		tagID, err := qtx.UpsertTag(ctx, repository.UpsertTagParams{
+			UserID: userID,
+			Name:   name,
+		})
+		if err != nil {
+			return err
+		}
+		err = qtx.AddMomentTag(ctx, repository.AddMomentTagParams{
+			MomentID: momentID,
+			TagID:    tagID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return qtx.DeleteUnusedTags(ctx, userID)
+}
+
+// ListTags 列出当前用户使用过的标签及对应的 moment 数量，使用最多的在前
+func (s *Service) ListTags(ctx context.Context, userID int64) ([]types.TagResponse, error) {
+	rows, err := s.Q.ListTagsWithCounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]types.TagResponse, 0, len(rows))
+	for _, row := range rows {
+		tags = append(tags, types.TagResponse{
+			Name:       row.Name,
+			Count:      row.MomentCount,
+			LastUsedAt: row.LastUsedAt.Time.Format(time.RFC3339),
+		})
+	}
+	return tags, nil
+}
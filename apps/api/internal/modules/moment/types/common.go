@@ -3,12 +3,15 @@ package types
 import "github.com/jackc/pgx/v5/pgtype"
 
 type Attachment struct {
-	ID           string `json:"id"`
-	ObjectKey    string `json:"object_key"`
-	OriginalName string `json:"original_name"`
-	MimeType     string `json:"mime_type"`
-	FileSize     int64  `json:"file_size"`
-	Position     int16  `json:"position"`
+	ID           string            `json:"id"`
+	ObjectKey    string            `json:"object_key"`
+	OriginalName string            `json:"original_name"`
+	MimeType     string            `json:"mime_type"`
+	FileSize     int64             `json:"file_size"`
+	Position     int16             `json:"position"`
+	CoverURL     string            `json:"cover_url,omitempty"`  // 封面的预签名链接，有效期与 STORAGE_PRESIGNED_EXPIRY 相同
+	CoverURLs    map[string]string `json:"cover_urls,omitempty"` // 服务端生成的各尺寸封面链接，键为长边像素
+	CoverStatus  string            `json:"cover_status"`
 }
 
 // AttachmentWithMeta 包含附件的完整信息，用于内部处理
@@ -18,3 +18,15 @@ type PaginatedQuery struct {
 	Cursor string `json:"cursor"`
 	Limit  int    `json:"limit"`
 }
+
+// UpdateMomentBody 只更新提供的字段，attachments 不为 null 时按给出的列表整体替换附件及顺序
+type UpdateMomentBody struct {
+	Content     *string                     `json:"content"`
+	Attachments *[]AttachmentIdWithPosition `json:"attachments"`
+}
+
+// UpdateMemoryDigestSettingsBody send_hour 为用户时区的小时（0-23），为 null 时保留原来的设置
+type UpdateMemoryDigestSettingsBody struct {
+	Enabled  bool   `json:"enabled"`
+	SendHour *int16 `json:"send_hour"`
+}
@@ -4,6 +4,40 @@ type MomentResponse struct {
 	ID          int64        `json:"id"`
 	Content     string       `json:"content"`
 	Attachments []Attachment `json:"attachments"`
+	Tags        []string     `json:"tags"`
 	UpdatedAt   string       `json:"updated_at"`
 	CreatedAt   string       `json:"created_at"`
 }
+
+// MomentSearchResult 是全文搜索的结果，snippet 中命中的词用 <mark></mark> 包裹
+type MomentSearchResult struct {
+	MomentResponse
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type MomentRevisionResponse struct {
+	ID          int64                      `json:"id"`
+	MomentID    int64                      `json:"moment_id"`
+	Content     string                     `json:"content"`
+	Attachments []AttachmentIdWithPosition `json:"attachments"`
+	CreatedAt   string                     `json:"created_at"`
+}
+
+type TagResponse struct {
+	Name       string `json:"name"`
+	Count      int64  `json:"count"`
+	LastUsedAt string `json:"last_used_at"`
+}
+
+// MemoryResponse 是那年今日中的一条 moment，years_ago 按用户时区的年份计算
+type MemoryResponse struct {
+	MomentResponse
+	YearsAgo int `json:"years_ago"`
+}
+
+type MemoryDigestSettingsResponse struct {
+	Enabled    bool    `json:"enabled"`
+	SendHour   int16   `json:"send_hour"`
+	LastSentOn *string `json:"last_sent_on"`
+}
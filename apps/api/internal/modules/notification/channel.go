@@ -0,0 +1,172 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// 内置的通知渠道名称
+const (
+	ChannelEmail    = "email"
+	ChannelWebhook  = "webhook"
+	ChannelPush     = "push"
+	ChannelTelegram = "telegram"
+)
+
+var (
+	// ErrUnknownChannel 是请求的通知渠道不存在或未配置时返回的哨兵错误
+	ErrUnknownChannel = errors.New("unknown notification channel")
+	// ErrInvalidChannelSettings 是用户保存的渠道配置不完整或不合法时返回的哨兵错误
+	ErrInvalidChannelSettings = errors.New("invalid notification channel settings")
+	// ErrForbiddenTarget 是渠道地址解析到回环或内网地址时返回的哨兵错误
+	ErrForbiddenTarget = errors.New("notification target address is not allowed")
+)
+
+// Message 是发送到任意渠道的通知内容
+type Message struct {
+	To    string // 可选的收件人，仅邮件渠道使用，为空时发送到渠道的默认收件人
+	Title string
+	Body  string
+	HTML  string // 可选的 HTML 正文，仅邮件渠道使用，其他渠道发送纯文本 Body
+}
+
+// Channel 是一个通知渠道，例如邮件、Webhook、推送或 Telegram
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// ChannelSettings 用户为 Webhook、推送或 Telegram 渠道保存的配置
+type ChannelSettings struct {
+	Channel  string
+	Provider string // 推送服务，仅 push 渠道使用
+	Target   string // webhook 和 push 为请求地址，telegram 为 chat id
+	Secret   string // webhook 签名密钥、push 访问令牌或 telegram bot token
+}
+
+// UserChannels 是用户可以自行配置的渠道，邮件渠道始终发送到账户邮箱，不需要配置
+var UserChannels = []string{ChannelWebhook, ChannelPush, ChannelTelegram}
+
+// NewChannel 根据用户保存的配置创建渠道，配置不完整或地址不合法时返回 ErrInvalidChannelSettings
+func (s *Service) NewChannel(settings ChannelSettings) (Channel, error) {
+	switch settings.Channel {
+	case ChannelWebhook:
+		if err := validateTargetURL(settings.Target); err != nil {
+			return nil, err
+		}
+		return NewWebhookChannel(s.client, settings.Target, settings.Secret), nil
+	case ChannelPush:
+		if err := validateTargetURL(settings.Target); err != nil {
+			return nil, err
+		}
+		push, err := NewPushChannel(s.client, settings.Provider, settings.Target, settings.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidChannelSettings, err)
+		}
+		return push, nil
+	case ChannelTelegram:
+		if settings.Target == "" || settings.Secret == "" {
+			return nil, fmt.Errorf("%w: telegram requires chat_id and token", ErrInvalidChannelSettings)
+		}
+		return NewTelegramChannel(s.client, s.telegramAPIURL, settings.Secret, settings.Target), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, settings.Channel)
+	}
+}
+
+// validateTargetURL 要求用户填写的请求地址是带主机名的 http(s) 地址
+func validateTargetURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidChannelSettings)
+	}
+	return nil
+}
+
+// newHTTPClient 创建用户渠道使用的 HTTP 客户端
+// 请求地址由用户填写，默认拒绝连接回环、内网和链路本地地址，避免被用来访问服务端所在的内部网络
+func newHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			ip = ip.Unmap()
+			if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+				return fmt.Errorf("%w: %s", ErrForbiddenTarget, ip)
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// EmailChannel 通过 Mailer 发送邮件，消息未指定收件人时发送到默认收件人
+type EmailChannel struct {
+	mailer Mailer
+	to     string
+}
+
+func NewEmailChannel(mailer Mailer, to string) *EmailChannel {
+	return &EmailChannel{mailer: mailer, to: to}
+}
+
+func (c *EmailChannel) Name() string {
+	return ChannelEmail
+}
+
+func (c *EmailChannel) Send(ctx context.Context, msg Message) error {
+	to := c.to
+	if msg.To != "" {
+		to = msg.To
+	}
+	err := c.mailer.Send(to, msg.Title, msg.Body, msg.HTML)
+	if isBenignSMTPError(err) {
+		return nil
+	}
+	return err
+}
+
+// isBenignSMTPError 判断是否为邮件已发送、但服务器在最后一条命令前关闭连接的错误
+func isBenignSMTPError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "sending SMTP RESET command")
+}
+
+// doRequest 发送 HTTP 请求，非 2xx 响应视为失败
+// 错误会写入投递记录并通过接口返回，请求地址中可能带有令牌（如 Telegram 的 bot token），因此不包含 URL
+func doRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("%s request failed: %w", req.Method, urlErr.Err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,247 @@
+package notification
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zeroicey/lifetrack-api/internal/config"
+	"go.uber.org/zap"
+)
+
+// capturedRequest 是 httptest 服务器收到的请求
+type capturedRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   []byte
+}
+
+// newCaptureServer 启动记录请求的本地服务器，status 为其返回的状态码
+func newCaptureServer(t *testing.T, status int) (*httptest.Server, *capturedRequest) {
+	t.Helper()
+	captured := &capturedRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*captured = capturedRequest{method: r.Method, path: r.URL.Path, header: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+		io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, captured
+}
+
+var testMessage = Message{Title: "Standup", Body: "Starts in 10 minutes"}
+
+func TestWebhookChannel(t *testing.T) {
+	srv, got := newCaptureServer(t, http.StatusNoContent)
+	channel := NewWebhookChannel(srv.Client(), srv.URL+"/hook", "s3cret")
+
+	if err := channel.Send(context.Background(), testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got.method != http.MethodPost || got.path != "/hook" {
+		t.Fatalf("request = %s %s, want POST /hook", got.method, got.path)
+	}
+	if ct := got.header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", ct)
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.Title != testMessage.Title || payload.Body != testMessage.Body || payload.SentAt.IsZero() {
+		t.Fatalf("payload = %+v", payload)
+	}
+
+	// 接收方用相同密钥对原始请求体计算 HMAC
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(got.body)
+	if sig := got.header.Get("X-Lifetrack-Signature"); sig != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+		t.Fatalf("signature = %q does not match body", sig)
+	}
+}
+
+func TestWebhookChannelUnsigned(t *testing.T) {
+	srv, got := newCaptureServer(t, http.StatusOK)
+	if err := NewWebhookChannel(srv.Client(), srv.URL, "").Send(context.Background(), testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if sig := got.header.Get("X-Lifetrack-Signature"); sig != "" {
+		t.Fatalf("signature = %q, want none without a secret", sig)
+	}
+}
+
+func TestWebhookChannelErrorStatus(t *testing.T) {
+	srv, _ := newCaptureServer(t, http.StatusBadGateway)
+	err := NewWebhookChannel(srv.Client(), srv.URL, "").Send(context.Background(), testMessage)
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 502") {
+		t.Fatalf("Send error = %v, want unexpected status 502", err)
+	}
+}
+
+func TestPushChannelNtfy(t *testing.T) {
+	srv, got := newCaptureServer(t, http.StatusOK)
+	channel, err := NewPushChannel(srv.Client(), "NTFY", srv.URL+"/reminders/", "tk_ntfy")
+	if err != nil {
+		t.Fatalf("NewPushChannel: %v", err)
+	}
+
+	if err := channel.Send(context.Background(), Message{Title: "会议提醒", Body: testMessage.Body}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got.method != http.MethodPost || got.path != "/reminders" {
+		t.Fatalf("request = %s %s, want POST /reminders", got.method, got.path)
+	}
+	if string(got.body) != testMessage.Body {
+		t.Fatalf("body = %q, want %q", got.body, testMessage.Body)
+	}
+	// 非 ASCII 标题以 RFC 2047 编码放在请求头中
+	title, err := new(mime.WordDecoder).DecodeHeader(got.header.Get("Title"))
+	if err != nil || title != "会议提醒" {
+		t.Fatalf("Title = %q (%v), want 会议提醒", title, err)
+	}
+	if auth := got.header.Get("Authorization"); auth != "Bearer tk_ntfy" {
+		t.Fatalf("Authorization = %q, want Bearer tk_ntfy", auth)
+	}
+}
+
+func TestPushChannelGotify(t *testing.T) {
+	srv, got := newCaptureServer(t, http.StatusOK)
+	channel, err := NewPushChannel(srv.Client(), PushProviderGotify, srv.URL, "app-token")
+	if err != nil {
+		t.Fatalf("NewPushChannel: %v", err)
+	}
+
+	if err := channel.Send(context.Background(), testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got.path != "/message" {
+		t.Fatalf("path = %q, want /message", got.path)
+	}
+	if key := got.header.Get("X-Gotify-Key"); key != "app-token" {
+		t.Fatalf("X-Gotify-Key = %q, want app-token", key)
+	}
+	var payload struct {
+		Title    string `json:"title"`
+		Message  string `json:"message"`
+		Priority int    `json:"priority"`
+	}
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.Title != testMessage.Title || payload.Message != testMessage.Body || payload.Priority != 5 {
+		t.Fatalf("payload = %+v", payload)
+	}
+}
+
+func TestPushChannelUnsupportedProvider(t *testing.T) {
+	if _, err := NewPushChannel(http.DefaultClient, "pushover", "http://example.invalid", ""); err == nil {
+		t.Fatal("NewPushChannel accepted an unsupported provider")
+	}
+}
+
+func TestTelegramChannel(t *testing.T) {
+	srv, got := newCaptureServer(t, http.StatusOK)
+	channel := NewTelegramChannel(srv.Client(), srv.URL+"/", "123:abc", "42")
+
+	if err := channel.Send(context.Background(), testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got.path != "/bot123:abc/sendMessage" {
+		t.Fatalf("path = %q, want /bot123:abc/sendMessage", got.path)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload["chat_id"] != "42" || payload["text"] != testMessage.Title+"\n\n"+testMessage.Body {
+		t.Fatalf("payload = %v", payload)
+	}
+}
+
+func TestTelegramChannelErrorHidesToken(t *testing.T) {
+	const botToken = "123456:SECRET-bot-token"
+
+	// 连接失败时 *url.Error 会带上完整请求地址
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	err := NewTelegramChannel(srv.Client(), srv.URL, botToken, "42").Send(context.Background(), testMessage)
+	if err == nil {
+		t.Fatal("Send to a closed server succeeded")
+	}
+	if strings.Contains(err.Error(), botToken) || strings.Contains(err.Error(), "SECRET") {
+		t.Fatalf("error leaks the bot token: %v", err)
+	}
+
+	errSrv, _ := newCaptureServer(t, http.StatusUnauthorized)
+	err = NewTelegramChannel(errSrv.Client(), errSrv.URL, botToken, "42").Send(context.Background(), testMessage)
+	if err == nil || strings.Contains(err.Error(), "SECRET") {
+		t.Fatalf("Send error = %v, want a status error without the bot token", err)
+	}
+}
+
+func TestNewChannelSettings(t *testing.T) {
+	s := NewService(zap.NewNop(), nil, nil, &config.NotifyConfig{Timeout: 5})
+
+	tests := []struct {
+		name     string
+		settings ChannelSettings
+		wantErr  error
+	}{
+		{"webhook", ChannelSettings{Channel: ChannelWebhook, Target: "https://example.com/hook"}, nil},
+		{"webhook without scheme", ChannelSettings{Channel: ChannelWebhook, Target: "example.com/hook"}, ErrInvalidChannelSettings},
+		{"webhook with file scheme", ChannelSettings{Channel: ChannelWebhook, Target: "file:///etc/passwd"}, ErrInvalidChannelSettings},
+		{"push", ChannelSettings{Channel: ChannelPush, Provider: PushProviderGotify, Target: "https://push.example.com"}, nil},
+		{"push with unsupported provider", ChannelSettings{Channel: ChannelPush, Provider: "pushover", Target: "https://push.example.com"}, ErrInvalidChannelSettings},
+		{"telegram", ChannelSettings{Channel: ChannelTelegram, Target: "42", Secret: "123:abc"}, nil},
+		{"telegram without token", ChannelSettings{Channel: ChannelTelegram, Target: "42"}, ErrInvalidChannelSettings},
+		{"email is not user configurable", ChannelSettings{Channel: ChannelEmail}, ErrUnknownChannel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.NewChannel(tt.settings)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("NewChannel: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewChannel error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUserChannelRejectsPrivateTarget(t *testing.T) {
+	srv, got := newCaptureServer(t, http.StatusOK)
+	settings := ChannelSettings{Channel: ChannelWebhook, Target: srv.URL}
+
+	// 默认不允许用户渠道访问回环地址
+	channel, err := NewService(zap.NewNop(), nil, nil, &config.NotifyConfig{Timeout: 5}).NewChannel(settings)
+	if err != nil {
+		t.Fatalf("NewChannel: %v", err)
+	}
+	if err := channel.Send(context.Background(), testMessage); !errors.Is(err, ErrForbiddenTarget) {
+		t.Fatalf("Send error = %v, want ErrForbiddenTarget", err)
+	}
+	if got.method != "" {
+		t.Fatal("request reached the loopback server")
+	}
+
+	channel, err = NewService(zap.NewNop(), nil, nil, &config.NotifyConfig{Timeout: 5, AllowPrivateTargets: true}).NewChannel(settings)
+	if err != nil {
+		t.Fatalf("NewChannel: %v", err)
+	}
+	if err := channel.Send(context.Background(), testMessage); err != nil {
+		t.Fatalf("Send with private targets allowed: %v", err)
+	}
+}
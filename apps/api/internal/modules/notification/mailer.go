@@ -22,8 +22,8 @@ func NewSMTPMailer(client *mail.Client, cfg *config.MailConfig, logger *zap.Logg
 	}
 }
 
-// Send 方法保持不变
-func (m *SMTPMailer) Send(to, subject, body string) error {
+// Send 发送邮件，htmlBody 不为空时发送 multipart/alternative 邮件，纯文本作为回退
+func (m *SMTPMailer) Send(to, subject, textBody, htmlBody string) error {
 	msg := mail.NewMsg()
 	if err := msg.From(m.config.From); err != nil {
 		return err
@@ -32,7 +32,10 @@ func (m *SMTPMailer) Send(to, subject, body string) error {
 		return err
 	}
 	msg.Subject(subject)
-	msg.SetBodyString(mail.TypeTextPlain, body)
+	msg.SetBodyString(mail.TypeTextPlain, textBody)
+	if htmlBody != "" {
+		msg.AddAlternativeString(mail.TypeTextHTML, htmlBody)
+	}
 
 	return m.client.DialAndSend(msg)
 }
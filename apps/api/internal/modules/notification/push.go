@@ -0,0 +1,77 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// 支持的推送服务
+const (
+	PushProviderNtfy   = "ntfy"
+	PushProviderGotify = "gotify"
+)
+
+// PushChannel 发送到 ntfy 或 Gotify 风格的推送服务
+type PushChannel struct {
+	client   *http.Client
+	provider string
+	url      string
+	token    string
+}
+
+func NewPushChannel(client *http.Client, provider, url, token string) (*PushChannel, error) {
+	provider = strings.ToLower(provider)
+	if provider != PushProviderNtfy && provider != PushProviderGotify {
+		return nil, fmt.Errorf("unsupported push provider %q", provider)
+	}
+	return &PushChannel{client: client, provider: provider, url: strings.TrimRight(url, "/"), token: token}, nil
+}
+
+func (c *PushChannel) Name() string {
+	return ChannelPush
+}
+
+func (c *PushChannel) Send(ctx context.Context, msg Message) error {
+	var (
+		req *http.Request
+		err error
+	)
+
+	switch c.provider {
+	case PushProviderGotify:
+		var payload []byte
+		payload, err = json.Marshal(map[string]any{
+			"title":    msg.Title,
+			"message":  msg.Body,
+			"priority": 5,
+		})
+		if err != nil {
+			return err
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/message", bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if c.token != "" {
+			req.Header.Set("X-Gotify-Key", c.token)
+		}
+	default:
+		// ntfy 直接把请求体作为消息内容，标题放在请求头中
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, c.url, strings.NewReader(msg.Body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Title", mime.BEncoding.Encode("UTF-8", msg.Title))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+	}
+
+	return doRequest(c.client, req)
+}
@@ -1,32 +1,72 @@
 package notification
 
 import (
-	"strings"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
 
+	"github.com/zeroicey/lifetrack-api/internal/config"
 	"go.uber.org/zap"
 )
 
 type Mailer interface {
-	Send(to, subject, body string) error
+	Send(to, subject, textBody, htmlBody string) error
 }
 
 type Service struct {
-	logger *zap.Logger
-	mailer Mailer
+	logger         *zap.Logger
+	mailer         Mailer
+	renderer       *Renderer
+	channels       map[string]Channel // 系统渠道（邮件），所有用户共用
+	client         *http.Client       // 用户渠道使用的 HTTP 客户端
+	telegramAPIURL string
 }
 
-func NewService(logger *zap.Logger, mailer Mailer) *Service {
-	return &Service{
-		logger: logger,
-		mailer: mailer,
+func NewService(logger *zap.Logger, mailer Mailer, renderer *Renderer, cfg *config.NotifyConfig, channels ...Channel) *Service {
+	s := &Service{
+		logger:         logger,
+		mailer:         mailer,
+		renderer:       renderer,
+		channels:       make(map[string]Channel, len(channels)),
+		client:         newHTTPClient(time.Duration(cfg.Timeout)*time.Second, cfg.AllowPrivateTargets),
+		telegramAPIURL: cfg.TelegramAPIURL,
 	}
+	for _, channel := range channels {
+		s.channels[channel.Name()] = channel
+	}
+	return s
 }
 
 func (s *Service) SendEmail(to, subject, body string) error {
-	s.logger.Info("Attempting to send email", zap.String("to", to), zap.String("subject", subject))
-	err := s.mailer.Send(to, subject, body)
+	return s.sendEmail(to, Message{Title: subject, Body: body})
+}
+
+// SendTemplateEmail 渲染模板并发送 HTML 邮件
+func (s *Service) SendTemplateEmail(to, name string, data any) error {
+	msg, err := s.Render(name, data)
 	if err != nil {
-		if strings.Contains(err.Error(), "sending SMTP RESET command") {
+		return err
+	}
+	return s.sendEmail(to, msg)
+}
+
+// Render 渲染通知模板，结果可以直接交给任意渠道发送
+func (s *Service) Render(name string, data any) (Message, error) {
+	msg, err := s.renderer.Render(name, data)
+	if err != nil {
+		s.logger.Error("Failed to render notification template", zap.String("template", name), zap.Error(err))
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+func (s *Service) sendEmail(to string, msg Message) error {
+	s.logger.Info("Attempting to send email", zap.String("to", to), zap.String("subject", msg.Title))
+	err := s.mailer.Send(to, msg.Title, msg.Body, msg.HTML)
+	if err != nil {
+		if isBenignSMTPError(err) {
 			s.logger.Info("Mail sent successfully, but connection was closed by server before final command.")
 			return nil
 		}
@@ -36,3 +76,39 @@ func (s *Service) SendEmail(to, subject, body string) error {
 	s.logger.Info("Email sent successfully")
 	return nil
 }
+
+// Send 通过指定的系统渠道发送通知
+func (s *Service) Send(ctx context.Context, channel string, msg Message) error {
+	ch, ok := s.channels[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+	return s.SendWith(ctx, ch, msg)
+}
+
+// SendWith 通过给定的渠道发送通知，用于 NewChannel 创建的用户渠道
+func (s *Service) SendWith(ctx context.Context, ch Channel, msg Message) error {
+	s.logger.Info("Attempting to send notification", zap.String("channel", ch.Name()), zap.String("title", msg.Title))
+	if err := ch.Send(ctx, msg); err != nil {
+		s.logger.Error("Failed to send notification", zap.String("channel", ch.Name()), zap.Error(err))
+		return err
+	}
+	s.logger.Info("Notification sent successfully", zap.String("channel", ch.Name()))
+	return nil
+}
+
+// HasChannel 判断是否为系统渠道
+func (s *Service) HasChannel(channel string) bool {
+	_, ok := s.channels[channel]
+	return ok
+}
+
+// Channels 返回所有系统渠道名称
+func (s *Service) Channels() []string {
+	names := make([]string, 0, len(s.channels))
+	for name := range s.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
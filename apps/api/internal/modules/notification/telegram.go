@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// TelegramChannel 通过 Telegram Bot API 的 sendMessage 发送消息
+type TelegramChannel struct {
+	client   *http.Client
+	apiURL   string
+	botToken string
+	chatID   string
+}
+
+func NewTelegramChannel(client *http.Client, apiURL, botToken, chatID string) *TelegramChannel {
+	return &TelegramChannel{client: client, apiURL: strings.TrimRight(apiURL, "/"), botToken: botToken, chatID: chatID}
+}
+
+func (c *TelegramChannel) Name() string {
+	return ChannelTelegram
+}
+
+func (c *TelegramChannel) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]string{
+		"chat_id": c.chatID,
+		"text":    msg.Title + "\n\n" + msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/bot"+c.botToken+"/sendMessage", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return doRequest(c.client, req)
+}
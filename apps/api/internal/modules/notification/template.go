@@ -0,0 +1,163 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// 内置的通知模板名称，每个模板由 <name>.subject.tmpl、<name>.txt.tmpl 和 <name>.html.tmpl 组成
+const (
+	TemplateReminder          = "reminder"
+	TemplateDigest            = "digest"
+	TemplatePasswordReset     = "password_reset"
+	TemplateEmailVerification = "email_verification"
+)
+
+// layoutTemplate 所有 HTML 模板共用的外层布局，内容模板通过 {{define "content"}} 填充
+const layoutTemplate = "layout.html.tmpl"
+
+//go:embed templates/*.tmpl
+var embeddedTemplates embed.FS
+
+// ErrUnknownTemplate 是请求的模板不存在时返回的哨兵错误
+var ErrUnknownTemplate = errors.New("unknown notification template")
+
+// ReminderData 是提醒模板的数据
+type ReminderData struct {
+	Name         string
+	Place        string
+	Description  string
+	StartTime    time.Time
+	EndTime      time.Time
+	RemindBefore int32
+}
+
+// DigestData 是摘要模板的数据
+type DigestData struct {
+	Title      string
+	Intro      string
+	Items      []DigestItem
+	ActionURL  string // 可选，摘要末尾的按钮链接
+	ActionText string
+}
+
+// DigestItem 是摘要中的一项
+type DigestItem struct {
+	Title  string
+	Detail string
+	URL    string // 可选，条目链接
+}
+
+// AccountEmailData 是账户类邮件（重置密码、验证邮箱）模板的数据
+type AccountEmailData struct {
+	Name      string
+	ActionURL string
+	ExpiresIn string
+}
+
+// Renderer 渲染通知模板，模板目录中的同名文件会覆盖内置模板
+type Renderer struct {
+	dir string
+}
+
+// NewRenderer 创建模板渲染器，dir 为空时只使用内置模板
+func NewRenderer(dir string) *Renderer {
+	return &Renderer{dir: dir}
+}
+
+var templateFuncs = map[string]any{
+	"formatTime": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05 MST")
+	},
+}
+
+// Render 渲染指定模板，返回包含标题、纯文本正文和 HTML 正文的消息
+func (r *Renderer) Render(name string, data any) (Message, error) {
+	subjectSrc, err := r.readTemplate(name + ".subject.tmpl")
+	if err != nil {
+		return Message{}, err
+	}
+	textSrc, err := r.readTemplate(name + ".txt.tmpl")
+	if err != nil {
+		return Message{}, err
+	}
+	htmlSrc, err := r.readTemplate(name + ".html.tmpl")
+	if err != nil {
+		return Message{}, err
+	}
+	layoutSrc, err := r.readTemplate(layoutTemplate)
+	if err != nil {
+		return Message{}, err
+	}
+
+	subject, err := executeText(name+".subject", subjectSrc, data)
+	if err != nil {
+		return Message{}, err
+	}
+	text, err := executeText(name+".txt", textSrc, data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	tmpl, err := htmltemplate.New("layout").Funcs(templateFuncs).Parse(layoutSrc)
+	if err != nil {
+		return Message{}, fmt.Errorf("parse %s: %w", layoutTemplate, err)
+	}
+	if _, err := tmpl.Parse(htmlSrc); err != nil {
+		return Message{}, fmt.Errorf("parse %s.html: %w", name, err)
+	}
+	var html bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, fmt.Errorf("execute %s.html: %w", name, err)
+	}
+
+	return Message{
+		// 标题只取单行，避免模板末尾换行导致邮件头不合法
+		Title: strings.TrimSpace(subject),
+		Body:  strings.TrimSpace(text),
+		HTML:  html.String(),
+	}, nil
+}
+
+// readTemplate 优先读取模板目录中的文件，不存在时回退到内置模板
+func (r *Renderer) readTemplate(file string) (string, error) {
+	if r.dir != "" {
+		content, err := os.ReadFile(filepath.Join(r.dir, file))
+		if err == nil {
+			return string(content), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+
+	content, err := embeddedTemplates.ReadFile("templates/" + file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("%w: %s", ErrUnknownTemplate, file)
+		}
+		return "", err
+	}
+	return string(content), nil
+}
+
+func executeText(name, src string, data any) (string, error) {
+	tmpl, err := texttemplate.New(name).Funcs(templateFuncs).Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("parse %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
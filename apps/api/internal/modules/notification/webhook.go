@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// WebhookChannel 以 JSON 形式 POST 到任意 HTTP 地址
+type WebhookChannel struct {
+	client *http.Client
+	url    string
+	secret string
+}
+
+type webhookPayload struct {
+	Title  string    `json:"title"`
+	Body   string    `json:"body"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+func NewWebhookChannel(client *http.Client, url, secret string) *WebhookChannel {
+	return &WebhookChannel{client: client, url: url, secret: secret}
+}
+
+func (c *WebhookChannel) Name() string {
+	return ChannelWebhook
+}
+
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(webhookPayload{Title: msg.Title, Body: msg.Body, SentAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.secret != "" {
+		// 接收方可用相同密钥校验请求来源
+		mac := hmac.New(sha256.New, []byte(c.secret))
+		mac.Write(payload)
+		req.Header.Set("X-Lifetrack-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	return doRequest(c.client, req)
+}
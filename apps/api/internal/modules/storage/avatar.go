@@ -0,0 +1,266 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage/driver"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage/types"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+// AvatarSizes 服务端生成的头像边长（像素），DefaultAvatarSize 用于 avatar_url
+var AvatarSizes = []int{64, 128, 256}
+
+// DefaultAvatarSize 默认返回的头像边长（像素）
+const DefaultAvatarSize = 256
+
+// avatarJPEGQuality 头像缩略图的 JPEG 质量
+const avatarJPEGQuality = 85
+
+// avatarMimeTypes 允许上传的头像格式
+var avatarMimeTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+var (
+	// ErrInvalidAvatar 是头像不是可解码的 JPEG、PNG 或 GIF 图片时返回的哨兵错误
+	ErrInvalidAvatar = errors.New("avatar must be a JPEG, PNG or GIF image")
+	// ErrAvatarTooLarge 是头像文件或图片尺寸超过限制时返回的哨兵错误
+	ErrAvatarTooLarge = errors.New("avatar is too large")
+	// ErrAvatarUploadNotFound 是头像上传对象不存在或不属于当前用户时返回的哨兵错误
+	ErrAvatarUploadNotFound = errors.New("avatar upload not found")
+)
+
+// avatarUploadPrefix 用户上传头像原图的对象前缀，原图处理后删除
+func avatarUploadPrefix(userID int64) string {
+	return fmt.Sprintf("avatars/%d/uploads/", userID)
+}
+
+// avatarObjectKey 头像某个尺寸的对象键
+func avatarObjectKey(avatarKey string, size int) string {
+	return fmt.Sprintf("%s/%d.jpg", avatarKey, size)
+}
+
+// CreateAvatarUpload 生成上传头像原图的预签名地址，上传完成后调用 CompleteAvatarUpload 处理
+func (s *Service) CreateAvatarUpload(ctx context.Context, userID int64, body types.AvatarUploadRequest) (types.AvatarUploadResponse, error) {
+	if !avatarMimeTypes[body.MimeType] {
+		return types.AvatarUploadResponse{}, ErrInvalidAvatar
+	}
+	if body.FileSize > s.config.Storage.AvatarMaxSize {
+		return types.AvatarUploadResponse{}, ErrAvatarTooLarge
+	}
+
+	objectKey := avatarUploadPrefix(userID) + uuid.NewString()
+	expiry := time.Duration(s.config.Storage.PresignedExpiry) * time.Minute
+	presignedURL, err := s.driver.PresignPut(ctx, objectKey, expiry)
+	if err != nil {
+		return types.AvatarUploadResponse{}, fmt.Errorf("failed to generate presigned URL: %w", err)
+	}
+
+	return types.AvatarUploadResponse{
+		ObjectKey: objectKey,
+		UploadURL: presignedURL,
+	}, nil
+}
+
+// CompleteAvatarUpload 读取上传的原图，生成各尺寸的头像并设为用户头像，原图随后删除
+func (s *Service) CompleteAvatarUpload(ctx context.Context, userID int64, objectKey string) (types.AvatarResponse, error) {
+	if !strings.HasPrefix(objectKey, avatarUploadPrefix(userID)) {
+		return types.AvatarResponse{}, ErrAvatarUploadNotFound
+	}
+
+	data, err := s.readAvatarUpload(ctx, objectKey)
+	if err != nil {
+		return types.AvatarResponse{}, err
+	}
+	defer s.removeObject(ctx, objectKey)
+
+	avatarKey, err := s.setAvatar(ctx, userID, data)
+	if err != nil {
+		return types.AvatarResponse{}, err
+	}
+	return s.AvatarURLs(ctx, avatarKey)
+}
+
+// readAvatarUpload 读取上传的头像原图，超过大小限制时返回 ErrAvatarTooLarge
+func (s *Service) readAvatarUpload(ctx context.Context, objectKey string) ([]byte, error) {
+	info, err := s.driver.Stat(ctx, objectKey)
+	if err != nil {
+		if errors.Is(err, driver.ErrNotFound) {
+			return nil, ErrAvatarUploadNotFound
+		}
+		return nil, fmt.Errorf("failed to get avatar upload info: %w", err)
+	}
+	if info.Size > s.config.Storage.AvatarMaxSize {
+		s.removeObject(ctx, objectKey)
+		return nil, ErrAvatarTooLarge
+	}
+
+	object, err := s.driver.Get(ctx, objectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get avatar upload: %w", err)
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(io.LimitReader(object, s.config.Storage.AvatarMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read avatar upload: %w", err)
+	}
+	if int64(len(data)) > s.config.Storage.AvatarMaxSize {
+		return nil, ErrAvatarTooLarge
+	}
+	return data, nil
+}
+
+// setAvatar 将图片裁剪缩放为各尺寸的 JPEG 写入对象存储，更新用户头像后删除旧头像
+func (s *Service) setAvatar(ctx context.Context, userID int64, data []byte) (string, error) {
+	img, _, err := pkg.DecodeImage(data)
+	if err != nil {
+		if errors.Is(err, pkg.ErrImageTooLarge) {
+			return "", ErrAvatarTooLarge
+		}
+		return "", ErrInvalidAvatar
+	}
+
+	avatarKey := fmt.Sprintf("avatars/%d/%s", userID, uuid.NewString())
+	for _, size := range AvatarSizes {
+		thumbnail, err := pkg.EncodeJPEG(pkg.SquareThumbnail(img, size), avatarJPEGQuality)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode avatar: %w", err)
+		}
+		err = s.driver.Put(ctx, avatarObjectKey(avatarKey, size), bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg")
+		if err != nil {
+			s.removeAvatarObjects(ctx, avatarKey)
+			return "", fmt.Errorf("failed to upload avatar: %w", err)
+		}
+	}
+
+	oldKey, err := s.Q.UpdateUserAvatar(ctx, repository.UpdateUserAvatarParams{
+		ID:        userID,
+		AvatarKey: avatarKey,
+	})
+	if err != nil {
+		s.removeAvatarObjects(ctx, avatarKey)
+		return "", fmt.Errorf("failed to update user avatar: %w", err)
+	}
+	s.removeAvatarObjects(ctx, oldKey)
+
+	return avatarKey, nil
+}
+
+// SetInlineAvatar 将 Base64 编码的图片（可带 data URI 前缀）设为用户头像，返回头像前缀
+func (s *Service) SetInlineAvatar(ctx context.Context, userID int64, value string) (string, error) {
+	data, err := decodeInlineAvatar(value)
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > s.config.Storage.AvatarMaxSize {
+		return "", ErrAvatarTooLarge
+	}
+	return s.setAvatar(ctx, userID, data)
+}
+
+// DeleteAvatar 删除用户头像
+func (s *Service) DeleteAvatar(ctx context.Context, userID int64) error {
+	oldKey, err := s.Q.UpdateUserAvatar(ctx, repository.UpdateUserAvatarParams{
+		ID:        userID,
+		AvatarKey: "",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clear user avatar: %w", err)
+	}
+	s.removeAvatarObjects(ctx, oldKey)
+	return nil
+}
+
+// AvatarURLs 生成各尺寸头像的临时访问地址，avatarKey 为空时返回空结果
+func (s *Service) AvatarURLs(ctx context.Context, avatarKey string) (types.AvatarResponse, error) {
+	if avatarKey == "" {
+		return types.AvatarResponse{}, nil
+	}
+
+	expiry := time.Duration(s.config.Storage.PresignedExpiry) * time.Minute
+	result := types.AvatarResponse{AvatarURLs: make(map[string]string, len(AvatarSizes))}
+	for _, size := range AvatarSizes {
+		presignedURL, err := s.driver.PresignGet(ctx, avatarObjectKey(avatarKey, size), expiry)
+		if err != nil {
+			return types.AvatarResponse{}, fmt.Errorf("failed to generate avatar URL: %w", err)
+		}
+		result.AvatarURLs[strconv.Itoa(size)] = presignedURL
+	}
+	result.AvatarURL = result.AvatarURLs[strconv.Itoa(DefaultAvatarSize)]
+	return result, nil
+}
+
+// MigrateInlineAvatars 将旧版保存在 users.avatar_base64 中的头像迁移到对象存储
+// 无法解码的头像直接清空，单个用户迁移失败不影响其他用户
+func (s *Service) MigrateInlineAvatars(ctx context.Context) error {
+	rows, err := s.Q.ListUsersWithInlineAvatar(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list inline avatars: %w", err)
+	}
+
+	migrated := 0
+	for _, row := range rows {
+		_, err := s.SetInlineAvatar(ctx, row.ID, row.AvatarBase64)
+		switch {
+		case err == nil:
+			migrated++
+		case errors.Is(err, ErrInvalidAvatar), errors.Is(err, ErrAvatarTooLarge):
+			s.logger.Warn("Dropping undecodable inline avatar", zap.Int64("user_id", row.ID), zap.Error(err))
+			if err := s.DeleteAvatar(ctx, row.ID); err != nil {
+				s.logger.Error("Failed to clear inline avatar", zap.Int64("user_id", row.ID), zap.Error(err))
+			}
+		default:
+			s.logger.Error("Failed to migrate inline avatar", zap.Int64("user_id", row.ID), zap.Error(err))
+		}
+	}
+
+	if len(rows) > 0 {
+		s.logger.Info("Migrated inline avatars to object storage", zap.Int("migrated", migrated), zap.Int("total", len(rows)))
+	}
+	return nil
+}
+
+// decodeInlineAvatar 解码旧版头像数据，兼容带 data URI 前缀的写法
+func decodeInlineAvatar(value string) ([]byte, error) {
+	if i := strings.Index(value, ";base64,"); strings.HasPrefix(value, "data:") && i >= 0 {
+		value = value[i+len(";base64,"):]
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, ErrInvalidAvatar
+	}
+	return data, nil
+}
+
+// removeAvatarObjects 删除头像的各尺寸对象，失败只记录日志
+func (s *Service) removeAvatarObjects(ctx context.Context, avatarKey string) {
+	if avatarKey == "" {
+		return
+	}
+	for _, size := range AvatarSizes {
+		s.removeObject(ctx, avatarObjectKey(avatarKey, size))
+	}
+}
+
+// removeObject 删除对象，失败只记录日志
+func (s *Service) removeObject(ctx context.Context, objectKey string) {
+	err := s.driver.Remove(ctx, objectKey)
+	if err != nil {
+		s.logger.Warn("Failed to remove object", zap.String("objectKey", objectKey), zap.Error(err))
+	}
+}
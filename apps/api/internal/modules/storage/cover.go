@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+// 附件的封面状态
+const (
+	CoverStatusPending     = "pending"     // 等待后台任务生成
+	CoverStatusProcessing  = "processing"  // 生成中
+	CoverStatusReady       = "ready"       // 可用，客户端上传的封面直接为 ready
+	CoverStatusFailed      = "failed"      // 生成失败，不再重试
+	CoverStatusUnsupported = "unsupported" // 文件类型不支持生成封面
+)
+
+// CoverSizes 服务端生成的封面长边尺寸（像素），DefaultCoverSize 的封面写入 cover_object_key
+var CoverSizes = []int{320, 640, 1280}
+
+// DefaultCoverSize 默认返回的封面尺寸（像素）
+const DefaultCoverSize = 640
+
+const (
+	// coverJPEGQuality 封面的 JPEG 质量
+	coverJPEGQuality = 80
+	// maxCoverAttempts 封面生成的最大尝试次数，读取或写入对象存储失败时重试
+	maxCoverAttempts = 3
+	// coverBatchSize 后台任务每次领取的附件数量
+	coverBatchSize = 10
+	// coverStaleAfter 处理中的附件超过该时间仍未完成时（例如实例退出）重新领取
+	coverStaleAfter = 10 * time.Minute
+	// defaultCoverPollInterval 未配置轮询间隔时使用的默认值
+	defaultCoverPollInterval = 30 * time.Second
+)
+
+// coverMimeTypes 服务端可以生成封面的图片格式，GIF 使用第一帧
+var coverMimeTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+var (
+	// ErrCoverNotReady 是封面还没有生成或无法生成时返回的哨兵错误
+	ErrCoverNotReady = errors.New("cover is not ready")
+	// errInvalidCoverSource 原图无法解码或超过大小限制，重试也不会成功
+	errInvalidCoverSource = errors.New("cover source is not a supported image")
+)
+
+// coverObjectKey 服务端生成的某个尺寸封面的对象键
+func coverObjectKey(objectKey string, size int) string {
+	return fmt.Sprintf("%s.cover-%d.jpg", strings.TrimSuffix(objectKey, filepath.Ext(objectKey)), size)
+}
+
+// initialCoverStatus 新附件的封面状态：客户端自带封面时为 ready，否则按文件类型决定是否由服务端生成
+func initialCoverStatus(mimeType string, clientCover bool) string {
+	switch {
+	case clientCover:
+		return CoverStatusReady
+	case coverMimeTypes[mimeType]:
+		return CoverStatusPending
+	default:
+		return CoverStatusUnsupported
+	}
+}
+
+// notifyCoverWorker 唤醒后台任务立即处理新完成的上传，任务正忙时不阻塞
+func (s *Service) notifyCoverWorker() {
+	select {
+	case s.coverWake <- struct{}{}:
+	default:
+	}
+}
+
+// PresignedCoverURLs 为服务端生成的各尺寸封面生成预签名下载链接，键为尺寸（像素）
+// 与 PresignedCoverURL 一样只做签名，调用方需要确认附件属于当前用户
+func (s *Service) PresignedCoverURLs(ctx context.Context, objectKey string, sizes []int32) (map[string]string, error) {
+	urls := make(map[string]string, len(sizes))
+	for _, size := range sizes {
+		url, err := s.PresignedCoverURL(ctx, coverObjectKey(objectKey, int(size)))
+		if err != nil {
+			return nil, err
+		}
+		urls[strconv.Itoa(int(size))] = url
+	}
+	return urls, nil
+}
+
+// GenerateCovers 领取待生成封面的附件并逐个处理，直到没有待处理的附件，返回处理的数量
+// 多个实例同时运行时通过 SKIP LOCKED 领取不同的附件
+func (s *Service) GenerateCovers(ctx context.Context) int {
+	processed := 0
+	for ctx.Err() == nil {
+		staleBefore := pgtype.Timestamptz{}
+		staleBefore.Scan(time.Now().Add(-coverStaleAfter))
+		attachments, err := s.Q.ClaimPendingCovers(ctx, repository.ClaimPendingCoversParams{
+			StaleBefore: staleBefore,
+			BatchSize:   coverBatchSize,
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to claim pending covers", zap.Error(err))
+			}
+			return processed
+		}
+		if len(attachments) == 0 {
+			return processed
+		}
+
+		for _, attachment := range attachments {
+			s.processCover(ctx, attachment)
+			processed++
+		}
+	}
+	return processed
+}
+
+// processCover 生成一个附件的封面并更新封面状态
+// 原图无法处理或重试次数用完时标记为 failed，其他错误放回 pending 等待下次重试
+func (s *Service) processCover(ctx context.Context, attachment repository.Attachment) {
+	logger := s.logger.With(zap.String("attachmentId", attachment.ID.String()), zap.String("objectKey", attachment.ObjectKey))
+
+	if attachment.CoverAttempts > maxCoverAttempts {
+		logger.Warn("Giving up cover generation after too many attempts")
+		s.setCoverStatus(ctx, attachment, CoverStatusFailed)
+		return
+	}
+
+	coverKey, coverMD5, err := s.generateCover(ctx, attachment.ObjectKey)
+	if err != nil {
+		status := CoverStatusPending
+		if errors.Is(err, errInvalidCoverSource) || attachment.CoverAttempts >= maxCoverAttempts {
+			status = CoverStatusFailed
+		}
+		logger.Warn("Failed to generate cover", zap.Int32("attempt", attachment.CoverAttempts), zap.String("coverStatus", status), zap.Error(err))
+		s.setCoverStatus(ctx, attachment, status)
+		return
+	}
+
+	sizes := make([]int32, len(CoverSizes))
+	for i, size := range CoverSizes {
+		sizes[i] = int32(size)
+	}
+	err = s.Q.MarkCoverReady(ctx, repository.MarkCoverReadyParams{
+		CoverObjectKey: coverKey,
+		CoverMd5:       coverMD5,
+		CoverSizes:     sizes,
+		ID:             attachment.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to mark cover as ready", zap.Error(err))
+		return
+	}
+	logger.Info("Cover generated", zap.String("coverObjectKey", coverKey))
+}
+
+// setCoverStatus 更新封面状态，失败只记录日志，处理中的记录超时后会被重新领取
+func (s *Service) setCoverStatus(ctx context.Context, attachment repository.Attachment, status string) {
+	err := s.Q.SetCoverStatus(ctx, repository.SetCoverStatusParams{
+		CoverStatus: status,
+		ID:          attachment.ID,
+	})
+	if err != nil {
+		s.logger.Error("Failed to update cover status",
+			zap.String("attachmentId", attachment.ID.String()),
+			zap.String("coverStatus", status),
+			zap.Error(err),
+		)
+	}
+}
+
+// generateCover 读取原图，按 CoverSizes 生成 JPEG 封面写入对象存储，返回默认尺寸封面的对象键和 MD5
+func (s *Service) generateCover(ctx context.Context, objectKey string) (string, string, error) {
+	data, err := s.readCoverSource(ctx, objectKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	img, _, err := pkg.DecodeImage(data)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", errInvalidCoverSource, err)
+	}
+
+	var coverKey, coverMD5 string
+	written := make([]string, 0, len(CoverSizes))
+	for _, size := range CoverSizes {
+		cover, err := pkg.EncodeJPEG(pkg.FitThumbnail(img, size), coverJPEGQuality)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to encode cover: %w", err)
+		}
+
+		key := coverObjectKey(objectKey, size)
+		err = s.driver.Put(ctx, key, bytes.NewReader(cover), int64(len(cover)), "image/jpeg")
+		if err != nil {
+			for _, k := range written {
+				s.removeObject(ctx, k)
+			}
+			return "", "", fmt.Errorf("failed to upload cover: %w", err)
+		}
+		written = append(written, key)
+
+		if size == DefaultCoverSize {
+			sum := md5.Sum(cover)
+			coverKey, coverMD5 = key, hex.EncodeToString(sum[:])
+		}
+	}
+	return coverKey, coverMD5, nil
+}
+
+// readCoverSource 读取生成封面用的原图，超过 CoverMaxSourceSize 时返回 errInvalidCoverSource
+func (s *Service) readCoverSource(ctx context.Context, objectKey string) ([]byte, error) {
+	maxSize := s.config.Storage.CoverMaxSourceSize
+	info, err := s.driver.Stat(ctx, objectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object info: %w", err)
+	}
+	if info.Size > maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds limit", errInvalidCoverSource, info.Size)
+	}
+
+	object, err := s.driver.Get(ctx, objectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(io.LimitReader(object, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w: exceeds size limit", errInvalidCoverSource)
+	}
+	return data, nil
+}
+
+// CoverWorker 后台生成封面的任务，上传完成时立即唤醒，另外定时轮询以处理重试和其他实例遗留的附件
+type CoverWorker struct {
+	service  *Service
+	interval time.Duration
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewCoverWorker 创建封面生成任务
+func NewCoverWorker(service *Service) *CoverWorker {
+	interval := time.Duration(service.config.Storage.CoverPollInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultCoverPollInterval
+	}
+	return &CoverWorker{service: service, interval: interval}
+}
+
+// Start 在后台启动任务
+func (w *CoverWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+	go w.run(ctx)
+	w.service.logger.Info("Cover worker started", zap.Duration("interval", w.interval))
+}
+
+// Stop 停止任务并等待正在处理的附件结束，被中断的附件超时后由其他实例重新领取
+func (w *CoverWorker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+	w.service.logger.Info("Cover worker stopped")
+}
+
+func (w *CoverWorker) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.service.GenerateCovers(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.service.coverWake:
+		}
+	}
+}
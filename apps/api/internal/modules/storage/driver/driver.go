@@ -0,0 +1,82 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/zeroicey/lifetrack-api/internal/config"
+)
+
+// 存储后端
+const (
+	ProviderLocal = "local" // 本地磁盘，由 API 自身提供签名的上传和下载地址
+)
+
+// ErrNotFound 是对象不存在时返回的哨兵错误
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo 对象的元数据
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ETag         string // 对象内容的 MD5（十六进制），不带引号；List 返回的对象不保证有该字段
+	LastModified time.Time
+}
+
+// Part 分片上传中已上传的分片
+type Part struct {
+	Number int // 分片号，从 1 开始
+	Size   int64
+	ETag   string // 对象存储返回的分片 ETag，本地存储为空
+}
+
+// Driver 对象存储驱动，storage.Service 只通过该接口读写对象
+type Driver interface {
+	// EnsureBucket 确保存储桶（或本地目录）存在
+	EnsureBucket(ctx context.Context) error
+	// PresignPut 生成在 expiry 内有效的上传地址，客户端通过 PUT 上传对象内容
+	PresignPut(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// PresignGet 生成在 expiry 内有效的下载地址
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// Stat 获取对象的元数据，对象不存在时返回 ErrNotFound
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Get 读取对象内容，对象不存在时返回 ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Put 写入对象，已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Remove 删除对象，对象不存在时不返回错误
+	Remove(ctx context.Context, key string) error
+	// List 遍历所有对象，fn 返回错误时停止遍历并返回该错误
+	List(ctx context.Context, fn func(ObjectInfo) error) error
+
+	// CreateMultipartUpload 开始分片上传，返回上传 ID
+	CreateMultipartUpload(ctx context.Context, key string, contentType string) (string, error)
+	// PresignPart 生成在 expiry 内有效的分片上传地址，客户端通过 PUT 上传分片内容
+	PresignPart(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration) (string, error)
+	// ListParts 按分片号顺序列出已上传的分片，上传 ID 不存在时返回 ErrNotFound
+	ListParts(ctx context.Context, key string, uploadID string) ([]Part, error)
+	// CompleteMultipartUpload 按顺序将分片合并为对象
+	CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []Part) error
+	// AbortMultipartUpload 取消分片上传并删除已上传的分片
+	AbortMultipartUpload(ctx context.Context, key string, uploadID string) error
+}
+
+// New 按 STORAGE_PROVIDER 创建存储驱动，local 使用本地磁盘，其余均按 S3 兼容协议访问
+func New(cfg *config.Config) (Driver, error) {
+	if cfg.Storage.Provider == ProviderLocal {
+		signingKey := cfg.Storage.SigningKey
+		if signingKey == "" {
+			signingKey = cfg.JWT.JWTSecret
+		}
+		return NewLocal(cfg.Storage.LocalDir, cfg.Storage.PublicURL+LocalRoutePrefix, []byte(signingKey))
+	}
+
+	driver, err := NewMinio(cfg.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MinIO client: %w", err)
+	}
+	return driver, nil
+}
@@ -0,0 +1,419 @@
+package driver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LocalRoutePrefix 本地存储签名地址的路由前缀，需与路由注册保持一致
+const LocalRoutePrefix = "/api/storage/files"
+
+// MaxLocalUploadSize 通过签名地址单次上传的最大字节数，与 S3 单次 PUT 的上限一致
+const MaxLocalUploadSize = 5 << 30
+
+// MaxPartNumber 分片号的上限，与 S3 一致
+const MaxPartNumber = 10000
+
+const (
+	// localTempDir 写入中的临时文件目录，完成后重命名到对象路径，保证读取方不会看到写了一半的对象
+	localTempDir = ".tmp"
+	// localMultipartDir 分片上传的目录，每个上传 ID 一个子目录，分片以分片号命名
+	localMultipartDir = ".multipart"
+)
+
+var (
+	// ErrInvalidKey 是对象键不是合法相对路径时返回的哨兵错误
+	ErrInvalidKey = errors.New("invalid object key")
+	// ErrInvalidSignature 是签名地址的签名不匹配时返回的哨兵错误
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrURLExpired 是签名地址已过期时返回的哨兵错误
+	ErrURLExpired = errors.New("signed URL has expired")
+	// ErrInvalidPart 是分片号或上传 ID 不合法时返回的哨兵错误
+	ErrInvalidPart = errors.New("invalid part")
+)
+
+// Local 将对象保存在本地目录中的驱动，上传和下载地址指向 API 自身，用 HMAC 签名并带过期时间
+type Local struct {
+	root       string
+	baseURL    string
+	signingKey []byte
+}
+
+// NewLocal 创建本地存储驱动，baseURL 为签名地址的前缀（包含 LocalRoutePrefix）
+func NewLocal(root string, baseURL string, signingKey []byte) (*Local, error) {
+	if len(signingKey) == 0 {
+		return nil, errors.New("local storage requires a signing key")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve local storage directory: %w", err)
+	}
+	return &Local{
+		root:       absRoot,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		signingKey: signingKey,
+	}, nil
+}
+
+func (l *Local) EnsureBucket(ctx context.Context) error {
+	for _, dir := range []string{localTempDir, localMultipartDir} {
+		if err := os.MkdirAll(filepath.Join(l.root, dir), 0o755); err != nil {
+			return fmt.Errorf("failed to create local storage directory: %w", err)
+		}
+	}
+	return nil
+}
+
+func (l *Local) PresignPut(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return l.presign(http.MethodPut, key, nil, expiry)
+}
+
+func (l *Local) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return l.presign(http.MethodGet, key, nil, expiry)
+}
+
+// Stat 返回对象大小，ETag 为读取整个文件计算出的 MD5
+func (l *Local) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	f, err := l.Open(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	hash := md5.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to read object: %w", err)
+	}
+	return ObjectInfo{Key: key, Size: size, ETag: hex.EncodeToString(hash.Sum(nil)), LastModified: info.ModTime()}, nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return l.Open(key)
+}
+
+// Put 写入对象，size 为 -1 时读到 r 结束，否则读取的字节数必须等于 size
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	return l.writeFile(path, func(w io.Writer) error {
+		written, err := io.Copy(w, r)
+		if err != nil {
+			return err
+		}
+		if size >= 0 && written != size {
+			return fmt.Errorf("object size mismatch: expected %d bytes, got %d", size, written)
+		}
+		return nil
+	})
+}
+
+// writeFile 先写入临时文件，成功后重命名到 path
+func (l *Local) writeFile(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Join(l.root, localTempDir), "upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	err = write(tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create object directory: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save object: %w", err)
+	}
+	return nil
+}
+
+func (l *Local) Remove(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	// 删除变空的上级目录，非空目录删除失败时停止
+	for dir := filepath.Dir(path); dir != l.root; dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// List 遍历存储目录中的对象，跳过临时文件，返回的对象不计算 ETag
+func (l *Local) List(ctx context.Context, fn func(ObjectInfo) error) error {
+	return filepath.WalkDir(l.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path == l.root {
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(l.root, path)
+		if err != nil {
+			return err
+		}
+		return fn(ObjectInfo{Key: filepath.ToSlash(rel), Size: info.Size(), LastModified: info.ModTime()})
+	})
+}
+
+// CreateMultipartUpload 创建分片目录，上传 ID 为随机 UUID
+func (l *Local) CreateMultipartUpload(ctx context.Context, key string, contentType string) (string, error) {
+	if _, err := l.path(key); err != nil {
+		return "", err
+	}
+	uploadID := uuid.NewString()
+	if err := os.MkdirAll(filepath.Join(l.root, localMultipartDir, uploadID), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+	return uploadID, nil
+}
+
+// PresignPart 生成分片上传地址，上传 ID 和分片号包含在签名中
+func (l *Local) PresignPart(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration) (string, error) {
+	params := url.Values{}
+	params.Set("partNumber", strconv.Itoa(partNumber))
+	params.Set("uploadId", uploadID)
+	return l.presign(http.MethodPut, key, params, expiry)
+}
+
+// PutPart 保存一个分片，重复上传同一分片时覆盖
+func (l *Local) PutPart(ctx context.Context, uploadID string, partNumber int, r io.Reader, size int64) error {
+	dir, err := l.multipartDir(uploadID)
+	if err != nil {
+		return err
+	}
+	if partNumber < 1 || partNumber > MaxPartNumber {
+		return ErrInvalidPart
+	}
+	return l.writeFile(filepath.Join(dir, strconv.Itoa(partNumber)), func(w io.Writer) error {
+		written, err := io.Copy(w, r)
+		if err != nil {
+			return err
+		}
+		if size >= 0 && written != size {
+			return fmt.Errorf("part size mismatch: expected %d bytes, got %d", size, written)
+		}
+		return nil
+	})
+}
+
+func (l *Local) ListParts(ctx context.Context, key string, uploadID string) ([]Part, error) {
+	dir, err := l.multipartDir(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]Part, 0, len(entries))
+	for _, entry := range entries {
+		number, err := strconv.Atoi(entry.Name())
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, Part{Number: number, Size: info.Size()})
+	}
+	slices.SortFunc(parts, func(a, b Part) int { return a.Number - b.Number })
+	return parts, nil
+}
+
+// CompleteMultipartUpload 按 parts 的顺序拼接分片写入对象，然后删除分片目录
+func (l *Local) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []Part) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	dir, err := l.multipartDir(uploadID)
+	if err != nil {
+		return err
+	}
+
+	err = l.writeFile(path, func(w io.Writer) error {
+		for _, part := range parts {
+			if err := appendFile(w, filepath.Join(dir, strconv.Itoa(part.Number))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func (l *Local) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	dir, err := l.multipartDir(uploadID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// multipartDir 返回上传 ID 对应的分片目录，上传 ID 不存在时返回 ErrNotFound
+func (l *Local) multipartDir(uploadID string) (string, error) {
+	if uuid.Validate(uploadID) != nil {
+		return "", ErrInvalidPart
+	}
+	dir := filepath.Join(l.root, localMultipartDir, uploadID)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%w: multipart upload %s", ErrNotFound, uploadID)
+	}
+	return dir, nil
+}
+
+// appendFile 将文件内容写入 w
+func appendFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// Open 打开对象文件用于读取，对象不存在时返回 ErrNotFound
+func (l *Local) Open(key string) (*os.File, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+		return nil, err
+	}
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		f.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return f, nil
+}
+
+// Verify 校验签名地址的 expires 和 signature 参数，其余查询参数同样包含在签名中，method 为请求方法
+func (l *Local) Verify(method string, key string, query url.Values) error {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	params := url.Values{}
+	for name, values := range query {
+		if name != "expires" && name != "signature" {
+			params[name] = values
+		}
+	}
+	if !hmac.Equal(signature, l.sign(method, key, params, expires)) {
+		return ErrInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return ErrURLExpired
+	}
+	return nil
+}
+
+// presign 生成带过期时间和签名的地址，签名包含请求方法和 params，下载地址不能用于上传
+func (l *Local) presign(method string, key string, params url.Values, expiry time.Duration) (string, error) {
+	if _, err := l.path(key); err != nil {
+		return "", err
+	}
+	expires := time.Now().Add(expiry).Unix()
+
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	query := url.Values{}
+	for name, values := range params {
+		query[name] = values
+	}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", hex.EncodeToString(l.sign(method, key, params, expires)))
+	return l.baseURL + "/" + strings.Join(segments, "/") + "?" + query.Encode(), nil
+}
+
+func (l *Local) sign(method string, key string, params url.Values, expires int64) []byte {
+	mac := hmac.New(sha256.New, l.signingKey)
+	fmt.Fprintf(mac, "%s\n%s\n%d\n%s", method, key, expires, params.Encode())
+	return mac.Sum(nil)
+}
+
+// path 将对象键转换为存储目录中的文件路径
+// 对象键必须是以 / 分隔的相对路径，且任何一段都不能以 . 开头，避免越出存储目录或覆盖临时文件
+func (l *Local) path(key string) (string, error) {
+	if key == "" || strings.ContainsRune(key, '\\') {
+		return "", ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || strings.HasPrefix(segment, ".") {
+			return "", ErrInvalidKey
+		}
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,182 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/zeroicey/lifetrack-api/internal/config"
+)
+
+// Minio 基于 MinIO 客户端的驱动，适用于 MinIO 以及 AWS、腾讯云、阿里云等 S3 兼容的对象存储
+type Minio struct {
+	client *minio.Client
+	bucket string
+	region string
+}
+
+// NewMinio 创建 S3 兼容存储的驱动
+func NewMinio(cfg *config.StorageConfig) (*Minio, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		BucketLookup: minio.BucketLookupDNS,
+		Secure:       cfg.UseSSL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Minio{client: client, bucket: cfg.BucketName, region: cfg.Region}, nil
+}
+
+func (m *Minio) EnsureBucket(ctx context.Context) error {
+	exists, err := m.client.BucketExists(ctx, m.bucket)
+	if err != nil {
+		return fmt.Errorf("failed to check if bucket exists: %w", err)
+	}
+	if !exists {
+		err = m.client.MakeBucket(ctx, m.bucket, minio.MakeBucketOptions{Region: m.region})
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *Minio) PresignPut(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	presignedURL, err := m.client.PresignedPutObject(ctx, m.bucket, key, expiry)
+	if err != nil {
+		return "", err
+	}
+	return presignedURL.String(), nil
+}
+
+func (m *Minio) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	presignedURL, err := m.client.PresignedGetObject(ctx, m.bucket, key, expiry, nil)
+	if err != nil {
+		return "", err
+	}
+	return presignedURL.String(), nil
+}
+
+func (m *Minio) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	info, err := m.client.StatObject(ctx, m.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, toDriverError(err)
+	}
+	// 移除ETag中的引号（如果存在）
+	return ObjectInfo{Key: info.Key, Size: info.Size, ETag: strings.Trim(info.ETag, `"`), LastModified: info.LastModified}, nil
+}
+
+func (m *Minio) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	// GetObject 不会请求服务端，先 Stat 一次以便对象不存在时返回 ErrNotFound
+	object, err := m.client.GetObject(ctx, m.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, toDriverError(err)
+	}
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, toDriverError(err)
+	}
+	return object, nil
+}
+
+func (m *Minio) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := m.client.PutObject(ctx, m.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (m *Minio) Remove(ctx context.Context, key string) error {
+	return m.client.RemoveObject(ctx, m.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (m *Minio) List(ctx context.Context, fn func(ObjectInfo) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for object := range m.client.ListObjects(ctx, m.bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return object.Err
+		}
+		err := fn(ObjectInfo{
+			Key:          object.Key,
+			Size:         object.Size,
+			ETag:         strings.Trim(object.ETag, `"`),
+			LastModified: object.LastModified,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (m *Minio) CreateMultipartUpload(ctx context.Context, key string, contentType string) (string, error) {
+	core := minio.Core{Client: m.client}
+	return core.NewMultipartUpload(ctx, m.bucket, key, minio.PutObjectOptions{ContentType: contentType})
+}
+
+func (m *Minio) PresignPart(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration) (string, error) {
+	params := url.Values{}
+	params.Set("partNumber", strconv.Itoa(partNumber))
+	params.Set("uploadId", uploadID)
+	presignedURL, err := m.client.Presign(ctx, "PUT", m.bucket, key, expiry, params)
+	if err != nil {
+		return "", err
+	}
+	return presignedURL.String(), nil
+}
+
+func (m *Minio) ListParts(ctx context.Context, key string, uploadID string) ([]Part, error) {
+	core := minio.Core{Client: m.client}
+	var parts []Part
+	marker := 0
+	for {
+		result, err := core.ListObjectParts(ctx, m.bucket, key, uploadID, marker, 1000)
+		if err != nil {
+			return nil, toDriverError(err)
+		}
+		for _, part := range result.ObjectParts {
+			parts = append(parts, Part{Number: part.PartNumber, Size: part.Size, ETag: part.ETag})
+		}
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+func (m *Minio) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []Part) error {
+	core := minio.Core{Client: m.client}
+	completeParts := make([]minio.CompletePart, len(parts))
+	for i, part := range parts {
+		completeParts[i] = minio.CompletePart{PartNumber: part.Number, ETag: part.ETag}
+	}
+	_, err := core.CompleteMultipartUpload(ctx, m.bucket, key, uploadID, completeParts, minio.PutObjectOptions{})
+	return toDriverError(err)
+}
+
+func (m *Minio) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	core := minio.Core{Client: m.client}
+	err := core.AbortMultipartUpload(ctx, m.bucket, key, uploadID)
+	if err != nil && minio.ToErrorResponse(err).Code == "NoSuchUpload" {
+		return nil
+	}
+	return err
+}
+
+// toDriverError 将对象或分片上传不存在的错误转换为 ErrNotFound
+func toDriverError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if code := minio.ToErrorResponse(err).Code; code == "NoSuchKey" || code == "NoSuchUpload" {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage/driver"
+	response "github.com/zeroicey/lifetrack-api/internal/pkg"
+	"go.uber.org/zap"
+)
+
+// LocalFileHandler 处理本地存储的签名上传和下载地址，请求通过 URL 签名认证，不需要 JWT
+type LocalFileHandler struct {
+	local  *driver.Local
+	logger *zap.Logger
+}
+
+// LocalFileRouter 本地存储签名地址的路由，挂载在 driver.LocalRoutePrefix 下
+func LocalFileRouter(local *driver.Local, logger *zap.Logger) chi.Router {
+	h := &LocalFileHandler{local: local, logger: logger}
+	r := chi.NewRouter()
+	r.Get("/*", h.Download)
+	r.Head("/*", h.Download)
+	r.Put("/*", h.Upload)
+	return r
+}
+
+// Download 返回对象内容，支持 Range 请求
+func (h *LocalFileHandler) Download(w http.ResponseWriter, r *http.Request) {
+	key := localFileKey(r)
+	if err := h.local.Verify(http.MethodGet, key, r.URL.Query()); err != nil {
+		writeLocalFileError(w, err)
+		return
+	}
+
+	f, err := h.local.Open(key)
+	if err != nil {
+		if !errors.Is(err, driver.ErrNotFound) {
+			h.logger.Error("Failed to open local object", zap.String("objectKey", key), zap.Error(err))
+		}
+		writeLocalFileError(w, err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		h.logger.Error("Failed to stat local object", zap.String("objectKey", key), zap.Error(err))
+		writeLocalFileError(w, err)
+		return
+	}
+	http.ServeContent(w, r, path.Base(key), info.ModTime(), f)
+}
+
+// Upload 保存请求体为对象内容或分片内容，与 S3 预签名 PUT 一样覆盖已有对象
+func (h *LocalFileHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	key := localFileKey(r)
+	if err := h.local.Verify(http.MethodPut, key, r.URL.Query()); err != nil {
+		writeLocalFileError(w, err)
+		return
+	}
+	if r.ContentLength > driver.MaxLocalUploadSize {
+		response.Error("Object is too large").SetStatusCode(http.StatusRequestEntityTooLarge).Build(w)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, driver.MaxLocalUploadSize)
+	var err error
+	if uploadID := r.URL.Query().Get("uploadId"); uploadID != "" {
+		// 分片上传，上传 ID 和分片号已包含在签名中
+		partNumber, _ := strconv.Atoi(r.URL.Query().Get("partNumber"))
+		err = h.local.PutPart(r.Context(), uploadID, partNumber, body, r.ContentLength)
+	} else {
+		err = h.local.Put(r.Context(), key, body, r.ContentLength, r.Header.Get("Content-Type"))
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			response.Error("Object is too large").SetStatusCode(http.StatusRequestEntityTooLarge).Build(w)
+			return
+		}
+		if !errors.Is(err, driver.ErrNotFound) && !errors.Is(err, driver.ErrInvalidPart) {
+			h.logger.Error("Failed to save local object", zap.String("objectKey", key), zap.Error(err))
+		}
+		writeLocalFileError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// localFileKey 从请求路径中取出对象键
+func localFileKey(r *http.Request) string {
+	return strings.TrimPrefix(r.URL.Path, driver.LocalRoutePrefix+"/")
+}
+
+func writeLocalFileError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, driver.ErrInvalidSignature), errors.Is(err, driver.ErrURLExpired):
+		response.Error(err.Error()).SetStatusCode(http.StatusForbidden).Build(w)
+	case errors.Is(err, driver.ErrInvalidKey), errors.Is(err, driver.ErrInvalidPart):
+		response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+	case errors.Is(err, driver.ErrNotFound):
+		response.Error("Object not found").SetStatusCode(http.StatusNotFound).Build(w)
+	default:
+		response.Error("Storage error").SetStatusCode(http.StatusInternalServerError).Build(w)
+	}
+}
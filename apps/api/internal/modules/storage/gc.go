@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage/driver"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage/types"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+// 附件被回收的原因
+const (
+	GCReasonAbandonedUpload = "abandoned_upload" // 上传超过宽限期仍未完成
+	GCReasonUnreferenced    = "unreferenced"     // 已完成，但没有被任何 moment 或历史版本引用
+)
+
+const (
+	// gcBatchSize 回收任务每次查询的附件数量
+	gcBatchSize = 100
+	// defaultGCGracePeriod 未配置宽限期时使用的默认值
+	defaultGCGracePeriod = 24 * time.Hour
+	// foreignKeyViolation 外键约束冲突的 SQLSTATE，附件在删除前被 moment 引用时返回
+	foreignKeyViolation = "23503"
+)
+
+// gcGracePeriod 附件和对象需要超过该时间没有被引用才会被回收
+// 附件按 updated_at 计时，去重命中和签发分片上传地址时都会刷新
+func (s *Service) gcGracePeriod() time.Duration {
+	if s.config.Storage.GCGracePeriod <= 0 {
+		return defaultGCGracePeriod
+	}
+	return time.Duration(s.config.Storage.GCGracePeriod) * time.Hour
+}
+
+// GarbageReport 预演回收任务，列出当前用户下次回收时会被删除的附件，不做任何删除
+func (s *Service) GarbageReport(ctx context.Context, userID int64) (types.GarbageReportResponse, error) {
+	gracePeriod := s.gcGracePeriod()
+	updatedBefore := pgtype.Timestamptz{}
+	updatedBefore.Scan(time.Now().Add(-gracePeriod))
+
+	attachments, err := s.Q.ListUserGarbageAttachments(ctx, repository.ListUserGarbageAttachmentsParams{
+		UserID:        userID,
+		UpdatedBefore: updatedBefore,
+	})
+	if err != nil {
+		return types.GarbageReportResponse{}, err
+	}
+
+	report := types.GarbageReportResponse{
+		GracePeriodHours: int(gracePeriod / time.Hour),
+		Attachments:      make([]types.GarbageAttachment, 0, len(attachments)),
+	}
+	for _, attachment := range attachments {
+		reason := GCReasonUnreferenced
+		if attachment.Status != "completed" {
+			reason = GCReasonAbandonedUpload
+		}
+		report.Attachments = append(report.Attachments, types.GarbageAttachment{
+			AttachmentID: attachment.ID.String(),
+			ObjectKey:    attachment.ObjectKey,
+			OriginalName: attachment.OriginalName,
+			Status:       attachment.Status,
+			FileSize:     attachment.FileSize,
+			Reason:       reason,
+			UpdatedAt:    attachment.UpdatedAt.Time.Format(time.RFC3339),
+		})
+		report.TotalSize += attachment.FileSize
+	}
+	report.Count = len(report.Attachments)
+	return report, nil
+}
+
+// CollectGarbage 回收超过宽限期的附件和孤立对象，由调度器定时调用
+// 先删除引用计数为 0 的附件记录及其对象，再清理存储桶中没有任何记录引用的对象
+func (s *Service) CollectGarbage(ctx context.Context) {
+	before := time.Now().Add(-s.gcGracePeriod())
+
+	attachments, objects := s.collectAttachments(ctx, before)
+	orphans := s.collectOrphanObjects(ctx, before)
+
+	if attachments > 0 || objects > 0 || orphans > 0 {
+		s.logger.Info("Storage garbage collected",
+			zap.Int("attachments", attachments),
+			zap.Int("objects", objects),
+			zap.Int("orphanObjects", orphans),
+		)
+	}
+}
+
+// collectAttachments 删除引用计数为 0 的附件，返回删除的记录数和对象数
+// 记录先于对象删除：删除记录时再次检查引用，被 moment_attachments 的 RESTRICT 外键拦下的附件跳过，
+// 对象删除失败时由 collectOrphanObjects 在之后的回收中清理
+func (s *Service) collectAttachments(ctx context.Context, before time.Time) (int, int) {
+	updatedBefore := pgtype.Timestamptz{}
+	updatedBefore.Scan(before)
+
+	deletedAttachments, deletedObjects := 0, 0
+	for ctx.Err() == nil {
+		candidates, err := s.Q.ListGarbageAttachments(ctx, repository.ListGarbageAttachmentsParams{
+			UpdatedBefore: updatedBefore,
+			BatchSize:     gcBatchSize,
+		})
+		if err != nil {
+			s.logger.Error("Failed to list garbage attachments", zap.Error(err))
+			break
+		}
+		if len(candidates) == 0 {
+			break
+		}
+
+		progressed := false
+		for _, candidate := range candidates {
+			attachment, err := s.Q.DeleteUnreferencedAttachment(ctx, repository.DeleteUnreferencedAttachmentParams{
+				ID:            candidate.ID,
+				UpdatedBefore: updatedBefore,
+			})
+			if err != nil {
+				var pgErr *pgconn.PgError
+				if !errors.Is(err, pgx.ErrNoRows) && !(errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation) {
+					s.logger.Error("Failed to delete garbage attachment", zap.String("attachmentId", candidate.ID.String()), zap.Error(err))
+				}
+				continue
+			}
+			progressed = true
+			deletedAttachments++
+
+			// 未合并的分片上传需要单独取消，已上传的分片不会出现在对象列表中
+			if attachment.UploadID != "" {
+				s.abortMultipartUpload(ctx, attachment.ObjectKey, attachment.UploadID)
+			}
+			for _, key := range attachmentObjectKeys(attachment.ObjectKey, attachment.CoverObjectKey, attachment.CoverSizes) {
+				if err := s.driver.Remove(ctx, key); err != nil {
+					s.logger.Warn("Failed to remove attachment object", zap.String("objectKey", key), zap.Error(err))
+					continue
+				}
+				deletedObjects++
+			}
+		}
+		// 整批都没有删除成功时停止，避免反复查询同一批附件
+		if !progressed {
+			break
+		}
+	}
+	return deletedAttachments, deletedObjects
+}
+
+// collectOrphanObjects 删除存储桶中超过宽限期且没有被任何附件或头像引用的对象，返回删除的数量
+// 只处理本服务生成的对象键，存储桶中的其他对象不受影响
+func (s *Service) collectOrphanObjects(ctx context.Context, before time.Time) int {
+	live, err := s.liveObjectKeys(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load live object keys", zap.Error(err))
+		return 0
+	}
+
+	deleted := 0
+	err = s.driver.List(ctx, func(object driver.ObjectInfo) error {
+		if !object.LastModified.Before(before) || !isManagedObjectKey(object.Key) {
+			return nil
+		}
+		if live[object.Key] || live[path.Dir(object.Key)] {
+			return nil
+		}
+		if err := s.driver.Remove(ctx, object.Key); err != nil {
+			s.logger.Warn("Failed to remove orphan object", zap.String("objectKey", object.Key), zap.Error(err))
+			return nil
+		}
+		deleted++
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		s.logger.Error("Failed to list objects", zap.Error(err))
+	}
+	return deleted
+}
+
+// liveObjectKeys 返回仍被引用的对象键，头像以头像前缀表示，其下各尺寸的对象都视为被引用
+func (s *Service) liveObjectKeys(ctx context.Context) (map[string]bool, error) {
+	attachments, err := s.Q.ListAttachmentObjectKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	avatarKeys, err := s.Q.ListUserAvatarKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	live := make(map[string]bool, len(attachments)*2+len(avatarKeys))
+	for _, attachment := range attachments {
+		for _, key := range attachmentObjectKeys(attachment.ObjectKey, attachment.CoverObjectKey, attachment.CoverSizes) {
+			live[key] = true
+		}
+	}
+	for _, avatarKey := range avatarKeys {
+		live[avatarKey] = true
+	}
+	return live, nil
+}
+
+// attachmentObjectKeys 附件使用的所有对象键：原文件、客户端上传的封面和服务端生成的各尺寸封面
+func attachmentObjectKeys(objectKey string, coverKey string, coverSizes []int32) []string {
+	keys := []string{objectKey}
+	if coverKey != "" && coverKey != objectKey {
+		keys = append(keys, coverKey)
+	}
+	for _, size := range coverSizes {
+		if key := coverObjectKey(objectKey, int(size)); key != coverKey {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// isManagedObjectKey 判断对象键是否由本服务生成：以 UUID 开头的附件对象，或 avatars/ 下的头像对象
+func isManagedObjectKey(key string) bool {
+	if strings.HasPrefix(key, "avatars/") {
+		return true
+	}
+	if strings.Contains(key, "/") || len(key) < 36 {
+		return false
+	}
+	_, err := uuid.Parse(key[:36])
+	return err == nil
+}
@@ -2,11 +2,13 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/zeroicey/lifetrack-api/internal/middleware"
 	"github.com/zeroicey/lifetrack-api/internal/modules/storage/types"
 	response "github.com/zeroicey/lifetrack-api/internal/pkg"
 )
@@ -27,16 +29,37 @@ func StorageRouter(s *Service, v *validator.Validate) chi.Router {
 	r.Post("/presigned/upload", h.GetPresignedUploadURL)
 	r.Get("/{attachmentID}/url", h.GetTemporaryAccessURL)
 	r.Get("/{attachmentID}/cover-url", h.GetTemporaryAccessCoverURL)
+
+	// 头像相关路由
+	r.Post("/avatar/presigned", h.CreateAvatarUpload)
+	r.Post("/avatar/completed", h.CompleteAvatarUpload)
+	r.Delete("/avatar", h.DeleteAvatar)
+
+	// 大文件分片上传
+	r.Post("/multipart", h.CreateMultipartUpload)
+	r.Get("/multipart/{attachmentID}", h.GetMultipartUpload)
+	r.Post("/multipart/{attachmentID}/parts", h.PresignMultipartParts)
+	r.Post("/multipart/{attachmentID}/complete", h.CompleteMultipartUpload)
+	r.Delete("/multipart/{attachmentID}", h.AbortMultipartUpload)
+
+	// 附件回收的预演报告
+	r.Get("/gc/report", h.GetGarbageReport)
 	return r
 }
 
 func (h *Handler) GetPresignedUploadURL(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	var bodies []types.PresignedUploadRequest
 	if err := json.NewDecoder(r.Body).Decode(&bodies); err != nil {
 		response.Error("Invalid request body").SetStatusCode(http.StatusBadRequest).Build(w)
 		return
 	}
-	result, err := h.S.CreateUploadRequest(r.Context(), &bodies)
+	result, err := h.S.CreateUploadRequest(r.Context(), userID, &bodies)
 	if err != nil {
 		response.Error("Failed to create upload request: " + err.Error()).SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
@@ -45,6 +68,12 @@ func (h *Handler) GetPresignedUploadURL(w http.ResponseWriter, r *http.Request)
 	response.Success("Presigned upload URL generated successfully").SetData(result).Build(w)
 }
 func (h *Handler) CompleteUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	// 1. 从URL路径中提取 attachmentID
 	attachmentIDStr := chi.URLParam(r, "attachmentID")
 	attachmentID, err := uuid.Parse(attachmentIDStr)
@@ -54,7 +83,7 @@ func (h *Handler) CompleteUpload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2. 调用 Service 层的方法来处理业务逻辑
-	err = h.S.CompleteUpload(r.Context(), attachmentID)
+	err = h.S.CompleteUpload(r.Context(), userID, attachmentID)
 	if err != nil {
 		// 这里的错误处理可以更精细，比如判断是否是 "not found" 错误
 		response.Error("Failed to complete upload: " + err.Error()).SetStatusCode(http.StatusInternalServerError).Build(w)
@@ -64,6 +93,12 @@ func (h *Handler) CompleteUpload(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetTemporaryAccessURL(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	// 1. 从路径参数中解析附件ID
 	attachmentIDStr := chi.URLParam(r, "attachmentID")
 	attachmentID, err := uuid.Parse(attachmentIDStr)
@@ -72,12 +107,14 @@ func (h *Handler) GetTemporaryAccessURL(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	// 2. 调用Service层获取URL
-	url, err := h.S.GeneratePresignedGetURL(r.Context(), attachmentID)
+	url, err := h.S.GeneratePresignedGetURL(r.Context(), userID, attachmentID)
 	if err != nil {
 		// 这里可以根据 service 层返回的错误类型来设置更精确的状态码
 		// 例如，如果是 "not found"，则返回 404
 		if err.Error() == "attachment not found or not completed" {
 			response.Error(err.Error()).SetStatusCode(http.StatusNotFound).Build(w)
+		} else if errors.Is(err, ErrCoverNotReady) {
+			response.Error(err.Error()).SetStatusCode(http.StatusConflict).Build(w)
 		} else {
 			response.Error("Failed to generate access URL: " + err.Error()).SetStatusCode(http.StatusInternalServerError).Build(w)
 		}
@@ -91,6 +128,12 @@ func (h *Handler) GetTemporaryAccessURL(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *Handler) GetTemporaryAccessCoverURL(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	// 1. 从路径参数中解析附件ID
 	attachmentIDStr := chi.URLParam(r, "attachmentID")
 	attachmentID, err := uuid.Parse(attachmentIDStr)
@@ -99,7 +142,7 @@ func (h *Handler) GetTemporaryAccessCoverURL(w http.ResponseWriter, r *http.Requ
 		return
 	}
 	// 2. 调用Service层获取URL
-	url, err := h.S.GeneratePresignedGetCoverURL(r.Context(), attachmentID)
+	url, err := h.S.GeneratePresignedGetCoverURL(r.Context(), userID, attachmentID)
 	if err != nil {
 		// 这里可以根据 service 层返回的错误类型来设置更精确的状态码
 		// 例如，如果是 "not found"，则返回 404
@@ -116,3 +159,239 @@ func (h *Handler) GetTemporaryAccessCoverURL(w http.ResponseWriter, r *http.Requ
 	}
 	response.Success("Temporary access URL generated successfully").SetData(responseData).Build(w)
 }
+
+// CreateAvatarUpload 获取上传头像原图的预签名地址
+func (h *Handler) CreateAvatarUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body types.AvatarUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Invalid request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	result, err := h.S.CreateAvatarUpload(r.Context(), userID, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidAvatar):
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		case errors.Is(err, ErrAvatarTooLarge):
+			response.Error(err.Error()).SetStatusCode(http.StatusRequestEntityTooLarge).Build(w)
+		default:
+			response.Error("Failed to create avatar upload: " + err.Error()).SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+	response.Success("Presigned avatar upload URL generated successfully").SetData(result).Build(w)
+}
+
+// CompleteAvatarUpload 处理已上传的头像原图，生成各尺寸头像并设为当前用户头像
+func (h *Handler) CompleteAvatarUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body types.CompleteAvatarUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ObjectKey == "" {
+		response.Error("Invalid request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	result, err := h.S.CompleteAvatarUpload(r.Context(), userID, body.ObjectKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAvatarUploadNotFound):
+			response.Error(err.Error()).SetStatusCode(http.StatusNotFound).Build(w)
+		case errors.Is(err, ErrInvalidAvatar):
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		case errors.Is(err, ErrAvatarTooLarge):
+			response.Error(err.Error()).SetStatusCode(http.StatusRequestEntityTooLarge).Build(w)
+		default:
+			response.Error("Failed to process avatar: " + err.Error()).SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+	response.Success("Avatar updated successfully").SetData(result).Build(w)
+}
+
+// DeleteAvatar 删除当前用户头像
+func (h *Handler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	if err := h.S.DeleteAvatar(r.Context(), userID); err != nil {
+		response.Error("Failed to delete avatar: " + err.Error()).SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+	response.Success("Avatar deleted successfully").Build(w)
+}
+
+// GetGarbageReport 预演附件回收，列出当前用户下次回收时会被删除的附件
+func (h *Handler) GetGarbageReport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	report, err := h.S.GarbageReport(r.Context(), userID)
+	if err != nil {
+		response.Error("Failed to build garbage report: " + err.Error()).SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+	response.Success("Garbage report generated successfully").SetData(report).Build(w)
+}
+
+// CreateMultipartUpload 开始分片上传，返回分片大小和分片数量
+func (h *Handler) CreateMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body types.MultipartUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Invalid request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if err := h.Validate.Struct(body); err != nil {
+		response.Error("Validation failed: " + err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	result, err := h.S.CreateMultipartUpload(r.Context(), userID, body)
+	if err != nil {
+		response.Error("Failed to create multipart upload: " + err.Error()).SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+	response.Success("Multipart upload created successfully").SetData(result).Build(w)
+}
+
+// GetMultipartUpload 返回已上传的分片，客户端中断后据此续传缺失的分片
+func (h *Handler) GetMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	attachmentID, err := uuid.Parse(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		response.Error("Invalid attachment ID format").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	result, err := h.S.GetMultipartUpload(r.Context(), userID, attachmentID)
+	if err != nil {
+		writeMultipartError(w, "Failed to get multipart upload: ", err)
+		return
+	}
+	response.Success("Multipart upload retrieved successfully").SetData(result).Build(w)
+}
+
+// PresignMultipartParts 获取指定分片的上传地址
+func (h *Handler) PresignMultipartParts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	attachmentID, err := uuid.Parse(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		response.Error("Invalid attachment ID format").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	var body types.MultipartPartsRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Invalid request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if err := h.Validate.Struct(body); err != nil {
+		response.Error("Validation failed: " + err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	result, err := h.S.PresignMultipartParts(r.Context(), userID, attachmentID, body.PartNumbers)
+	if err != nil {
+		writeMultipartError(w, "Failed to generate part upload URLs: ", err)
+		return
+	}
+	response.Success("Part upload URLs generated successfully").SetData(result).Build(w)
+}
+
+// CompleteMultipartUpload 合并分片，SHA-256 由后台任务校验，校验未完成时返回 202
+func (h *Handler) CompleteMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	attachmentID, err := uuid.Parse(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		response.Error("Invalid attachment ID format").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	status, err := h.S.CompleteMultipartUpload(r.Context(), userID, attachmentID)
+	if err != nil {
+		writeMultipartError(w, "Failed to complete multipart upload: ", err)
+		return
+	}
+	if status == AttachmentStatusVerifying {
+		response.Success("Upload is being verified").SetStatusCode(http.StatusAccepted).SetData(types.MultipartCompleteResponse{
+			AttachmentID: attachmentID.String(),
+			Status:       status,
+		}).Build(w)
+		return
+	}
+	response.Success("Upload completed successfully").SetStatusCode(http.StatusNoContent).Build(w)
+}
+
+// AbortMultipartUpload 取消分片上传并删除已上传的分片
+func (h *Handler) AbortMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	attachmentID, err := uuid.Parse(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		response.Error("Invalid attachment ID format").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	if err := h.S.AbortMultipartUpload(r.Context(), userID, attachmentID); err != nil {
+		writeMultipartError(w, "Failed to abort multipart upload: ", err)
+		return
+	}
+	response.Success("Multipart upload aborted successfully").Build(w)
+}
+
+func writeMultipartError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, ErrMultipartUploadNotFound):
+		response.Error(err.Error()).SetStatusCode(http.StatusNotFound).Build(w)
+	case errors.Is(err, ErrUploadCompleted):
+		response.Error(err.Error()).SetStatusCode(http.StatusConflict).Build(w)
+	case errors.Is(err, ErrInvalidPartNumber), errors.Is(err, ErrIncompleteUpload):
+		response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+	case errors.Is(err, ErrChecksumMismatch):
+		response.Error(err.Error()).SetStatusCode(http.StatusUnprocessableEntity).Build(w)
+	default:
+		response.Error(message + err.Error()).SetStatusCode(http.StatusInternalServerError).Build(w)
+	}
+}
@@ -0,0 +1,287 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage/driver"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage/types"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// multipartPartSize 默认分片大小，文件过大导致分片数量超过上限时按上限均分
+	multipartPartSize = 16 << 20
+	// maxMultipartParts 分片数量上限，与 S3 一致
+	maxMultipartParts = driver.MaxPartNumber
+)
+
+var (
+	// ErrMultipartUploadNotFound 是分片上传不存在、不属于当前用户或已被取消时返回的哨兵错误
+	ErrMultipartUploadNotFound = errors.New("multipart upload not found")
+	// ErrUploadCompleted 是上传已经完成，不能再上传分片时返回的哨兵错误
+	ErrUploadCompleted = errors.New("upload is already completed")
+	// ErrInvalidPartNumber 是分片号超出分片数量时返回的哨兵错误
+	ErrInvalidPartNumber = errors.New("invalid part number")
+	// ErrIncompleteUpload 是合并时分片缺失或分片大小不符时返回的哨兵错误
+	ErrIncompleteUpload = errors.New("upload is incomplete")
+	// ErrChecksumMismatch 是后台校验发现合并后文件的 SHA-256 或大小与开始上传时声明的不一致时返回的哨兵错误，需取消后重新上传
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// multipartLayout 按文件大小计算分片大小和分片数量
+func multipartLayout(fileSize int64) (int64, int) {
+	partSize := max(int64(multipartPartSize), (fileSize+maxMultipartParts-1)/maxMultipartParts)
+	return partSize, int((fileSize + partSize - 1) / partSize)
+}
+
+// CreateMultipartUpload 开始分片上传，已上传过相同 SHA-256 的文件时直接返回已有的附件
+func (s *Service) CreateMultipartUpload(ctx context.Context, userID int64, body types.MultipartUploadRequest) (types.MultipartUploadResponse, error) {
+	checksum := strings.ToLower(body.SHA256)
+	existing, err := s.Q.FindCompletedAttachmentBySHA256(ctx, repository.FindCompletedAttachmentBySHA256Params{
+		UserID: userID,
+		Sha256: checksum,
+	})
+	if err == nil {
+		// 已有附件即将被新的 moment 引用，刷新 updated_at，避免在引用前被回收
+		if err := s.Q.TouchAttachment(ctx, existing.ID); err != nil {
+			return types.MultipartUploadResponse{}, err
+		}
+		return types.MultipartUploadResponse{
+			AttachmentID: existing.ID.String(),
+			ObjectKey:    existing.ObjectKey,
+			IsDuplicate:  true,
+			CoverStatus:  existing.CoverStatus,
+		}, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return types.MultipartUploadResponse{}, err
+	}
+
+	objectKey := uuid.NewString() + filepath.Ext(body.FileName)
+	uploadID, err := s.driver.CreateMultipartUpload(ctx, objectKey, body.MimeType)
+	if err != nil {
+		return types.MultipartUploadResponse{}, fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+
+	partSize, partCount := multipartLayout(body.FileSize)
+	attachment, err := s.Q.CreateMultipartAttachment(ctx, repository.CreateMultipartAttachmentParams{
+		ObjectKey:    objectKey,
+		OriginalName: body.FileName,
+		MimeType:     body.MimeType,
+		FileSize:     body.FileSize,
+		UserID:       userID,
+		CoverStatus:  initialCoverStatus(body.MimeType, false),
+		Sha256:       checksum,
+		UploadID:     uploadID,
+		PartSize:     partSize,
+	})
+	if err != nil {
+		s.abortMultipartUpload(ctx, objectKey, uploadID)
+		return types.MultipartUploadResponse{}, fmt.Errorf("failed to create attachment record: %w", err)
+	}
+
+	return types.MultipartUploadResponse{
+		AttachmentID: attachment.ID.String(),
+		ObjectKey:    attachment.ObjectKey,
+		PartSize:     partSize,
+		PartCount:    partCount,
+		CoverStatus:  attachment.CoverStatus,
+	}, nil
+}
+
+// GetMultipartUpload 返回分片上传的进度，分片已经合并时所有分片都视为已上传
+func (s *Service) GetMultipartUpload(ctx context.Context, userID int64, attachmentID uuid.UUID) (types.MultipartUploadStatusResponse, error) {
+	attachment, err := s.getMultipartAttachment(ctx, userID, attachmentID)
+	if err != nil {
+		return types.MultipartUploadStatusResponse{}, err
+	}
+
+	_, partCount := multipartLayout(attachment.FileSize)
+	resp := types.MultipartUploadStatusResponse{
+		AttachmentID:  attachment.ID.String(),
+		Status:        attachment.Status,
+		PartSize:      attachment.PartSize,
+		PartCount:     partCount,
+		UploadedParts: make([]types.UploadedPart, 0, partCount),
+	}
+
+	if attachment.UploadID == "" {
+		for number := 1; number <= partCount; number++ {
+			resp.UploadedParts = append(resp.UploadedParts, types.UploadedPart{
+				PartNumber: number,
+				Size:       expectedPartSize(attachment, number, partCount),
+			})
+		}
+		return resp, nil
+	}
+
+	parts, err := s.driver.ListParts(ctx, attachment.ObjectKey, attachment.UploadID)
+	if err != nil {
+		if errors.Is(err, driver.ErrNotFound) {
+			return types.MultipartUploadStatusResponse{}, ErrMultipartUploadNotFound
+		}
+		return types.MultipartUploadStatusResponse{}, fmt.Errorf("failed to list uploaded parts: %w", err)
+	}
+	for _, part := range parts {
+		resp.UploadedParts = append(resp.UploadedParts, types.UploadedPart{PartNumber: part.Number, Size: part.Size})
+	}
+	return resp, nil
+}
+
+// PresignMultipartParts 为指定的分片生成上传地址，重新上传已上传的分片会覆盖原内容
+func (s *Service) PresignMultipartParts(ctx context.Context, userID int64, attachmentID uuid.UUID, partNumbers []int) ([]types.MultipartPartURL, error) {
+	attachment, err := s.getMultipartAttachment(ctx, userID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.Status != AttachmentStatusUploading || attachment.UploadID == "" {
+		return nil, ErrUploadCompleted
+	}
+
+	_, partCount := multipartLayout(attachment.FileSize)
+	expiry := time.Duration(s.config.Storage.PresignedExpiry) * time.Minute
+	urls := make([]types.MultipartPartURL, 0, len(partNumbers))
+	for _, number := range partNumbers {
+		if number < 1 || number > partCount {
+			return nil, fmt.Errorf("%w: %d (expected 1-%d)", ErrInvalidPartNumber, number, partCount)
+		}
+		uploadURL, err := s.driver.PresignPart(ctx, attachment.ObjectKey, attachment.UploadID, number, expiry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate presigned URL: %w", err)
+		}
+		urls = append(urls, types.MultipartPartURL{PartNumber: number, UploadURL: uploadURL})
+	}
+
+	// 上传分片不会更新附件记录，每次签发地址时刷新 updated_at，仍在上传的大文件不会超过宽限期被回收
+	if err := s.Q.TouchAttachment(ctx, attachment.ID); err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
+
+// CompleteMultipartUpload 检查分片是否齐全并合并，合并后标记为 verifying，由 VerifyWorker 在后台读取对象校验 SHA-256
+// 分片上传的对象 ETag 不是 MD5，不能像单次上传那样比较 ETag，大文件也不适合在请求中读取整个对象
+// 返回附件当前的状态，重复调用不会再次合并，客户端通过 GetMultipartUpload 查询校验结果
+func (s *Service) CompleteMultipartUpload(ctx context.Context, userID int64, attachmentID uuid.UUID) (string, error) {
+	attachment, err := s.getMultipartAttachment(ctx, userID, attachmentID)
+	if err != nil {
+		return "", err
+	}
+	switch attachment.Status {
+	case AttachmentStatusCompleted:
+		return attachment.Status, nil
+	case AttachmentStatusVerifying:
+		s.notifyVerifyWorker()
+		return attachment.Status, nil
+	case AttachmentStatusFailed:
+		return "", fmt.Errorf("%w for attachment %s", ErrChecksumMismatch, attachmentID.String())
+	}
+	logger := s.logger.With(zap.String("attachmentId", attachmentID.String()), zap.String("objectKey", attachment.ObjectKey))
+
+	if attachment.UploadID != "" {
+		parts, err := s.driver.ListParts(ctx, attachment.ObjectKey, attachment.UploadID)
+		if err != nil {
+			if errors.Is(err, driver.ErrNotFound) {
+				return "", ErrMultipartUploadNotFound
+			}
+			return "", fmt.Errorf("failed to list uploaded parts: %w", err)
+		}
+		if err := checkParts(attachment, parts); err != nil {
+			return "", err
+		}
+
+		if err := s.driver.CompleteMultipartUpload(ctx, attachment.ObjectKey, attachment.UploadID, parts); err != nil {
+			logger.Error("Failed to complete multipart upload", zap.Error(err))
+			return "", fmt.Errorf("failed to complete multipart upload: %w", err)
+		}
+	}
+
+	if err := s.Q.MarkAttachmentVerifying(ctx, attachment.ID); err != nil {
+		return "", fmt.Errorf("could not update attachment status for ID %s: %w", attachmentID.String(), err)
+	}
+	logger.Info("Multipart upload merged, waiting for verification")
+
+	s.notifyVerifyWorker()
+	return AttachmentStatusVerifying, nil
+}
+
+// AbortMultipartUpload 取消未完成的分片上传，删除已上传的分片、合并后的对象和附件记录
+// 已被 moment 引用的附件不能取消
+func (s *Service) AbortMultipartUpload(ctx context.Context, userID int64, attachmentID uuid.UUID) error {
+	attachment, err := s.Q.DeleteUploadingAttachment(ctx, repository.DeleteUploadingAttachmentParams{
+		ID:     pkg.UUIDToPgUUID(attachmentID),
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrMultipartUploadNotFound
+		}
+		return err
+	}
+
+	if attachment.UploadID != "" {
+		s.abortMultipartUpload(ctx, attachment.ObjectKey, attachment.UploadID)
+	}
+	s.removeObject(ctx, attachment.ObjectKey)
+	return nil
+}
+
+// getMultipartAttachment 获取当前用户的分片上传附件，单次上传的附件视为不存在
+func (s *Service) getMultipartAttachment(ctx context.Context, userID int64, attachmentID uuid.UUID) (repository.Attachment, error) {
+	attachment, err := s.Q.GetAttachmentById(ctx, repository.GetAttachmentByIdParams{
+		ID:     pkg.UUIDToPgUUID(attachmentID),
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.Attachment{}, ErrMultipartUploadNotFound
+		}
+		return repository.Attachment{}, err
+	}
+	if attachment.PartSize == 0 {
+		return repository.Attachment{}, ErrMultipartUploadNotFound
+	}
+	return attachment, nil
+}
+
+// abortMultipartUpload 取消分片上传，失败只记录日志
+func (s *Service) abortMultipartUpload(ctx context.Context, objectKey string, uploadID string) {
+	if err := s.driver.AbortMultipartUpload(ctx, objectKey, uploadID); err != nil {
+		s.logger.Warn("Failed to abort multipart upload", zap.String("objectKey", objectKey), zap.Error(err))
+	}
+}
+
+// checkParts 检查分片是否齐全：分片号连续，除最后一个分片外大小都等于 part_size
+func checkParts(attachment repository.Attachment, parts []driver.Part) error {
+	_, partCount := multipartLayout(attachment.FileSize)
+	if len(parts) != partCount {
+		return fmt.Errorf("%w: %d of %d parts uploaded", ErrIncompleteUpload, len(parts), partCount)
+	}
+	for i, part := range parts {
+		number := i + 1
+		if part.Number != number {
+			return fmt.Errorf("%w: part %d is missing", ErrIncompleteUpload, number)
+		}
+		if expected := expectedPartSize(attachment, number, partCount); part.Size != expected {
+			return fmt.Errorf("%w: part %d has %d bytes, expected %d", ErrIncompleteUpload, number, part.Size, expected)
+		}
+	}
+	return nil
+}
+
+// expectedPartSize 第 number 个分片应有的大小
+func expectedPartSize(attachment repository.Attachment, number int, partCount int) int64 {
+	if number < partCount {
+		return attachment.PartSize
+	}
+	return attachment.FileSize - int64(partCount-1)*attachment.PartSize
+}
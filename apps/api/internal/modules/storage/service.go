@@ -5,13 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/minio/minio-go/v7"
 	"github.com/zeroicey/lifetrack-api/internal/config"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage/driver"
 	"github.com/zeroicey/lifetrack-api/internal/modules/storage/types"
 	"github.com/zeroicey/lifetrack-api/internal/pkg"
 	"github.com/zeroicey/lifetrack-api/internal/repository"
@@ -19,40 +18,38 @@ import (
 )
 
 type Service struct {
-	Q      *repository.Queries
-	DB     *pgxpool.Pool
-	logger *zap.Logger
-	client *minio.Client
-	config *config.Config
+	Q          *repository.Queries
+	DB         *pgxpool.Pool
+	logger     *zap.Logger
+	driver     driver.Driver
+	config     *config.Config
+	coverWake  chan struct{} // 上传完成后唤醒 CoverWorker
+	verifyWake chan struct{} // 分片合并后唤醒 VerifyWorker
 }
 
-func NewService(db *pgxpool.Pool, q *repository.Queries, client *minio.Client, logger *zap.Logger, config *config.Config) *Service {
+func NewService(db *pgxpool.Pool, q *repository.Queries, storageDriver driver.Driver, logger *zap.Logger, config *config.Config) *Service {
 
 	return &Service{
-		DB:     db,
-		Q:      q,
-		client: client,
-		logger: logger,
-		config: config,
+		DB:         db,
+		Q:          q,
+		driver:     storageDriver,
+		logger:     logger,
+		config:     config,
+		coverWake:  make(chan struct{}, 1),
+		verifyWake: make(chan struct{}, 1),
 	}
 }
 
 func (s *Service) EnsureBucketExists(ctx context.Context) error {
-	bucketName := s.config.Storage.BucketName
-	exists, err := s.client.BucketExists(ctx, bucketName)
-	if err != nil {
-		return fmt.Errorf("failed to check if bucket exists: %w", err)
-	}
-	if !exists {
-		err = s.client.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{Region: s.config.Storage.Region})
-		if err != nil {
-			return fmt.Errorf("failed to create bucket: %w", err)
-		}
-	}
-	return nil
+	return s.driver.EnsureBucket(ctx)
+}
+
+// Driver 返回对象存储驱动
+func (s *Service) Driver() driver.Driver {
+	return s.driver
 }
 
-func (s *Service) CreateUploadRequest(ctx context.Context, bodies *[]types.PresignedUploadRequest) ([]types.PresignedUploadResponse, error) {
+func (s *Service) CreateUploadRequest(ctx context.Context, userID int64, bodies *[]types.PresignedUploadRequest) ([]types.PresignedUploadResponse, error) {
 	tx, err := s.DB.Begin(ctx)
 	if err != nil {
 		return nil, err
@@ -65,19 +62,32 @@ func (s *Service) CreateUploadRequest(ctx context.Context, bodies *[]types.Presi
 
 	for _, body := range *bodies {
 		// Check if attachment already exists
-		existingAttachment, err := qtx.FindCompletedAttachmentByMD5(ctx, body.MD5)
+		existingAttachment, err := qtx.FindCompletedAttachmentByMD5(ctx, repository.FindCompletedAttachmentByMD5Params{
+			Md5:    body.MD5,
+			UserID: userID,
+		})
 		if err == nil && existingAttachment.ID.Valid {
+			// 已有附件即将被新的 moment 引用，刷新 updated_at，避免在引用前被回收
+			if err := qtx.TouchAttachment(ctx, existingAttachment.ID); err != nil {
+				return nil, err
+			}
 			responses = append(responses, types.PresignedUploadResponse{
 				AttachmentID: existingAttachment.ID.String(),
 				ObjectKey:    existingAttachment.ObjectKey,
 				IsDuplicate:  true,
+				CoverStatus:  existingAttachment.CoverStatus,
 			})
 			continue
 		}
 
+		// 客户端同时提供 cover_ext 和 cover_md5 时自行上传封面，否则由服务端在上传完成后生成
+		clientCover := body.CoverExt != "" && body.CoverMD5 != ""
 		ext := filepath.Ext(body.FileName)
 		objectKey := uuid.NewString()
-		coverObjectKey := objectKey + "." + body.CoverExt
+		coverObjectKey, coverMD5 := "", ""
+		if clientCover {
+			coverObjectKey, coverMD5 = objectKey+"."+body.CoverExt, body.CoverMD5
+		}
 		objectKey = objectKey + ext
 
 		attachment, err := qtx.CreateAttachment(ctx, repository.CreateAttachmentParams{
@@ -86,30 +96,36 @@ func (s *Service) CreateUploadRequest(ctx context.Context, bodies *[]types.Presi
 			OriginalName:   body.FileName,
 			MimeType:       body.MimeType,
 			Md5:            body.MD5,
-			CoverMd5:       body.CoverMD5,
+			CoverMd5:       coverMD5,
 			FileSize:       body.FileSize,
+			UserID:         userID,
+			CoverStatus:    initialCoverStatus(body.MimeType, clientCover),
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create attachment record: %w", err)
 		}
 
 		expiry := time.Duration(s.config.Storage.PresignedExpiry) * time.Minute
-		presignedURL, err := s.client.PresignedPutObject(ctx, s.config.Storage.BucketName, objectKey, expiry)
+		presignedURL, err := s.driver.PresignPut(ctx, objectKey, expiry)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate presigned URL: %w", err)
 		}
 
-		coverPresignedURL, err := s.client.PresignedPutObject(ctx, s.config.Storage.BucketName, coverObjectKey, expiry)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate presigned URL: %w", err)
+		var coverUploadURL string
+		if clientCover {
+			coverUploadURL, err = s.driver.PresignPut(ctx, coverObjectKey, expiry)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate presigned URL: %w", err)
+			}
 		}
 
 		responses = append(responses, types.PresignedUploadResponse{
 			AttachmentID:   attachment.ID.String(),
-			UploadURL:      presignedURL.String(),
-			CoverUploadUrl: coverPresignedURL.String(),
+			UploadURL:      presignedURL,
+			CoverUploadUrl: coverUploadURL,
 			ObjectKey:      attachment.ObjectKey,
 			IsDuplicate:    false,
+			CoverStatus:    attachment.CoverStatus,
 		})
 	}
 
@@ -120,9 +136,12 @@ func (s *Service) CreateUploadRequest(ctx context.Context, bodies *[]types.Presi
 	return responses, nil
 }
 
-func (s *Service) CompleteUpload(ctx context.Context, attachmentID uuid.UUID) error {
+func (s *Service) CompleteUpload(ctx context.Context, userID int64, attachmentID uuid.UUID) error {
 	// 首先获取数据库中的attachment记录
-	attachment, err := s.Q.GetAttachmentById(ctx, pkg.UUIDToPgUUID(attachmentID))
+	attachment, err := s.Q.GetAttachmentById(ctx, repository.GetAttachmentByIdParams{
+		ID:     pkg.UUIDToPgUUID(attachmentID),
+		UserID: userID,
+	})
 	if err != nil {
 		s.logger.Error("Failed to get attachment from DB",
 			zap.String("attachmentId", attachmentID.String()),
@@ -131,10 +150,16 @@ func (s *Service) CompleteUpload(ctx context.Context, attachmentID uuid.UUID) er
 		return fmt.Errorf("could not get attachment for ID %s: %w", attachmentID.String(), err)
 	}
 
+	// 分片上传的对象 ETag 不是 MD5，改为校验 SHA-256
+	if attachment.PartSize > 0 {
+		_, err := s.CompleteMultipartUpload(ctx, userID, attachmentID)
+		return err
+	}
+
 	// 验证主文件的MD5
 	fileMD5, err := s.getObjectETag(ctx, attachment.ObjectKey)
 	if err != nil {
-		s.logger.Error("Failed to get file ETag from MinIO",
+		s.logger.Error("Failed to get file ETag from storage",
 			zap.String("attachmentId", attachmentID.String()),
 			zap.String("objectKey", attachment.ObjectKey),
 			zap.Error(err),
@@ -151,30 +176,33 @@ func (s *Service) CompleteUpload(ctx context.Context, attachmentID uuid.UUID) er
 		return fmt.Errorf("file MD5 mismatch for attachment %s: expected %s, got %s", attachmentID.String(), attachment.Md5, fileMD5)
 	}
 
-	// 验证封面文件的MD5
-	coverMD5, err := s.getObjectETag(ctx, attachment.CoverObjectKey)
-	if err != nil {
-		s.logger.Error("Failed to get cover ETag from MinIO",
-			zap.String("attachmentId", attachmentID.String()),
-			zap.String("coverObjectKey", attachment.CoverObjectKey),
-			zap.Error(err),
-		)
-		return fmt.Errorf("could not get cover ETag for attachment %s: %w", attachmentID.String(), err)
-	}
+	// 验证客户端上传的封面文件的MD5，服务端生成的封面在上传完成后才开始生成
+	if attachment.CoverMd5 != "" {
+		coverMD5, err := s.getObjectETag(ctx, attachment.CoverObjectKey)
+		if err != nil {
+			s.logger.Error("Failed to get cover ETag from storage",
+				zap.String("attachmentId", attachmentID.String()),
+				zap.String("coverObjectKey", attachment.CoverObjectKey),
+				zap.Error(err),
+			)
+			return fmt.Errorf("could not get cover ETag for attachment %s: %w", attachmentID.String(), err)
+		}
 
-	if coverMD5 != attachment.CoverMd5 {
-		s.logger.Error("Cover MD5 mismatch",
-			zap.String("attachmentId", attachmentID.String()),
-			zap.String("expectedCoverMD5", attachment.CoverMd5),
-			zap.String("actualCoverMD5", coverMD5),
-		)
-		return fmt.Errorf("cover MD5 mismatch for attachment %s: expected %s, got %s", attachmentID.String(), attachment.CoverMd5, coverMD5)
+		if coverMD5 != attachment.CoverMd5 {
+			s.logger.Error("Cover MD5 mismatch",
+				zap.String("attachmentId", attachmentID.String()),
+				zap.String("expectedCoverMD5", attachment.CoverMd5),
+				zap.String("actualCoverMD5", coverMD5),
+			)
+			return fmt.Errorf("cover MD5 mismatch for attachment %s: expected %s, got %s", attachmentID.String(), attachment.CoverMd5, coverMD5)
+		}
 	}
 
 	// MD5验证通过，更新状态为completed
 	err = s.Q.UpdateAttachmentStatus(ctx, repository.UpdateAttachmentStatusParams{
 		ID:     pkg.UUIDToPgUUID(attachmentID),
 		Status: "completed",
+		UserID: userID,
 	})
 	if err != nil {
 		s.logger.Error("Failed to mark attachment as completed in DB",
@@ -190,25 +218,27 @@ func (s *Service) CompleteUpload(ctx context.Context, attachmentID uuid.UUID) er
 		zap.String("coverObjectKey", attachment.CoverObjectKey),
 	)
 
+	if attachment.CoverStatus == CoverStatusPending {
+		s.notifyCoverWorker()
+	}
+
 	return nil
 }
 
-// getObjectETag 从MinIO获取对象的ETag（通常是MD5哈希值）
+// getObjectETag 从对象存储获取对象的ETag（通常是MD5哈希值）
 func (s *Service) getObjectETag(ctx context.Context, objectKey string) (string, error) {
-	// 使用StatObject获取对象信息，包括ETag
-	objectInfo, err := s.client.StatObject(ctx, s.config.Storage.BucketName, objectKey, minio.StatObjectOptions{})
+	objectInfo, err := s.driver.Stat(ctx, objectKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to get object info from MinIO: %w", err)
+		return "", fmt.Errorf("failed to get object info from storage: %w", err)
 	}
-
-	// 移除ETag中的引号（如果存在）
-	etag := strings.Trim(objectInfo.ETag, `"`)
-
-	return etag, nil
+	return objectInfo.ETag, nil
 }
 
-func (s *Service) GeneratePresignedGetURL(ctx context.Context, attachmentID uuid.UUID) (string, error) {
-	objectKey, err := s.Q.GetCompletedAttachmentObjectKey(ctx, pkg.UUIDToPgUUID(attachmentID))
+func (s *Service) GeneratePresignedGetURL(ctx context.Context, userID int64, attachmentID uuid.UUID) (string, error) {
+	objectKey, err := s.Q.GetCompletedAttachmentObjectKey(ctx, repository.GetCompletedAttachmentObjectKeyParams{
+		ID:     pkg.UUIDToPgUUID(attachmentID),
+		UserID: userID,
+	})
 	if err != nil {
 		s.logger.Warn("Failed to get completed attachment object key",
 			zap.String("attachmentId", attachmentID.String()),
@@ -218,7 +248,7 @@ func (s *Service) GeneratePresignedGetURL(ctx context.Context, attachmentID uuid
 	}
 
 	expiry := time.Duration(s.config.Storage.PresignedExpiry) * time.Minute
-	presignedURL, err := s.client.PresignedGetObject(ctx, s.config.Storage.BucketName, objectKey, expiry, nil)
+	presignedURL, err := s.driver.PresignGet(ctx, objectKey, expiry)
 	if err != nil {
 		s.logger.Error("Failed to generate presigned GET URL",
 			zap.String("objectKey", objectKey),
@@ -226,11 +256,14 @@ func (s *Service) GeneratePresignedGetURL(ctx context.Context, attachmentID uuid
 		)
 		return "", fmt.Errorf("could not generate access URL")
 	}
-	return presignedURL.String(), nil
+	return presignedURL, nil
 }
 
-func (s *Service) GeneratePresignedGetCoverURL(ctx context.Context, attachmentID uuid.UUID) (string, error) {
-	objectKey, err := s.Q.GetCompletedAttachmentCoverObjectKey(ctx, pkg.UUIDToPgUUID(attachmentID))
+func (s *Service) GeneratePresignedGetCoverURL(ctx context.Context, userID int64, attachmentID uuid.UUID) (string, error) {
+	objectKey, err := s.Q.GetCompletedAttachmentCoverObjectKey(ctx, repository.GetCompletedAttachmentCoverObjectKeyParams{
+		ID:     pkg.UUIDToPgUUID(attachmentID),
+		UserID: userID,
+	})
 	if err != nil {
 		s.logger.Warn("Failed to get completed attachment object key",
 			zap.String("attachmentId", attachmentID.String()),
@@ -238,9 +271,18 @@ func (s *Service) GeneratePresignedGetCoverURL(ctx context.Context, attachmentID
 		)
 		return "", fmt.Errorf("attachment not found or not completed")
 	}
+	if objectKey == "" {
+		return "", ErrCoverNotReady
+	}
+
+	return s.PresignedCoverURL(ctx, objectKey)
+}
 
+// PresignedCoverURL 为封面对象生成预签名下载链接，只做签名不查询数据库
+// 调用方需要确认 objectKey 属于当前用户，例如来自按用户过滤的附件查询
+func (s *Service) PresignedCoverURL(ctx context.Context, objectKey string) (string, error) {
 	expiry := time.Duration(s.config.Storage.PresignedExpiry) * time.Minute
-	presignedURL, err := s.client.PresignedGetObject(ctx, s.config.Storage.BucketName, objectKey, expiry, nil)
+	presignedURL, err := s.driver.PresignGet(ctx, objectKey, expiry)
 	if err != nil {
 		s.logger.Error("Failed to generate presigned GET Cover URL",
 			zap.String("objectKey", objectKey),
@@ -248,5 +290,5 @@ func (s *Service) GeneratePresignedGetCoverURL(ctx context.Context, attachmentID
 		)
 		return "", fmt.Errorf("could not generate access cover URL")
 	}
-	return presignedURL.String(), nil
+	return presignedURL, nil
 }
@@ -1,5 +1,6 @@
 package types
 
+// PresignedUploadRequest cover_ext 和 cover_md5 可选，都提供时由客户端上传封面，否则由服务端生成
 type PresignedUploadRequest struct {
 	FileName string `json:"file_name"`
 	MimeType string `json:"mime_type"`
@@ -8,3 +9,25 @@ type PresignedUploadRequest struct {
 	CoverMD5 string `json:"cover_md5"`
 	MD5      string `json:"md5"`
 }
+
+type AvatarUploadRequest struct {
+	MimeType string `json:"mime_type" validate:"required"`
+	FileSize int64  `json:"file_size" validate:"required,gt=0"`
+}
+
+type CompleteAvatarUploadRequest struct {
+	ObjectKey string `json:"object_key" validate:"required"`
+}
+
+// MultipartUploadRequest 分片上传大文件，sha256 为整个文件内容的 SHA-256（十六进制），分片合并后用于校验
+type MultipartUploadRequest struct {
+	FileName string `json:"file_name" validate:"required"`
+	MimeType string `json:"mime_type" validate:"required"`
+	FileSize int64  `json:"file_size" validate:"required,gt=0"`
+	SHA256   string `json:"sha256" validate:"required,len=64,hexadecimal"`
+}
+
+// MultipartPartsRequest 需要上传地址的分片号，从 1 开始
+type MultipartPartsRequest struct {
+	PartNumbers []int `json:"part_numbers" validate:"required,min=1,max=1000,dive,gt=0"`
+}
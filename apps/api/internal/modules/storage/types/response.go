@@ -6,4 +6,68 @@ type PresignedUploadResponse struct {
 	CoverUploadUrl string `json:"cover_upload_url,omitempty"`
 	ObjectKey      string `json:"object_key"`
 	IsDuplicate    bool   `json:"is_duplicate"`
+	CoverStatus    string `json:"cover_status"` // 为 pending 时封面由服务端在上传完成后生成，客户端无需上传
+}
+
+type AvatarUploadResponse struct {
+	ObjectKey string `json:"object_key"`
+	UploadURL string `json:"upload_url"`
+}
+
+type AvatarResponse struct {
+	AvatarURL  string            `json:"avatar_url"`
+	AvatarURLs map[string]string `json:"avatar_urls"` // 各尺寸头像的临时访问地址，键为边长（像素）
+}
+
+// GarbageAttachment 下次回收时会被删除的附件
+type GarbageAttachment struct {
+	AttachmentID string `json:"attachment_id"`
+	ObjectKey    string `json:"object_key"`
+	OriginalName string `json:"original_name"`
+	Status       string `json:"status"`
+	FileSize     int64  `json:"file_size"`
+	Reason       string `json:"reason"` // abandoned_upload 或 unreferenced
+	UpdatedAt    string `json:"updated_at"`
+}
+
+// GarbageReportResponse 回收任务的预演结果，只列出不删除
+type GarbageReportResponse struct {
+	GracePeriodHours int                 `json:"grace_period_hours"`
+	Count            int                 `json:"count"`
+	TotalSize        int64               `json:"total_size"` // 字节
+	Attachments      []GarbageAttachment `json:"attachments"`
+}
+
+type MultipartUploadResponse struct {
+	AttachmentID string `json:"attachment_id"`
+	ObjectKey    string `json:"object_key"`
+	IsDuplicate  bool   `json:"is_duplicate"`
+	PartSize     int64  `json:"part_size,omitempty"`  // 每个分片的字节数，最后一个分片可以更小
+	PartCount    int    `json:"part_count,omitempty"` // 分片数量
+	CoverStatus  string `json:"cover_status"`
+}
+
+// MultipartUploadStatusResponse 分片上传的进度，客户端据此跳过已上传的分片继续上传
+type MultipartUploadStatusResponse struct {
+	AttachmentID  string         `json:"attachment_id"`
+	Status        string         `json:"status"` // verifying 表示正在后台校验，failed 表示校验不通过，需要取消后重新上传
+	PartSize      int64          `json:"part_size"`
+	PartCount     int            `json:"part_count"`
+	UploadedParts []UploadedPart `json:"uploaded_parts"`
+}
+
+// MultipartCompleteResponse 分片已合并、等待后台校验时返回，客户端轮询 GetMultipartUpload 获取校验结果
+type MultipartCompleteResponse struct {
+	AttachmentID string `json:"attachment_id"`
+	Status       string `json:"status"`
+}
+
+type UploadedPart struct {
+	PartNumber int   `json:"part_number"`
+	Size       int64 `json:"size"`
+}
+
+type MultipartPartURL struct {
+	PartNumber int    `json:"part_number"`
+	UploadURL  string `json:"upload_url"`
 }
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage/driver"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+// 附件的上传状态
+const (
+	AttachmentStatusUploading = "uploading"
+	AttachmentStatusVerifying = "verifying" // 分片已合并，等待后台任务校验 SHA-256
+	AttachmentStatusCompleted = "completed"
+	AttachmentStatusFailed    = "failed" // 合并后的文件校验不通过，需要取消后重新上传
+)
+
+const (
+	// verifyBatchSize 后台任务每次领取的附件数量
+	verifyBatchSize = 5
+	// verifyStaleAfter 校验中的附件超过该时间仍未完成时（例如实例退出）重新领取，需要覆盖读取最大文件的时间
+	verifyStaleAfter = 30 * time.Minute
+	// verifyPollInterval 轮询间隔，合并完成时会立即唤醒，轮询只用于重试和其他实例遗留的附件
+	verifyPollInterval = time.Minute
+)
+
+// notifyVerifyWorker 唤醒后台任务立即校验新合并的上传，任务正忙时不阻塞
+func (s *Service) notifyVerifyWorker() {
+	select {
+	case s.verifyWake <- struct{}{}:
+	default:
+	}
+}
+
+// VerifyUploads 领取等待校验的分片上传并逐个校验，直到没有待校验的附件，返回处理的数量
+// 多个实例同时运行时通过 SKIP LOCKED 领取不同的附件
+func (s *Service) VerifyUploads(ctx context.Context) int {
+	processed := 0
+	for ctx.Err() == nil {
+		staleBefore := pgtype.Timestamptz{}
+		staleBefore.Scan(time.Now().Add(-verifyStaleAfter))
+		attachments, err := s.Q.ClaimVerifyingAttachments(ctx, repository.ClaimVerifyingAttachmentsParams{
+			StaleBefore: staleBefore,
+			BatchSize:   verifyBatchSize,
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to claim verifying attachments", zap.Error(err))
+			}
+			return processed
+		}
+		if len(attachments) == 0 {
+			return processed
+		}
+
+		for _, attachment := range attachments {
+			if !s.verifyUpload(ctx, attachment) {
+				// 读取失败的附件已放回等待领取，留到下次轮询，避免在同一轮中反复重试
+				return processed
+			}
+			processed++
+		}
+	}
+	return processed
+}
+
+// verifyUpload 读取合并后的对象计算 SHA-256 和 MD5，与开始上传时声明的一致时标记为 completed，否则标记为 failed
+// 校验通过后记录服务端计算的 MD5 用于去重；读取对象失败时放回等待领取并返回 false
+func (s *Service) verifyUpload(ctx context.Context, attachment repository.Attachment) bool {
+	logger := s.logger.With(zap.String("attachmentId", attachment.ID.String()), zap.String("objectKey", attachment.ObjectKey))
+
+	sha256Sum, md5Sum, size, err := s.objectChecksums(ctx, attachment.ObjectKey)
+	if err != nil && !errors.Is(err, driver.ErrNotFound) {
+		logger.Warn("Failed to read merged object", zap.Error(err))
+		if err := s.Q.ReleaseVerifyingAttachment(ctx, attachment.ID); err != nil {
+			logger.Error("Failed to release verifying attachment", zap.Error(err))
+		}
+		return false
+	}
+	if err != nil || size != attachment.FileSize || sha256Sum != attachment.Sha256 {
+		logger.Error("File SHA-256 mismatch",
+			zap.String("expectedSHA256", attachment.Sha256),
+			zap.String("actualSHA256", sha256Sum),
+			zap.Int64("expectedSize", attachment.FileSize),
+			zap.Int64("actualSize", size),
+			zap.Error(err),
+		)
+		if err := s.Q.FailAttachmentVerification(ctx, attachment.ID); err != nil {
+			logger.Error("Failed to mark attachment verification as failed", zap.Error(err))
+		}
+		return true
+	}
+
+	err = s.Q.CompleteMultipartAttachment(ctx, repository.CompleteMultipartAttachmentParams{
+		Md5: md5Sum,
+		ID:  attachment.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to mark attachment as completed", zap.Error(err))
+		return true
+	}
+	logger.Info("Multipart upload verified")
+
+	if attachment.CoverStatus == CoverStatusPending {
+		s.notifyCoverWorker()
+	}
+	return true
+}
+
+// objectChecksums 读取整个对象，返回 SHA-256、MD5（十六进制）和大小
+func (s *Service) objectChecksums(ctx context.Context, objectKey string) (string, string, int64, error) {
+	object, err := s.driver.Get(ctx, objectKey)
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer object.Close()
+
+	sha256Hash, md5Hash := sha256.New(), md5.New()
+	size, err := io.Copy(io.MultiWriter(sha256Hash, md5Hash), object)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return hex.EncodeToString(sha256Hash.Sum(nil)), hex.EncodeToString(md5Hash.Sum(nil)), size, nil
+}
+
+// VerifyWorker 后台校验分片上传的任务，分片合并时立即唤醒，另外定时轮询以处理重试和其他实例遗留的附件
+type VerifyWorker struct {
+	service  *Service
+	interval time.Duration
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewVerifyWorker 创建分片上传校验任务
+func NewVerifyWorker(service *Service) *VerifyWorker {
+	return &VerifyWorker{service: service, interval: verifyPollInterval}
+}
+
+// Start 在后台启动任务
+func (w *VerifyWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+	go w.run(ctx)
+	w.service.logger.Info("Verify worker started", zap.Duration("interval", w.interval))
+}
+
+// Stop 停止任务并等待正在校验的附件结束，被中断的附件超时后由其他实例重新领取
+func (w *VerifyWorker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+	w.service.logger.Info("Verify worker stopped")
+}
+
+func (w *VerifyWorker) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.service.VerifyUploads(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.service.verifyWake:
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/zeroicey/lifetrack-api/internal/middleware"
 	response "github.com/zeroicey/lifetrack-api/internal/pkg"
 	"github.com/zeroicey/lifetrack-api/internal/repository"
 
@@ -33,6 +34,12 @@ func TaskRouter(s *Service) chi.Router {
 }
 
 func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	var body task.CreateTaskBody
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
@@ -43,6 +50,7 @@ func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		GroupID:  body.GroupID,
 		Content:  body.Content,
 		Deadline: body.Deadline,
+		UserID:   userID,
 	})
 
 	if err != nil {
@@ -58,6 +66,12 @@ func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetTaskById(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil || id <= 0 {
@@ -65,7 +79,7 @@ func (h *Handler) GetTaskById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := h.S.GetTaskById(r.Context(), id)
+	task, err := h.S.GetTaskById(r.Context(), userID, id)
 	if err != nil {
 		if errors.Is(err, ErrTaskNotFound) {
 			response.Error("Task not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -79,6 +93,12 @@ func (h *Handler) GetTaskById(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil || id <= 0 {
@@ -97,6 +117,7 @@ func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		Content:  body.Content,
 		Deadline: body.Deadline,
 		Status:   repository.TaskStatus(body.Status),
+		UserID:   userID,
 	})
 
 	if err != nil {
@@ -112,6 +133,12 @@ func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil || id <= 0 {
@@ -119,7 +146,7 @@ func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.S.DeleteTask(r.Context(), id)
+	err = h.S.DeleteTask(r.Context(), userID, id)
 	if err != nil {
 		if errors.Is(err, ErrTaskNotFound) {
 			response.Error("Task not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -25,7 +25,10 @@ func NewService(q *repository.Queries) *Service {
 
 func (s *Service) CreateTask(ctx context.Context, params repository.CreateTaskParams) (types.TaskResponse, error) {
 	// 检查任务组是否存在
-	groupExists, err := s.Q.TaskGroupExists(ctx, params.GroupID)
+	groupExists, err := s.Q.TaskGroupExists(ctx, repository.TaskGroupExistsParams{
+		ID:     params.GroupID,
+		UserID: params.UserID,
+	})
 	if err != nil {
 		return types.TaskResponse{}, err
 	}
@@ -41,11 +44,14 @@ func (s *Service) CreateTask(ctx context.Context, params repository.CreateTaskPa
 	return s.convertToTaskResponse(task), nil
 }
 
-func (s *Service) GetTaskById(ctx context.Context, id int64) (types.TaskResponse, error) {
-	if err := s.checkTaskExists(ctx, id); err != nil {
+func (s *Service) GetTaskById(ctx context.Context, userID int64, id int64) (types.TaskResponse, error) {
+	if err := s.checkTaskExists(ctx, userID, id); err != nil {
 		return types.TaskResponse{}, err
 	}
-	task, err := s.Q.GetTaskById(ctx, id)
+	task, err := s.Q.GetTaskById(ctx, repository.GetTaskByIdParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		return types.TaskResponse{}, err
 	}
@@ -53,7 +59,7 @@ func (s *Service) GetTaskById(ctx context.Context, id int64) (types.TaskResponse
 }
 
 func (s *Service) UpdateTask(ctx context.Context, params repository.UpdateTaskByIdParams) (types.TaskResponse, error) {
-	if err := s.checkTaskExists(ctx, params.ID); err != nil {
+	if err := s.checkTaskExists(ctx, params.UserID, params.ID); err != nil {
 		return types.TaskResponse{}, err
 	}
 	task, err := s.Q.UpdateTaskById(ctx, params)
@@ -63,15 +69,21 @@ func (s *Service) UpdateTask(ctx context.Context, params repository.UpdateTaskBy
 	return s.convertToTaskResponse(task), nil
 }
 
-func (s *Service) DeleteTask(ctx context.Context, id int64) error {
-	if err := s.checkTaskExists(ctx, id); err != nil {
+func (s *Service) DeleteTask(ctx context.Context, userID int64, id int64) error {
+	if err := s.checkTaskExists(ctx, userID, id); err != nil {
 		return err
 	}
-	return s.Q.DeleteTaskById(ctx, id)
+	return s.Q.DeleteTaskById(ctx, repository.DeleteTaskByIdParams{
+		ID:     id,
+		UserID: userID,
+	})
 }
 
-func (s *Service) checkTaskExists(ctx context.Context, id int64) error {
-	exists, err := s.Q.TaskExists(ctx, id)
+func (s *Service) checkTaskExists(ctx context.Context, userID int64, id int64) error {
+	exists, err := s.Q.TaskExists(ctx, repository.TaskExistsParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/zeroicey/lifetrack-api/internal/middleware"
 	"github.com/zeroicey/lifetrack-api/internal/modules/taskgroup/types"
 	response "github.com/zeroicey/lifetrack-api/internal/pkg"
 	"github.com/zeroicey/lifetrack-api/internal/repository"
@@ -32,6 +33,7 @@ func TaskGroupRouter(s *Service) chi.Router {
 
 	r.Get("/", h.ListGroups)
 	r.Post("/", h.CreateGroup)
+	r.Get("/current", h.GetCurrentGroup)
 
 	r.Route("/{groupID}", func(r chi.Router) {
 		r.Use(h.groupIDContext)
@@ -62,6 +64,12 @@ func (h *Handler) groupIDContext(next http.Handler) http.Handler {
 // 支持通过 "with_tasks=true" 查询参数来决定是否一并返回每个组内的任务列表。
 // 这是一个统一的端点，根据请求参数调用不同的业务逻辑。
 func (h *Handler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	// 步骤 1: 解析查询参数 (这部分你已经写得很好了)
 	query := r.URL.Query()
 	withTasks := query.Get("with_tasks") == "true"
@@ -83,10 +91,10 @@ func (h *Handler) ListGroups(w http.ResponseWriter, r *http.Request) {
 	// 步骤 3: 根据 withTasks 参数，条件性地调用不同的 service
 	if withTasks {
 		// 当需要返回任务时，调用我们新写的 ListGroupsWithTasks
-		data, err = h.S.ListGroupsWithTasks(r.Context(), params)
+		data, err = h.S.ListGroupsWithTasks(r.Context(), userID, params)
 	} else {
 		// 否则，调用原来的 ListGroups
-		data, err = h.S.ListGroups(r.Context(), params)
+		data, err = h.S.ListGroups(r.Context(), userID, params)
 	}
 
 	// 步骤 4: 统一处理错误和响应
@@ -98,7 +106,44 @@ func (h *Handler) ListGroups(w http.ResponseWriter, r *http.Request) {
 	response.Success("Task groups retrieved successfully").SetData(data).Build(w)
 }
 
+// GetCurrentGroup 获取当前日/周/月/年对应的任务组及其任务，"当前"按用户时区或 tz 查询参数计算
+func (h *Handler) GetCurrentGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	query := r.URL.Query()
+	groupType := query.Get("type")
+	if groupType == "" {
+		groupType = string(repository.TaskGroupTypeDay)
+	}
+
+	group, err := h.S.GetCurrentGroup(r.Context(), userID, groupType, query.Get(response.TimezoneQueryParam))
+	if err != nil {
+		if errors.Is(err, ErrTaskGroupNotFound) {
+			response.Error("Task group not found").SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		if errors.Is(err, ErrInvalidGroupType) || errors.Is(err, ErrInvalidTimezone) || errors.Is(err, ErrNoCurrentPeriod) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		response.Error("Failed to retrieve current task group").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Task group retrieved successfully").SetData(group).Build(w)
+}
+
 func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	var body types.CreateGroupBody
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		// **修正点**: 使用正确的链式调用
@@ -117,6 +162,7 @@ func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 		Name:        body.Name,
 		Description: body.Description.String,
 		Type:        groupType,
+		UserID:      userID,
 	}
 
 	newGroup, err := h.S.CreateGroup(r.Context(), params)
@@ -131,12 +177,18 @@ func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	ctx := r.Context()
 	id := ctx.Value(keyGroupID).(int64)
 	withTasks := r.URL.Query().Get("with_tasks") == "true"
 
 	if withTasks {
-		groupWithTasks, err := h.S.GetGroupWithTasksByID(ctx, id)
+		groupWithTasks, err := h.S.GetGroupWithTasksByID(ctx, userID, id)
 		if err != nil {
 			if errors.Is(err, ErrTaskGroupNotFound) {
 				// **修正点**: 使用正确的链式调用
@@ -152,7 +204,7 @@ func (h *Handler) GetGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	group, err := h.S.GetGroupByID(ctx, id)
+	group, err := h.S.GetGroupByID(ctx, userID, id)
 	if err != nil {
 		if errors.Is(err, ErrTaskGroupNotFound) {
 			// **修正点**: 使用正确的链式调用
@@ -168,6 +220,12 @@ func (h *Handler) GetGroup(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	id := r.Context().Value(keyGroupID).(int64)
 
 	var body types.UpdateGroupBody
@@ -182,6 +240,7 @@ func (h *Handler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 		Name:        body.Name,
 		Description: body.Description.String,
 		Type:        repository.TaskGroupType(body.Type),
+		UserID:      userID,
 	}
 
 	updatedGroup, err := h.S.UpdateGroup(r.Context(), params)
@@ -201,9 +260,15 @@ func (h *Handler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
 	id := r.Context().Value(keyGroupID).(int64)
 
-	err := h.S.DeleteGroup(r.Context(), id)
+	err := h.S.DeleteGroup(r.Context(), userID, id)
 	if err != nil {
 		if errors.Is(err, ErrTaskGroupNotFound) {
 			// **修正点**: 使用正确的链式调用
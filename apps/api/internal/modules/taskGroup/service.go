@@ -3,12 +3,14 @@ package taskgroup
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/zeroicey/lifetrack-api/internal/modules/taskgroup/types"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
 	"github.com/zeroicey/lifetrack-api/internal/repository"
 )
 
@@ -20,6 +22,15 @@ type Service struct {
 // ErrTaskGroupNotFound 是一个哨兵错误，在未找到任务组时返回
 var ErrTaskGroupNotFound = errors.New("task group not found")
 
+// ErrInvalidGroupType 是一个哨兵错误，在任务组类型不合法时返回
+var ErrInvalidGroupType = errors.New("invalid task group type")
+
+// ErrNoCurrentPeriod 是一个哨兵错误，custom 类型的任务组没有"当前周期"
+var ErrNoCurrentPeriod = errors.New("task group type has no current period")
+
+// ErrInvalidTimezone 是一个哨兵错误，在时区不是合法 IANA 名称时返回
+var ErrInvalidTimezone = pkg.ErrInvalidTimezone
+
 // NewService 创建一个新的 Service 实例
 func NewService(q *repository.Queries) *Service {
 	return &Service{Q: q}
@@ -31,7 +42,7 @@ func NewService(q *repository.Queries) *Service {
 
 // ListGroups 根据提供的参数（名称或类型）搜索任务组列表
 // 这是对 ListGroupsByType 和 GetGroupByName(返回列表)的统一和重构
-func (s *Service) ListGroups(ctx context.Context, params types.ListGroupsParams) ([]types.TaskGroupResponse, error) {
+func (s *Service) ListGroups(ctx context.Context, userID int64, params types.ListGroupsParams) ([]types.TaskGroupResponse, error) {
 	var (
 		groups []repository.TaskGroup
 		err    error
@@ -40,7 +51,10 @@ func (s *Service) ListGroups(ctx context.Context, params types.ListGroupsParams)
 	// 根据参数调用不同的数据库查询
 	if params.Name != nil {
 		// 按名称搜索（业务上假定唯一，但接口统一返回列表）
-		group, err_ := s.Q.GetTaskGroupByName(ctx, *params.Name)
+		group, err_ := s.Q.GetTaskGroupByName(ctx, repository.GetTaskGroupByNameParams{
+			Name:   *params.Name,
+			UserID: userID,
+		})
 		if err_ != nil {
 			if errors.Is(err_, pgx.ErrNoRows) {
 				return []types.TaskGroupResponse{}, nil // 未找到，返回空列表，而非错误
@@ -54,10 +68,13 @@ func (s *Service) ListGroups(ctx context.Context, params types.ListGroupsParams)
 		if err_ != nil {
 			return nil, err_ // 无效的类型输入
 		}
-		groups, err = s.Q.GetTaskGroupsByType(ctx, groupType)
+		groups, err = s.Q.GetTaskGroupsByType(ctx, repository.GetTaskGroupsByTypeParams{
+			Type:   groupType,
+			UserID: userID,
+		})
 	} else {
 		// 无参数，获取所有
-		groups, err = s.Q.GetAllTaskGroups(ctx)
+		groups, err = s.Q.GetAllTaskGroups(ctx, userID)
 	}
 
 	if err != nil {
@@ -74,7 +91,7 @@ func (s *Service) ListGroups(ctx context.Context, params types.ListGroupsParams)
 // 优化建议：
 // 1. (最佳) 在 repository 层使用 SQL JOIN 一次性查询出所有数据。
 // 2. (次佳) 在 service 层先获取所有 group ID，然后用 "WHERE group_id IN (...)" 一次性查询所有相关任务，最后在内存中进行匹配。
-func (s *Service) ListGroupsWithTasks(ctx context.Context, params types.ListGroupsParams) ([]types.TaskGroupWithTasksResponse, error) {
+func (s *Service) ListGroupsWithTasks(ctx context.Context, userID int64, params types.ListGroupsParams) ([]types.TaskGroupWithTasksResponse, error) {
 	var (
 		groups []repository.TaskGroup
 		err    error
@@ -82,7 +99,10 @@ func (s *Service) ListGroupsWithTasks(ctx context.Context, params types.ListGrou
 
 	// 步骤 1: 获取基础的任务组列表 (重用 ListGroups 的内部逻辑)
 	if params.Name != nil {
-		group, err_ := s.Q.GetTaskGroupByName(ctx, *params.Name)
+		group, err_ := s.Q.GetTaskGroupByName(ctx, repository.GetTaskGroupByNameParams{
+			Name:   *params.Name,
+			UserID: userID,
+		})
 		if err_ != nil {
 			if errors.Is(err_, pgx.ErrNoRows) {
 				return []types.TaskGroupWithTasksResponse{}, nil // 未找到，返回空列表
@@ -95,9 +115,12 @@ func (s *Service) ListGroupsWithTasks(ctx context.Context, params types.ListGrou
 		if err_ != nil {
 			return nil, err_
 		}
-		groups, err = s.Q.GetTaskGroupsByType(ctx, groupType)
+		groups, err = s.Q.GetTaskGroupsByType(ctx, repository.GetTaskGroupsByTypeParams{
+			Type:   groupType,
+			UserID: userID,
+		})
 	} else {
-		groups, err = s.Q.GetAllTaskGroups(ctx)
+		groups, err = s.Q.GetAllTaskGroups(ctx, userID)
 	}
 
 	if err != nil {
@@ -112,7 +135,10 @@ func (s *Service) ListGroupsWithTasks(ctx context.Context, params types.ListGrou
 	responses := make([]types.TaskGroupWithTasksResponse, 0, len(groups))
 	for _, group := range groups {
 		// 步骤 2a: 获取当前组的所有任务 (这是 N+1 问题中的 "+1" 查询)
-		tasks, err := s.Q.GetTasksByGroupId(ctx, group.ID)
+		tasks, err := s.Q.GetTasksByGroupId(ctx, repository.GetTasksByGroupIdParams{
+			GroupID: group.ID,
+			UserID:  userID,
+		})
 		if err != nil {
 			// 如果获取单个组的任务失败，应中断并返回错误，因为调用者期望获得完整或无数据
 			return nil, err
@@ -132,8 +158,11 @@ func (s *Service) ListGroupsWithTasks(ctx context.Context, params types.ListGrou
 // ----------------------------------------------------------------------------
 
 // GetGroupByID 通过其唯一ID获取单个任务组
-func (s *Service) GetGroupByID(ctx context.Context, id int64) (types.TaskGroupResponse, error) {
-	group, err := s.Q.GetTaskGroupById(ctx, id)
+func (s *Service) GetGroupByID(ctx context.Context, userID int64, id int64) (types.TaskGroupResponse, error) {
+	group, err := s.Q.GetTaskGroupById(ctx, repository.GetTaskGroupByIdParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return types.TaskGroupResponse{}, ErrTaskGroupNotFound
@@ -145,8 +174,11 @@ func (s *Service) GetGroupByID(ctx context.Context, id int64) (types.TaskGroupRe
 
 // GetGroupWithTasksByID 通过其唯一ID获取单个任务组及其所有任务
 // 推荐：未来可以优化为 sqlc 的 JOIN 查询，以减少数据库交互次数
-func (s *Service) GetGroupWithTasksByID(ctx context.Context, id int64) (types.TaskGroupWithTasksResponse, error) {
-	groupInfo, err := s.Q.GetTaskGroupById(ctx, id)
+func (s *Service) GetGroupWithTasksByID(ctx context.Context, userID int64, id int64) (types.TaskGroupWithTasksResponse, error) {
+	groupInfo, err := s.Q.GetTaskGroupById(ctx, repository.GetTaskGroupByIdParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return types.TaskGroupWithTasksResponse{}, ErrTaskGroupNotFound
@@ -154,7 +186,10 @@ func (s *Service) GetGroupWithTasksByID(ctx context.Context, id int64) (types.Ta
 		return types.TaskGroupWithTasksResponse{}, err
 	}
 
-	tasks, err := s.Q.GetTasksByGroupId(ctx, id)
+	tasks, err := s.Q.GetTasksByGroupId(ctx, repository.GetTasksByGroupIdParams{
+		GroupID: id,
+		UserID:  userID,
+	})
 	if err != nil {
 		return types.TaskGroupWithTasksResponse{}, err
 	}
@@ -165,6 +200,44 @@ func (s *Service) GetGroupWithTasksByID(ctx context.Context, id int64) (types.Ta
 	), nil
 }
 
+// GetCurrentGroup 获取当前周期对应的任务组及其任务
+// 当前周期按 timezone（为空时为用户时区）计算，避免跨时区时"今天"的任务组错位
+func (s *Service) GetCurrentGroup(ctx context.Context, userID int64, typeStr, timezone string) (types.TaskGroupWithTasksResponse, error) {
+	groupType, err := s.parseType(typeStr)
+	if err != nil {
+		return types.TaskGroupWithTasksResponse{}, err
+	}
+	loc, err := pkg.ResolveLocation(ctx, s.Q, userID, timezone)
+	if err != nil {
+		return types.TaskGroupWithTasksResponse{}, err
+	}
+	name, ok := periodName(time.Now().In(loc), groupType)
+	if !ok {
+		return types.TaskGroupWithTasksResponse{}, ErrNoCurrentPeriod
+	}
+
+	group, err := s.Q.GetTaskGroupByName(ctx, repository.GetTaskGroupByNameParams{
+		Name:   name,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.TaskGroupWithTasksResponse{}, ErrTaskGroupNotFound
+		}
+		return types.TaskGroupWithTasksResponse{}, err
+	}
+
+	tasks, err := s.Q.GetTasksByGroupId(ctx, repository.GetTasksByGroupIdParams{
+		GroupID: group.ID,
+		UserID:  userID,
+	})
+	if err != nil {
+		return types.TaskGroupWithTasksResponse{}, err
+	}
+
+	return s.populateTasksForGroup(s.convertToTaskGroupResponse(group), tasks), nil
+}
+
 // ----------------------------------------------------------------------------
 // 写入操作 (Write Operations)
 // ----------------------------------------------------------------------------
@@ -198,11 +271,14 @@ func (s *Service) UpdateGroup(ctx context.Context, params repository.UpdateTaskG
 
 // DeleteGroup 删除一个任务组
 // 注意：移除了删除前多余的 checkGroupExists 调用
-func (s *Service) DeleteGroup(ctx context.Context, id int64) error {
+func (s *Service) DeleteGroup(ctx context.Context, userID int64, id int64) error {
 	// sqlc 生成的 Exec 方法会返回一个 CommandTag，可以检查受影响的行数
 	// 但为了简化，我们直接依赖 DeleteTaskGroupById 在找不到时是否返回错误
 	// 如果 sqlc 配置为不返回错误，则需要检查受影响的行数
-	err := s.Q.DeleteTaskGroupById(ctx, id)
+	err := s.Q.DeleteTaskGroupById(ctx, repository.DeleteTaskGroupByIdParams{
+		ID:     id,
+		UserID: userID,
+	})
 	// 假设：如果没找到可删除的行，sqlc方法会返回 pgx.ErrNoRows 或类似的错误。
 	// 如果它不返回错误，那么这个操作就是幂等的，也很好。
 	return err
@@ -271,6 +347,24 @@ func (s *Service) pgTimestampToString(pt pgtype.Timestamptz) string {
 	return pt.Time.Format(time.RFC3339)
 }
 
+// periodName 返回 t 所在周期的任务组名称：日(2025-07-14)、周(2025-W28)、月(2025-07)、年(2025)
+// 周使用 ISO 8601 周数和周年份，与 web 端 genNameFromType 的 RRRR-'W'II 一致
+func periodName(t time.Time, groupType repository.TaskGroupType) (string, bool) {
+	switch groupType {
+	case repository.TaskGroupTypeDay:
+		return t.Format("2006-01-02"), true
+	case repository.TaskGroupTypeWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), true
+	case repository.TaskGroupTypeMonth:
+		return t.Format("2006-01"), true
+	case repository.TaskGroupTypeYear:
+		return t.Format("2006"), true
+	default:
+		return "", false
+	}
+}
+
 // parseType 验证并转换类型字符串
 func (s *Service) parseType(typeStr string) (repository.TaskGroupType, error) {
 	normalizedType := repository.TaskGroupType(strings.ToLower(strings.TrimSpace(typeStr)))
@@ -278,6 +372,6 @@ func (s *Service) parseType(typeStr string) (repository.TaskGroupType, error) {
 	case repository.TaskGroupTypeDay, repository.TaskGroupTypeWeek, repository.TaskGroupTypeMonth, repository.TaskGroupTypeYear, repository.TaskGroupTypeCustom:
 		return normalizedType, nil
 	default:
-		return "", errors.New("invalid task group type")
+		return "", ErrInvalidGroupType
 	}
 }
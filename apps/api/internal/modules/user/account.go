@@ -0,0 +1,339 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/modules/notification"
+	"github.com/zeroicey/lifetrack-api/internal/modules/user/types"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+// 账户令牌的用途
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+// accountTokenBytes 账户令牌的随机字节数
+const accountTokenBytes = 32
+
+var (
+	// ErrInvalidAccountToken 是重置密码或验证邮箱的令牌无效、已使用或已过期时返回的哨兵错误
+	ErrInvalidAccountToken = errors.New("invalid or expired token")
+	// ErrEmailAlreadyVerified 是邮箱已验证时重复请求验证邮件返回的哨兵错误
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+)
+
+// ChangePassword 修改密码，需要验证当前密码，成功后撤销除当前会话外的所有会话、个人访问令牌和日历订阅令牌
+func (s *Service) ChangePassword(ctx context.Context, userID int64, sessionID string, currentPassword, newPassword string) error {
+	user, err := s.Q.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if err := s.VerifyPassword(user.PasswordHash, currentPassword); err != nil {
+		return ErrInvalidPassword
+	}
+
+	hashed, err := s.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Q.WithTx(tx)
+	err = qtx.UpdateUserPassword(ctx, repository.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: hashed,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 通过个人访问令牌调用时没有会话ID，撤销所有会话
+	if id, err := pkg.StringToPgUUID(sessionID); err == nil {
+		err = qtx.RevokeOtherUserSessions(ctx, repository.RevokeOtherUserSessionsParams{
+			UserID: userID,
+			ID:     id,
+		})
+	} else {
+		err = qtx.RevokeAllUserSessions(ctx, userID)
+	}
+	if err != nil {
+		return err
+	}
+	if err := qtx.DeleteAPITokensByUser(ctx, userID); err != nil {
+		return err
+	}
+	if _, err := qtx.DeleteCalendarToken(ctx, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// passwordResetTimeout 后台签发重置密码令牌的超时时间
+const passwordResetTimeout = 30 * time.Second
+
+// RequestPasswordReset 向邮箱发送重置密码链接，邮箱不存在时同样返回成功，避免泄露用户是否存在
+// 查询用户和签发令牌都在后台完成，请求耗时不会因为邮箱是否存在而不同
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), passwordResetTimeout)
+		defer cancel()
+		if err := s.sendPasswordReset(ctx, email); err != nil {
+			s.logger.Error("Failed to send password reset email", zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// sendPasswordReset 邮箱属于某个用户时签发重置密码令牌并发送邮件，否则什么也不做
+func (s *Service) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := s.Q.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	ttl := time.Duration(s.config.Auth.PasswordResetExpiry) * time.Minute
+	return s.sendAccountEmail(ctx, user, TokenPurposePasswordReset, user.Email, ttl, "/reset-password", notification.TemplatePasswordReset)
+}
+
+// ResetPassword 使用邮件中的令牌设置新密码，成功后撤销用户的所有会话、个人访问令牌和日历订阅令牌
+func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	hashed, err := s.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Q.WithTx(tx)
+	accountToken, err := qtx.ConsumeAccountToken(ctx, repository.ConsumeAccountTokenParams{
+		TokenHash: hashToken(token),
+		Purpose:   TokenPurposePasswordReset,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidAccountToken
+		}
+		return err
+	}
+
+	err = qtx.UpdateUserPassword(ctx, repository.UpdateUserPasswordParams{
+		ID:           accountToken.UserID,
+		PasswordHash: hashed,
+	})
+	if err != nil {
+		return err
+	}
+	if err := qtx.RevokeAllUserSessions(ctx, accountToken.UserID); err != nil {
+		return err
+	}
+	if err := qtx.DeleteAPITokensByUser(ctx, accountToken.UserID); err != nil {
+		return err
+	}
+	if _, err := qtx.DeleteCalendarToken(ctx, accountToken.UserID); err != nil {
+		return err
+	}
+	err = qtx.DeleteUnusedAccountTokens(ctx, repository.DeleteUnusedAccountTokensParams{
+		UserID:  accountToken.UserID,
+		Purpose: TokenPurposePasswordReset,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ResendEmailVerification 重新发送当前邮箱的验证邮件
+func (s *Service) ResendEmailVerification(ctx context.Context, userID int64) error {
+	user, err := s.Q.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if user.EmailVerifiedAt.Valid {
+		return ErrEmailAlreadyVerified
+	}
+	return s.sendEmailVerification(ctx, user, user.Email)
+}
+
+// RequestEmailChange 向新邮箱发送验证邮件，验证通过后才会修改邮箱
+func (s *Service) RequestEmailChange(ctx context.Context, userID int64, password, newEmail string) error {
+	user, err := s.Q.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if err := s.VerifyPassword(user.PasswordHash, password); err != nil {
+		return ErrInvalidPassword
+	}
+
+	newEmail = strings.TrimSpace(newEmail)
+	if _, err := s.Q.GetUserByEmail(ctx, newEmail); err == nil {
+		return ErrUserAlreadyExists
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	return s.sendEmailVerification(ctx, user, newEmail)
+}
+
+// VerifyEmail 使用邮件中的令牌验证邮箱，修改邮箱时同时更新为新地址
+func (s *Service) VerifyEmail(ctx context.Context, token string) (types.UserResponse, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return types.UserResponse{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Q.WithTx(tx)
+	accountToken, err := qtx.ConsumeAccountToken(ctx, repository.ConsumeAccountTokenParams{
+		TokenHash: hashToken(token),
+		Purpose:   TokenPurposeEmailVerification,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.UserResponse{}, ErrInvalidAccountToken
+		}
+		return types.UserResponse{}, err
+	}
+
+	// 新邮箱在发送验证邮件后可能已被其他用户注册
+	if existing, err := qtx.GetUserByEmail(ctx, accountToken.Email); err == nil && existing.ID != accountToken.UserID {
+		return types.UserResponse{}, ErrUserAlreadyExists
+	} else if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return types.UserResponse{}, err
+	}
+
+	user, err := qtx.VerifyUserEmail(ctx, repository.VerifyUserEmailParams{
+		ID:    accountToken.UserID,
+		Email: accountToken.Email,
+	})
+	if err != nil {
+		return types.UserResponse{}, err
+	}
+	err = qtx.DeleteUnusedAccountTokens(ctx, repository.DeleteUnusedAccountTokensParams{
+		UserID:  accountToken.UserID,
+		Purpose: TokenPurposeEmailVerification,
+	})
+	if err != nil {
+		return types.UserResponse{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return types.UserResponse{}, err
+	}
+	return s.convertToUserResponse(ctx, user), nil
+}
+
+// sendEmailVerification 向 email 发送验证邮件，email 可以是用户当前邮箱或待修改的新邮箱
+func (s *Service) sendEmailVerification(ctx context.Context, user repository.User, email string) error {
+	ttl := time.Duration(s.config.Auth.EmailVerificationExpiry) * time.Hour
+	return s.sendAccountEmail(ctx, user, TokenPurposeEmailVerification, email, ttl, "/verify-email", notification.TemplateEmailVerification)
+}
+
+// sendAccountEmail 签发一次性账户令牌并发送包含链接的邮件，同一用途的旧令牌随之失效
+// 令牌在当前请求中写入数据库，邮件在后台发送
+func (s *Service) sendAccountEmail(ctx context.Context, user repository.User, purpose, email string, ttl time.Duration, path, template string) error {
+	buf := make([]byte, accountTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(buf)
+
+	expiresAt := pgtype.Timestamptz{}
+	expiresAt.Scan(time.Now().Add(ttl))
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Q.WithTx(tx)
+	err = qtx.DeleteUnusedAccountTokens(ctx, repository.DeleteUnusedAccountTokensParams{
+		UserID:  user.ID,
+		Purpose: purpose,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = qtx.CreateAccountToken(ctx, repository.CreateAccountTokenParams{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		Email:     email,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	data := notification.AccountEmailData{
+		Name:      user.Name,
+		ActionURL: strings.TrimRight(s.config.Auth.AppURL, "/") + path + "?token=" + url.QueryEscape(token),
+		ExpiresIn: formatExpiry(ttl),
+	}
+	go func() {
+		if err := s.notificationService.SendTemplateEmail(email, template, data); err != nil {
+			s.logger.Error("Failed to send account email",
+				zap.Int64("user_id", user.ID),
+				zap.String("purpose", purpose),
+				zap.Error(err),
+			)
+		}
+	}()
+	return nil
+}
+
+// formatExpiry 将有效期格式化为邮件中显示的文字，例如 "1 hour"、"30 minutes"
+func formatExpiry(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		hours := int(d / time.Hour)
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	minutes := int(d / time.Minute)
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
@@ -0,0 +1,139 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/middleware"
+	"github.com/zeroicey/lifetrack-api/internal/modules/user/types"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+)
+
+const (
+	// apiTokenBytes 个人访问令牌的随机字节数
+	apiTokenBytes = 24
+	// apiTokenPrefixLen 列表中展示的令牌前缀长度（包含 APITokenPrefix）
+	apiTokenPrefixLen = 10
+)
+
+var (
+	// ErrInvalidScope 是授权范围不合法时返回的哨兵错误
+	ErrInvalidScope = errors.New("invalid token scope")
+	// ErrAPITokenNotFound 是个人访问令牌不存在时返回的哨兵错误
+	ErrAPITokenNotFound = errors.New("api token not found")
+)
+
+// CreateAPIToken 创建个人访问令牌，明文只在创建时返回一次，数据库中只保存其哈希
+func (s *Service) CreateAPIToken(ctx context.Context, userID int64, body types.CreateAPITokenBody) (types.APITokenResponse, error) {
+	scopes := make([]string, 0, len(body.Scopes))
+	for _, scope := range body.Scopes {
+		scope = strings.TrimSpace(scope)
+		if !middleware.ValidScope(scope) {
+			return types.APITokenResponse{}, ErrInvalidScope
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return types.APITokenResponse{}, ErrInvalidScope
+	}
+
+	buf := make([]byte, apiTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return types.APITokenResponse{}, err
+	}
+	token := middleware.APITokenPrefix + hex.EncodeToString(buf)
+
+	// 未指定有效期时永不过期
+	expiresAt := pgtype.Timestamptz{}
+	if body.ExpiresInDays > 0 {
+		expiresAt.Scan(time.Now().AddDate(0, 0, body.ExpiresInDays))
+	}
+
+	apiToken, err := s.Q.CreateAPIToken(ctx, repository.CreateAPITokenParams{
+		UserID:      userID,
+		Name:        strings.TrimSpace(body.Name),
+		TokenPrefix: token[:apiTokenPrefixLen],
+		TokenHash:   hashToken(token),
+		Scopes:      scopes,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return types.APITokenResponse{}, err
+	}
+
+	result := convertToAPITokenResponse(apiToken)
+	result.Token = token
+	return result, nil
+}
+
+// ListAPITokens 获取用户的所有个人访问令牌
+func (s *Service) ListAPITokens(ctx context.Context, userID int64) ([]types.APITokenResponse, error) {
+	apiTokens, err := s.Q.ListAPITokensByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]types.APITokenResponse, 0, len(apiTokens))
+	for _, apiToken := range apiTokens {
+		result = append(result, convertToAPITokenResponse(apiToken))
+	}
+	return result, nil
+}
+
+// RevokeAPIToken 撤销（删除）用户的个人访问令牌
+func (s *Service) RevokeAPIToken(ctx context.Context, userID int64, id int64) error {
+	rows, err := s.Q.DeleteAPIToken(ctx, repository.DeleteAPITokenParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// ValidateAPIToken 校验个人访问令牌并记录使用时间，供 JWT 认证中间件使用
+func (s *Service) ValidateAPIToken(ctx context.Context, token string) (middleware.APIToken, error) {
+	row, err := s.Q.TouchAPIToken(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return middleware.APIToken{}, middleware.ErrInvalidAPIToken
+		}
+		return middleware.APIToken{}, err
+	}
+
+	return middleware.APIToken{
+		ID:     row.ID,
+		UserID: row.UserID,
+		Email:  row.Email,
+		Scopes: row.Scopes,
+	}, nil
+}
+
+func convertToAPITokenResponse(apiToken repository.ApiToken) types.APITokenResponse {
+	var expiresAt, lastUsedAt *time.Time
+	if apiToken.ExpiresAt.Valid {
+		expiresAt = &apiToken.ExpiresAt.Time
+	}
+	if apiToken.LastUsedAt.Valid {
+		lastUsedAt = &apiToken.LastUsedAt.Time
+	}
+	return types.APITokenResponse{
+		ID:         apiToken.ID,
+		Name:       apiToken.Name,
+		Prefix:     apiToken.TokenPrefix,
+		Scopes:     apiToken.Scopes,
+		ExpiresAt:  expiresAt,
+		LastUsedAt: lastUsedAt,
+		CreatedAt:  apiToken.CreatedAt.Time,
+	}
+}
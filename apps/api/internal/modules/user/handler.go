@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/mail"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/zeroicey/lifetrack-api/internal/middleware"
 	response "github.com/zeroicey/lifetrack-api/internal/pkg"
 	"github.com/zeroicey/lifetrack-api/internal/repository"
 
@@ -25,13 +28,62 @@ func NewHandler(s *Service, jwtManager *pkg.JWTManager) *Handler {
 	}
 }
 
-func UserRouter(s *Service, jwtManager *pkg.JWTManager) chi.Router {
+// UserRouter 用户路由，authLimiter 按 IP 限制登录、注册和重置密码，apiLimiter 限制其余接口，认证后按用户计数
+func UserRouter(s *Service, jwtManager *pkg.JWTManager, authLimiter, apiLimiter *middleware.RateLimiter) chi.Router {
 	h := NewHandler(s, jwtManager)
 	r := chi.NewRouter()
-	r.Post("/register", h.RegisterUser)
-	r.Post("/login", h.LoginUser)
-	r.Get("/exists", h.CheckUserExists)
-	r.Get("/profile", h.GetUser)
+
+	// 登录、注册和重置密码按 IP 严格限流，防止撞库和批量注册
+	r.Group(func(auth chi.Router) {
+		auth.Use(middleware.RateLimit(authLimiter))
+		auth.Post("/register", h.RegisterUser)
+		auth.Post("/login", h.LoginUser)
+		auth.Post("/login/2fa", h.LoginTwoFactor)
+		auth.Post("/password/forgot", h.ForgotPassword)
+		auth.Post("/password/reset", h.ResetPassword)
+	})
+
+	r.Group(func(public chi.Router) {
+		public.Use(middleware.RateLimit(apiLimiter))
+		public.Get("/exists", h.CheckUserExists)
+		public.Post("/refresh", h.RefreshToken)
+		public.Post("/logout", h.Logout)
+		public.Post("/email/verify", h.VerifyEmail)
+	})
+
+	r.Group(func(protected chi.Router) {
+		protected.Use(middleware.JWTAuth(jwtManager, s, s))
+		protected.Use(middleware.RateLimit(apiLimiter))
+		protected.Get("/profile", h.GetUser)
+		protected.Put("/profile", h.UpdateProfile)
+		protected.Put("/timezone", h.UpdateTimezone)
+
+		// 密码与邮箱相关路由
+		protected.Post("/password", h.ChangePassword)
+		protected.Post("/email", h.ChangeEmail)
+		protected.Post("/email/resend-verification", h.ResendEmailVerification)
+
+		// 邀请码相关路由
+		protected.Get("/invites", h.ListInvites)
+		protected.Post("/invites", h.CreateInvite)
+		protected.Delete("/invites/{id}", h.DeleteInvite)
+
+		// 会话相关路由
+		protected.Get("/sessions", h.ListSessions)
+		protected.Delete("/sessions/{id}", h.RevokeSession)
+
+		// 个人访问令牌相关路由
+		protected.Get("/tokens", h.ListAPITokens)
+		protected.Post("/tokens", h.CreateAPIToken)
+		protected.Delete("/tokens/{id}", h.RevokeAPIToken)
+
+		// 两步验证相关路由
+		protected.Get("/2fa", h.GetTwoFactorStatus)
+		protected.Post("/2fa/setup", h.SetupTwoFactor)
+		protected.Post("/2fa/enable", h.EnableTwoFactor)
+		protected.Post("/2fa/disable", h.DisableTwoFactor)
+		protected.Post("/2fa/recovery-codes", h.RegenerateRecoveryCodes)
+	})
 
 	return r
 }
@@ -74,13 +126,21 @@ func (h *Handler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		Name:         body.Name,
 		PasswordHash: hashedPassword,
 		Birthday:     body.Birthday,
-		AvatarBase64: body.AvatarBase64.String,
 		Bio:          body.Bio.String,
-	})
+		Timezone:     body.Timezone,
+	}, body.InviteCode, body.AvatarBase64.String)
 
 	if err != nil {
+		if errors.Is(err, ErrInvalidTimezone) {
+			response.Error("Invalid timezone, expected an IANA name such as Asia/Shanghai").SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
 		if errors.Is(err, ErrUserAlreadyExists) {
-			response.Error("User already exists. This system only supports one user.").SetStatusCode(http.StatusConflict).Build(w)
+			response.Error("User already exists").SetStatusCode(http.StatusConflict).Build(w)
+			return
+		}
+		if errors.Is(err, ErrInviteRequired) || errors.Is(err, ErrInvalidInvite) {
+			response.Error(err.Error()).SetStatusCode(http.StatusForbidden).Build(w)
 			return
 		}
 		response.Error("Failed to create user").SetStatusCode(http.StatusInternalServerError).Build(w)
@@ -99,13 +159,20 @@ func (h *Handler) CheckUserExists(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.Success("User existence check completed").SetData(user.UserExistsResponse{
-		Exists: exists,
+		Exists:           exists,
+		RegistrationMode: h.S.RegistrationMode(),
 	}).Build(w)
 }
 
 // GetUser 获取用户信息接口
 func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
-	userInfo, err := h.S.GetUser(r.Context())
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	userInfo, err := h.S.GetUser(r.Context(), userID)
 	if err != nil {
 		if errors.Is(err, ErrUserNotFound) {
 			response.Error("User not found").SetStatusCode(http.StatusNotFound).Build(w)
@@ -118,6 +185,73 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 	response.Success("User information retrieved successfully").SetData(userInfo).Build(w)
 }
 
+// UpdateProfile 更新个人资料接口，修改邮箱时向新邮箱发送验证邮件
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body user.UpdateProfileBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	result, err := h.S.UpdateProfile(r.Context(), userID, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidName), errors.Is(err, ErrInvalidBirthday), errors.Is(err, ErrInvalidPassword):
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		case errors.Is(err, ErrUserAlreadyExists):
+			response.Error("Email is already in use").SetStatusCode(http.StatusConflict).Build(w)
+		case errors.Is(err, ErrUserNotFound):
+			response.Error(err.Error()).SetStatusCode(http.StatusNotFound).Build(w)
+		default:
+			response.Error("Failed to update profile").SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+
+	message := "Profile updated successfully"
+	if result.EmailChangePending {
+		message = "Profile updated, verification email sent to the new address"
+	}
+	response.Success(message).SetData(result).Build(w)
+}
+
+// UpdateTimezone 更新用户时区接口
+func (h *Handler) UpdateTimezone(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body user.UpdateTimezoneBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	userInfo, err := h.S.UpdateTimezone(r.Context(), userID, body.Timezone)
+	if err != nil {
+		if errors.Is(err, ErrInvalidTimezone) {
+			response.Error("Invalid timezone, expected an IANA name such as Asia/Shanghai").SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		if errors.Is(err, ErrUserNotFound) {
+			response.Error("User not found").SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		response.Error("Failed to update timezone").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Timezone updated successfully").SetData(userInfo).Build(w)
+}
+
 // LoginUser 用户登录接口
 func (h *Handler) LoginUser(w http.ResponseWriter, r *http.Request) {
 	var body user.LoginUserBody
@@ -139,6 +273,11 @@ func (h *Handler) LoginUser(w http.ResponseWriter, r *http.Request) {
 	// 验证用户登录
 	userInfo, err := h.S.LoginUser(r.Context(), body.Email, body.Password)
 	if err != nil {
+		var lockedErr *LoginLockedError
+		if errors.As(err, &lockedErr) {
+			middleware.TooManyRequests(w, lockedErr.RetryAfter)
+			return
+		}
 		if errors.Is(err, ErrUserNotFound) {
 			response.Error("Invalid email or password").SetStatusCode(http.StatusUnauthorized).Build(w)
 			return
@@ -151,8 +290,87 @@ func (h *Handler) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 生成JWT token
-	token, err := h.JWTManager.GenerateToken(userInfo.ID, userInfo.Email)
+	// 启用两步验证时只返回 challenge token，验证码通过后才签发登录 token
+	twoFactor, err := h.S.TwoFactorEnabled(r.Context(), userInfo.ID)
+	if err != nil {
+		response.Error("Login failed").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+	if twoFactor {
+		challengeID, err := h.S.CreateLoginChallenge(r.Context(), userInfo.ID)
+		if err != nil {
+			response.Error("Login failed").SetStatusCode(http.StatusInternalServerError).Build(w)
+			return
+		}
+		challengeToken, err := h.JWTManager.GenerateChallengeToken(userInfo.ID, userInfo.Email, challengeID)
+		if err != nil {
+			response.Error("Failed to generate token").SetStatusCode(http.StatusInternalServerError).Build(w)
+			return
+		}
+		response.Success("Two-factor authentication required").SetData(user.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		}).Build(w)
+		return
+	}
+
+	h.completeLogin(w, r, userInfo)
+}
+
+// LoginTwoFactor 两步验证登录的第二步，提交 challenge token 和验证码（或恢复码）
+func (h *Handler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var body user.TwoFactorLoginBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if body.ChallengeToken == "" || body.Code == "" {
+		response.Error("Challenge token and code are required").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	claims, err := h.JWTManager.ValidateChallengeToken(body.ChallengeToken)
+	if err != nil {
+		response.Error("Invalid or expired challenge token").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	if err := h.S.VerifyLoginSecondFactor(r.Context(), claims.ID, claims.UserID, claims.Email, body.Code); err != nil {
+		var lockedErr *LoginLockedError
+		if errors.As(err, &lockedErr) {
+			middleware.TooManyRequests(w, lockedErr.RetryAfter)
+			return
+		}
+		if errors.Is(err, ErrInvalidChallenge) {
+			response.Error(err.Error()).SetStatusCode(http.StatusUnauthorized).Build(w)
+			return
+		}
+		if errors.Is(err, ErrInvalidTwoFactorCode) || errors.Is(err, ErrTwoFactorNotEnabled) {
+			response.Error(ErrInvalidTwoFactorCode.Error()).SetStatusCode(http.StatusUnauthorized).Build(w)
+			return
+		}
+		response.Error("Login failed").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	userInfo, err := h.S.GetUser(r.Context(), claims.UserID)
+	if err != nil {
+		response.Error("Invalid or expired challenge token").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	h.completeLogin(w, r, userInfo)
+}
+
+// completeLogin 创建会话并返回登录 token
+func (h *Handler) completeLogin(w http.ResponseWriter, r *http.Request, userInfo user.UserResponse) {
+	// 创建会话并生成JWT token
+	session, err := h.S.CreateSession(r.Context(), userInfo.ID, r.UserAgent(), pkg.ClientIP(r))
+	if err != nil {
+		response.Error("Failed to create session").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+	token, err := h.JWTManager.GenerateToken(userInfo.ID, userInfo.Email, session.SessionID)
 	if err != nil {
 		response.Error("Failed to generate token").SetStatusCode(http.StatusInternalServerError).Build(w)
 		return
@@ -160,9 +378,577 @@ func (h *Handler) LoginUser(w http.ResponseWriter, r *http.Request) {
 
 	// 返回登录响应
 	loginResponse := user.LoginResponse{
-		Token: token,
-		User:  userInfo,
+		Token:        token,
+		RefreshToken: session.RefreshToken,
+		ExpiresIn:    int(h.JWTManager.AccessTokenTTL().Seconds()),
+		User:         userInfo,
 	}
 
 	response.Success("Login successful").SetData(loginResponse).Build(w)
 }
+
+// CreateInvite 创建邀请码接口
+func (h *Handler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body user.CreateInviteBody
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+	}
+	if body.Email != "" {
+		if _, err := mail.ParseAddress(body.Email); err != nil {
+			response.Error("Invalid email").SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+	}
+
+	invite, err := h.S.CreateInvite(r.Context(), userID, body)
+	if err != nil {
+		response.Error("Failed to create invite").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Invite created successfully").SetStatusCode(http.StatusCreated).SetData(invite).Build(w)
+}
+
+// ListInvites 获取当前用户创建的邀请接口
+func (h *Handler) ListInvites(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	invites, err := h.S.ListInvites(r.Context(), userID)
+	if err != nil {
+		response.Error("Failed to get invites").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Invites retrieved successfully").SetData(invites).Build(w)
+}
+
+// DeleteInvite 撤销尚未使用的邀请接口
+func (h *Handler) DeleteInvite(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.Error("Invalid invite ID").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	err = h.S.DeleteInvite(r.Context(), userID, id)
+	if err != nil {
+		if errors.Is(err, ErrInviteNotFound) {
+			response.Error("Invite not found").SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		response.Error("Failed to delete invite").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Invite deleted successfully").Build(w)
+}
+
+// RefreshToken 使用 refresh token 换取新的 access token 和 refresh token 接口
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var body user.RefreshTokenBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	session, err := h.S.RefreshSession(r.Context(), body.RefreshToken, pkg.ClientIP(r))
+	if err != nil {
+		if errors.Is(err, ErrInvalidRefreshToken) {
+			response.Error(err.Error()).SetStatusCode(http.StatusUnauthorized).Build(w)
+			return
+		}
+		response.Error("Failed to refresh token").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	userInfo, err := h.S.GetUser(r.Context(), session.UserID)
+	if err != nil {
+		response.Error("User not found").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	token, err := h.JWTManager.GenerateToken(userInfo.ID, userInfo.Email, session.SessionID)
+	if err != nil {
+		response.Error("Failed to generate token").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Token refreshed successfully").SetData(user.TokenResponse{
+		Token:        token,
+		RefreshToken: session.RefreshToken,
+		ExpiresIn:    int(h.JWTManager.AccessTokenTTL().Seconds()),
+	}).Build(w)
+}
+
+// Logout 退出登录接口，撤销 refresh token 所属的会话
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	var body user.RefreshTokenBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if body.RefreshToken == "" {
+		response.Error("Refresh token is required").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	err := h.S.Logout(r.Context(), body.RefreshToken)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRefreshToken) {
+			response.Error(err.Error()).SetStatusCode(http.StatusUnauthorized).Build(w)
+			return
+		}
+		response.Error("Failed to logout").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Logout successful").Build(w)
+}
+
+// ListSessions 获取当前用户所有有效会话接口
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+	sessionID, _ := middleware.GetSessionIDFromContext(r.Context())
+
+	sessions, err := h.S.ListSessions(r.Context(), userID, sessionID)
+	if err != nil {
+		response.Error("Failed to get sessions").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Sessions retrieved successfully").SetData(sessions).Build(w)
+}
+
+// RevokeSession 撤销当前用户的某个会话接口
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	err := h.S.RevokeSession(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			response.Error("Session not found").SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		response.Error("Failed to revoke session").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Session revoked successfully").Build(w)
+}
+
+// CreateAPIToken 创建个人访问令牌接口
+func (h *Handler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body user.CreateAPITokenBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if body.Name == "" {
+		response.Error("Name is required").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if body.ExpiresInDays < 0 {
+		response.Error("expires_in_days must not be negative").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	apiToken, err := h.S.CreateAPIToken(r.Context(), userID, body)
+	if err != nil {
+		if errors.Is(err, ErrInvalidScope) {
+			response.Error("Invalid scopes, expected read, write or <resource>:read|write").SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		response.Error("Failed to create api token").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("API token created successfully").SetStatusCode(http.StatusCreated).SetData(apiToken).Build(w)
+}
+
+// ListAPITokens 获取当前用户的个人访问令牌接口
+func (h *Handler) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	apiTokens, err := h.S.ListAPITokens(r.Context(), userID)
+	if err != nil {
+		response.Error("Failed to get api tokens").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("API tokens retrieved successfully").SetData(apiTokens).Build(w)
+}
+
+// RevokeAPIToken 撤销个人访问令牌接口
+func (h *Handler) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.Error("Invalid token ID").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	err = h.S.RevokeAPIToken(r.Context(), userID, id)
+	if err != nil {
+		if errors.Is(err, ErrAPITokenNotFound) {
+			response.Error("API token not found").SetStatusCode(http.StatusNotFound).Build(w)
+			return
+		}
+		response.Error("Failed to revoke api token").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("API token revoked successfully").Build(w)
+}
+
+// GetTwoFactorStatus 获取两步验证状态接口
+func (h *Handler) GetTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	status, err := h.S.GetTwoFactorStatus(r.Context(), userID)
+	if err != nil {
+		response.Error("Failed to get two-factor status").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Two-factor status retrieved successfully").SetData(status).Build(w)
+}
+
+// SetupTwoFactor 开始绑定 TOTP 验证器接口，返回密钥和 otpauth 地址
+func (h *Handler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	setup, err := h.S.SetupTwoFactor(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, ErrTwoFactorAlreadyEnabled) {
+			response.Error(err.Error()).SetStatusCode(http.StatusConflict).Build(w)
+			return
+		}
+		response.Error("Failed to set up two-factor authentication").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Two-factor setup started").SetData(setup).Build(w)
+}
+
+// EnableTwoFactor 验证验证码并启用两步验证接口，返回恢复码
+func (h *Handler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body user.TwoFactorCodeBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	codes, err := h.S.EnableTwoFactor(r.Context(), userID, body.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTwoFactorCode):
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		case errors.Is(err, ErrTwoFactorNotEnabled):
+			response.Error("Two-factor setup has not been started").SetStatusCode(http.StatusBadRequest).Build(w)
+		case errors.Is(err, ErrTwoFactorAlreadyEnabled):
+			response.Error(err.Error()).SetStatusCode(http.StatusConflict).Build(w)
+		default:
+			response.Error("Failed to enable two-factor authentication").SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+
+	response.Success("Two-factor authentication enabled").SetData(user.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}).Build(w)
+}
+
+// DisableTwoFactor 关闭两步验证接口
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body user.DisableTwoFactorBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	err := h.S.DisableTwoFactor(r.Context(), userID, body.Password, body.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidPassword), errors.Is(err, ErrInvalidTwoFactorCode):
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		case errors.Is(err, ErrTwoFactorNotEnabled):
+			response.Error(err.Error()).SetStatusCode(http.StatusConflict).Build(w)
+		default:
+			response.Error("Failed to disable two-factor authentication").SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+
+	response.Success("Two-factor authentication disabled").Build(w)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码接口
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body user.TwoFactorCodeBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	codes, err := h.S.RegenerateRecoveryCodes(r.Context(), userID, body.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTwoFactorCode):
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		case errors.Is(err, ErrTwoFactorNotEnabled):
+			response.Error(err.Error()).SetStatusCode(http.StatusConflict).Build(w)
+		default:
+			response.Error("Failed to regenerate recovery codes").SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+
+	response.Success("Recovery codes regenerated").SetData(user.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}).Build(w)
+}
+
+// ChangePassword 修改密码接口，成功后其他会话全部失效
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+	sessionID, _ := middleware.GetSessionIDFromContext(r.Context())
+
+	var body user.ChangePasswordBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if body.CurrentPassword == "" {
+		response.Error("Current password is required").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if len(body.NewPassword) < 6 {
+		response.Error("Password must be at least 6 characters").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	err := h.S.ChangePassword(r.Context(), userID, sessionID, body.CurrentPassword, body.NewPassword)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidPassword):
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		case errors.Is(err, ErrUserNotFound):
+			response.Error(err.Error()).SetStatusCode(http.StatusNotFound).Build(w)
+		default:
+			response.Error("Failed to change password").SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+
+	response.Success("Password changed successfully").Build(w)
+}
+
+// ForgotPassword 发送重置密码邮件接口，无论邮箱是否存在都返回成功
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var body user.ForgotPasswordBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if body.Email == "" {
+		response.Error("Email is required").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	if err := h.S.RequestPasswordReset(r.Context(), body.Email); err != nil {
+		response.Error("Failed to request password reset").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("If the email is registered, a password reset link has been sent").SetStatusCode(http.StatusAccepted).Build(w)
+}
+
+// ResetPassword 使用重置令牌设置新密码接口
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var body user.ResetPasswordBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if body.Token == "" {
+		response.Error("Token is required").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if len(body.NewPassword) < 6 {
+		response.Error("Password must be at least 6 characters").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	if err := h.S.ResetPassword(r.Context(), body.Token, body.NewPassword); err != nil {
+		if errors.Is(err, ErrInvalidAccountToken) {
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+			return
+		}
+		response.Error("Failed to reset password").SetStatusCode(http.StatusInternalServerError).Build(w)
+		return
+	}
+
+	response.Success("Password reset successfully").Build(w)
+}
+
+// VerifyEmail 使用验证令牌确认邮箱接口
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var body user.VerifyEmailBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if body.Token == "" {
+		response.Error("Token is required").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	userInfo, err := h.S.VerifyEmail(r.Context(), body.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidAccountToken):
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		case errors.Is(err, ErrUserAlreadyExists):
+			response.Error("Email is already in use").SetStatusCode(http.StatusConflict).Build(w)
+		default:
+			response.Error("Failed to verify email").SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+
+	response.Success("Email verified successfully").SetData(userInfo).Build(w)
+}
+
+// ResendEmailVerification 重新发送邮箱验证邮件接口
+func (h *Handler) ResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	if err := h.S.ResendEmailVerification(r.Context(), userID); err != nil {
+		switch {
+		case errors.Is(err, ErrEmailAlreadyVerified):
+			response.Error(err.Error()).SetStatusCode(http.StatusConflict).Build(w)
+		case errors.Is(err, ErrUserNotFound):
+			response.Error(err.Error()).SetStatusCode(http.StatusNotFound).Build(w)
+		default:
+			response.Error("Failed to send verification email").SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+
+	response.Success("Verification email sent").SetStatusCode(http.StatusAccepted).Build(w)
+}
+
+// ChangeEmail 修改邮箱接口，向新邮箱发送验证邮件，验证后生效
+func (h *Handler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.Error("Unauthorized").SetStatusCode(http.StatusUnauthorized).Build(w)
+		return
+	}
+
+	var body user.ChangeEmailBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error("Failed to decode request body").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if body.Email == "" {
+		response.Error("Email is required").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+	if body.Password == "" {
+		response.Error("Password is required").SetStatusCode(http.StatusBadRequest).Build(w)
+		return
+	}
+
+	if err := h.S.RequestEmailChange(r.Context(), userID, body.Password, body.Email); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidPassword):
+			response.Error(err.Error()).SetStatusCode(http.StatusBadRequest).Build(w)
+		case errors.Is(err, ErrUserAlreadyExists):
+			response.Error("Email is already in use").SetStatusCode(http.StatusConflict).Build(w)
+		case errors.Is(err, ErrUserNotFound):
+			response.Error(err.Error()).SetStatusCode(http.StatusNotFound).Build(w)
+		default:
+			response.Error("Failed to request email change").SetStatusCode(http.StatusInternalServerError).Build(w)
+		}
+		return
+	}
+
+	response.Success("Verification email sent to the new address").SetStatusCode(http.StatusAccepted).Build(w)
+}
@@ -0,0 +1,91 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/modules/user/types"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+)
+
+// inviteCodeBytes 邀请码的随机字节数，编码后为 32 位十六进制字符串
+const inviteCodeBytes = 16
+
+// CreateInvite 创建邀请码，明文只在创建时返回一次，数据库中只保存其哈希
+func (s *Service) CreateInvite(ctx context.Context, userID int64, body types.CreateInviteBody) (types.InviteResponse, error) {
+	buf := make([]byte, inviteCodeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return types.InviteResponse{}, err
+	}
+	code := hex.EncodeToString(buf)
+
+	expiresAt := pgtype.Timestamptz{}
+	expiresAt.Scan(time.Now().Add(time.Duration(s.config.Auth.InviteExpiry) * time.Hour))
+
+	invite, err := s.Q.CreateInvite(ctx, repository.CreateInviteParams{
+		CodeHash:  hashInviteCode(code),
+		Email:     strings.TrimSpace(body.Email),
+		CreatedBy: userID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return types.InviteResponse{}, err
+	}
+
+	result := convertToInviteResponse(invite)
+	result.Code = code
+	return result, nil
+}
+
+// ListInvites 获取用户创建的所有邀请
+func (s *Service) ListInvites(ctx context.Context, userID int64) ([]types.InviteResponse, error) {
+	invites, err := s.Q.ListInvitesByCreator(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]types.InviteResponse, 0, len(invites))
+	for _, invite := range invites {
+		result = append(result, convertToInviteResponse(invite))
+	}
+	return result, nil
+}
+
+// DeleteInvite 撤销用户创建且尚未使用的邀请
+func (s *Service) DeleteInvite(ctx context.Context, userID int64, id int64) error {
+	rows, err := s.Q.DeleteInvite(ctx, repository.DeleteInviteParams{
+		ID:        id,
+		CreatedBy: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// hashInviteCode 计算邀请码的 SHA-256 哈希
+func hashInviteCode(code string) string {
+	return hashToken(strings.TrimSpace(code))
+}
+
+func convertToInviteResponse(invite repository.Invite) types.InviteResponse {
+	var usedAt *time.Time
+	if invite.UsedAt.Valid {
+		usedAt = &invite.UsedAt.Time
+	}
+	return types.InviteResponse{
+		ID:        invite.ID,
+		Email:     invite.Email,
+		Used:      invite.UsedAt.Valid,
+		ExpiresAt: invite.ExpiresAt.Time,
+		UsedAt:    usedAt,
+		CreatedAt: invite.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,162 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+// ErrLoginLocked 是连续登录失败次数过多、邮箱被暂时锁定时返回的哨兵错误
+var ErrLoginLocked = errors.New("too many failed login attempts")
+
+// LoginLockedError 携带锁定剩余时长，errors.Is(err, ErrLoginLocked) 为 true
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrLoginLocked, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+// lockoutKey 登录失败按小写邮箱统计
+func lockoutKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkLoginLockout 邮箱处于锁定期时返回 *LoginLockedError
+func (s *Service) checkLoginLockout(ctx context.Context, email string) error {
+	failure, err := s.Q.GetLoginFailure(ctx, lockoutKey(email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if failure.LockedUntil.Valid {
+		if remaining := time.Until(failure.LockedUntil.Time); remaining > 0 {
+			return &LoginLockedError{RetryAfter: remaining}
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure 记录一次登录失败，达到阈值后锁定邮箱，每多失败一次锁定时长翻倍
+// 本次失败触发锁定时返回 *LoginLockedError
+func (s *Service) recordLoginFailure(ctx context.Context, email string) error {
+	cfg := s.config.RateLimit
+	if cfg.LoginMaxAttempts <= 0 {
+		return nil
+	}
+
+	resetBefore := pgtype.Timestamptz{}
+	resetBefore.Scan(time.Now().Add(-time.Duration(cfg.LoginFailureWindow) * time.Minute))
+
+	failure, err := s.Q.RecordLoginFailure(ctx, repository.RecordLoginFailureParams{
+		Email:       lockoutKey(email),
+		ResetBefore: resetBefore,
+	})
+	if err != nil {
+		return err
+	}
+	if int(failure.FailedCount) < cfg.LoginMaxAttempts {
+		return nil
+	}
+
+	lockout := lockoutDuration(int(failure.FailedCount)-cfg.LoginMaxAttempts,
+		time.Duration(cfg.LoginLockoutBase)*time.Second,
+		time.Duration(cfg.LoginLockoutMax)*time.Second)
+
+	lockedUntil := pgtype.Timestamptz{}
+	lockedUntil.Scan(time.Now().Add(lockout))
+	err = s.Q.LockLogin(ctx, repository.LockLoginParams{
+		LockedUntil: lockedUntil,
+		Email:       failure.Email,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Warn("Login locked after repeated failures",
+		zap.String("email", failure.Email),
+		zap.Int32("failed_count", failure.FailedCount),
+		zap.Duration("lockout", lockout),
+	)
+	return &LoginLockedError{RetryAfter: lockout}
+}
+
+// clearLoginFailures 登录成功后清除失败记录
+func (s *Service) clearLoginFailures(ctx context.Context, email string) {
+	if err := s.Q.ClearLoginFailures(ctx, lockoutKey(email)); err != nil {
+		s.logger.Warn("Failed to clear login failures", zap.Error(err))
+	}
+}
+
+// lockoutDuration 计算第 n 次（从 0 开始）锁定的时长：base * 2^n，不超过 max
+func lockoutDuration(n int, base, max time.Duration) time.Duration {
+	lockout := base
+	for range n {
+		lockout *= 2
+		if lockout >= max {
+			return max
+		}
+	}
+	return min(lockout, max)
+}
+
+// VerifyLoginSecondFactor 校验登录时的两步验证码，验证码错误同样计入登录失败次数
+// 每次提交先计入 challenge 的尝试次数，验证通过后 challenge 被标记为已使用，同一 challenge token 不能再次登录
+func (s *Service) VerifyLoginSecondFactor(ctx context.Context, challengeID string, userID int64, email, code string) error {
+	id, err := pkg.StringToPgUUID(challengeID)
+	if err != nil {
+		return ErrInvalidChallenge
+	}
+	if err := s.checkLoginLockout(ctx, email); err != nil {
+		return err
+	}
+
+	_, err = s.Q.BeginTwoFactorChallengeAttempt(ctx, repository.BeginTwoFactorChallengeAttemptParams{
+		ID:          id,
+		UserID:      userID,
+		MaxAttempts: challengeMaxAttempts,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidChallenge
+		}
+		return err
+	}
+
+	err = s.VerifySecondFactor(ctx, userID, code)
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		if lockErr := s.recordLoginFailure(ctx, email); lockErr != nil {
+			return lockErr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.Q.ConsumeTwoFactorChallenge(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInvalidChallenge
+	}
+
+	s.clearLoginFailures(ctx, email)
+	return nil
+}
@@ -0,0 +1,85 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/modules/user/types"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+)
+
+var (
+	// ErrInvalidName 是用户名为空时返回的哨兵错误
+	ErrInvalidName = errors.New("name cannot be empty")
+	// ErrInvalidBirthday 是生日不是 YYYY-MM-DD 格式或晚于今天时返回的哨兵错误
+	ErrInvalidBirthday = errors.New("invalid birthday, expected YYYY-MM-DD")
+)
+
+// UpdateProfile 更新用户名、简介和生日，只修改 body 中提供的字段
+// 邮箱变化时需要当前密码，新邮箱通过验证邮件确认后才会生效
+func (s *Service) UpdateProfile(ctx context.Context, userID int64, body types.UpdateProfileBody) (types.UpdateProfileResponse, error) {
+	user, err := s.Q.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.UpdateProfileResponse{}, ErrUserNotFound
+		}
+		return types.UpdateProfileResponse{}, err
+	}
+
+	params := repository.UpdateUserProfileParams{
+		ID:       userID,
+		Name:     user.Name,
+		Birthday: user.Birthday,
+		Bio:      user.Bio,
+	}
+	if body.Name != nil {
+		params.Name = strings.TrimSpace(*body.Name)
+		if params.Name == "" {
+			return types.UpdateProfileResponse{}, ErrInvalidName
+		}
+	}
+	if body.Bio != nil {
+		params.Bio = *body.Bio
+	}
+	if body.Birthday != nil {
+		params.Birthday, err = parseBirthday(*body.Birthday)
+		if err != nil {
+			return types.UpdateProfileResponse{}, err
+		}
+	}
+
+	// 先校验密码并发送验证邮件，失败时不修改其他字段
+	emailChange := body.Email != nil && !strings.EqualFold(strings.TrimSpace(*body.Email), user.Email)
+	if emailChange {
+		if err := s.RequestEmailChange(ctx, userID, body.Password, *body.Email); err != nil {
+			return types.UpdateProfileResponse{}, err
+		}
+	}
+
+	updated, err := s.Q.UpdateUserProfile(ctx, params)
+	if err != nil {
+		return types.UpdateProfileResponse{}, err
+	}
+
+	return types.UpdateProfileResponse{
+		UserResponse:       s.convertToUserResponse(ctx, updated),
+		EmailChangePending: emailChange,
+	}, nil
+}
+
+// parseBirthday 解析 YYYY-MM-DD 格式的生日，空字符串表示清除
+func parseBirthday(value string) (pgtype.Date, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return pgtype.Date{}, nil
+	}
+	birthday, err := time.Parse(time.DateOnly, value)
+	if err != nil || birthday.After(time.Now()) {
+		return pgtype.Date{}, ErrInvalidBirthday
+	}
+	return pgtype.Date{Time: birthday, Valid: true}, nil
+}
@@ -4,14 +4,27 @@ import (
 	"context"
 	"errors"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/zeroicey/lifetrack-api/internal/config"
+	"github.com/zeroicey/lifetrack-api/internal/modules/notification"
+	"github.com/zeroicey/lifetrack-api/internal/modules/storage"
 	"github.com/zeroicey/lifetrack-api/internal/modules/user/types"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
 	"github.com/zeroicey/lifetrack-api/internal/repository"
+	"go.uber.org/zap"
 )
 
 type Service struct {
-	Q *repository.Queries // Q 是 sqlc 生成的 Queries 结构体实例
+	Q                   *repository.Queries // Q 是 sqlc 生成的 Queries 结构体实例
+	DB                  *pgxpool.Pool
+	logger              *zap.Logger
+	config              *config.Config
+	notificationService *notification.Service
+	storageService      *storage.Service
 }
 
 // Sentinel errors for user domain
@@ -19,10 +32,17 @@ var (
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrUserNotFound      = errors.New("user not found")
 	ErrInvalidPassword   = errors.New("invalid password")
+	ErrInvalidTimezone   = pkg.ErrInvalidTimezone
+	// ErrInviteRequired 是邀请注册模式下未提供邀请码时返回的哨兵错误
+	ErrInviteRequired = errors.New("invite code is required")
+	// ErrInvalidInvite 是邀请码无效、已使用、已过期或不属于该邮箱时返回的哨兵错误
+	ErrInvalidInvite = errors.New("invalid or expired invite code")
+	// ErrInviteNotFound 是邀请不存在或已被使用时返回的哨兵错误
+	ErrInviteNotFound = errors.New("invite not found")
 )
 
-func NewService(q *repository.Queries) *Service {
-	return &Service{Q: q}
+func NewService(db *pgxpool.Pool, q *repository.Queries, logger *zap.Logger, config *config.Config, notificationService *notification.Service, storageService *storage.Service) *Service {
+	return &Service{Q: q, DB: db, logger: logger, config: config, notificationService: notificationService, storageService: storageService}
 }
 
 // CheckUserExists 检查系统中是否已有用户
@@ -34,34 +54,123 @@ func (s *Service) CheckUserExists(ctx context.Context) (bool, error) {
 	return exists, nil
 }
 
-// RegisterUser 注册新用户（单用户系统，只允许注册一个用户）
-func (s *Service) RegisterUser(ctx context.Context, params repository.CreateUserParams) (types.UserResponse, error) {
-	// 检查是否已有用户存在
-	exists, err := s.CheckUserExists(ctx)
+// RegistrationMode 返回当前的注册模式
+func (s *Service) RegistrationMode() string {
+	return s.config.Auth.RegistrationMode
+}
+
+// RegisterUser 注册新用户，成功后发送验证邮件
+// 第一个用户和开放注册模式下直接创建，邀请注册模式下需要有效的邀请码，创建用户与占用邀请码在同一事务中完成
+// avatarBase64 不为空时转存为对象存储中的头像，失败不影响注册，用户可以之后重新上传
+func (s *Service) RegisterUser(ctx context.Context, params repository.CreateUserParams, inviteCode, avatarBase64 string) (types.UserResponse, error) {
+	user, err := s.createUser(ctx, params, inviteCode)
 	if err != nil {
 		return types.UserResponse{}, err
 	}
-	if exists {
-		return types.UserResponse{}, ErrUserAlreadyExists
+
+	if avatarBase64 != "" {
+		avatarKey, err := s.storageService.SetInlineAvatar(ctx, user.ID, avatarBase64)
+		if err != nil {
+			s.logger.Warn("Failed to store avatar", zap.Int64("user_id", user.ID), zap.Error(err))
+		}
+		user.AvatarKey = avatarKey
+	}
+
+	if err := s.sendEmailVerification(ctx, user, user.Email); err != nil {
+		s.logger.Warn("Failed to send verification email", zap.Int64("user_id", user.ID), zap.Error(err))
+	}
+	return s.convertToUserResponse(ctx, user), nil
+}
+
+// createUser 按注册模式创建用户
+func (s *Service) createUser(ctx context.Context, params repository.CreateUserParams, inviteCode string) (repository.User, error) {
+	// 验证时区，未指定时使用 UTC
+	if params.Timezone == "" {
+		params.Timezone = "UTC"
+	}
+	if _, err := pkg.LoadLocation(params.Timezone); err != nil {
+		return repository.User{}, err
+	}
+
+	// 检查邮箱是否已被注册
+	if _, err := s.Q.GetUserByEmail(ctx, params.Email); err == nil {
+		return repository.User{}, ErrUserAlreadyExists
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return repository.User{}, err
 	}
 
-	// 创建用户
-	user, err := s.Q.CreateUser(ctx, params)
+	exists, err := s.CheckUserExists(ctx)
 	if err != nil {
-		return types.UserResponse{}, err
+		return repository.User{}, err
+	}
+	if !exists || s.config.Auth.RegistrationMode == config.RegistrationModeOpen {
+		return s.Q.CreateUser(ctx, params)
+	}
+
+	if inviteCode == "" {
+		return repository.User{}, ErrInviteRequired
 	}
 
-	return s.convertToUserResponse(user), nil
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Q.WithTx(tx)
+	user, err := qtx.CreateUser(ctx, params)
+	if err != nil {
+		return repository.User{}, err
+	}
+
+	_, err = qtx.ClaimInvite(ctx, repository.ClaimInviteParams{
+		UserID:   pgtype.Int8{Int64: user.ID, Valid: true},
+		CodeHash: hashInviteCode(inviteCode),
+		Email:    user.Email,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.User{}, ErrInvalidInvite
+		}
+		return repository.User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.User{}, err
+	}
+
+	return user, nil
 }
 
 // GetUser 获取用户信息
-func (s *Service) GetUser(ctx context.Context) (types.UserResponse, error) {
-	user, err := s.Q.GetUser(ctx)
+func (s *Service) GetUser(ctx context.Context, userID int64) (types.UserResponse, error) {
+	user, err := s.Q.GetUserByID(ctx, userID)
 	if err != nil {
 		return types.UserResponse{}, ErrUserNotFound
 	}
 
-	return s.convertToUserResponse(user), nil
+	return s.convertToUserResponse(ctx, user), nil
+}
+
+// UpdateTimezone 更新用户的时区偏好
+func (s *Service) UpdateTimezone(ctx context.Context, userID int64, timezone string) (types.UserResponse, error) {
+	loc, err := pkg.LoadLocation(timezone)
+	if err != nil || timezone == "" {
+		return types.UserResponse{}, ErrInvalidTimezone
+	}
+
+	user, err := s.Q.UpdateUserTimezone(ctx, repository.UpdateUserTimezoneParams{
+		ID:       userID,
+		Timezone: loc.String(),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.UserResponse{}, ErrUserNotFound
+		}
+		return types.UserResponse{}, err
+	}
+
+	return s.convertToUserResponse(ctx, user), nil
 }
 
 // HashPassword 对密码进行哈希处理
@@ -80,31 +189,55 @@ func (s *Service) VerifyPassword(hashedPassword, password string) error {
 
 // LoginUser 用户登录验证
 func (s *Service) LoginUser(ctx context.Context, email, password string) (types.UserResponse, error) {
+	// 连续失败次数过多的邮箱在锁定期内直接拒绝
+	if err := s.checkLoginLockout(ctx, email); err != nil {
+		return types.UserResponse{}, err
+	}
+
 	// 根据邮箱获取用户
 	user, err := s.Q.GetUserByEmail(ctx, email)
 	if err != nil {
+		if lockErr := s.recordLoginFailure(ctx, email); lockErr != nil {
+			return types.UserResponse{}, lockErr
+		}
 		return types.UserResponse{}, ErrUserNotFound
 	}
 
 	// 验证密码
 	if err := s.VerifyPassword(user.PasswordHash, password); err != nil {
+		if lockErr := s.recordLoginFailure(ctx, email); lockErr != nil {
+			return types.UserResponse{}, lockErr
+		}
 		return types.UserResponse{}, ErrInvalidPassword
 	}
 
-	return s.convertToUserResponse(user), nil
+	// 启用两步验证时等验证码通过后再清除失败记录，避免反复输入密码来重置验证码的尝试次数
+	if enabled, err := s.TwoFactorEnabled(ctx, user.ID); err == nil && !enabled {
+		s.clearLoginFailures(ctx, email)
+	}
+	return s.convertToUserResponse(ctx, user), nil
 }
 
 // convertToUserResponse 将数据库模型转换为响应模型
-func (s *Service) convertToUserResponse(user repository.User) types.UserResponse {
+// 头像地址生成失败时只记录日志，不影响返回用户信息
+func (s *Service) convertToUserResponse(ctx context.Context, user repository.User) types.UserResponse {
+	avatar, err := s.storageService.AvatarURLs(ctx, user.AvatarKey)
+	if err != nil {
+		s.logger.Warn("Failed to generate avatar URLs", zap.Int64("user_id", user.ID), zap.Error(err))
+	}
+
 	response := types.UserResponse{
-		ID:           user.ID,
-		Email:        user.Email,
-		Name:         user.Name,
-		Birthday:     user.Birthday.Time.Format("2006-01-02"),
-		Bio:          user.Bio,
-		AvatarBase64: user.AvatarBase64,
-		CreatedAt:    user.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:    user.UpdatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+		ID:            user.ID,
+		Email:         user.Email,
+		Name:          user.Name,
+		Birthday:      user.Birthday.Time.Format("2006-01-02"),
+		Bio:           user.Bio,
+		Timezone:      user.Timezone,
+		AvatarURL:     avatar.AvatarURL,
+		AvatarURLs:    avatar.AvatarURLs,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		CreatedAt:     user.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:     user.UpdatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
 	}
 	return response
 }
@@ -0,0 +1,179 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/modules/user/types"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+)
+
+// refreshTokenBytes refresh token 的随机字节数
+const refreshTokenBytes = 32
+
+var (
+	// ErrInvalidRefreshToken 是 refresh token 无效、已过期、已轮换或会话已撤销时返回的哨兵错误
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrSessionNotFound 是会话不存在或已被撤销时返回的哨兵错误
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// SessionTokens 是创建或刷新会话后返回给处理器的凭据，处理器据此签发 access token
+type SessionTokens struct {
+	SessionID    string
+	UserID       int64
+	RefreshToken string
+}
+
+// CreateSession 为登录用户创建会话并生成 refresh token，明文只返回这一次
+func (s *Service) CreateSession(ctx context.Context, userID int64, userAgent, ip string) (SessionTokens, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	session, err := s.Q.CreateSession(ctx, repository.CreateSessionParams{
+		UserID:           userID,
+		RefreshTokenHash: hashToken(refreshToken),
+		UserAgent:        userAgent,
+		IpAddress:        ip,
+		ExpiresAt:        s.refreshTokenExpiry(),
+	})
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	return SessionTokens{
+		SessionID:    session.ID.String(),
+		UserID:       session.UserID,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// RefreshSession 用 refresh token 换取新的 refresh token（轮换），旧 token 立即失效
+// 已轮换的 token 被再次使用时视为泄露，撤销整个会话
+func (s *Service) RefreshSession(ctx context.Context, refreshToken, ip string) (SessionTokens, error) {
+	if refreshToken == "" {
+		return SessionTokens{}, ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, err := generateRefreshToken()
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	tokenHash := hashToken(refreshToken)
+	session, err := s.Q.RotateSessionRefreshToken(ctx, repository.RotateSessionRefreshTokenParams{
+		NewRefreshTokenHash: hashToken(newRefreshToken),
+		IpAddress:           ip,
+		ExpiresAt:           s.refreshTokenExpiry(),
+		RefreshTokenHash:    tokenHash,
+	})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return SessionTokens{}, err
+		}
+		if _, err := s.Q.RevokeSessionByPreviousRefreshTokenHash(ctx, tokenHash); err != nil {
+			return SessionTokens{}, err
+		}
+		return SessionTokens{}, ErrInvalidRefreshToken
+	}
+
+	return SessionTokens{
+		SessionID:    session.ID.String(),
+		UserID:       session.UserID,
+		RefreshToken: newRefreshToken,
+	}, nil
+}
+
+// Logout 撤销 refresh token 所属的会话
+func (s *Service) Logout(ctx context.Context, refreshToken string) error {
+	rows, err := s.Q.RevokeSessionByRefreshTokenHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInvalidRefreshToken
+	}
+	return nil
+}
+
+// ListSessions 获取用户所有有效的会话，currentSessionID 对应的会话标记为当前会话
+func (s *Service) ListSessions(ctx context.Context, userID int64, currentSessionID string) ([]types.SessionResponse, error) {
+	sessions, err := s.Q.ListActiveSessionsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]types.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		id := session.ID.String()
+		result = append(result, types.SessionResponse{
+			ID:         id,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IpAddress,
+			Current:    id == currentSessionID,
+			ExpiresAt:  session.ExpiresAt.Time,
+			LastUsedAt: session.LastUsedAt.Time,
+			CreatedAt:  session.CreatedAt.Time,
+		})
+	}
+	return result, nil
+}
+
+// RevokeSession 撤销用户的某个会话，该会话签发的 access token 立即失效
+func (s *Service) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	id, err := pkg.StringToPgUUID(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+
+	rows, err := s.Q.RevokeSession(ctx, repository.RevokeSessionParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// IsSessionActive 检查会话是否未撤销且未过期，供 JWT 认证中间件使用
+func (s *Service) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	id, err := pkg.StringToPgUUID(sessionID)
+	if err != nil {
+		return false, nil
+	}
+	return s.Q.IsSessionActive(ctx, id)
+}
+
+func (s *Service) refreshTokenExpiry() pgtype.Timestamptz {
+	expiresAt := pgtype.Timestamptz{}
+	expiresAt.Scan(time.Now().AddDate(0, 0, s.config.JWT.RefreshTokenTTL))
+	return expiresAt
+}
+
+// generateRefreshToken 生成随机的 refresh token
+func generateRefreshToken() (string, error) {
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken 计算 token 的 SHA-256 哈希，数据库中只保存哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,313 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zeroicey/lifetrack-api/internal/modules/user/types"
+	"github.com/zeroicey/lifetrack-api/internal/pkg"
+	"github.com/zeroicey/lifetrack-api/internal/repository"
+)
+
+const (
+	// totpIssuer 验证器中显示的发行方名称
+	totpIssuer = "LifeTrack"
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// recoveryCodeBytes 单个恢复码的随机字节数，编码后为 10 位字符
+	recoveryCodeBytes = 6
+	// challengeMaxAttempts 每个登录 challenge 最多提交验证码的次数，超过后需要重新输入密码
+	challengeMaxAttempts = 5
+)
+
+var (
+	// ErrTwoFactorAlreadyEnabled 是重复绑定已启用的两步验证时返回的哨兵错误
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnabled 是两步验证未绑定或未启用时返回的哨兵错误
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrInvalidTwoFactorCode 是验证码或恢复码错误、已使用时返回的哨兵错误
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrInvalidChallenge 是登录 challenge 不存在、已过期、已使用或提交次数用尽时返回的哨兵错误
+	ErrInvalidChallenge = errors.New("invalid or expired challenge token")
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GetTwoFactorStatus 获取用户的两步验证状态
+func (s *Service) GetTwoFactorStatus(ctx context.Context, userID int64) (types.TwoFactorStatusResponse, error) {
+	enabled, err := s.TwoFactorEnabled(ctx, userID)
+	if err != nil {
+		return types.TwoFactorStatusResponse{}, err
+	}
+	if !enabled {
+		return types.TwoFactorStatusResponse{}, nil
+	}
+
+	remaining, err := s.Q.CountUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return types.TwoFactorStatusResponse{}, err
+	}
+	return types.TwoFactorStatusResponse{
+		Enabled:                true,
+		RecoveryCodesRemaining: int(remaining),
+	}, nil
+}
+
+// CreateLoginChallenge 密码验证通过后创建两步验证登录的 challenge，返回的 ID 作为 challenge token 的 jti
+func (s *Service) CreateLoginChallenge(ctx context.Context, userID int64) (string, error) {
+	if err := s.Q.DeleteExpiredTwoFactorChallenges(ctx, userID); err != nil {
+		return "", err
+	}
+
+	expiresAt := pgtype.Timestamptz{}
+	expiresAt.Scan(time.Now().Add(pkg.ChallengeTokenTTL))
+	challenge, err := s.Q.CreateTwoFactorChallenge(ctx, repository.CreateTwoFactorChallengeParams{
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	return challenge.ID.String(), nil
+}
+
+// TwoFactorEnabled 判断用户是否已启用两步验证
+func (s *Service) TwoFactorEnabled(ctx context.Context, userID int64) (bool, error) {
+	totp, err := s.Q.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return totp.Enabled, nil
+}
+
+// SetupTwoFactor 生成新的 TOTP 密钥，需要调用 EnableTwoFactor 验证后才会启用
+func (s *Service) SetupTwoFactor(ctx context.Context, userID int64) (types.TwoFactorSetupResponse, error) {
+	user, err := s.Q.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.TwoFactorSetupResponse{}, ErrUserNotFound
+		}
+		return types.TwoFactorSetupResponse{}, err
+	}
+
+	secret, err := pkg.GenerateTOTPSecret()
+	if err != nil {
+		return types.TwoFactorSetupResponse{}, err
+	}
+
+	_, err = s.Q.UpsertUserTOTP(ctx, repository.UpsertUserTOTPParams{
+		UserID: userID,
+		Secret: secret,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.TwoFactorSetupResponse{}, ErrTwoFactorAlreadyEnabled
+		}
+		return types.TwoFactorSetupResponse{}, err
+	}
+
+	return types.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: pkg.TOTPProvisioningURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// EnableTwoFactor 校验验证器生成的验证码并启用两步验证，返回一次性恢复码
+func (s *Service) EnableTwoFactor(ctx context.Context, userID int64, code string) ([]string, error) {
+	totp, err := s.Q.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTwoFactorNotEnabled
+		}
+		return nil, err
+	}
+	if totp.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := pkg.ValidateTOTP(totp.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Q.WithTx(tx)
+	err = qtx.EnableUserTOTP(ctx, repository.EnableUserTOTPParams{
+		UserID:       userID,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return nil, err
+	}
+	codes, err := replaceRecoveryCodes(ctx, qtx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor 关闭两步验证，需要同时提供密码和验证码（或恢复码）
+func (s *Service) DisableTwoFactor(ctx context.Context, userID int64, password, code string) error {
+	user, err := s.Q.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if err := s.VerifyPassword(user.PasswordHash, password); err != nil {
+		return ErrInvalidPassword
+	}
+	if err := s.VerifySecondFactor(ctx, userID, code); err != nil {
+		return err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Q.WithTx(tx)
+	if err := qtx.DeleteUserTOTP(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.DeleteRecoveryCodesByUser(ctx, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部失效，需要提供验证器生成的验证码
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	if err := s.verifyTOTPCode(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	codes, err := replaceRecoveryCodes(ctx, s.Q.WithTx(tx), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifySecondFactor 校验两步验证的第二因素，6 位数字按 TOTP 验证码处理，其他按恢复码处理
+func (s *Service) VerifySecondFactor(ctx context.Context, userID int64, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == pkg.TOTPDigits {
+		return s.verifyTOTPCode(ctx, userID, code)
+	}
+
+	enabled, err := s.TwoFactorEnabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	rows, err := s.Q.UseRecoveryCode(ctx, repository.UseRecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: hashToken(normalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// verifyTOTPCode 校验 TOTP 验证码，同一时间步的验证码只能使用一次
+func (s *Service) verifyTOTPCode(ctx context.Context, userID int64, code string) error {
+	totp, err := s.Q.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+	if !totp.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	step, ok := pkg.ValidateTOTP(totp.Secret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	rows, err := s.Q.UpdateUserTOTPLastUsedStep(ctx, repository.UpdateUserTOTPLastUsedStepParams{
+		UserID:       userID,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// replaceRecoveryCodes 删除用户的旧恢复码并生成一组新的，返回明文
+func replaceRecoveryCodes(ctx context.Context, q *repository.Queries, userID int64) ([]string, error) {
+	if err := q.DeleteRecoveryCodesByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		buf := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))
+		code := raw[:5] + "-" + raw[5:]
+
+		err := q.CreateRecoveryCode(ctx, repository.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode 忽略大小写、空格和连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
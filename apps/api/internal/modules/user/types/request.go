@@ -7,11 +7,77 @@ type RegisterUserBody struct {
 	Name         string      `json:"name" validate:"required"`
 	Password     string      `json:"password" validate:"required,min=6"`
 	Birthday     pgtype.Date `json:"birthday"`
-	AvatarBase64 pgtype.Text `json:"avatar_base64"`
+	AvatarBase64 pgtype.Text `json:"avatar_base64"` // 可选，注册后转存到对象存储
 	Bio          pgtype.Text `json:"bio"`
+	Timezone     string      `json:"timezone"`    // IANA 时区，为空时使用 UTC
+	InviteCode   string      `json:"invite_code"` // 邀请注册模式下必填，第一个用户无需邀请码
 }
 
 type LoginUserBody struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+}
+
+type UpdateTimezoneBody struct {
+	Timezone string `json:"timezone"`
+}
+
+type CreateInviteBody struct {
+	Email string `json:"email" validate:"omitempty,email"` // 可选，指定后只有该邮箱可以使用邀请码
+}
+
+type RefreshTokenBody struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type CreateAPITokenBody struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`          // 例如 ["read"]、["habits:write"]
+	ExpiresInDays int      `json:"expires_in_days"` // 有效期（天），为 0 时永不过期
+}
+
+type TwoFactorLoginBody struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"` // TOTP 验证码或恢复码
+}
+
+type TwoFactorCodeBody struct {
+	Code string `json:"code"`
+}
+
+type DisableTwoFactorBody struct {
+	Password string `json:"password"`
+	Code     string `json:"code"` // TOTP 验证码或恢复码
+}
+
+type ChangePasswordBody struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ForgotPasswordBody struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordBody struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type VerifyEmailBody struct {
+	Token string `json:"token"`
+}
+
+type ChangeEmailBody struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// UpdateProfileBody 只更新提供的字段，修改邮箱需要当前密码，并在新邮箱验证后生效
+type UpdateProfileBody struct {
+	Name     *string `json:"name"`
+	Bio      *string `json:"bio"`
+	Birthday *string `json:"birthday"` // YYYY-MM-DD，空字符串表示清除
+	Email    *string `json:"email"`
+	Password string  `json:"password"`
 }
\ No newline at end of file
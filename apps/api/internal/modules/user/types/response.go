@@ -1,21 +1,91 @@
 package types
 
+import "time"
+
 type UserResponse struct {
-	ID           int64  `json:"id"`
-	Email        string `json:"email"`
-	Name         string `json:"name"`
-	Birthday     string `json:"birthday"`
-	AvatarBase64 string `json:"avatar_base64"`
-	Bio          string `json:"bio"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
+	ID            int64             `json:"id"`
+	Email         string            `json:"email"`
+	Name          string            `json:"name"`
+	Birthday      string            `json:"birthday"`
+	AvatarURL     string            `json:"avatar_url"`            // 默认尺寸头像的临时访问地址，未设置头像时为空
+	AvatarURLs    map[string]string `json:"avatar_urls,omitempty"` // 各尺寸头像的临时访问地址，键为边长（像素）
+	EmailVerified bool              `json:"email_verified"`
+	Bio           string            `json:"bio"`
+	Timezone      string            `json:"timezone"`
+	CreatedAt     string            `json:"created_at"`
+	UpdatedAt     string            `json:"updated_at"`
 }
 
 type UserExistsResponse struct {
-	Exists bool `json:"exists"`
+	Exists           bool   `json:"exists"`
+	RegistrationMode string `json:"registration_mode"`
 }
 
 type LoginResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	ExpiresIn    int          `json:"expires_in"` // access token 有效期（秒）
+	User         UserResponse `json:"user"`
+}
+
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // access token 有效期（秒）
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	Current    bool      `json:"current"` // 是否为发起请求的会话
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type InviteResponse struct {
+	ID        int64      `json:"id"`
+	Code      string     `json:"code,omitempty"` // 明文邀请码，只在创建时返回
+	Email     string     `json:"email"`
+	Used      bool       `json:"used"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type APITokenResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"` // 明文令牌，只在创建时返回
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"` // 提交验证码时使用，5 分钟内有效，验证通过后失效，最多提交 5 次
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// 地址，客户端渲染为二维码
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"` // 明文恢复码，只返回这一次
+}
+
+// UpdateProfileResponse 在用户信息之外返回是否已向新邮箱发送验证邮件
+type UpdateProfileResponse struct {
+	UserResponse
+	EmailChangePending bool `json:"email_change_pending"`
 }
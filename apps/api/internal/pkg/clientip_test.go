@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolveClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.1 ", ""})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{"direct client ignores headers", "203.0.113.7:5000", []string{"1.2.3.4"}, "5.6.7.8", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:5000", []string{"198.51.100.9"}, "", "198.51.100.9"},
+		{"spoofed leftmost entry is skipped", "10.0.0.2:5000", []string{"1.2.3.4, 198.51.100.9, 192.168.1.1"}, "", "198.51.100.9"},
+		{"multiple headers", "10.0.0.2:5000", []string{"1.2.3.4", "198.51.100.9"}, "", "198.51.100.9"},
+		{"all hops trusted", "10.0.0.2:5000", []string{"10.1.1.1, 10.2.2.2"}, "", "10.1.1.1"},
+		{"invalid hop stops the walk", "10.0.0.2:5000", []string{"1.2.3.4, bogus"}, "", "10.0.0.2"},
+		{"x-real-ip", "192.168.1.1:5000", nil, "198.51.100.9", "198.51.100.9"},
+		{"invalid x-real-ip", "192.168.1.1:5000", nil, "bogus", "192.168.1.1"},
+		{"ipv4-mapped peer", "[::ffff:10.0.0.2]:5000", []string{"198.51.100.9"}, "", "198.51.100.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := ResolveClientIP(r, trusted); got != tt.want {
+				t.Fatalf("ResolveClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatal("ParseTrustedProxies accepted an invalid CIDR")
+	}
+}
+
+func TestClientIPUsesResolvedAddress(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.2:5000"
+	if got := ClientIP(r); got != "10.0.0.2" {
+		t.Fatalf("ClientIP = %q, want RemoteAddr host", got)
+	}
+	r = r.WithContext(WithClientIP(r.Context(), "198.51.100.9"))
+	if got := ClientIP(r); got != "198.51.100.9" {
+		t.Fatalf("ClientIP = %q, want resolved address", got)
+	}
+}
@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// jpegWithOrientation 生成左半红、右半蓝的 JPEG，并插入带 Orientation 标签的 EXIF 段
+func jpegWithOrientation(t *testing.T, w, h int, orientation uint16, order binary.ByteOrder) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			c := color.RGBA{R: 0xff, A: 0xff}
+			if x >= w/2 {
+				c = color.RGBA{B: 0xff, A: 0xff}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], exifOrientationTag)
+	order.PutUint16(tiff[12:], 3)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	encoded := buf.Bytes()
+	out := append([]byte{}, encoded[:2]...)
+	out = append(out, app1...)
+	return append(out, encoded[2:]...)
+}
+
+func isRed(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return r > 0xc000 && b < 0x4000
+}
+
+func TestExifOrientation(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		if got := ExifOrientation(jpegWithOrientation(t, 32, 16, 6, order)); got != 6 {
+			t.Errorf("ExifOrientation(%v) = %d, want 6", order, got)
+		}
+	}
+	if got := ExifOrientation([]byte("not a jpeg")); got != 1 {
+		t.Errorf("ExifOrientation(non-jpeg) = %d, want 1", got)
+	}
+}
+
+func TestDecodeImageAppliesOrientation(t *testing.T) {
+	tests := []struct {
+		orientation uint16
+		w, h        int
+		redAt       image.Point // 应为红色的像素
+	}{
+		{1, 32, 16, image.Pt(0, 0)},
+		{3, 32, 16, image.Pt(31, 0)},
+		{6, 16, 32, image.Pt(0, 0)},
+		{8, 16, 32, image.Pt(0, 31)},
+	}
+
+	for _, tt := range tests {
+		img, _, err := DecodeImage(jpegWithOrientation(t, 32, 16, tt.orientation, binary.BigEndian))
+		if err != nil {
+			t.Fatalf("orientation %d: %v", tt.orientation, err)
+		}
+		if b := img.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("orientation %d: size = %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), tt.w, tt.h)
+			continue
+		}
+		if !isRed(img.At(tt.redAt.X, tt.redAt.Y)) {
+			t.Errorf("orientation %d: pixel %v is not red", tt.orientation, tt.redAt)
+		}
+	}
+}
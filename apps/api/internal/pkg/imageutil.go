@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	// 注册 GIF、PNG、WebP 解码器，JPEG 由 image/jpeg 注册
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// MaxImageDimension 允许解码的图片最大边长（像素），防止超大图片耗尽内存
+const MaxImageDimension = 8192
+
+// ErrImageTooLarge 是图片尺寸超过 MaxImageDimension 时返回的哨兵错误
+var ErrImageTooLarge = errors.New("image dimensions are too large")
+
+// DecodeImage 解码 JPEG、PNG、GIF（第一帧）或 WebP 图片，先读取尺寸，超过上限时不做完整解码
+// JPEG 会按 EXIF Orientation 旋转，返回的图片与查看器中显示的方向一致
+func DecodeImage(data []byte) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Width > MaxImageDimension || cfg.Height > MaxImageDimension {
+		return nil, format, ErrImageTooLarge
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, format, err
+	}
+	if format == "jpeg" {
+		img = ApplyOrientation(img, ExifOrientation(data))
+	}
+	return img, format, nil
+}
+
+// SquareThumbnail 从图片中心裁剪出正方形并缩放为 size×size
+func SquareThumbnail(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+	return resample(src, image.Rect(x0, y0, x0+side, y0+side), size, size)
+}
+
+// FitThumbnail 按比例缩放图片，使长边不超过 maxSide，不会放大比 maxSide 小的图片
+func FitThumbnail(src image.Image, maxSide int) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			w, h = maxSide, max(h*maxSide/w, 1)
+		} else {
+			w, h = max(w*maxSide/h, 1), maxSide
+		}
+	}
+	return resample(src, bounds, w, h)
+}
+
+// resample 将 src 中的 rect 区域缩放为 w×h
+// 缩小时对每个目标像素覆盖的源像素取平均，透明部分合成到白色背景上
+func resample(src image.Image, rect image.Rectangle, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	scaleX := float64(rect.Dx()) / float64(w)
+	scaleY := float64(rect.Dy()) / float64(h)
+	for dy := range h {
+		sy0 := rect.Min.Y + int(float64(dy)*scaleY)
+		sy1 := max(rect.Min.Y+int(float64(dy+1)*scaleY), sy0+1)
+		for dx := range w {
+			sx0 := rect.Min.X + int(float64(dx)*scaleX)
+			sx1 := max(rect.Min.X+int(float64(dx+1)*scaleX), sx0+1)
+
+			var r, g, b, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					// RGBA() 返回预乘 alpha 的值，加上 (1 - alpha) 的白色
+					r += uint64(pr + 0xffff - pa)
+					g += uint64(pg + 0xffff - pa)
+					b += uint64(pb + 0xffff - pa)
+					n++
+				}
+			}
+			dst.SetRGBA(dx, dy, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
+
+// EncodeJPEG 将图片编码为 JPEG
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
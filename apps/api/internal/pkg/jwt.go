@@ -7,10 +7,17 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// ChallengeTokenPurpose 两步验证登录 challenge token 的用途标识
+const ChallengeTokenPurpose = "2fa_challenge"
+
+// ChallengeTokenTTL challenge token 的有效期，用户需要在此时间内输入验证码
+const ChallengeTokenTTL = 5 * time.Minute
+
 // JWTClaims 定义JWT载荷
 type JWTClaims struct {
-	UserID int64  `json:"user_id"`
-	Email  string `json:"email"`
+	UserID  int64  `json:"user_id"`
+	Email   string `json:"email"`
+	Purpose string `json:"purpose,omitempty"` // 为空表示普通登录 token
 	jwt.RegisteredClaims
 }
 
@@ -26,23 +33,50 @@ func (c *JWTClaims) GetEmail() string {
 
 // JWTManager JWT管理器
 type JWTManager struct {
-	secret []byte
+	secret    []byte
+	accessTTL time.Duration
 }
 
-// NewJWTManager 创建JWT管理器
-func NewJWTManager(secret string) *JWTManager {
+// NewJWTManager 创建JWT管理器，accessTTL 为登录 token 的有效期
+func NewJWTManager(secret string, accessTTL time.Duration) *JWTManager {
 	return &JWTManager{
-		secret: []byte(secret),
+		secret:    []byte(secret),
+		accessTTL: accessTTL,
 	}
 }
 
-// GenerateToken 生成JWT token
-func (j *JWTManager) GenerateToken(userID int64, email string) (string, error) {
+// AccessTokenTTL 返回登录 token 的有效期
+func (j *JWTManager) AccessTokenTTL() time.Duration {
+	return j.accessTTL
+}
+
+// GenerateToken 生成登录 JWT token，jti 为会话ID，会话撤销后 token 随之失效
+func (j *JWTManager) GenerateToken(userID int64, email string, sessionID string) (string, error) {
 	claims := JWTClaims{
 		UserID: userID,
 		Email:  email,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24小时过期
+			ID:        sessionID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "lifetrack-api",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(j.secret)
+}
+
+// GenerateChallengeToken 生成两步验证登录的短期 challenge token，只能用于提交验证码
+// jti 为 challenge ID，验证通过后 challenge 被标记为已使用，token 随之失效
+func (j *JWTManager) GenerateChallengeToken(userID int64, email string, challengeID string) (string, error) {
+	claims := JWTClaims{
+		UserID:  userID,
+		Email:   email,
+		Purpose: ChallengeTokenPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        challengeID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ChallengeTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "lifetrack-api",
 		},
@@ -52,8 +86,38 @@ func (j *JWTManager) GenerateToken(userID int64, email string) (string, error) {
 	return token.SignedString(j.secret)
 }
 
-// ValidateToken 验证JWT token
+// ValidateToken 验证普通登录 JWT token，拒绝特定用途的 token
 func (j *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
+	claims, err := j.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, errors.New("invalid token purpose")
+	}
+	if claims.ID == "" {
+		return nil, errors.New("token has no session")
+	}
+	return claims, nil
+}
+
+// ValidateChallengeToken 验证两步验证登录的 challenge token
+func (j *JWTManager) ValidateChallengeToken(tokenString string) (*JWTClaims, error) {
+	claims, err := j.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != ChallengeTokenPurpose {
+		return nil, errors.New("invalid token purpose")
+	}
+	if claims.ID == "" {
+		return nil, errors.New("token has no challenge")
+	}
+	return claims, nil
+}
+
+// parseToken 解析并校验签名，返回载荷
+func (j *JWTManager) parseToken(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
@@ -0,0 +1,36 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChallengeToken(t *testing.T) {
+	j := NewJWTManager("test-secret", time.Hour)
+
+	token, err := j.GenerateChallengeToken(7, "a@example.com", "5f0c6a9e-8c1e-4c53-a0c4-1f3b2d5e7a90")
+	if err != nil {
+		t.Fatalf("GenerateChallengeToken: %v", err)
+	}
+	claims, err := j.ValidateChallengeToken(token)
+	if err != nil {
+		t.Fatalf("ValidateChallengeToken: %v", err)
+	}
+	if claims.UserID != 7 || claims.ID != "5f0c6a9e-8c1e-4c53-a0c4-1f3b2d5e7a90" {
+		t.Fatalf("claims = %+v, want user 7 with the challenge ID as jti", claims)
+	}
+
+	// challenge token 不能当作登录 token 使用
+	if _, err := j.ValidateToken(token); err == nil {
+		t.Fatal("ValidateToken accepted a challenge token")
+	}
+
+	// 没有 jti 的 challenge token 无法对应到 challenge 记录，不能使用
+	noID, err := j.GenerateChallengeToken(7, "a@example.com", "")
+	if err != nil {
+		t.Fatalf("GenerateChallengeToken: %v", err)
+	}
+	if _, err := j.ValidateChallengeToken(noID); err == nil {
+		t.Fatal("ValidateChallengeToken accepted a token without jti")
+	}
+}
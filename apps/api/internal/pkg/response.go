@@ -9,6 +9,7 @@ type Responder struct {
 	statusCode int
 	message    string
 	data       any
+	headers    map[string]string
 }
 
 func NewResponder(message string, statusCode int, data any) *Responder {
@@ -37,7 +38,19 @@ func (r *Responder) SetStatusCode(code int) *Responder {
 	return r
 }
 
+// SetHeader 设置额外的响应头，例如 429 响应的 Retry-After
+func (r *Responder) SetHeader(key, value string) *Responder {
+	if r.headers == nil {
+		r.headers = make(map[string]string)
+	}
+	r.headers[key] = value
+	return r
+}
+
 func (r *Responder) Build(w http.ResponseWriter) {
+	for key, value := range r.headers {
+		w.Header().Set(key, value)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(r.statusCode)
 
@@ -0,0 +1,513 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency RRULE 的重复频率
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+	FrequencyYearly  Frequency = "YEARLY"
+)
+
+// maxRecurrencePeriods 展开时最多遍历的周期数，防止无限循环
+const maxRecurrencePeriods = 100000
+
+// ErrInvalidRRule 是 RRULE 格式不合法时返回的哨兵错误
+var ErrInvalidRRule = errors.New("invalid rrule")
+
+// WeekdayNum 对应 BYDAY 中的一项，例如 MO、1MO、-1FR
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int // 0 表示周期内的每一个该星期几
+}
+
+// RRule 是 RFC 5545 重复规则的解析结果
+// 支持 FREQ、INTERVAL、COUNT、UNTIL、BYDAY、BYMONTHDAY、BYMONTH、BYSETPOS、WKST
+type RRule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// ParseRRule 解析 RRULE 字符串，允许带或不带 "RRULE:" 前缀
+func ParseRRule(value string) (*RRule, error) {
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(strings.ToUpper(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRRule)
+	}
+
+	rule := &RRule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRRule, part)
+		}
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch Frequency(val) {
+			case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+				rule.Freq = Frequency(val)
+			default:
+				err = fmt.Errorf("unsupported FREQ %q", val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err == nil && rule.Interval < 1 {
+				err = errors.New("INTERVAL must be positive")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if err == nil && rule.Count < 1 {
+				err = errors.New("COUNT must be positive")
+			}
+		case "UNTIL":
+			rule.Until, err = parseRRuleTime(val)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(val, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(val, 1, 12)
+			for _, m := range months {
+				rule.ByMonth = append(rule.ByMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			rule.BySetPos, err = parseIntList(val, -366, 366)
+		case "WKST":
+			wd, found := weekdayCodes[val]
+			if !found {
+				err = fmt.Errorf("unknown WKST %q", val)
+			}
+			rule.WeekStart = wd
+		default:
+			err = fmt.Errorf("unsupported part %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRRule)
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRRule)
+	}
+	for _, d := range rule.ByDay {
+		if d.N != 0 && rule.Freq != FrequencyMonthly && rule.Freq != FrequencyYearly {
+			return nil, fmt.Errorf("%w: numeric BYDAY is only valid for MONTHLY or YEARLY", ErrInvalidRRule)
+		}
+	}
+	return rule, nil
+}
+
+// String 将规则格式化为 RRULE 字符串（不含 "RRULE:" 前缀）
+func (r *RRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = weekdayCode(d.Weekday)
+			if d.N != 0 {
+				days[i] = strconv.Itoa(d.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]int, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = int(m)
+		}
+		parts = append(parts, "BYMONTH="+joinInts(months))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayCode(r.WeekStart))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Between 返回以 dtstart 为起点、开始时间落在 [from, to) 内的所有实例开始时间
+// 实例的时分秒和时区均取自 dtstart
+func (r *RRule) Between(dtstart, from, to time.Time) []time.Time {
+	var result []time.Time
+	r.iterate(dtstart, to, func(t time.Time) bool {
+		if !t.Before(from) {
+			result = append(result, t)
+		}
+		return true
+	})
+	return result
+}
+
+// After 返回 dtstart 之后第一个严格晚于 t 的实例开始时间
+func (r *RRule) After(dtstart, t time.Time) (time.Time, bool) {
+	var found time.Time
+	r.iterate(dtstart, time.Time{}, func(occ time.Time) bool {
+		if occ.After(t) {
+			found = occ
+			return false
+		}
+		return true
+	})
+	return found, !found.IsZero()
+}
+
+// iterate 按时间顺序依次产出实例，to 为零值时不设上限；yield 返回 false 时停止
+func (r *RRule) iterate(dtstart, to time.Time, yield func(time.Time) bool) {
+	emitted := 0
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		candidates := r.periodCandidates(dtstart, period)
+		if len(candidates) == 0 {
+			// 周期本身已经超出上限时结束
+			ps := r.periodStart(dtstart, period)
+			if (!to.IsZero() && ps.After(to)) || (!r.Until.IsZero() && ps.After(r.Until)) {
+				return
+			}
+			continue
+		}
+		for _, c := range candidates {
+			if c.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && c.After(r.Until) {
+				return
+			}
+			if !to.IsZero() && !c.Before(to) {
+				return
+			}
+			if !yield(c) {
+				return
+			}
+			emitted++
+			if r.Count > 0 && emitted >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// periodStart 返回第 n 个周期的起始日期（当天零点）
+func (r *RRule) periodStart(dtstart time.Time, n int) time.Time {
+	y, m, d := dtstart.Date()
+	loc := dtstart.Location()
+	step := n * r.Interval
+	switch r.Freq {
+	case FrequencyDaily:
+		return time.Date(y, m, d+step, 0, 0, 0, 0, loc)
+	case FrequencyWeekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		return time.Date(y, m, d-offset+7*step, 0, 0, 0, 0, loc)
+	case FrequencyMonthly:
+		return time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(y+step, time.January, 1, 0, 0, 0, 0, loc)
+	}
+}
+
+// periodCandidates 计算第 n 个周期内所有符合规则的实例，按时间升序
+func (r *RRule) periodCandidates(dtstart time.Time, n int) []time.Time {
+	start := r.periodStart(dtstart, n)
+	var days []time.Time
+
+	switch r.Freq {
+	case FrequencyDaily:
+		if r.matchesMonth(start) && r.matchesMonthDay(start) && r.matchesWeekday(start) {
+			days = append(days, start)
+		}
+	case FrequencyWeekly:
+		for i := 0; i < 7; i++ {
+			day := start.AddDate(0, 0, i)
+			if !r.matchesMonth(day) {
+				continue
+			}
+			if len(r.ByDay) == 0 {
+				if day.Weekday() == dtstart.Weekday() {
+					days = append(days, day)
+				}
+			} else if r.matchesWeekday(day) {
+				days = append(days, day)
+			}
+		}
+	case FrequencyMonthly:
+		if r.matchesMonth(start) {
+			days = r.monthCandidates(start, dtstart)
+		}
+	case FrequencyYearly:
+		days = r.yearCandidates(start, dtstart)
+	}
+
+	days = r.applySetPos(days)
+
+	h, mi, s := dtstart.Clock()
+	result := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		y, m, d := day.Date()
+		result = append(result, localTime(y, m, d, h, mi, s, dtstart.Nanosecond(), dtstart.Location()))
+	}
+	return result
+}
+
+// localTime 返回 loc 中的本地时间
+// 夏令时切换跳过的不存在时间按切换前的 UTC 偏移解释（RFC 5545 3.3.5），例如 02:30 EST 即 03:30 EDT；
+// 重复出现的时间由 time.Date 取第一次
+func localTime(y int, m time.Month, d, h, mi, s, ns int, loc *time.Location) time.Time {
+	t := time.Date(y, m, d, h, mi, s, ns, loc)
+	if t.Hour() == h && t.Minute() == mi {
+		return t
+	}
+	wall := time.Date(y, m, d, h, mi, s, ns, time.UTC)
+	_, offset := wall.Add(-24 * time.Hour).In(loc).Zone()
+	return wall.Add(-time.Duration(offset) * time.Second).In(loc)
+}
+
+// monthCandidates 计算某个月份内符合 BYMONTHDAY/BYDAY 的日期
+func (r *RRule) monthCandidates(monthStart, dtstart time.Time) []time.Time {
+	daysInMonth := monthStart.AddDate(0, 1, -1).Day()
+	var days []time.Time
+	for d := 1; d <= daysInMonth; d++ {
+		day := monthStart.AddDate(0, 0, d-1)
+		switch {
+		case len(r.ByMonthDay) == 0 && len(r.ByDay) == 0:
+			if d == dtstart.Day() {
+				days = append(days, day)
+			}
+		case len(r.ByMonthDay) > 0 && !r.matchesMonthDay(day):
+			continue
+		case len(r.ByDay) > 0 && !r.matchesWeekdayIn(day, monthStart, daysInMonth):
+			continue
+		default:
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// yearCandidates 计算某一年内符合规则的日期
+func (r *RRule) yearCandidates(yearStart, dtstart time.Time) []time.Time {
+	// 没有 BYMONTH 但有 BYDAY 时，序号相对于整年计算
+	if len(r.ByMonth) == 0 && len(r.ByDay) > 0 && len(r.ByMonthDay) == 0 {
+		daysInYear := yearStart.AddDate(1, 0, 0).Sub(yearStart).Hours() / 24
+		var days []time.Time
+		for d := 0; d < int(daysInYear+0.5); d++ {
+			day := yearStart.AddDate(0, 0, d)
+			if r.matchesWeekdayIn(day, yearStart, int(daysInYear+0.5)) {
+				days = append(days, day)
+			}
+		}
+		return days
+	}
+
+	// 没有 BYMONTH 时，只有在 BYMONTHDAY 和 BYDAY 都为空的情况下才沿用 DTSTART 的月份，
+	// 否则 BYMONTHDAY 等规则作用于全年每个月（RFC 5545 3.3.10 的扩展表）
+	months := r.ByMonth
+	if len(months) == 0 {
+		if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+			months = []time.Month{dtstart.Month()}
+		} else {
+			months = make([]time.Month, 0, 12)
+			for m := time.January; m <= time.December; m++ {
+				months = append(months, m)
+			}
+		}
+	}
+	sorted := append([]time.Month(nil), months...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var days []time.Time
+	for _, m := range sorted {
+		monthStart := time.Date(yearStart.Year(), m, 1, 0, 0, 0, 0, yearStart.Location())
+		days = append(days, r.monthCandidates(monthStart, dtstart)...)
+	}
+	return days
+}
+
+// applySetPos 按 BYSETPOS 从周期候选中挑选实例
+func (r *RRule) applySetPos(days []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 || len(days) == 0 {
+		return days
+	}
+	picked := make(map[int]bool)
+	for _, pos := range r.BySetPos {
+		idx := pos - 1
+		if pos < 0 {
+			idx = len(days) + pos
+		}
+		if idx >= 0 && idx < len(days) {
+			picked[idx] = true
+		}
+	}
+	var result []time.Time
+	for i, day := range days {
+		if picked[i] {
+			result = append(result, day)
+		}
+	}
+	return result
+}
+
+func (r *RRule) matchesMonth(day time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if day.Month() == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	for _, md := range r.ByMonthDay {
+		if md == day.Day() || (md < 0 && daysInMonth+md+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) matchesWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekdayIn 判断 day 是否匹配 BYDAY，带序号的项相对于 [periodStart, periodStart+length) 计算
+func (r *RRule) matchesWeekdayIn(day, periodStart time.Time, length int) bool {
+	index := int(day.Sub(periodStart).Hours()/24+0.5) + 1
+	for _, wd := range r.ByDay {
+		if wd.Weekday != day.Weekday() {
+			continue
+		}
+		switch {
+		case wd.N == 0:
+			return true
+		case wd.N > 0 && (index-1)/7+1 == wd.N:
+			return true
+		case wd.N < 0 && (length-index)/7+1 == -wd.N:
+			return true
+		}
+	}
+	return false
+}
+
+func parseRRuleTime(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var result []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY %q", item)
+		}
+		code := item[len(item)-2:]
+		wd, ok := weekdayCodes[code]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY %q", item)
+		}
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid BYDAY %q", item)
+			}
+		}
+		result = append(result, WeekdayNum{Weekday: wd, N: n})
+	}
+	return result, nil
+}
+
+func parseIntList(value string, lo, hi int) ([]int, error) {
+	var result []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n == 0 || n < lo || n > hi {
+			return nil, fmt.Errorf("invalid value %q", item)
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+func weekdayCode(wd time.Weekday) string {
+	for code, d := range weekdayCodes {
+		if d == wd {
+			return code
+		}
+	}
+	return ""
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,395 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadTestLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func formatTimes(times []time.Time) string {
+	parts := make([]string, len(times))
+	for i, t := range times {
+		parts[i] = t.Format("20060102T150405")
+	}
+	return strings.Join(parts, ",")
+}
+
+// TestRRuleRFC5545Examples 覆盖 RFC 5545 3.8.5.3 节中不依赖 BYWEEKNO/BYYEARDAY 等未支持规则部分的示例
+// 时间均为 America/New_York 本地时间，1997 年 10 月 26 日和 1998 年 4 月 5 日跨越夏令时切换
+func TestRRuleRFC5545Examples(t *testing.T) {
+	ny := loadTestLocation(t, "America/New_York")
+
+	tests := []struct {
+		name    string
+		dtstart string
+		rule    string
+		first   int // 大于 0 时只比较前 first 个实例（无限重复的规则）
+		want    string
+	}{
+		{
+			name:    "daily for 10 occurrences",
+			dtstart: "19970902T090000",
+			rule:    "FREQ=DAILY;COUNT=10",
+			want:    "19970902T090000,19970903T090000,19970904T090000,19970905T090000,19970906T090000,19970907T090000,19970908T090000,19970909T090000,19970910T090000,19970911T090000",
+		},
+		{
+			name:    "every other day",
+			dtstart: "19970902T090000",
+			rule:    "FREQ=DAILY;INTERVAL=2",
+			first:   5,
+			want:    "19970902T090000,19970904T090000,19970906T090000,19970908T090000,19970910T090000",
+		},
+		{
+			name:    "every 10 days, 5 occurrences",
+			dtstart: "19970902T090000",
+			rule:    "FREQ=DAILY;INTERVAL=10;COUNT=5",
+			want:    "19970902T090000,19970912T090000,19970922T090000,19971002T090000,19971012T090000",
+		},
+		{
+			name:    "every day in January for 3 years (yearly)",
+			dtstart: "19980101T090000",
+			rule:    "FREQ=YEARLY;UNTIL=20000131T140000Z;BYMONTH=1;BYDAY=SU,MO,TU,WE,TH,FR,SA",
+			first:   3,
+			want:    "19980101T090000,19980102T090000,19980103T090000",
+		},
+		{
+			name:    "weekly for 10 occurrences across DST end",
+			dtstart: "19970902T090000",
+			rule:    "FREQ=WEEKLY;COUNT=10",
+			want:    "19970902T090000,19970909T090000,19970916T090000,19970923T090000,19970930T090000,19971007T090000,19971014T090000,19971021T090000,19971028T090000,19971104T090000",
+		},
+		{
+			name:    "weekly on Tuesday and Thursday for five weeks",
+			dtstart: "19970902T090000",
+			rule:    "FREQ=WEEKLY;UNTIL=19971007T000000Z;WKST=SU;BYDAY=TU,TH",
+			want:    "19970902T090000,19970904T090000,19970909T090000,19970911T090000,19970916T090000,19970918T090000,19970923T090000,19970925T090000,19970930T090000,19971002T090000",
+		},
+		{
+			name:    "every other week on Monday, Wednesday and Friday until December 24",
+			dtstart: "19970902T090000",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;UNTIL=19971224T000000Z;WKST=SU;BYDAY=MO,WE,FR",
+			want: "19970903T090000,19970905T090000,19970915T090000,19970917T090000,19970919T090000,19970929T090000," +
+				"19971001T090000,19971003T090000,19971013T090000,19971015T090000,19971017T090000,19971027T090000,19971029T090000,19971031T090000," +
+				"19971110T090000,19971112T090000,19971114T090000,19971124T090000,19971126T090000,19971128T090000," +
+				"19971208T090000,19971210T090000,19971212T090000,19971222T090000",
+		},
+		{
+			name:    "every other week on Tuesday and Thursday for 8 occurrences",
+			dtstart: "19970902T090000",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=8;WKST=SU;BYDAY=TU,TH",
+			want:    "19970902T090000,19970904T090000,19970916T090000,19970918T090000,19970930T090000,19971002T090000,19971014T090000,19971016T090000",
+		},
+		{
+			name:    "monthly on the first Friday for 10 occurrences",
+			dtstart: "19970905T090000",
+			rule:    "FREQ=MONTHLY;COUNT=10;BYDAY=1FR",
+			want:    "19970905T090000,19971003T090000,19971107T090000,19971205T090000,19980102T090000,19980206T090000,19980306T090000,19980403T090000,19980501T090000,19980605T090000",
+		},
+		{
+			name:    "every other month on the first and last Sunday for 10 occurrences",
+			dtstart: "19970907T090000",
+			rule:    "FREQ=MONTHLY;INTERVAL=2;COUNT=10;BYDAY=1SU,-1SU",
+			want:    "19970907T090000,19970928T090000,19971102T090000,19971130T090000,19980104T090000,19980125T090000,19980301T090000,19980329T090000,19980503T090000,19980531T090000",
+		},
+		{
+			name:    "monthly on the second-to-last Monday for 6 months",
+			dtstart: "19970922T090000",
+			rule:    "FREQ=MONTHLY;COUNT=6;BYDAY=-2MO",
+			want:    "19970922T090000,19971020T090000,19971117T090000,19971222T090000,19980119T090000,19980216T090000",
+		},
+		{
+			name:    "monthly on the third-to-last day of the month",
+			dtstart: "19970928T090000",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-3",
+			first:   6,
+			want:    "19970928T090000,19971029T090000,19971128T090000,19971229T090000,19980129T090000,19980226T090000",
+		},
+		{
+			name:    "monthly on the 2nd and 15th for 10 occurrences",
+			dtstart: "19970902T090000",
+			rule:    "FREQ=MONTHLY;COUNT=10;BYMONTHDAY=2,15",
+			want:    "19970902T090000,19970915T090000,19971002T090000,19971015T090000,19971102T090000,19971115T090000,19971202T090000,19971215T090000,19980102T090000,19980115T090000",
+		},
+		{
+			name:    "monthly on the first and last day for 10 occurrences",
+			dtstart: "19970930T090000",
+			rule:    "FREQ=MONTHLY;COUNT=10;BYMONTHDAY=1,-1",
+			want:    "19970930T090000,19971001T090000,19971031T090000,19971101T090000,19971130T090000,19971201T090000,19971231T090000,19980101T090000,19980131T090000,19980201T090000",
+		},
+		{
+			name:    "yearly in June and July for 10 occurrences",
+			dtstart: "19970610T090000",
+			rule:    "FREQ=YEARLY;COUNT=10;BYMONTH=6,7",
+			want:    "19970610T090000,19970710T090000,19980610T090000,19980710T090000,19990610T090000,19990710T090000,20000610T090000,20000710T090000,20010610T090000,20010710T090000",
+		},
+		{
+			name:    "every 20th Monday of the year",
+			dtstart: "19970519T090000",
+			rule:    "FREQ=YEARLY;BYDAY=20MO",
+			first:   3,
+			want:    "19970519T090000,19980518T090000,19990517T090000",
+		},
+		{
+			name:    "every Thursday in March",
+			dtstart: "19970313T090000",
+			rule:    "FREQ=YEARLY;BYMONTH=3;BYDAY=TH",
+			first:   11,
+			want:    "19970313T090000,19970320T090000,19970327T090000,19980305T090000,19980312T090000,19980319T090000,19980326T090000,19990304T090000,19990311T090000,19990318T090000,19990325T090000",
+		},
+		{
+			name:    "every Friday the 13th",
+			dtstart: "19970902T090000",
+			rule:    "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			first:   5,
+			want:    "19980213T090000,19980313T090000,19981113T090000,19990813T090000,20001013T090000",
+		},
+		{
+			name:    "first Saturday that follows the first Sunday of the month",
+			dtstart: "19970913T090000",
+			rule:    "FREQ=MONTHLY;BYDAY=SA;BYMONTHDAY=7,8,9,10,11,12,13",
+			first:   10,
+			want:    "19970913T090000,19971011T090000,19971108T090000,19971213T090000,19980110T090000,19980207T090000,19980307T090000,19980411T090000,19980509T090000,19980613T090000",
+		},
+		{
+			name:    "yearly on the first day of every month",
+			dtstart: "19970901T090000",
+			rule:    "FREQ=YEARLY;COUNT=6;BYMONTHDAY=1",
+			want:    "19970901T090000,19971001T090000,19971101T090000,19971201T090000,19980101T090000,19980201T090000",
+		},
+		{
+			name:    "every Friday the 13th with a yearly frequency",
+			dtstart: "19970902T090000",
+			rule:    "FREQ=YEARLY;BYDAY=FR;BYMONTHDAY=13",
+			first:   5,
+			want:    "19980213T090000,19980313T090000,19981113T090000,19990813T090000,20001013T090000",
+		},
+		{
+			name:    "US presidential election day every 4 years",
+			dtstart: "19961105T090000",
+			rule:    "FREQ=YEARLY;INTERVAL=4;BYMONTH=11;BYDAY=TU;BYMONTHDAY=2,3,4,5,6,7,8",
+			first:   3,
+			want:    "19961105T090000,20001107T090000,20041102T090000",
+		},
+		{
+			name:    "third instance of Tuesday, Wednesday or Thursday for the next 3 months",
+			dtstart: "19970904T090000",
+			rule:    "FREQ=MONTHLY;COUNT=3;BYDAY=TU,WE,TH;BYSETPOS=3",
+			want:    "19970904T090000,19971007T090000,19971106T090000",
+		},
+		{
+			name:    "second-to-last weekday of the month",
+			dtstart: "19970929T090000",
+			rule:    "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-2",
+			first:   7,
+			want:    "19970929T090000,19971030T090000,19971127T090000,19971230T090000,19980129T090000,19980226T090000,19980330T090000",
+		},
+		{
+			name:    "WKST=MO changes the generated set",
+			dtstart: "19970805T090000",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO",
+			want:    "19970805T090000,19970810T090000,19970819T090000,19970824T090000",
+		},
+		{
+			name:    "WKST=SU changes the generated set",
+			dtstart: "19970805T090000",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+			want:    "19970805T090000,19970817T090000,19970819T090000,19970831T090000",
+		},
+		{
+			name:    "invalid dates are skipped, not moved",
+			dtstart: "20070115T090000",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=15,30;COUNT=5",
+			want:    "20070115T090000,20070130T090000,20070215T090000,20070315T090000,20070330T090000",
+		},
+		{
+			name:    "monthly on the 31st skips shorter months",
+			dtstart: "20070131T090000",
+			rule:    "FREQ=MONTHLY;COUNT=5",
+			want:    "20070131T090000,20070331T090000,20070531T090000,20070731T090000,20070831T090000",
+		},
+		{
+			name:    "yearly on February 29th",
+			dtstart: "20240229T090000",
+			rule:    "FREQ=YEARLY;COUNT=2",
+			want:    "20240229T090000,20280229T090000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dtstart, err := time.ParseInLocation("20060102T150405", tt.dtstart, ny)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rule, err := ParseRRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRRule(%q): %v", tt.rule, err)
+			}
+			got := rule.Between(dtstart, dtstart, dtstart.AddDate(10, 0, 0))
+			if tt.first > 0 && len(got) > tt.first {
+				got = got[:tt.first]
+			}
+			if formatTimes(got) != tt.want {
+				t.Fatalf("occurrences\n got: %s\nwant: %s", formatTimes(got), tt.want)
+			}
+			for _, occ := range got {
+				if occ.Location() != ny {
+					t.Fatalf("occurrence %s is not in the dtstart time zone", occ)
+				}
+			}
+		})
+	}
+}
+
+func TestRRuleUntilCount(t *testing.T) {
+	ny := loadTestLocation(t, "America/New_York")
+	dtstart := time.Date(1997, time.September, 2, 9, 0, 0, 0, ny)
+
+	// RFC 5545: Daily until December 24, 1997，UNTIL 为 UTC，12 月 24 日 09:00 EST 晚于 UNTIL
+	rule, err := ParseRRule("FREQ=DAILY;UNTIL=19971224T000000Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rule.Between(dtstart, dtstart, dtstart.AddDate(1, 0, 0))
+	if len(got) != 113 {
+		t.Fatalf("got %d occurrences, want 113", len(got))
+	}
+	if last := got[len(got)-1]; !last.Equal(time.Date(1997, time.December, 23, 9, 0, 0, 0, ny)) {
+		t.Fatalf("last occurrence = %s, want 1997-12-23 09:00 EST", last)
+	}
+
+	// UNTIL 恰好等于某次实例时包含该实例
+	rule, err = ParseRRule("FREQ=DAILY;UNTIL=19970904T130000Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := formatTimes(rule.Between(dtstart, dtstart, dtstart.AddDate(1, 0, 0))); got != "19970902T090000,19970903T090000,19970904T090000" {
+		t.Fatalf("inclusive UNTIL = %s", got)
+	}
+
+	// COUNT 从 dtstart 开始计数，查询范围不影响计数
+	rule, err = ParseRRule("FREQ=DAILY;COUNT=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rule.Between(dtstart, dtstart.AddDate(0, 0, 2), dtstart.AddDate(1, 0, 0)); formatTimes(got) != "19970904T090000" {
+		t.Fatalf("COUNT with later range = %s, want 19970904T090000", formatTimes(got))
+	}
+	if got := rule.Between(dtstart, dtstart.AddDate(0, 0, 3), dtstart.AddDate(1, 0, 0)); len(got) != 0 {
+		t.Fatalf("COUNT exhausted = %s, want none", formatTimes(got))
+	}
+}
+
+func TestRRuleDST(t *testing.T) {
+	ny := loadTestLocation(t, "America/New_York")
+
+	tests := []struct {
+		name    string
+		dtstart time.Time
+		rule    string
+		n       int
+		want    []string // UTC 时间
+	}{
+		{
+			name:    "local time is kept across spring forward",
+			dtstart: time.Date(2025, time.March, 8, 9, 0, 0, 0, ny),
+			rule:    "FREQ=DAILY",
+			n:       3,
+			want:    []string{"2025-03-08T14:00:00Z", "2025-03-09T13:00:00Z", "2025-03-10T13:00:00Z"},
+		},
+		{
+			name:    "local time is kept across fall back",
+			dtstart: time.Date(2025, time.November, 1, 9, 0, 0, 0, ny),
+			rule:    "FREQ=DAILY",
+			n:       3,
+			want:    []string{"2025-11-01T13:00:00Z", "2025-11-02T14:00:00Z", "2025-11-03T14:00:00Z"},
+		},
+		{
+			// RFC 5545 3.3.5：不存在的本地时间按切换前的 UTC 偏移解释，02:30 EST 即 03:30 EDT
+			name:    "nonexistent local time in the spring gap",
+			dtstart: time.Date(2025, time.March, 8, 2, 30, 0, 0, ny),
+			rule:    "FREQ=DAILY",
+			n:       3,
+			want:    []string{"2025-03-08T07:30:00Z", "2025-03-09T07:30:00Z", "2025-03-10T06:30:00Z"},
+		},
+		{
+			name:    "weekly across both transitions",
+			dtstart: time.Date(2025, time.March, 3, 18, 0, 0, 0, ny),
+			rule:    "FREQ=WEEKLY;BYDAY=MO",
+			n:       2,
+			want:    []string{"2025-03-03T23:00:00Z", "2025-03-10T22:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := rule.Between(tt.dtstart, tt.dtstart, tt.dtstart.AddDate(1, 0, 0))
+			if len(got) < tt.n {
+				t.Fatalf("got %d occurrences, want at least %d", len(got), tt.n)
+			}
+			for i, want := range tt.want {
+				if g := got[i].UTC().Format(time.RFC3339); g != want {
+					t.Fatalf("occurrence %d = %s, want %s", i, g, want)
+				}
+			}
+		})
+	}
+
+	// 回拨当天 01:30 出现两次，实例取第一次（夏令时）
+	dtstart := time.Date(2025, time.October, 31, 1, 30, 0, 0, ny)
+	rule, _ := ParseRRule("FREQ=DAILY;COUNT=3")
+	got := rule.Between(dtstart, dtstart, dtstart.AddDate(0, 1, 0))
+	if g := got[2].UTC().Format(time.RFC3339); g != "2025-11-02T05:30:00Z" {
+		t.Fatalf("ambiguous local time = %s, want 2025-11-02T05:30:00Z", g)
+	}
+}
+
+func TestParseRRule(t *testing.T) {
+	valid := map[string]string{
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE":       "FREQ=WEEKLY;BYDAY=MO,WE",
+		"freq=monthly;interval=2;bysetpos=-1": "FREQ=MONTHLY;INTERVAL=2;BYSETPOS=-1",
+		"FREQ=DAILY;UNTIL=20250101T000000Z":   "FREQ=DAILY;UNTIL=20250101T000000Z",
+		"FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU":    "FREQ=YEARLY;BYDAY=-1SU;BYMONTH=3",
+		"FREQ=WEEKLY;WKST=SU":                 "FREQ=WEEKLY;WKST=SU",
+	}
+	for input, want := range valid {
+		rule, err := ParseRRule(input)
+		if err != nil {
+			t.Errorf("ParseRRule(%q): %v", input, err)
+			continue
+		}
+		if got := rule.String(); got != want {
+			t.Errorf("ParseRRule(%q).String() = %q, want %q", input, got, want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101T000000Z",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=DAILY;BYWEEKNO=20",
+	}
+	for _, input := range invalid {
+		if _, err := ParseRRule(input); err == nil {
+			t.Errorf("ParseRRule(%q) succeeded, want error", input)
+		}
+	}
+}
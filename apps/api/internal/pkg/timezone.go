@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DateLayout 日期查询参数使用的格式
+const DateLayout = "2006-01-02"
+
+// TimezoneQueryParam 覆盖用户时区的查询参数名，例如 ?tz=Asia/Shanghai
+const TimezoneQueryParam = "tz"
+
+// ErrInvalidTimezone 是时区不是合法 IANA 名称时返回的哨兵错误
+var ErrInvalidTimezone = errors.New("invalid time zone")
+
+// TimezoneLookup 查询用户偏好的时区，repository.Queries 实现了该接口
+type TimezoneLookup interface {
+	GetUserTimezone(ctx context.Context, id int64) (string, error)
+}
+
+// LoadLocation 加载 IANA 时区，空字符串表示 UTC
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTimezone, name)
+	}
+	return loc, nil
+}
+
+// ResolveLocation 返回请求使用的时区：优先使用 override，其次是 userID 对应用户的偏好，都没有时为 UTC
+func ResolveLocation(ctx context.Context, lookup TimezoneLookup, userID int64, override string) (*time.Location, error) {
+	if override != "" {
+		return LoadLocation(override)
+	}
+
+	name, err := lookup.GetUserTimezone(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.UTC, nil
+		}
+		return nil, err
+	}
+	// 存储的时区已在写入时校验，失效时（例如 tzdata 变化）回退到 UTC
+	loc, err := LoadLocation(name)
+	if err != nil {
+		return time.UTC, nil
+	}
+	return loc, nil
+}
+
+// ParseDate 将 YYYY-MM-DD 解析为该日期在 loc 中的零点
+func ParseDate(value string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation(DateLayout, value, loc)
+}
+
+// StartOfDay 返回 t 在其所在时区当天的零点
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// DayRange 返回 t 所在日期的 [开始, 结束) 区间，夏令时切换日的长度不一定是 24 小时
+func DayRange(t time.Time) (time.Time, time.Time) {
+	start := StartOfDay(t)
+	return start, start.AddDate(0, 0, 1)
+}
@@ -17,19 +17,29 @@ INSERT INTO events (
     place,
     description,
     start_time,
-    end_time
+    end_time,
+    rrule,
+    exdates,
+    uid,
+    timezone,
+    user_id
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 )
-RETURNING id, name, place, description, start_time, end_time, created_at, updated_at
+RETURNING id, name, place, description, start_time, end_time, rrule, exdates, uid, timezone, created_at, updated_at, user_id
 `
 
 type CreateEventParams struct {
-	Name        string             `json:"name"`
-	Place       string             `json:"place"`
-	Description string             `json:"description"`
-	StartTime   pgtype.Timestamptz `json:"start_time"`
-	EndTime     pgtype.Timestamptz `json:"end_time"`
+	Name        string               `json:"name"`
+	Place       string               `json:"place"`
+	Description string               `json:"description"`
+	StartTime   pgtype.Timestamptz   `json:"start_time"`
+	EndTime     pgtype.Timestamptz   `json:"end_time"`
+	Rrule       string               `json:"rrule"`
+	Exdates     []pgtype.Timestamptz `json:"exdates"`
+	Uid         string               `json:"uid"`
+	Timezone    string               `json:"timezone"`
+	UserID      int64                `json:"user_id"`
 }
 
 func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event, error) {
@@ -39,6 +49,11 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		arg.Description,
 		arg.StartTime,
 		arg.EndTime,
+		arg.Rrule,
+		arg.Exdates,
+		arg.Uid,
+		arg.Timezone,
+		arg.UserID,
 	)
 	var i Event
 	err := row.Scan(
@@ -48,8 +63,13 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		&i.Description,
 		&i.StartTime,
 		&i.EndTime,
+		&i.Rrule,
+		&i.Exdates,
+		&i.Uid,
+		&i.Timezone,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
 	)
 	return i, err
 }
@@ -57,26 +77,30 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 const createEventReminder = `-- name: CreateEventReminder :one
 INSERT INTO event_reminders (
     event_id,
-    remind_before
+    remind_before,
+    channel
 ) VALUES (
-    $1, $2
+    $1, $2, $3
 )
-RETURNING id, event_id, remind_before, notified, created_at
+RETURNING id, event_id, remind_before, channel, notified, last_notified_occurrence, created_at
 `
 
 type CreateEventReminderParams struct {
-	EventID      int64 `json:"event_id"`
-	RemindBefore int32 `json:"remind_before"`
+	EventID      int64  `json:"event_id"`
+	RemindBefore int32  `json:"remind_before"`
+	Channel      string `json:"channel"`
 }
 
 func (q *Queries) CreateEventReminder(ctx context.Context, arg CreateEventReminderParams) (EventReminder, error) {
-	row := q.db.QueryRow(ctx, createEventReminder, arg.EventID, arg.RemindBefore)
+	row := q.db.QueryRow(ctx, createEventReminder, arg.EventID, arg.RemindBefore, arg.Channel)
 	var i EventReminder
 	err := row.Scan(
 		&i.ID,
 		&i.EventID,
 		&i.RemindBefore,
+		&i.Channel,
 		&i.Notified,
+		&i.LastNotifiedOccurrence,
 		&i.CreatedAt,
 	)
 	return i, err
@@ -84,24 +108,99 @@ func (q *Queries) CreateEventReminder(ctx context.Context, arg CreateEventRemind
 
 const deleteEvent = `-- name: DeleteEvent :exec
 DELETE FROM events
-WHERE id = $1
+WHERE id = $1 AND user_id = $2
 `
 
-func (q *Queries) DeleteEvent(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, deleteEvent, id)
+type DeleteEventParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteEvent(ctx context.Context, arg DeleteEventParams) error {
+	_, err := q.db.Exec(ctx, deleteEvent, arg.ID, arg.UserID)
 	return err
 }
 
+const deleteEventOverride = `-- name: DeleteEventOverride :one
+DELETE FROM event_overrides
+WHERE id = $1 AND event_id IN (SELECT id FROM events WHERE user_id = $2)
+RETURNING id, event_id, recurrence_id, name, place, description, start_time, end_time, created_at, updated_at
+`
+
+type DeleteEventOverrideParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteEventOverride(ctx context.Context, arg DeleteEventOverrideParams) (EventOverride, error) {
+	row := q.db.QueryRow(ctx, deleteEventOverride, arg.ID, arg.UserID)
+	var i EventOverride
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.RecurrenceID,
+		&i.Name,
+		&i.Place,
+		&i.Description,
+		&i.StartTime,
+		&i.EndTime,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const deleteEventReminder = `-- name: DeleteEventReminder :exec
 DELETE FROM event_reminders
-WHERE id = $1
+WHERE id = $1 AND event_id IN (SELECT id FROM events WHERE user_id = $2)
 `
 
-func (q *Queries) DeleteEventReminder(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, deleteEventReminder, id)
+type DeleteEventReminderParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteEventReminder(ctx context.Context, arg DeleteEventReminderParams) error {
+	_, err := q.db.Exec(ctx, deleteEventReminder, arg.ID, arg.UserID)
 	return err
 }
 
+const eventExists = `-- name: EventExists :one
+SELECT EXISTS(
+    SELECT 1 FROM events WHERE id = $1 AND user_id = $2
+) AS exists
+`
+
+type EventExistsParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) EventExists(ctx context.Context, arg EventExistsParams) (bool, error) {
+	row := q.db.QueryRow(ctx, eventExists, arg.ID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const eventUIDExists = `-- name: EventUIDExists :one
+SELECT EXISTS(
+    SELECT 1 FROM events WHERE uid = $1 AND user_id = $2
+) AS exists
+`
+
+type EventUIDExistsParams struct {
+	Uid    string `json:"uid"`
+	UserID int64  `json:"user_id"`
+}
+
+func (q *Queries) EventUIDExists(ctx context.Context, arg EventUIDExistsParams) (bool, error) {
+	row := q.db.QueryRow(ctx, eventUIDExists, arg.Uid, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const getAllEvents = `-- name: GetAllEvents :many
 SELECT 
     e.id,
@@ -110,34 +209,45 @@ SELECT
     e.description,
     e.start_time,
     e.end_time,
+    e.rrule,
+    e.exdates,
+    e.uid,
+    e.timezone,
     e.created_at,
     e.updated_at,
     er.id as reminder_id,
     er.remind_before,
+    er.channel as reminder_channel,
     er.notified,
     er.created_at as reminder_created_at
 FROM events e
 LEFT JOIN event_reminders er ON e.id = er.event_id
+WHERE e.user_id = $1
 ORDER BY e.start_time ASC, er.remind_before ASC
 `
 
 type GetAllEventsRow struct {
-	ID                int64              `json:"id"`
-	Name              string             `json:"name"`
-	Place             string             `json:"place"`
-	Description       string             `json:"description"`
-	StartTime         pgtype.Timestamptz `json:"start_time"`
-	EndTime           pgtype.Timestamptz `json:"end_time"`
-	CreatedAt         pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
-	ReminderID        pgtype.Int8        `json:"reminder_id"`
-	RemindBefore      pgtype.Int4        `json:"remind_before"`
-	Notified          pgtype.Bool        `json:"notified"`
-	ReminderCreatedAt pgtype.Timestamptz `json:"reminder_created_at"`
-}
-
-func (q *Queries) GetAllEvents(ctx context.Context) ([]GetAllEventsRow, error) {
-	rows, err := q.db.Query(ctx, getAllEvents)
+	ID                int64                `json:"id"`
+	Name              string               `json:"name"`
+	Place             string               `json:"place"`
+	Description       string               `json:"description"`
+	StartTime         pgtype.Timestamptz   `json:"start_time"`
+	EndTime           pgtype.Timestamptz   `json:"end_time"`
+	Rrule             string               `json:"rrule"`
+	Exdates           []pgtype.Timestamptz `json:"exdates"`
+	Uid               string               `json:"uid"`
+	Timezone          string               `json:"timezone"`
+	CreatedAt         pgtype.Timestamptz   `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz   `json:"updated_at"`
+	ReminderID        pgtype.Int8          `json:"reminder_id"`
+	RemindBefore      pgtype.Int4          `json:"remind_before"`
+	ReminderChannel   pgtype.Text          `json:"reminder_channel"`
+	Notified          pgtype.Bool          `json:"notified"`
+	ReminderCreatedAt pgtype.Timestamptz   `json:"reminder_created_at"`
+}
+
+func (q *Queries) GetAllEvents(ctx context.Context, userID int64) ([]GetAllEventsRow, error) {
+	rows, err := q.db.Query(ctx, getAllEvents, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -152,10 +262,15 @@ func (q *Queries) GetAllEvents(ctx context.Context) ([]GetAllEventsRow, error) {
 			&i.Description,
 			&i.StartTime,
 			&i.EndTime,
+			&i.Rrule,
+			&i.Exdates,
+			&i.Uid,
+			&i.Timezone,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.ReminderID,
 			&i.RemindBefore,
+			&i.ReminderChannel,
 			&i.Notified,
 			&i.ReminderCreatedAt,
 		); err != nil {
@@ -177,35 +292,50 @@ SELECT
     e.description,
     e.start_time,
     e.end_time,
+    e.rrule,
+    e.exdates,
+    e.uid,
+    e.timezone,
     e.created_at,
     e.updated_at,
     er.id as reminder_id,
     er.remind_before,
+    er.channel as reminder_channel,
     er.notified,
     er.created_at as reminder_created_at
 FROM events e
 LEFT JOIN event_reminders er ON e.id = er.event_id
-WHERE e.id = $1
+WHERE e.id = $1 AND e.user_id = $2
 ORDER BY er.remind_before ASC
 `
 
 type GetEventByIDRow struct {
-	ID                int64              `json:"id"`
-	Name              string             `json:"name"`
-	Place             string             `json:"place"`
-	Description       string             `json:"description"`
-	StartTime         pgtype.Timestamptz `json:"start_time"`
-	EndTime           pgtype.Timestamptz `json:"end_time"`
-	CreatedAt         pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
-	ReminderID        pgtype.Int8        `json:"reminder_id"`
-	RemindBefore      pgtype.Int4        `json:"remind_before"`
-	Notified          pgtype.Bool        `json:"notified"`
-	ReminderCreatedAt pgtype.Timestamptz `json:"reminder_created_at"`
-}
-
-func (q *Queries) GetEventByID(ctx context.Context, id int64) (GetEventByIDRow, error) {
-	row := q.db.QueryRow(ctx, getEventByID, id)
+	ID                int64                `json:"id"`
+	Name              string               `json:"name"`
+	Place             string               `json:"place"`
+	Description       string               `json:"description"`
+	StartTime         pgtype.Timestamptz   `json:"start_time"`
+	EndTime           pgtype.Timestamptz   `json:"end_time"`
+	Rrule             string               `json:"rrule"`
+	Exdates           []pgtype.Timestamptz `json:"exdates"`
+	Uid               string               `json:"uid"`
+	Timezone          string               `json:"timezone"`
+	CreatedAt         pgtype.Timestamptz   `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz   `json:"updated_at"`
+	ReminderID        pgtype.Int8          `json:"reminder_id"`
+	RemindBefore      pgtype.Int4          `json:"remind_before"`
+	ReminderChannel   pgtype.Text          `json:"reminder_channel"`
+	Notified          pgtype.Bool          `json:"notified"`
+	ReminderCreatedAt pgtype.Timestamptz   `json:"reminder_created_at"`
+}
+
+type GetEventByIDParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) GetEventByID(ctx context.Context, arg GetEventByIDParams) (GetEventByIDRow, error) {
+	row := q.db.QueryRow(ctx, getEventByID, arg.ID, arg.UserID)
 	var i GetEventByIDRow
 	err := row.Scan(
 		&i.ID,
@@ -214,22 +344,66 @@ func (q *Queries) GetEventByID(ctx context.Context, id int64) (GetEventByIDRow,
 		&i.Description,
 		&i.StartTime,
 		&i.EndTime,
+		&i.Rrule,
+		&i.Exdates,
+		&i.Uid,
+		&i.Timezone,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.ReminderID,
 		&i.RemindBefore,
+		&i.ReminderChannel,
 		&i.Notified,
 		&i.ReminderCreatedAt,
 	)
 	return i, err
 }
 
+const getEventOverridesByEventIDs = `-- name: GetEventOverridesByEventIDs :many
+SELECT id, event_id, recurrence_id, name, place, description, start_time, end_time, created_at, updated_at FROM event_overrides
+WHERE event_id = ANY($1::bigint[])
+ORDER BY recurrence_id ASC
+`
+
+func (q *Queries) GetEventOverridesByEventIDs(ctx context.Context, eventIds []int64) ([]EventOverride, error) {
+	rows, err := q.db.Query(ctx, getEventOverridesByEventIDs, eventIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EventOverride
+	for rows.Next() {
+		var i EventOverride
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.RecurrenceID,
+			&i.Name,
+			&i.Place,
+			&i.Description,
+			&i.StartTime,
+			&i.EndTime,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getEventRemindersByEventID = `-- name: GetEventRemindersByEventID :many
 SELECT 
     id,
     event_id,
     remind_before,
+    channel,
     notified,
+    last_notified_occurrence,
     created_at
 FROM event_reminders
 WHERE event_id = $1
@@ -249,7 +423,9 @@ func (q *Queries) GetEventRemindersByEventID(ctx context.Context, eventID int64)
 			&i.ID,
 			&i.EventID,
 			&i.RemindBefore,
+			&i.Channel,
 			&i.Notified,
+			&i.LastNotifiedOccurrence,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -267,16 +443,19 @@ SELECT
     er.id,
     er.event_id,
     er.remind_before,
+    er.channel,
     er.notified,
     er.created_at,
     e.name,
     e.place,
     e.description,
     e.start_time,
-    e.end_time
+    e.end_time,
+    e.user_id
 FROM event_reminders er
 JOIN events e ON er.event_id = e.id
 WHERE er.notified = false
+    AND e.rrule = ''
     AND e.start_time <= NOW() + INTERVAL '1 minute' * er.remind_before
 ORDER BY e.start_time ASC
 `
@@ -285,6 +464,7 @@ type GetEventRemindersToNotifyRow struct {
 	ID           int64              `json:"id"`
 	EventID      int64              `json:"event_id"`
 	RemindBefore int32              `json:"remind_before"`
+	Channel      string             `json:"channel"`
 	Notified     bool               `json:"notified"`
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
 	Name         string             `json:"name"`
@@ -292,6 +472,7 @@ type GetEventRemindersToNotifyRow struct {
 	Description  string             `json:"description"`
 	StartTime    pgtype.Timestamptz `json:"start_time"`
 	EndTime      pgtype.Timestamptz `json:"end_time"`
+	UserID       int64              `json:"user_id"`
 }
 
 func (q *Queries) GetEventRemindersToNotify(ctx context.Context) ([]GetEventRemindersToNotifyRow, error) {
@@ -307,6 +488,7 @@ func (q *Queries) GetEventRemindersToNotify(ctx context.Context) ([]GetEventRemi
 			&i.ID,
 			&i.EventID,
 			&i.RemindBefore,
+			&i.Channel,
 			&i.Notified,
 			&i.CreatedAt,
 			&i.Name,
@@ -314,6 +496,7 @@ func (q *Queries) GetEventRemindersToNotify(ctx context.Context) ([]GetEventRemi
 			&i.Description,
 			&i.StartTime,
 			&i.EndTime,
+			&i.UserID,
 		); err != nil {
 			return nil, err
 		}
@@ -333,40 +516,53 @@ SELECT
     e.description,
     e.start_time,
     e.end_time,
+    e.rrule,
+    e.exdates,
+    e.uid,
+    e.timezone,
     e.created_at,
     e.updated_at,
     er.id as reminder_id,
     er.remind_before,
+    er.channel as reminder_channel,
     er.notified,
     er.created_at as reminder_created_at
 FROM events e
 LEFT JOIN event_reminders er ON e.id = er.event_id
-WHERE e.start_time >= $1 AND e.end_time <= $2
+WHERE e.user_id = $3
+    AND ((e.rrule = '' AND e.start_time < $2 AND (e.end_time > $1 OR e.start_time >= $1))
+        OR (e.rrule <> '' AND e.start_time < $2))
 ORDER BY e.start_time ASC, er.remind_before ASC
 `
 
 type GetEventsByDateRangeParams struct {
 	StartTime pgtype.Timestamptz `json:"start_time"`
 	EndTime   pgtype.Timestamptz `json:"end_time"`
+	UserID    int64              `json:"user_id"`
 }
 
 type GetEventsByDateRangeRow struct {
-	ID                int64              `json:"id"`
-	Name              string             `json:"name"`
-	Place             string             `json:"place"`
-	Description       string             `json:"description"`
-	StartTime         pgtype.Timestamptz `json:"start_time"`
-	EndTime           pgtype.Timestamptz `json:"end_time"`
-	CreatedAt         pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
-	ReminderID        pgtype.Int8        `json:"reminder_id"`
-	RemindBefore      pgtype.Int4        `json:"remind_before"`
-	Notified          pgtype.Bool        `json:"notified"`
-	ReminderCreatedAt pgtype.Timestamptz `json:"reminder_created_at"`
+	ID                int64                `json:"id"`
+	Name              string               `json:"name"`
+	Place             string               `json:"place"`
+	Description       string               `json:"description"`
+	StartTime         pgtype.Timestamptz   `json:"start_time"`
+	EndTime           pgtype.Timestamptz   `json:"end_time"`
+	Rrule             string               `json:"rrule"`
+	Exdates           []pgtype.Timestamptz `json:"exdates"`
+	Uid               string               `json:"uid"`
+	Timezone          string               `json:"timezone"`
+	CreatedAt         pgtype.Timestamptz   `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz   `json:"updated_at"`
+	ReminderID        pgtype.Int8          `json:"reminder_id"`
+	RemindBefore      pgtype.Int4          `json:"remind_before"`
+	ReminderChannel   pgtype.Text          `json:"reminder_channel"`
+	Notified          pgtype.Bool          `json:"notified"`
+	ReminderCreatedAt pgtype.Timestamptz   `json:"reminder_created_at"`
 }
 
 func (q *Queries) GetEventsByDateRange(ctx context.Context, arg GetEventsByDateRangeParams) ([]GetEventsByDateRangeRow, error) {
-	rows, err := q.db.Query(ctx, getEventsByDateRange, arg.StartTime, arg.EndTime)
+	rows, err := q.db.Query(ctx, getEventsByDateRange, arg.StartTime, arg.EndTime, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -381,10 +577,15 @@ func (q *Queries) GetEventsByDateRange(ctx context.Context, arg GetEventsByDateR
 			&i.Description,
 			&i.StartTime,
 			&i.EndTime,
+			&i.Rrule,
+			&i.Exdates,
+			&i.Uid,
+			&i.Timezone,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.ReminderID,
 			&i.RemindBefore,
+			&i.ReminderChannel,
 			&i.Notified,
 			&i.ReminderCreatedAt,
 		); err != nil {
@@ -398,6 +599,144 @@ func (q *Queries) GetEventsByDateRange(ctx context.Context, arg GetEventsByDateR
 	return items, nil
 }
 
+const getEventsOverlappingRange = `-- name: GetEventsOverlappingRange :many
+SELECT id, name, place, description, start_time, end_time, rrule, exdates, uid, timezone, created_at, updated_at, user_id
+FROM events
+WHERE ((rrule = '' AND start_time < $1 AND end_time > $2)
+        OR (rrule <> '' AND start_time < $1))
+    AND user_id = $3
+ORDER BY start_time ASC
+`
+
+type GetEventsOverlappingRangeParams struct {
+	RangeEnd   pgtype.Timestamptz `json:"range_end"`
+	RangeStart pgtype.Timestamptz `json:"range_start"`
+	UserID     int64              `json:"user_id"`
+}
+
+func (q *Queries) GetEventsOverlappingRange(ctx context.Context, arg GetEventsOverlappingRangeParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, getEventsOverlappingRange, arg.RangeEnd, arg.RangeStart, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Place,
+			&i.Description,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Rrule,
+			&i.Exdates,
+			&i.Uid,
+			&i.Timezone,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecurringEventReminders = `-- name: GetRecurringEventReminders :many
+SELECT 
+    er.id,
+    er.event_id,
+    er.remind_before,
+    er.channel,
+    er.last_notified_occurrence,
+    er.created_at,
+    e.name,
+    e.place,
+    e.description,
+    e.start_time,
+    e.end_time,
+    e.rrule,
+    e.exdates,
+    e.timezone,
+    e.user_id
+FROM event_reminders er
+JOIN events e ON er.event_id = e.id
+WHERE e.rrule <> ''
+ORDER BY e.start_time ASC
+`
+
+type GetRecurringEventRemindersRow struct {
+	ID                     int64                `json:"id"`
+	EventID                int64                `json:"event_id"`
+	RemindBefore           int32                `json:"remind_before"`
+	Channel                string               `json:"channel"`
+	LastNotifiedOccurrence pgtype.Timestamptz   `json:"last_notified_occurrence"`
+	CreatedAt              pgtype.Timestamptz   `json:"created_at"`
+	Name                   string               `json:"name"`
+	Place                  string               `json:"place"`
+	Description            string               `json:"description"`
+	StartTime              pgtype.Timestamptz   `json:"start_time"`
+	EndTime                pgtype.Timestamptz   `json:"end_time"`
+	Rrule                  string               `json:"rrule"`
+	Exdates                []pgtype.Timestamptz `json:"exdates"`
+	Timezone               string               `json:"timezone"`
+	UserID                 int64                `json:"user_id"`
+}
+
+func (q *Queries) GetRecurringEventReminders(ctx context.Context) ([]GetRecurringEventRemindersRow, error) {
+	rows, err := q.db.Query(ctx, getRecurringEventReminders)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRecurringEventRemindersRow
+	for rows.Next() {
+		var i GetRecurringEventRemindersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.RemindBefore,
+			&i.Channel,
+			&i.LastNotifiedOccurrence,
+			&i.CreatedAt,
+			&i.Name,
+			&i.Place,
+			&i.Description,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Rrule,
+			&i.Exdates,
+			&i.Timezone,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rearmEventReminders = `-- name: RearmEventReminders :exec
+UPDATE event_reminders er
+SET notified = e.start_time <= NOW(), last_notified_occurrence = NULL
+FROM events e
+WHERE e.id = er.event_id AND er.event_id = $1
+`
+
+func (q *Queries) RearmEventReminders(ctx context.Context, eventID int64) error {
+	_, err := q.db.Exec(ctx, rearmEventReminders, eventID)
+	return err
+}
+
 const updateEvent = `-- name: UpdateEvent :one
 UPDATE events
 SET 
@@ -405,18 +744,25 @@ SET
     place = $3,
     description = $4,
     start_time = $5,
-    end_time = $6
-WHERE id = $1
-RETURNING id, name, place, description, start_time, end_time, created_at, updated_at
+    end_time = $6,
+    rrule = $7,
+    exdates = $8,
+    timezone = $9
+WHERE id = $1 AND user_id = $10
+RETURNING id, name, place, description, start_time, end_time, rrule, exdates, uid, timezone, created_at, updated_at, user_id
 `
 
 type UpdateEventParams struct {
-	ID          int64              `json:"id"`
-	Name        string             `json:"name"`
-	Place       string             `json:"place"`
-	Description string             `json:"description"`
-	StartTime   pgtype.Timestamptz `json:"start_time"`
-	EndTime     pgtype.Timestamptz `json:"end_time"`
+	ID          int64                `json:"id"`
+	Name        string               `json:"name"`
+	Place       string               `json:"place"`
+	Description string               `json:"description"`
+	StartTime   pgtype.Timestamptz   `json:"start_time"`
+	EndTime     pgtype.Timestamptz   `json:"end_time"`
+	Rrule       string               `json:"rrule"`
+	Exdates     []pgtype.Timestamptz `json:"exdates"`
+	Timezone    string               `json:"timezone"`
+	UserID      int64                `json:"user_id"`
 }
 
 func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event, error) {
@@ -427,6 +773,10 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event
 		arg.Description,
 		arg.StartTime,
 		arg.EndTime,
+		arg.Rrule,
+		arg.Exdates,
+		arg.Timezone,
+		arg.UserID,
 	)
 	var i Event
 	err := row.Scan(
@@ -436,12 +786,61 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event
 		&i.Description,
 		&i.StartTime,
 		&i.EndTime,
+		&i.Rrule,
+		&i.Exdates,
+		&i.Uid,
+		&i.Timezone,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const updateEventReminderChannel = `-- name: UpdateEventReminderChannel :one
+UPDATE event_reminders
+SET channel = $2
+WHERE id = $1 AND event_id IN (SELECT id FROM events WHERE user_id = $3)
+RETURNING id, event_id, remind_before, channel, notified, last_notified_occurrence, created_at
+`
+
+type UpdateEventReminderChannelParams struct {
+	ID      int64  `json:"id"`
+	Channel string `json:"channel"`
+	UserID  int64  `json:"user_id"`
+}
+
+func (q *Queries) UpdateEventReminderChannel(ctx context.Context, arg UpdateEventReminderChannelParams) (EventReminder, error) {
+	row := q.db.QueryRow(ctx, updateEventReminderChannel, arg.ID, arg.Channel, arg.UserID)
+	var i EventReminder
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.RemindBefore,
+		&i.Channel,
+		&i.Notified,
+		&i.LastNotifiedOccurrence,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
+const updateEventReminderLastNotifiedOccurrence = `-- name: UpdateEventReminderLastNotifiedOccurrence :exec
+UPDATE event_reminders
+SET last_notified_occurrence = $2
+WHERE id = $1
+`
+
+type UpdateEventReminderLastNotifiedOccurrenceParams struct {
+	ID                     int64              `json:"id"`
+	LastNotifiedOccurrence pgtype.Timestamptz `json:"last_notified_occurrence"`
+}
+
+func (q *Queries) UpdateEventReminderLastNotifiedOccurrence(ctx context.Context, arg UpdateEventReminderLastNotifiedOccurrenceParams) error {
+	_, err := q.db.Exec(ctx, updateEventReminderLastNotifiedOccurrence, arg.ID, arg.LastNotifiedOccurrence)
+	return err
+}
+
 const updateEventReminderNotified = `-- name: UpdateEventReminderNotified :exec
 UPDATE event_reminders
 SET notified = $2
@@ -457,3 +856,61 @@ func (q *Queries) UpdateEventReminderNotified(ctx context.Context, arg UpdateEve
 	_, err := q.db.Exec(ctx, updateEventReminderNotified, arg.ID, arg.Notified)
 	return err
 }
+
+const upsertEventOverride = `-- name: UpsertEventOverride :one
+INSERT INTO event_overrides (
+    event_id,
+    recurrence_id,
+    name,
+    place,
+    description,
+    start_time,
+    end_time
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (event_id, recurrence_id) DO UPDATE
+SET 
+    name = EXCLUDED.name,
+    place = EXCLUDED.place,
+    description = EXCLUDED.description,
+    start_time = EXCLUDED.start_time,
+    end_time = EXCLUDED.end_time
+RETURNING id, event_id, recurrence_id, name, place, description, start_time, end_time, created_at, updated_at
+`
+
+type UpsertEventOverrideParams struct {
+	EventID      int64              `json:"event_id"`
+	RecurrenceID pgtype.Timestamptz `json:"recurrence_id"`
+	Name         string             `json:"name"`
+	Place        string             `json:"place"`
+	Description  string             `json:"description"`
+	StartTime    pgtype.Timestamptz `json:"start_time"`
+	EndTime      pgtype.Timestamptz `json:"end_time"`
+}
+
+func (q *Queries) UpsertEventOverride(ctx context.Context, arg UpsertEventOverrideParams) (EventOverride, error) {
+	row := q.db.QueryRow(ctx, upsertEventOverride,
+		arg.EventID,
+		arg.RecurrenceID,
+		arg.Name,
+		arg.Place,
+		arg.Description,
+		arg.StartTime,
+		arg.EndTime,
+	)
+	var i EventOverride
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.RecurrenceID,
+		&i.Name,
+		&i.Place,
+		&i.Description,
+		&i.StartTime,
+		&i.EndTime,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
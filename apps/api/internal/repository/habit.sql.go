@@ -12,18 +12,19 @@ import (
 )
 
 const createHabit = `-- name: CreateHabit :one
-INSERT INTO habits (name, description)
-VALUES ($1, $2)
-RETURNING id, name, description, created_at, updated_at
+INSERT INTO habits (name, description, user_id)
+VALUES ($1, $2, $3)
+RETURNING id, name, description, created_at, updated_at, user_id
 `
 
 type CreateHabitParams struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	UserID      int64  `json:"user_id"`
 }
 
 func (q *Queries) CreateHabit(ctx context.Context, arg CreateHabitParams) (Habit, error) {
-	row := q.db.QueryRow(ctx, createHabit, arg.Name, arg.Description)
+	row := q.db.QueryRow(ctx, createHabit, arg.Name, arg.Description, arg.UserID)
 	var i Habit
 	err := row.Scan(
 		&i.ID,
@@ -31,17 +32,23 @@ func (q *Queries) CreateHabit(ctx context.Context, arg CreateHabitParams) (Habit
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
 	)
 	return i, err
 }
 
 const deleteHabitById = `-- name: DeleteHabitById :exec
 DELETE FROM habits
-WHERE id = $1
+WHERE id = $1 AND user_id = $2
 `
 
-func (q *Queries) DeleteHabitById(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, deleteHabitById, id)
+type DeleteHabitByIdParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteHabitById(ctx context.Context, arg DeleteHabitByIdParams) error {
+	_, err := q.db.Exec(ctx, deleteHabitById, arg.ID, arg.UserID)
 	return err
 }
 
@@ -56,6 +63,7 @@ SELECT
     MAX(hl.happened_at)::timestamptz as last_log_time
 FROM habits h
 LEFT JOIN habit_logs hl ON h.id = hl.habit_id
+WHERE h.user_id = $1
 GROUP BY h.id, h.name, h.description, h.created_at, h.updated_at
 ORDER BY h.updated_at DESC
 `
@@ -70,8 +78,8 @@ type GetAllHabitsRow struct {
 	LastLogTime pgtype.Timestamptz `json:"last_log_time"`
 }
 
-func (q *Queries) GetAllHabits(ctx context.Context) ([]GetAllHabitsRow, error) {
-	rows, err := q.db.Query(ctx, getAllHabits)
+func (q *Queries) GetAllHabits(ctx context.Context, userID int64) ([]GetAllHabitsRow, error) {
+	rows, err := q.db.Query(ctx, getAllHabits, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -109,10 +117,15 @@ SELECT
     MAX(hl.happened_at)::timestamptz as last_log_time
 FROM habits h
 LEFT JOIN habit_logs hl ON h.id = hl.habit_id
-WHERE h.id = $1
+WHERE h.id = $1 AND h.user_id = $2
 GROUP BY h.id, h.name, h.description, h.created_at, h.updated_at
 `
 
+type GetHabitByIdParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
 type GetHabitByIdRow struct {
 	ID          int64              `json:"id"`
 	Name        string             `json:"name"`
@@ -123,8 +136,8 @@ type GetHabitByIdRow struct {
 	LastLogTime pgtype.Timestamptz `json:"last_log_time"`
 }
 
-func (q *Queries) GetHabitById(ctx context.Context, id int64) (GetHabitByIdRow, error) {
-	row := q.db.QueryRow(ctx, getHabitById, id)
+func (q *Queries) GetHabitById(ctx context.Context, arg GetHabitByIdParams) (GetHabitByIdRow, error) {
+	row := q.db.QueryRow(ctx, getHabitById, arg.ID, arg.UserID)
 	var i GetHabitByIdRow
 	err := row.Scan(
 		&i.ID,
@@ -139,11 +152,16 @@ func (q *Queries) GetHabitById(ctx context.Context, id int64) (GetHabitByIdRow,
 }
 
 const getHabitByName = `-- name: GetHabitByName :one
-SELECT id, name, description, created_at, updated_at FROM habits WHERE name = $1
+SELECT id, name, description, created_at, updated_at, user_id FROM habits WHERE name = $1 AND user_id = $2
 `
 
-func (q *Queries) GetHabitByName(ctx context.Context, name string) (Habit, error) {
-	row := q.db.QueryRow(ctx, getHabitByName, name)
+type GetHabitByNameParams struct {
+	Name   string `json:"name"`
+	UserID int64  `json:"user_id"`
+}
+
+func (q *Queries) GetHabitByName(ctx context.Context, arg GetHabitByNameParams) (Habit, error) {
+	row := q.db.QueryRow(ctx, getHabitByName, arg.Name, arg.UserID)
 	var i Habit
 	err := row.Scan(
 		&i.ID,
@@ -151,18 +169,24 @@ func (q *Queries) GetHabitByName(ctx context.Context, name string) (Habit, error
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
 	)
 	return i, err
 }
 
 const habitExists = `-- name: HabitExists :one
 SELECT EXISTS(
-    SELECT 1 FROM habits WHERE id = $1
+    SELECT 1 FROM habits WHERE id = $1 AND user_id = $2
 ) AS exists
 `
 
-func (q *Queries) HabitExists(ctx context.Context, id int64) (bool, error) {
-	row := q.db.QueryRow(ctx, habitExists, id)
+type HabitExistsParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) HabitExists(ctx context.Context, arg HabitExistsParams) (bool, error) {
+	row := q.db.QueryRow(ctx, habitExists, arg.ID, arg.UserID)
 	var exists bool
 	err := row.Scan(&exists)
 	return exists, err
@@ -174,18 +198,24 @@ SET
     name = $1,
     description = $2
 WHERE
-    id = $3
-RETURNING id, name, description, created_at, updated_at
+    id = $3 AND user_id = $4
+RETURNING id, name, description, created_at, updated_at, user_id
 `
 
 type UpdateHabitByIdParams struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	ID          int64  `json:"id"`
+	UserID      int64  `json:"user_id"`
 }
 
 func (q *Queries) UpdateHabitById(ctx context.Context, arg UpdateHabitByIdParams) (Habit, error) {
-	row := q.db.QueryRow(ctx, updateHabitById, arg.Name, arg.Description, arg.ID)
+	row := q.db.QueryRow(ctx, updateHabitById,
+		arg.Name,
+		arg.Description,
+		arg.ID,
+		arg.UserID,
+	)
 	var i Habit
 	err := row.Scan(
 		&i.ID,
@@ -193,6 +223,7 @@ func (q *Queries) UpdateHabitById(ctx context.Context, arg UpdateHabitByIdParams
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
 	)
 	return i, err
 }
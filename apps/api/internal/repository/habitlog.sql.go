@@ -12,60 +12,87 @@ import (
 )
 
 const createHabitLog = `-- name: CreateHabitLog :one
-INSERT INTO habit_logs (habit_id, happened_at)
-VALUES ($1, $2)
-RETURNING id, habit_id, happened_at
+INSERT INTO habit_logs (habit_id, happened_at, user_id)
+VALUES ($1, $2, $3)
+RETURNING id, habit_id, happened_at, user_id
 `
 
 type CreateHabitLogParams struct {
 	HabitID    int64              `json:"habit_id"`
 	HappenedAt pgtype.Timestamptz `json:"happened_at"`
+	UserID     int64              `json:"user_id"`
 }
 
 func (q *Queries) CreateHabitLog(ctx context.Context, arg CreateHabitLogParams) (HabitLog, error) {
-	row := q.db.QueryRow(ctx, createHabitLog, arg.HabitID, arg.HappenedAt)
+	row := q.db.QueryRow(ctx, createHabitLog, arg.HabitID, arg.HappenedAt, arg.UserID)
 	var i HabitLog
-	err := row.Scan(&i.ID, &i.HabitID, &i.HappenedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.HabitID,
+		&i.HappenedAt,
+		&i.UserID,
+	)
 	return i, err
 }
 
 const createHabitLogNow = `-- name: CreateHabitLogNow :one
-INSERT INTO habit_logs (habit_id)
-VALUES ($1)
-RETURNING id, habit_id, happened_at
+INSERT INTO habit_logs (habit_id, user_id)
+VALUES ($1, $2)
+RETURNING id, habit_id, happened_at, user_id
 `
 
-func (q *Queries) CreateHabitLogNow(ctx context.Context, habitID int64) (HabitLog, error) {
-	row := q.db.QueryRow(ctx, createHabitLogNow, habitID)
+type CreateHabitLogNowParams struct {
+	HabitID int64 `json:"habit_id"`
+	UserID  int64 `json:"user_id"`
+}
+
+func (q *Queries) CreateHabitLogNow(ctx context.Context, arg CreateHabitLogNowParams) (HabitLog, error) {
+	row := q.db.QueryRow(ctx, createHabitLogNow, arg.HabitID, arg.UserID)
 	var i HabitLog
-	err := row.Scan(&i.ID, &i.HabitID, &i.HappenedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.HabitID,
+		&i.HappenedAt,
+		&i.UserID,
+	)
 	return i, err
 }
 
 const deleteHabitLogById = `-- name: DeleteHabitLogById :exec
 DELETE FROM habit_logs
-WHERE id = $1
+WHERE id = $1 AND user_id = $2
 `
 
-func (q *Queries) DeleteHabitLogById(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, deleteHabitLogById, id)
+type DeleteHabitLogByIdParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteHabitLogById(ctx context.Context, arg DeleteHabitLogByIdParams) error {
+	_, err := q.db.Exec(ctx, deleteHabitLogById, arg.ID, arg.UserID)
 	return err
 }
 
 const deleteHabitLogsByHabitId = `-- name: DeleteHabitLogsByHabitId :exec
 DELETE FROM habit_logs
-WHERE habit_id = $1
+WHERE habit_id = $1 AND user_id = $2
 `
 
-func (q *Queries) DeleteHabitLogsByHabitId(ctx context.Context, habitID int64) error {
-	_, err := q.db.Exec(ctx, deleteHabitLogsByHabitId, habitID)
+type DeleteHabitLogsByHabitIdParams struct {
+	HabitID int64 `json:"habit_id"`
+	UserID  int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteHabitLogsByHabitId(ctx context.Context, arg DeleteHabitLogsByHabitIdParams) error {
+	_, err := q.db.Exec(ctx, deleteHabitLogsByHabitId, arg.HabitID, arg.UserID)
 	return err
 }
 
 const getAllHabitLogs = `-- name: GetAllHabitLogs :many
-SELECT hl.id, hl.habit_id, hl.happened_at, h.name as habit_name 
+SELECT hl.id, hl.habit_id, hl.happened_at, hl.user_id, h.name as habit_name 
 FROM habit_logs hl
 JOIN habits h ON hl.habit_id = h.id
+WHERE hl.user_id = $1
 ORDER BY hl.happened_at DESC
 `
 
@@ -73,11 +100,12 @@ type GetAllHabitLogsRow struct {
 	ID         int64              `json:"id"`
 	HabitID    int64              `json:"habit_id"`
 	HappenedAt pgtype.Timestamptz `json:"happened_at"`
+	UserID     int64              `json:"user_id"`
 	HabitName  string             `json:"habit_name"`
 }
 
-func (q *Queries) GetAllHabitLogs(ctx context.Context) ([]GetAllHabitLogsRow, error) {
-	rows, err := q.db.Query(ctx, getAllHabitLogs)
+func (q *Queries) GetAllHabitLogs(ctx context.Context, userID int64) ([]GetAllHabitLogsRow, error) {
+	rows, err := q.db.Query(ctx, getAllHabitLogs, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +117,7 @@ func (q *Queries) GetAllHabitLogs(ctx context.Context) ([]GetAllHabitLogsRow, er
 			&i.ID,
 			&i.HabitID,
 			&i.HappenedAt,
+			&i.UserID,
 			&i.HabitName,
 		); err != nil {
 			return nil, err
@@ -102,48 +131,77 @@ func (q *Queries) GetAllHabitLogs(ctx context.Context) ([]GetAllHabitLogsRow, er
 }
 
 const getHabitLogById = `-- name: GetHabitLogById :one
-SELECT hl.id, hl.habit_id, hl.happened_at, h.name as habit_name 
+SELECT hl.id, hl.habit_id, hl.happened_at, hl.user_id, h.name as habit_name 
 FROM habit_logs hl
 JOIN habits h ON hl.habit_id = h.id
-WHERE hl.id = $1
+WHERE hl.id = $1 AND hl.user_id = $2
 `
 
+type GetHabitLogByIdParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
 type GetHabitLogByIdRow struct {
 	ID         int64              `json:"id"`
 	HabitID    int64              `json:"habit_id"`
 	HappenedAt pgtype.Timestamptz `json:"happened_at"`
+	UserID     int64              `json:"user_id"`
 	HabitName  string             `json:"habit_name"`
 }
 
-func (q *Queries) GetHabitLogById(ctx context.Context, id int64) (GetHabitLogByIdRow, error) {
-	row := q.db.QueryRow(ctx, getHabitLogById, id)
+func (q *Queries) GetHabitLogById(ctx context.Context, arg GetHabitLogByIdParams) (GetHabitLogByIdRow, error) {
+	row := q.db.QueryRow(ctx, getHabitLogById, arg.ID, arg.UserID)
 	var i GetHabitLogByIdRow
 	err := row.Scan(
 		&i.ID,
 		&i.HabitID,
 		&i.HappenedAt,
+		&i.UserID,
 		&i.HabitName,
 	)
 	return i, err
 }
 
 const getHabitLogsByDate = `-- name: GetHabitLogsByDate :many
-SELECT id, habit_id, happened_at FROM habit_logs
-WHERE DATE(happened_at) = $1
-ORDER BY happened_at DESC
+SELECT hl.id, hl.habit_id, hl.happened_at, hl.user_id, h.name as habit_name
+FROM habit_logs hl
+JOIN habits h ON hl.habit_id = h.id
+WHERE hl.user_id = $1 AND hl.happened_at >= $2 AND hl.happened_at < $3
+ORDER BY hl.happened_at DESC
 `
 
-// 获取指定日期的习惯日志
-func (q *Queries) GetHabitLogsByDate(ctx context.Context, happenedAt pgtype.Timestamptz) ([]HabitLog, error) {
-	rows, err := q.db.Query(ctx, getHabitLogsByDate, happenedAt)
+type GetHabitLogsByDateParams struct {
+	UserID   int64              `json:"user_id"`
+	DayStart pgtype.Timestamptz `json:"day_start"`
+	DayEnd   pgtype.Timestamptz `json:"day_end"`
+}
+
+type GetHabitLogsByDateRow struct {
+	ID         int64              `json:"id"`
+	HabitID    int64              `json:"habit_id"`
+	HappenedAt pgtype.Timestamptz `json:"happened_at"`
+	UserID     int64              `json:"user_id"`
+	HabitName  string             `json:"habit_name"`
+}
+
+// 获取指定日期的习惯日志，日期边界由调用方按用户时区计算
+func (q *Queries) GetHabitLogsByDate(ctx context.Context, arg GetHabitLogsByDateParams) ([]GetHabitLogsByDateRow, error) {
+	rows, err := q.db.Query(ctx, getHabitLogsByDate, arg.UserID, arg.DayStart, arg.DayEnd)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []HabitLog
+	var items []GetHabitLogsByDateRow
 	for rows.Next() {
-		var i HabitLog
-		if err := rows.Scan(&i.ID, &i.HabitID, &i.HappenedAt); err != nil {
+		var i GetHabitLogsByDateRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.HabitID,
+			&i.HappenedAt,
+			&i.UserID,
+			&i.HabitName,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -155,22 +213,28 @@ func (q *Queries) GetHabitLogsByDate(ctx context.Context, happenedAt pgtype.Time
 }
 
 const getHabitLogsByHabitId = `-- name: GetHabitLogsByHabitId :many
-SELECT hl.id, hl.habit_id, hl.happened_at, h.name as habit_name 
+SELECT hl.id, hl.habit_id, hl.happened_at, hl.user_id, h.name as habit_name 
 FROM habit_logs hl
 JOIN habits h ON hl.habit_id = h.id
-WHERE hl.habit_id = $1
+WHERE hl.habit_id = $1 AND hl.user_id = $2
 ORDER BY hl.happened_at DESC
 `
 
+type GetHabitLogsByHabitIdParams struct {
+	HabitID int64 `json:"habit_id"`
+	UserID  int64 `json:"user_id"`
+}
+
 type GetHabitLogsByHabitIdRow struct {
 	ID         int64              `json:"id"`
 	HabitID    int64              `json:"habit_id"`
 	HappenedAt pgtype.Timestamptz `json:"happened_at"`
+	UserID     int64              `json:"user_id"`
 	HabitName  string             `json:"habit_name"`
 }
 
-func (q *Queries) GetHabitLogsByHabitId(ctx context.Context, habitID int64) ([]GetHabitLogsByHabitIdRow, error) {
-	rows, err := q.db.Query(ctx, getHabitLogsByHabitId, habitID)
+func (q *Queries) GetHabitLogsByHabitId(ctx context.Context, arg GetHabitLogsByHabitIdParams) ([]GetHabitLogsByHabitIdRow, error) {
+	rows, err := q.db.Query(ctx, getHabitLogsByHabitId, arg.HabitID, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -182,6 +246,7 @@ func (q *Queries) GetHabitLogsByHabitId(ctx context.Context, habitID int64) ([]G
 			&i.ID,
 			&i.HabitID,
 			&i.HappenedAt,
+			&i.UserID,
 			&i.HabitName,
 		); err != nil {
 			return nil, err
@@ -195,27 +260,50 @@ func (q *Queries) GetHabitLogsByHabitId(ctx context.Context, habitID int64) ([]G
 }
 
 const getHabitLogsByHabitIdAndDate = `-- name: GetHabitLogsByHabitIdAndDate :many
-SELECT id, habit_id, happened_at FROM habit_logs
-WHERE habit_id = $1 AND DATE(happened_at) = $2
-ORDER BY happened_at DESC
+SELECT hl.id, hl.habit_id, hl.happened_at, hl.user_id, h.name as habit_name
+FROM habit_logs hl
+JOIN habits h ON hl.habit_id = h.id
+WHERE hl.habit_id = $1 AND hl.user_id = $2 AND hl.happened_at >= $3 AND hl.happened_at < $4
+ORDER BY hl.happened_at DESC
 `
 
 type GetHabitLogsByHabitIdAndDateParams struct {
+	HabitID  int64              `json:"habit_id"`
+	UserID   int64              `json:"user_id"`
+	DayStart pgtype.Timestamptz `json:"day_start"`
+	DayEnd   pgtype.Timestamptz `json:"day_end"`
+}
+
+type GetHabitLogsByHabitIdAndDateRow struct {
+	ID         int64              `json:"id"`
 	HabitID    int64              `json:"habit_id"`
 	HappenedAt pgtype.Timestamptz `json:"happened_at"`
+	UserID     int64              `json:"user_id"`
+	HabitName  string             `json:"habit_name"`
 }
 
-// 获取指定习惯在指定日期的日志
-func (q *Queries) GetHabitLogsByHabitIdAndDate(ctx context.Context, arg GetHabitLogsByHabitIdAndDateParams) ([]HabitLog, error) {
-	rows, err := q.db.Query(ctx, getHabitLogsByHabitIdAndDate, arg.HabitID, arg.HappenedAt)
+// 获取指定习惯在指定日期的日志，日期边界由调用方按用户时区计算
+func (q *Queries) GetHabitLogsByHabitIdAndDate(ctx context.Context, arg GetHabitLogsByHabitIdAndDateParams) ([]GetHabitLogsByHabitIdAndDateRow, error) {
+	rows, err := q.db.Query(ctx, getHabitLogsByHabitIdAndDate,
+		arg.HabitID,
+		arg.UserID,
+		arg.DayStart,
+		arg.DayEnd,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []HabitLog
+	var items []GetHabitLogsByHabitIdAndDateRow
 	for rows.Next() {
-		var i HabitLog
-		if err := rows.Scan(&i.ID, &i.HabitID, &i.HappenedAt); err != nil {
+		var i GetHabitLogsByHabitIdAndDateRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.HabitID,
+			&i.HappenedAt,
+			&i.UserID,
+			&i.HabitName,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -227,8 +315,8 @@ func (q *Queries) GetHabitLogsByHabitIdAndDate(ctx context.Context, arg GetHabit
 }
 
 const getHabitLogsByHabitIdWithLimit = `-- name: GetHabitLogsByHabitIdWithLimit :many
-SELECT id, habit_id, happened_at FROM habit_logs
-WHERE habit_id = $1
+SELECT id, habit_id, happened_at, user_id FROM habit_logs
+WHERE habit_id = $1 AND user_id = $3
 ORDER BY happened_at DESC
 LIMIT $2
 `
@@ -236,10 +324,11 @@ LIMIT $2
 type GetHabitLogsByHabitIdWithLimitParams struct {
 	HabitID int64 `json:"habit_id"`
 	Limit   int32 `json:"limit"`
+	UserID  int64 `json:"user_id"`
 }
 
 func (q *Queries) GetHabitLogsByHabitIdWithLimit(ctx context.Context, arg GetHabitLogsByHabitIdWithLimitParams) ([]HabitLog, error) {
-	rows, err := q.db.Query(ctx, getHabitLogsByHabitIdWithLimit, arg.HabitID, arg.Limit)
+	rows, err := q.db.Query(ctx, getHabitLogsByHabitIdWithLimit, arg.HabitID, arg.Limit, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -247,7 +336,12 @@ func (q *Queries) GetHabitLogsByHabitIdWithLimit(ctx context.Context, arg GetHab
 	var items []HabitLog
 	for rows.Next() {
 		var i HabitLog
-		if err := rows.Scan(&i.ID, &i.HabitID, &i.HappenedAt); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.HabitID,
+			&i.HappenedAt,
+			&i.UserID,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -260,11 +354,16 @@ func (q *Queries) GetHabitLogsByHabitIdWithLimit(ctx context.Context, arg GetHab
 
 const getHabitLogsCountByHabitId = `-- name: GetHabitLogsCountByHabitId :one
 SELECT COUNT(*) as count FROM habit_logs
-WHERE habit_id = $1
+WHERE habit_id = $1 AND user_id = $2
 `
 
-func (q *Queries) GetHabitLogsCountByHabitId(ctx context.Context, habitID int64) (int64, error) {
-	row := q.db.QueryRow(ctx, getHabitLogsCountByHabitId, habitID)
+type GetHabitLogsCountByHabitIdParams struct {
+	HabitID int64 `json:"habit_id"`
+	UserID  int64 `json:"user_id"`
+}
+
+func (q *Queries) GetHabitLogsCountByHabitId(ctx context.Context, arg GetHabitLogsCountByHabitIdParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getHabitLogsCountByHabitId, arg.HabitID, arg.UserID)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -275,16 +374,23 @@ SELECT COUNT(*) as count FROM habit_logs
 WHERE habit_id = $1
   AND happened_at >= $2
   AND happened_at <= $3
+  AND user_id = $4
 `
 
 type GetHabitLogsCountByHabitIdInDateRangeParams struct {
 	HabitID      int64              `json:"habit_id"`
 	HappenedAt   pgtype.Timestamptz `json:"happened_at"`
 	HappenedAt_2 pgtype.Timestamptz `json:"happened_at_2"`
+	UserID       int64              `json:"user_id"`
 }
 
 func (q *Queries) GetHabitLogsCountByHabitIdInDateRange(ctx context.Context, arg GetHabitLogsCountByHabitIdInDateRangeParams) (int64, error) {
-	row := q.db.QueryRow(ctx, getHabitLogsCountByHabitIdInDateRange, arg.HabitID, arg.HappenedAt, arg.HappenedAt_2)
+	row := q.db.QueryRow(ctx, getHabitLogsCountByHabitIdInDateRange,
+		arg.HabitID,
+		arg.HappenedAt,
+		arg.HappenedAt_2,
+		arg.UserID,
+	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -301,14 +407,15 @@ SELECT
     hl.happened_at as log_happened_at
 FROM habits h
 LEFT JOIN habit_logs hl ON h.id = hl.habit_id
-WHERE h.id = $1
+WHERE h.id = $1 AND h.user_id = $3
 ORDER BY hl.happened_at DESC
 LIMIT $2
 `
 
 type GetHabitWithRecentLogsParams struct {
-	ID    int64 `json:"id"`
-	Limit int32 `json:"limit"`
+	ID     int64 `json:"id"`
+	Limit  int32 `json:"limit"`
+	UserID int64 `json:"user_id"`
 }
 
 type GetHabitWithRecentLogsRow struct {
@@ -323,7 +430,7 @@ type GetHabitWithRecentLogsRow struct {
 
 // 获取习惯及其最近的日志记录
 func (q *Queries) GetHabitWithRecentLogs(ctx context.Context, arg GetHabitWithRecentLogsParams) ([]GetHabitWithRecentLogsRow, error) {
-	rows, err := q.db.Query(ctx, getHabitWithRecentLogs, arg.ID, arg.Limit)
+	rows, err := q.db.Query(ctx, getHabitWithRecentLogs, arg.ID, arg.Limit, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -350,41 +457,19 @@ func (q *Queries) GetHabitWithRecentLogs(ctx context.Context, arg GetHabitWithRe
 	return items, nil
 }
 
-const getTodayHabitLogs = `-- name: GetTodayHabitLogs :many
-SELECT id, habit_id, happened_at FROM habit_logs
-WHERE DATE(happened_at) = CURRENT_DATE
-ORDER BY happened_at DESC
-`
-
-// 获取今天的习惯日志
-func (q *Queries) GetTodayHabitLogs(ctx context.Context) ([]HabitLog, error) {
-	rows, err := q.db.Query(ctx, getTodayHabitLogs)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []HabitLog
-	for rows.Next() {
-		var i HabitLog
-		if err := rows.Scan(&i.ID, &i.HabitID, &i.HappenedAt); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
 const habitLogExists = `-- name: HabitLogExists :one
 SELECT EXISTS(
-    SELECT 1 FROM habit_logs WHERE id = $1
+    SELECT 1 FROM habit_logs WHERE id = $1 AND user_id = $2
 ) AS exists
 `
 
-func (q *Queries) HabitLogExists(ctx context.Context, id int64) (bool, error) {
-	row := q.db.QueryRow(ctx, habitLogExists, id)
+type HabitLogExistsParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) HabitLogExists(ctx context.Context, arg HabitLogExistsParams) (bool, error) {
+	row := q.db.QueryRow(ctx, habitLogExists, arg.ID, arg.UserID)
 	var exists bool
 	err := row.Scan(&exists)
 	return exists, err
@@ -393,18 +478,24 @@ func (q *Queries) HabitLogExists(ctx context.Context, id int64) (bool, error) {
 const updateHabitLogById = `-- name: UpdateHabitLogById :one
 UPDATE habit_logs
 SET happened_at = $2
-WHERE id = $1
-RETURNING id, habit_id, happened_at
+WHERE id = $1 AND user_id = $3
+RETURNING id, habit_id, happened_at, user_id
 `
 
 type UpdateHabitLogByIdParams struct {
 	ID         int64              `json:"id"`
 	HappenedAt pgtype.Timestamptz `json:"happened_at"`
+	UserID     int64              `json:"user_id"`
 }
 
 func (q *Queries) UpdateHabitLogById(ctx context.Context, arg UpdateHabitLogByIdParams) (HabitLog, error) {
-	row := q.db.QueryRow(ctx, updateHabitLogById, arg.ID, arg.HappenedAt)
+	row := q.db.QueryRow(ctx, updateHabitLogById, arg.ID, arg.HappenedAt, arg.UserID)
 	var i HabitLog
-	err := row.Scan(&i.ID, &i.HabitID, &i.HappenedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.HabitID,
+		&i.HappenedAt,
+		&i.UserID,
+	)
 	return i, err
 }
@@ -99,6 +99,48 @@ func (ns NullTaskStatus) Value() (driver.Value, error) {
 	return string(ns.TaskStatus), nil
 }
 
+// 通过邮件发送的一次性账户令牌，用于重置密码和验证邮箱
+type AccountToken struct {
+	// 主键，自增ID
+	ID int64 `json:"id"`
+	// 所属用户ID
+	UserID int64 `json:"user_id"`
+	// 用途 (password_reset, email_verification)
+	Purpose string `json:"purpose"`
+	// 令牌的 SHA-256 哈希，明文只出现在邮件链接中
+	TokenHash string `json:"token_hash"`
+	// 待验证的邮箱地址，仅邮箱验证使用，修改邮箱时为新地址
+	Email string `json:"email"`
+	// 过期时间
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	// 使用时间，为空表示未使用
+	UsedAt pgtype.Timestamptz `json:"used_at"`
+	// 创建时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// 个人访问令牌，供脚本和第三方集成调用 API，按 scope 限制可访问的资源
+type ApiToken struct {
+	// 主键，自增ID
+	ID int64 `json:"id"`
+	// 所属用户ID
+	UserID int64 `json:"user_id"`
+	// 令牌名称，便于用户区分用途
+	Name string `json:"name"`
+	// 令牌明文的前几位，用于在列表中识别令牌
+	TokenPrefix string `json:"token_prefix"`
+	// 令牌的 SHA-256 哈希，明文只在创建时返回一次
+	TokenHash string `json:"token_hash"`
+	// 授权范围，例如 read、write、habits:write
+	Scopes []string `json:"scopes"`
+	// 过期时间，为空表示永不过期
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	// 最近一次使用时间
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	// 创建时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
 // 存储所有上传文件的元数据，如图片、视频、音频等
 type Attachment struct {
 	// 附件的唯一标识符 (UUID)
@@ -106,21 +148,53 @@ type Attachment struct {
 	// 文件在 MinIO 中的唯一存储键 (路径/名称)
 	ObjectKey string `json:"object_key"`
 	// 文件的原始名称
-	OriginalName   string `json:"original_name"`
+	OriginalName string `json:"original_name"`
+	// 封面在对象存储中的键，服务端生成时为默认尺寸的封面，封面未就绪时为空
 	CoverObjectKey string `json:"cover_object_key"`
 	// 文件的媒体类型 (e.g., image/jpeg)
 	MimeType string `json:"mime_type"`
 	// 文件内容的 MD5 哈希值，用于验证文件完整性
-	Md5      string `json:"md5"`
+	Md5 string `json:"md5"`
+	// 封面内容的 MD5 哈希值，客户端上传封面时用于校验
 	CoverMd5 string `json:"cover_md5"`
 	// 文件大小（字节）
 	FileSize int64 `json:"file_size"`
-	// 文件上传状态 (e.g., uploading, completed, failed)
+	// 文件上传状态 (uploading, verifying, completed, failed)，verifying 表示分片已合并、等待后台校验 SHA-256，failed 表示校验不通过
 	Status string `json:"status"`
 	// 记录创建时间
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	// 记录最后更新时间
 	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	// 上传者用户ID
+	UserID int64 `json:"user_id"`
+	// 封面状态：pending 等待生成、processing 生成中、ready 可用、failed 生成失败、unsupported 不支持的文件类型
+	CoverStatus string `json:"cover_status"`
+	// 服务端生成的封面尺寸（长边像素），各尺寸的对象为 <object_key 去掉扩展名>.cover-<尺寸>.jpg，客户端上传的封面为空
+	CoverSizes []int32 `json:"cover_sizes"`
+	// 封面生成的尝试次数，超过上限后标记为 failed
+	CoverAttempts int32 `json:"cover_attempts"`
+	// 文件内容的 SHA-256 哈希值，分片上传完成时用于校验，单次上传的附件为空
+	Sha256 string `json:"sha256"`
+	// 对象存储的分片上传 ID，分片合并后清空，单次上传的附件为空
+	UploadID string `json:"upload_id"`
+	// 分片大小（字节），最后一个分片可以更小，单次上传的附件为 0
+	PartSize int64 `json:"part_size"`
+	// 后台任务领取校验的时间，为空表示等待领取，超时未完成（例如实例退出）时重新领取
+	VerifyStartedAt pgtype.Timestamptz `json:"verify_started_at"`
+}
+
+// 日历订阅令牌，每个用户最多一个，只能读取 .ics 订阅源，重新生成或删除后旧地址立即失效
+type CalendarToken struct {
+	// 所属用户ID
+	UserID int64 `json:"user_id"`
+	// 令牌明文的前几位，用于识别当前订阅地址
+	TokenPrefix string `json:"token_prefix"`
+	// 令牌的 SHA-256 哈希，明文只在生成时返回一次
+	TokenHash string `json:"token_hash"`
+	// 日历客户端最近一次拉取订阅源的时间
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	// 生成时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
 type Event struct {
@@ -136,6 +210,40 @@ type Event struct {
 	StartTime pgtype.Timestamptz `json:"start_time"`
 	// 事件的结束时间
 	EndTime pgtype.Timestamptz `json:"end_time"`
+	// 重复规则 (RFC 5545 RRULE)，为空表示不重复
+	Rrule string `json:"rrule"`
+	// 重复事件中被排除的实例开始时间 (EXDATE)
+	Exdates []pgtype.Timestamptz `json:"exdates"`
+	// iCalendar UID，导入的事件保留来源 UID，为空时导出使用默认 UID
+	Uid string `json:"uid"`
+	// 事件所在的 IANA 时区，重复规则按该时区展开
+	Timezone string `json:"timezone"`
+	// 记录创建时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	// 记录最后更新时间
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	// 所有者用户ID
+	UserID int64 `json:"user_id"`
+}
+
+// 重复事件的单次实例修改 (RECURRENCE-ID)
+type EventOverride struct {
+	// 主键，自增ID
+	ID int64 `json:"id"`
+	// 关联的重复事件ID
+	EventID int64 `json:"event_id"`
+	// 被修改实例的原始开始时间
+	RecurrenceID pgtype.Timestamptz `json:"recurrence_id"`
+	// 该实例的名称
+	Name string `json:"name"`
+	// 该实例的地点
+	Place string `json:"place"`
+	// 该实例的描述
+	Description string `json:"description"`
+	// 该实例的开始时间
+	StartTime pgtype.Timestamptz `json:"start_time"`
+	// 该实例的结束时间
+	EndTime pgtype.Timestamptz `json:"end_time"`
 	// 记录创建时间
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	// 记录最后更新时间
@@ -150,8 +258,12 @@ type EventReminder struct {
 	EventID int64 `json:"event_id"`
 	// 提醒前的时间间隔（单位：分）
 	RemindBefore int32 `json:"remind_before"`
+	// 提醒渠道，如 email、webhook、push、telegram
+	Channel string `json:"channel"`
 	// 是否已通知
 	Notified bool `json:"notified"`
+	// 重复事件中最近一次已提醒实例的原始开始时间
+	LastNotifiedOccurrence pgtype.Timestamptz `json:"last_notified_occurrence"`
 	// 创建时间
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
@@ -168,6 +280,8 @@ type Habit struct {
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	// 记录最后更新时间
 	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	// 所有者用户ID
+	UserID int64 `json:"user_id"`
 }
 
 // 习惯日志表
@@ -178,6 +292,54 @@ type HabitLog struct {
 	HabitID int64 `json:"habit_id"`
 	// 发生时间
 	HappenedAt pgtype.Timestamptz `json:"happened_at"`
+	// 所有者用户ID
+	UserID int64 `json:"user_id"`
+}
+
+// 注册邀请码，邀请制注册模式下新用户需要持有未使用且未过期的邀请码
+type Invite struct {
+	// 主键，自增ID
+	ID int64 `json:"id"`
+	// 邀请码的 SHA-256 哈希，明文只在创建时返回一次
+	CodeHash string `json:"code_hash"`
+	// 限定使用该邀请码的邮箱，为空表示不限
+	Email string `json:"email"`
+	// 创建邀请的用户ID
+	CreatedBy int64 `json:"created_by"`
+	// 使用邀请注册的用户ID
+	UsedBy pgtype.Int8 `json:"used_by"`
+	// 过期时间
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	// 使用时间
+	UsedAt pgtype.Timestamptz `json:"used_at"`
+	// 创建时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// 登录失败记录，按邮箱统计连续失败次数，超过阈值后逐步延长锁定时间，登录成功后删除
+type LoginFailure struct {
+	// 登录邮箱（小写），不要求对应的用户存在
+	Email string `json:"email"`
+	// 连续失败次数，距上次失败超过统计窗口后重新计数
+	FailedCount int32 `json:"failed_count"`
+	// 锁定截止时间，为空或已过期表示未锁定
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	// 最近一次失败时间
+	LastFailedAt pgtype.Timestamptz `json:"last_failed_at"`
+}
+
+// 那年今日晨间邮件的订阅设置，没有记录表示未订阅
+type MemoryDigestSetting struct {
+	// 所属用户ID
+	UserID int64 `json:"user_id"`
+	// 是否发送那年今日邮件
+	Enabled bool `json:"enabled"`
+	// 发送时间（用户时区的小时，0-23）
+	SendHour int16 `json:"send_hour"`
+	// 最近一次发送对应的用户本地日期，用于保证每天最多发送一次
+	LastSentOn pgtype.Date `json:"last_sent_on"`
+	// 设置最后修改时间
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
 }
 
 // 用于存储即使信息，包括文本内容和附件
@@ -190,6 +352,10 @@ type Moment struct {
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	// 记录最后更新时间
 	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	// 所有者用户ID
+	UserID int64 `json:"user_id"`
+	// 由 content 生成的全文搜索向量
+	SearchVector string `json:"search_vector"`
 }
 
 // 连接 moments 和 attachments 的多对多联结表
@@ -204,6 +370,134 @@ type MomentAttachment struct {
 	Position int16 `json:"position"`
 }
 
+// 连接 moments 和 tags 的多对多联结表，moment 创建、编辑时按内容重新生成
+type MomentTag struct {
+	// 关联的 Moment ID
+	MomentID int64 `json:"moment_id"`
+	// 关联的标签ID
+	TagID int64 `json:"tag_id"`
+}
+
+// moment 的历史版本，每次编辑或恢复前保存修改前的内容和附件
+type MomentRevision struct {
+	// 主键，自增ID
+	ID int64 `json:"id"`
+	// 所属 moment ID
+	MomentID int64 `json:"moment_id"`
+	// 修改前的文本内容
+	Content string `json:"content"`
+	// 修改前的附件列表，格式为 [{"attachment_id": "...", "position": 0}]
+	Attachments []byte `json:"attachments"`
+	// 保存时间，即这次修改的时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// 用户自己配置的提醒渠道，提醒只会投递到事件所有者配置的地址，邮件渠道不需要配置
+type NotificationChannel struct {
+	// 所属用户ID
+	UserID int64 `json:"user_id"`
+	// 渠道名称 (webhook, push, telegram)
+	Channel string `json:"channel"`
+	// 推送服务 (ntfy, gotify)，仅 push 渠道使用
+	Provider string `json:"provider"`
+	// 投递目标，webhook 和 push 为请求地址，telegram 为 chat id
+	Target string `json:"target"`
+	// webhook 签名密钥、push 访问令牌或 telegram bot token，不会通过接口返回
+	Secret string `json:"secret"`
+	// 创建时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	// 最后修改时间
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+// 提醒投递发件箱，每条记录对应一次提醒（重复事件为每次实例）
+type ReminderDelivery struct {
+	// 主键，自增ID
+	ID int64 `json:"id"`
+	// 关联的提醒ID
+	ReminderID int64 `json:"reminder_id"`
+	// 关联的事件ID
+	EventID int64 `json:"event_id"`
+	// 被提醒实例的原始开始时间
+	Occurrence pgtype.Timestamptz `json:"occurrence"`
+	// 投递渠道
+	Channel string `json:"channel"`
+	// 通知标题
+	Title string `json:"title"`
+	// 通知正文
+	Body string `json:"body"`
+	// 通知的 HTML 正文，仅邮件渠道使用，为空时只发送纯文本
+	Html string `json:"html"`
+	// 投递状态 (pending, sending, sent, failed)，sending 表示已被领取正在投递，failed 表示超过最大重试次数
+	Status string `json:"status"`
+	// 已尝试次数
+	Attempts int32 `json:"attempts"`
+	// 下次尝试时间
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	// 最近一次失败的错误信息
+	LastError string `json:"last_error"`
+	// 投递成功时间
+	SentAt pgtype.Timestamptz `json:"sent_at"`
+	// 记录创建时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	// 记录最后更新时间
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	// 收件人地址，仅邮件渠道使用，为空时使用默认收件人
+	Recipient string `json:"recipient"`
+}
+
+// 提醒投递的每次尝试记录
+type ReminderDeliveryAttempt struct {
+	// 主键，自增ID
+	ID int64 `json:"id"`
+	// 关联的投递记录ID
+	DeliveryID int64 `json:"delivery_id"`
+	// 第几次尝试，手动重试后重新计数
+	Attempt int32 `json:"attempt"`
+	// 是否投递成功
+	Success bool `json:"success"`
+	// 失败时的错误信息
+	Error string `json:"error"`
+	// 尝试时间
+	AttemptedAt pgtype.Timestamptz `json:"attempted_at"`
+}
+
+// 登录会话，每次登录创建一条，access token 的 jti 为会话ID，撤销后该会话的 token 全部失效
+type Session struct {
+	// 会话ID (UUID)，同时作为 access token 的 jti
+	ID pgtype.UUID `json:"id"`
+	// 所属用户ID
+	UserID int64 `json:"user_id"`
+	// 当前 refresh token 的 SHA-256 哈希，每次刷新都会轮换
+	RefreshTokenHash string `json:"refresh_token_hash"`
+	// 上一个 refresh token 的哈希，再次出现时视为泄露并撤销会话
+	PreviousRefreshTokenHash string `json:"previous_refresh_token_hash"`
+	// 登录时的 User-Agent
+	UserAgent string `json:"user_agent"`
+	// 最近一次登录或刷新的客户端 IP
+	IpAddress string `json:"ip_address"`
+	// refresh token 过期时间，每次刷新顺延
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	// 撤销时间，为空表示会话有效
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+	// 最近一次刷新时间
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	// 创建时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// 从 moment 内容中解析出的话题标签（#reading），每个用户独立
+type Tag struct {
+	// 主键，自增ID
+	ID int64 `json:"id"`
+	// 所属用户ID
+	UserID int64 `json:"user_id"`
+	// 标签名，小写且不含 #
+	Name string `json:"name"`
+	// 首次使用时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
 // 任务表，存储具体的任务信息
 type Task struct {
 	// 主键，自增ID
@@ -220,6 +514,8 @@ type Task struct {
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	// 更新时间
 	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	// 所有者用户ID
+	UserID int64 `json:"user_id"`
 }
 
 // 任务分组表，仅可保存年任务组(2025)，月任务组(2025-07)，周任务组(2025-W28)，日任务组(2025-07-14)
@@ -236,6 +532,24 @@ type TaskGroup struct {
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	// 更新时间
 	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	// 所有者用户ID
+	UserID int64 `json:"user_id"`
+}
+
+// 两步验证登录的 challenge，密码验证通过后创建一条，验证码通过后标记为已使用，不能重复使用
+type TwoFactorChallenge struct {
+	// challenge ID (UUID)，同时作为 challenge token 的 jti
+	ID pgtype.UUID `json:"id"`
+	// 所属用户ID
+	UserID int64 `json:"user_id"`
+	// 已提交验证码的次数，达到上限后 challenge 作废，需要重新输入密码登录
+	Attempts int32 `json:"attempts"`
+	// 验证通过的时间，为空表示尚未使用
+	UsedAt pgtype.Timestamptz `json:"used_at"`
+	// 过期时间，与 challenge token 的有效期一致
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	// 创建时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
 // 用户表，存储用户基本信息
@@ -250,12 +564,48 @@ type User struct {
 	PasswordHash string `json:"password_hash"`
 	// 用户生日
 	Birthday pgtype.Date `json:"birthday"`
-	// 头像Base64编码数据
+	// 已废弃：旧版内联头像的 Base64 数据，启动时迁移到对象存储后清空
 	AvatarBase64 string `json:"avatar_base64"`
 	// 用户简介
 	Bio string `json:"bio"`
+	// 用户的 IANA 时区，用于日期边界和邮件中的时间显示
+	Timezone string `json:"timezone"`
 	// 创建时间
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	// 更新时间
 	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	// 邮箱验证时间，为空表示尚未验证
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+	// 头像在对象存储中的前缀，各尺寸的对象为 <avatar_key>/<尺寸>.jpg，为空表示未设置头像
+	AvatarKey string `json:"avatar_key"`
+}
+
+// 两步验证的一次性恢复码，无法使用验证器时代替 TOTP 验证码登录
+type UserRecoveryCode struct {
+	// 主键，自增ID
+	ID int64 `json:"id"`
+	// 用户ID
+	UserID int64 `json:"user_id"`
+	// 恢复码的 SHA-256 哈希，明文只在生成时返回一次
+	CodeHash string `json:"code_hash"`
+	// 使用时间，为空表示未使用
+	UsedAt pgtype.Timestamptz `json:"used_at"`
+	// 创建时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// 用户的 TOTP 两步验证配置，enabled 为 FALSE 表示正在绑定、尚未验证
+type UserTotp struct {
+	// 用户ID
+	UserID int64 `json:"user_id"`
+	// Base32 编码的 TOTP 密钥
+	Secret string `json:"secret"`
+	// 是否已通过验证并启用
+	Enabled bool `json:"enabled"`
+	// 最近一次成功验证的时间步，同一时间步的验证码不能重复使用
+	LastUsedStep int64 `json:"last_used_step"`
+	// 启用时间
+	EnabledAt pgtype.Timestamptz `json:"enabled_at"`
+	// 创建时间
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
@@ -11,86 +11,133 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const addAttachmentToMoment = `-- name: AddAttachmentToMoment :exec
+const addAttachmentToMoment = `-- name: AddAttachmentToMoment :execrows
 INSERT INTO moment_attachments (moment_id, attachment_id, position)
-VALUES ($1, $2, $3)
+SELECT $1, a.id, $3
+FROM attachments a
+WHERE a.id = $2 AND a.user_id = $4 AND a.status = 'completed'
 `
 
 type AddAttachmentToMomentParams struct {
 	MomentID     int64       `json:"moment_id"`
 	AttachmentID pgtype.UUID `json:"attachment_id"`
 	Position     int16       `json:"position"`
+	UserID       int64       `json:"user_id"`
+}
+
+// 只能添加属于同一用户且已上传完成的附件，返回 0 表示附件不存在、不属于该用户或尚未完成
+// 未完成、校验中或校验失败的上传被引用后既不会显示，也无法取消或回收
+func (q *Queries) AddAttachmentToMoment(ctx context.Context, arg AddAttachmentToMomentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, addAttachmentToMoment,
+		arg.MomentID,
+		arg.AttachmentID,
+		arg.Position,
+		arg.UserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-func (q *Queries) AddAttachmentToMoment(ctx context.Context, arg AddAttachmentToMomentParams) error {
-	_, err := q.db.Exec(ctx, addAttachmentToMoment, arg.MomentID, arg.AttachmentID, arg.Position)
+const clearMomentAttachments = `-- name: ClearMomentAttachments :exec
+DELETE FROM moment_attachments
+WHERE moment_id = $1
+`
+
+func (q *Queries) ClearMomentAttachments(ctx context.Context, momentID int64) error {
+	_, err := q.db.Exec(ctx, clearMomentAttachments, momentID)
 	return err
 }
 
 const createMoment = `-- name: CreateMoment :one
 INSERT INTO moments
-(content)
-VALUES ($1)
-RETURNING id, content, created_at, updated_at
+(content, user_id)
+VALUES ($1, $2)
+RETURNING id, content, created_at, updated_at, user_id, search_vector
 `
 
-func (q *Queries) CreateMoment(ctx context.Context, content string) (Moment, error) {
-	row := q.db.QueryRow(ctx, createMoment, content)
+type CreateMomentParams struct {
+	Content string `json:"content"`
+	UserID  int64  `json:"user_id"`
+}
+
+func (q *Queries) CreateMoment(ctx context.Context, arg CreateMomentParams) (Moment, error) {
+	row := q.db.QueryRow(ctx, createMoment, arg.Content, arg.UserID)
 	var i Moment
 	err := row.Scan(
 		&i.ID,
 		&i.Content,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
+		&i.SearchVector,
 	)
 	return i, err
 }
 
 const deleteMomentByID = `-- name: DeleteMomentByID :exec
 DELETE FROM moments
-WHERE id = $1
+WHERE id = $1 AND user_id = $2
 `
 
-func (q *Queries) DeleteMomentByID(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, deleteMomentByID, id)
+type DeleteMomentByIDParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteMomentByID(ctx context.Context, arg DeleteMomentByIDParams) error {
+	_, err := q.db.Exec(ctx, deleteMomentByID, arg.ID, arg.UserID)
 	return err
 }
 
-const getMomentAttachmentsByID = `-- name: GetMomentAttachmentsByID :many
-SELECT 
-    a.id, a.object_key, a.original_name, a.cover_object_key, a.mime_type, a.md5, a.cover_md5, a.file_size, a.status, a.created_at, a.updated_at,
+const getAttachmentsByMomentIDs = `-- name: GetAttachmentsByMomentIDs :many
+SELECT
+    ma.moment_id,
+    a.id, a.object_key, a.original_name, a.cover_object_key, a.mime_type, a.md5, a.cover_md5, a.file_size, a.status, a.created_at, a.updated_at, a.user_id, a.cover_status, a.cover_sizes, a.cover_attempts, a.sha256, a.upload_id, a.part_size, a.verify_started_at,
     ma.position
 FROM attachments a
 INNER JOIN moment_attachments ma ON a.id = ma.attachment_id
-WHERE ma.moment_id = $1 AND a.status = 'completed'
-ORDER BY ma.position
+WHERE ma.moment_id = ANY($1::bigint[]) AND a.status = 'completed'
+ORDER BY ma.moment_id, ma.position
 `
 
-type GetMomentAttachmentsByIDRow struct {
-	ID             pgtype.UUID        `json:"id"`
-	ObjectKey      string             `json:"object_key"`
-	OriginalName   string             `json:"original_name"`
-	CoverObjectKey string             `json:"cover_object_key"`
-	MimeType       string             `json:"mime_type"`
-	Md5            string             `json:"md5"`
-	CoverMd5       string             `json:"cover_md5"`
-	FileSize       int64              `json:"file_size"`
-	Status         string             `json:"status"`
-	CreatedAt      pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
-	Position       int16              `json:"position"`
-}
-
-func (q *Queries) GetMomentAttachmentsByID(ctx context.Context, momentID int64) ([]GetMomentAttachmentsByIDRow, error) {
-	rows, err := q.db.Query(ctx, getMomentAttachmentsByID, momentID)
+type GetAttachmentsByMomentIDsRow struct {
+	MomentID        int64              `json:"moment_id"`
+	ID              pgtype.UUID        `json:"id"`
+	ObjectKey       string             `json:"object_key"`
+	OriginalName    string             `json:"original_name"`
+	CoverObjectKey  string             `json:"cover_object_key"`
+	MimeType        string             `json:"mime_type"`
+	Md5             string             `json:"md5"`
+	CoverMd5        string             `json:"cover_md5"`
+	FileSize        int64              `json:"file_size"`
+	Status          string             `json:"status"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	UserID          int64              `json:"user_id"`
+	CoverStatus     string             `json:"cover_status"`
+	CoverSizes      []int32            `json:"cover_sizes"`
+	CoverAttempts   int32              `json:"cover_attempts"`
+	Sha256          string             `json:"sha256"`
+	UploadID        string             `json:"upload_id"`
+	PartSize        int64              `json:"part_size"`
+	VerifyStartedAt pgtype.Timestamptz `json:"verify_started_at"`
+	Position        int16              `json:"position"`
+}
+
+// 一次取出多条 moment 的附件，按 moment 和位置排序
+func (q *Queries) GetAttachmentsByMomentIDs(ctx context.Context, momentIds []int64) ([]GetAttachmentsByMomentIDsRow, error) {
+	rows, err := q.db.Query(ctx, getAttachmentsByMomentIDs, momentIds)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetMomentAttachmentsByIDRow
+	var items []GetAttachmentsByMomentIDsRow
 	for rows.Next() {
-		var i GetMomentAttachmentsByIDRow
+		var i GetAttachmentsByMomentIDsRow
 		if err := rows.Scan(
+			&i.MomentID,
 			&i.ID,
 			&i.ObjectKey,
 			&i.OriginalName,
@@ -102,6 +149,14 @@ func (q *Queries) GetMomentAttachmentsByID(ctx context.Context, momentID int64)
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.UserID,
+			&i.CoverStatus,
+			&i.CoverSizes,
+			&i.CoverAttempts,
+			&i.Sha256,
+			&i.UploadID,
+			&i.PartSize,
+			&i.VerifyStartedAt,
 			&i.Position,
 		); err != nil {
 			return nil, err
@@ -115,25 +170,59 @@ func (q *Queries) GetMomentAttachmentsByID(ctx context.Context, momentID int64)
 }
 
 const getMomentByID = `-- name: GetMomentByID :one
-SELECT id, content, created_at, updated_at FROM moments
-WHERE id = $1 LIMIT 1
+SELECT id, content, created_at, updated_at, user_id, search_vector FROM moments
+WHERE id = $1 AND user_id = $2 LIMIT 1
 `
 
-func (q *Queries) GetMomentByID(ctx context.Context, id int64) (Moment, error) {
-	row := q.db.QueryRow(ctx, getMomentByID, id)
+type GetMomentByIDParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) GetMomentByID(ctx context.Context, arg GetMomentByIDParams) (Moment, error) {
+	row := q.db.QueryRow(ctx, getMomentByID, arg.ID, arg.UserID)
 	var i Moment
 	err := row.Scan(
 		&i.ID,
 		&i.Content,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
+		&i.SearchVector,
+	)
+	return i, err
+}
+
+const getMomentForUpdate = `-- name: GetMomentForUpdate :one
+SELECT id, content, created_at, updated_at, user_id, search_vector FROM moments
+WHERE id = $1 AND user_id = $2
+FOR UPDATE
+`
+
+type GetMomentForUpdateParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+// 锁定 moment 行，编辑和恢复历史版本时防止并发修改
+func (q *Queries) GetMomentForUpdate(ctx context.Context, arg GetMomentForUpdateParams) (Moment, error) {
+	row := q.db.QueryRow(ctx, getMomentForUpdate, arg.ID, arg.UserID)
+	var i Moment
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.SearchVector,
 	)
 	return i, err
 }
 
 const getMomentsPaginated = `-- name: GetMomentsPaginated :many
-SELECT id, content, created_at, updated_at FROM moments
-WHERE ($1::timestamp IS NULL OR created_at < $1::timestamp)
+SELECT id, content, created_at, updated_at, user_id, search_vector FROM moments
+WHERE user_id = $3
+    AND ($1::timestamp IS NULL OR created_at < $1::timestamp)
 ORDER BY created_at DESC
 LIMIT $2
 `
@@ -141,10 +230,11 @@ LIMIT $2
 type GetMomentsPaginatedParams struct {
 	Column1 pgtype.Timestamp `json:"column_1"`
 	Limit   int32            `json:"limit"`
+	UserID  int64            `json:"user_id"`
 }
 
 func (q *Queries) GetMomentsPaginated(ctx context.Context, arg GetMomentsPaginatedParams) ([]Moment, error) {
-	rows, err := q.db.Query(ctx, getMomentsPaginated, arg.Column1, arg.Limit)
+	rows, err := q.db.Query(ctx, getMomentsPaginated, arg.Column1, arg.Limit, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -157,6 +247,93 @@ func (q *Queries) GetMomentsPaginated(ctx context.Context, arg GetMomentsPaginat
 			&i.Content,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.UserID,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMomentAttachmentRefs = `-- name: ListMomentAttachmentRefs :many
+SELECT attachment_id, position FROM moment_attachments
+WHERE moment_id = $1
+ORDER BY position
+`
+
+type ListMomentAttachmentRefsRow struct {
+	AttachmentID pgtype.UUID `json:"attachment_id"`
+	Position     int16       `json:"position"`
+}
+
+// 包括未完成上传的附件，用于保存历史版本
+func (q *Queries) ListMomentAttachmentRefs(ctx context.Context, momentID int64) ([]ListMomentAttachmentRefsRow, error) {
+	rows, err := q.db.Query(ctx, listMomentAttachmentRefs, momentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMomentAttachmentRefsRow
+	for rows.Next() {
+		var i ListMomentAttachmentRefsRow
+		if err := rows.Scan(&i.AttachmentID, &i.Position); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMomentsOnThisDay = `-- name: ListMomentsOnThisDay :many
+SELECT id, content, created_at, updated_at, user_id, search_vector FROM moments
+WHERE user_id = $1
+    AND EXTRACT(MONTH FROM created_at AT TIME ZONE $2::text) = $3::int
+    AND EXTRACT(DAY FROM created_at AT TIME ZONE $2::text) BETWEEN $4::int AND $5::int
+    AND EXTRACT(YEAR FROM created_at AT TIME ZONE $2::text) < $6::int
+ORDER BY created_at DESC
+`
+
+type ListMomentsOnThisDayParams struct {
+	UserID   int64  `json:"user_id"`
+	Timezone string `json:"timezone"`
+	Month    int32  `json:"month"`
+	Day      int32  `json:"day"`
+	DayEnd   int32  `json:"day_end"`
+	Year     int32  `json:"year"`
+}
+
+// 那年今日：按用户时区取往年同月同日的 moment，day_end 用于在非闰年的 2 月 28 日一并返回 2 月 29 日
+func (q *Queries) ListMomentsOnThisDay(ctx context.Context, arg ListMomentsOnThisDayParams) ([]Moment, error) {
+	rows, err := q.db.Query(ctx, listMomentsOnThisDay,
+		arg.UserID,
+		arg.Timezone,
+		arg.Month,
+		arg.Day,
+		arg.DayEnd,
+		arg.Year,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Moment
+	for rows.Next() {
+		var i Moment
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.SearchVector,
 		); err != nil {
 			return nil, err
 		}
@@ -170,12 +347,17 @@ func (q *Queries) GetMomentsPaginated(ctx context.Context, arg GetMomentsPaginat
 
 const momentExists = `-- name: MomentExists :one
 SELECT EXISTS(
-    SELECT 1 FROM moments WHERE id = $1
+    SELECT 1 FROM moments WHERE id = $1 AND user_id = $2
 ) AS exists
 `
 
-func (q *Queries) MomentExists(ctx context.Context, id int64) (bool, error) {
-	row := q.db.QueryRow(ctx, momentExists, id)
+type MomentExistsParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) MomentExists(ctx context.Context, arg MomentExistsParams) (bool, error) {
+	row := q.db.QueryRow(ctx, momentExists, arg.ID, arg.UserID)
 	var exists bool
 	err := row.Scan(&exists)
 	return exists, err
@@ -195,3 +377,163 @@ func (q *Queries) RemoveAttachmentFromMoment(ctx context.Context, arg RemoveAtta
 	_, err := q.db.Exec(ctx, removeAttachmentFromMoment, arg.MomentID, arg.AttachmentID)
 	return err
 }
+
+const searchMoments = `-- name: SearchMoments :many
+SELECT
+    m.id, m.content, m.created_at, m.updated_at, m.user_id, m.search_vector,
+    ts_rank_cd(m.search_vector, q.query)::float8 AS rank,
+    ts_headline('simple', m.content, q.query, 'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=2, MaxWords=20, MinWords=5') AS snippet
+FROM moments m, to_tsquery('simple', $1) AS q(query)
+WHERE m.user_id = $2
+    AND m.search_vector @@ q.query
+ORDER BY rank DESC, m.created_at DESC, m.id DESC
+LIMIT $3 OFFSET $4
+`
+
+type SearchMomentsParams struct {
+	Query     string `json:"query"`
+	UserID    int64  `json:"user_id"`
+	RowLimit  int32  `json:"row_limit"`
+	RowOffset int32  `json:"row_offset"`
+}
+
+type SearchMomentsRow struct {
+	ID           int64              `json:"id"`
+	Content      string             `json:"content"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	UserID       int64              `json:"user_id"`
+	SearchVector string             `json:"search_vector"`
+	Rank         float64            `json:"rank"`
+	Snippet      string             `json:"snippet"`
+}
+
+// 按相关度排序的全文搜索，query 为 to_tsquery 语法，offset 用于翻页
+// snippet 中命中的词由 chr(2)、chr(3) 包裹，由服务层转义 HTML 后再替换为 <mark>
+func (q *Queries) SearchMoments(ctx context.Context, arg SearchMomentsParams) ([]SearchMomentsRow, error) {
+	rows, err := q.db.Query(ctx, searchMoments,
+		arg.Query,
+		arg.UserID,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchMomentsRow
+	for rows.Next() {
+		var i SearchMomentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.SearchVector,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchMomentsByCreatedAt = `-- name: SearchMomentsByCreatedAt :many
+SELECT
+    m.id, m.content, m.created_at, m.updated_at, m.user_id, m.search_vector,
+    ts_rank_cd(m.search_vector, q.query)::float8 AS rank,
+    ts_headline('simple', m.content, q.query, 'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=2, MaxWords=20, MinWords=5') AS snippet
+FROM moments m, to_tsquery('simple', $1) AS q(query)
+WHERE m.user_id = $2
+    AND m.search_vector @@ q.query
+    AND ($3::timestamp IS NULL OR m.created_at < $3::timestamp)
+ORDER BY m.created_at DESC
+LIMIT $4
+`
+
+type SearchMomentsByCreatedAtParams struct {
+	Query    string           `json:"query"`
+	UserID   int64            `json:"user_id"`
+	Cursor   pgtype.Timestamp `json:"cursor"`
+	RowLimit int32            `json:"row_limit"`
+}
+
+type SearchMomentsByCreatedAtRow struct {
+	ID           int64              `json:"id"`
+	Content      string             `json:"content"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	UserID       int64              `json:"user_id"`
+	SearchVector string             `json:"search_vector"`
+	Rank         float64            `json:"rank"`
+	Snippet      string             `json:"snippet"`
+}
+
+// 按创建时间倒序的全文搜索，游标与 GetMomentsPaginated 相同
+// snippet 中命中的词由 chr(2)、chr(3) 包裹，由服务层转义 HTML 后再替换为 <mark>
+func (q *Queries) SearchMomentsByCreatedAt(ctx context.Context, arg SearchMomentsByCreatedAtParams) ([]SearchMomentsByCreatedAtRow, error) {
+	rows, err := q.db.Query(ctx, searchMomentsByCreatedAt,
+		arg.Query,
+		arg.UserID,
+		arg.Cursor,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchMomentsByCreatedAtRow
+	for rows.Next() {
+		var i SearchMomentsByCreatedAtRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.SearchVector,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateMomentContent = `-- name: UpdateMomentContent :one
+UPDATE moments
+SET content = $3
+WHERE id = $1 AND user_id = $2
+RETURNING id, content, created_at, updated_at, user_id, search_vector
+`
+
+type UpdateMomentContentParams struct {
+	ID      int64  `json:"id"`
+	UserID  int64  `json:"user_id"`
+	Content string `json:"content"`
+}
+
+func (q *Queries) UpdateMomentContent(ctx context.Context, arg UpdateMomentContentParams) (Moment, error) {
+	row := q.db.QueryRow(ctx, updateMomentContent, arg.ID, arg.UserID, arg.Content)
+	var i Moment
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.SearchVector,
+	)
+	return i, err
+}
@@ -11,6 +11,145 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimPendingCovers = `-- name: ClaimPendingCovers :many
+UPDATE attachments
+SET cover_status = 'processing',
+    cover_attempts = cover_attempts + 1
+WHERE id IN (
+    SELECT id FROM attachments
+    WHERE status = 'completed'
+        AND (cover_status = 'pending' OR (cover_status = 'processing' AND updated_at < $1))
+    ORDER BY created_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, object_key, original_name, cover_object_key, mime_type, md5, cover_md5, file_size, status, created_at, updated_at, user_id, cover_status, cover_sizes, cover_attempts, sha256, upload_id, part_size, verify_started_at
+`
+
+type ClaimPendingCoversParams struct {
+	StaleBefore pgtype.Timestamptz `json:"stale_before"`
+	BatchSize   int32              `json:"batch_size"`
+}
+
+// 领取一批待生成封面的附件，处理中但超过 stale_before 仍未完成的（实例退出）重新领取
+func (q *Queries) ClaimPendingCovers(ctx context.Context, arg ClaimPendingCoversParams) ([]Attachment, error) {
+	rows, err := q.db.Query(ctx, claimPendingCovers, arg.StaleBefore, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attachment
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.ObjectKey,
+			&i.OriginalName,
+			&i.CoverObjectKey,
+			&i.MimeType,
+			&i.Md5,
+			&i.CoverMd5,
+			&i.FileSize,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.CoverStatus,
+			&i.CoverSizes,
+			&i.CoverAttempts,
+			&i.Sha256,
+			&i.UploadID,
+			&i.PartSize,
+			&i.VerifyStartedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimVerifyingAttachments = `-- name: ClaimVerifyingAttachments :many
+UPDATE attachments
+SET verify_started_at = NOW()
+WHERE id IN (
+    SELECT id FROM attachments
+    WHERE status = 'verifying'
+        AND (verify_started_at IS NULL OR verify_started_at < $1)
+    ORDER BY created_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, object_key, original_name, cover_object_key, mime_type, md5, cover_md5, file_size, status, created_at, updated_at, user_id, cover_status, cover_sizes, cover_attempts, sha256, upload_id, part_size, verify_started_at
+`
+
+type ClaimVerifyingAttachmentsParams struct {
+	StaleBefore pgtype.Timestamptz `json:"stale_before"`
+	BatchSize   int32              `json:"batch_size"`
+}
+
+// 领取一批等待校验的附件，校验中但超过 stale_before 仍未完成的（实例退出）重新领取
+func (q *Queries) ClaimVerifyingAttachments(ctx context.Context, arg ClaimVerifyingAttachmentsParams) ([]Attachment, error) {
+	rows, err := q.db.Query(ctx, claimVerifyingAttachments, arg.StaleBefore, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attachment
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.ObjectKey,
+			&i.OriginalName,
+			&i.CoverObjectKey,
+			&i.MimeType,
+			&i.Md5,
+			&i.CoverMd5,
+			&i.FileSize,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.CoverStatus,
+			&i.CoverSizes,
+			&i.CoverAttempts,
+			&i.Sha256,
+			&i.UploadID,
+			&i.PartSize,
+			&i.VerifyStartedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeMultipartAttachment = `-- name: CompleteMultipartAttachment :exec
+UPDATE attachments
+SET status = 'completed',
+    md5 = $1
+WHERE id = $2 AND status = 'verifying'
+`
+
+type CompleteMultipartAttachmentParams struct {
+	Md5 string      `json:"md5"`
+	ID  pgtype.UUID `json:"id"`
+}
+
+// 校验通过后写入服务端计算的 MD5，使分片上传的附件同样可以按 MD5 去重
+func (q *Queries) CompleteMultipartAttachment(ctx context.Context, arg CompleteMultipartAttachmentParams) error {
+	_, err := q.db.Exec(ctx, completeMultipartAttachment, arg.Md5, arg.ID)
+	return err
+}
+
 const createAttachment = `-- name: CreateAttachment :one
 INSERT INTO attachments (
     object_key,
@@ -20,10 +159,12 @@ INSERT INTO attachments (
     md5,
     cover_md5,
     file_size,
-    status
+    status,
+    user_id,
+    cover_status
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, 'uploading'
-) RETURNING id, object_key, original_name, cover_object_key, mime_type, md5, cover_md5, file_size, status, created_at, updated_at
+    $1, $2, $3, $4, $5, $6, $7, 'uploading', $8, $9
+) RETURNING id, object_key, original_name, cover_object_key, mime_type, md5, cover_md5, file_size, status, created_at, updated_at, user_id, cover_status, cover_sizes, cover_attempts, sha256, upload_id, part_size, verify_started_at
 `
 
 type CreateAttachmentParams struct {
@@ -34,6 +175,8 @@ type CreateAttachmentParams struct {
 	Md5            string `json:"md5"`
 	CoverMd5       string `json:"cover_md5"`
 	FileSize       int64  `json:"file_size"`
+	UserID         int64  `json:"user_id"`
+	CoverStatus    string `json:"cover_status"`
 }
 
 func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error) {
@@ -45,7 +188,164 @@ func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentPara
 		arg.Md5,
 		arg.CoverMd5,
 		arg.FileSize,
+		arg.UserID,
+		arg.CoverStatus,
+	)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.ObjectKey,
+		&i.OriginalName,
+		&i.CoverObjectKey,
+		&i.MimeType,
+		&i.Md5,
+		&i.CoverMd5,
+		&i.FileSize,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.CoverStatus,
+		&i.CoverSizes,
+		&i.CoverAttempts,
+		&i.Sha256,
+		&i.UploadID,
+		&i.PartSize,
+		&i.VerifyStartedAt,
+	)
+	return i, err
+}
+
+const createMultipartAttachment = `-- name: CreateMultipartAttachment :one
+INSERT INTO attachments (
+    object_key,
+    cover_object_key,
+    original_name,
+    mime_type,
+    md5,
+    cover_md5,
+    file_size,
+    status,
+    user_id,
+    cover_status,
+    sha256,
+    upload_id,
+    part_size
+) VALUES (
+    $1, '', $2, $3, '', '', $4, 'uploading', $5, $6, $7, $8, $9
+) RETURNING id, object_key, original_name, cover_object_key, mime_type, md5, cover_md5, file_size, status, created_at, updated_at, user_id, cover_status, cover_sizes, cover_attempts, sha256, upload_id, part_size, verify_started_at
+`
+
+type CreateMultipartAttachmentParams struct {
+	ObjectKey    string `json:"object_key"`
+	OriginalName string `json:"original_name"`
+	MimeType     string `json:"mime_type"`
+	FileSize     int64  `json:"file_size"`
+	UserID       int64  `json:"user_id"`
+	CoverStatus  string `json:"cover_status"`
+	Sha256       string `json:"sha256"`
+	UploadID     string `json:"upload_id"`
+	PartSize     int64  `json:"part_size"`
+}
+
+func (q *Queries) CreateMultipartAttachment(ctx context.Context, arg CreateMultipartAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRow(ctx, createMultipartAttachment,
+		arg.ObjectKey,
+		arg.OriginalName,
+		arg.MimeType,
+		arg.FileSize,
+		arg.UserID,
+		arg.CoverStatus,
+		arg.Sha256,
+		arg.UploadID,
+		arg.PartSize,
+	)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.ObjectKey,
+		&i.OriginalName,
+		&i.CoverObjectKey,
+		&i.MimeType,
+		&i.Md5,
+		&i.CoverMd5,
+		&i.FileSize,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.CoverStatus,
+		&i.CoverSizes,
+		&i.CoverAttempts,
+		&i.Sha256,
+		&i.UploadID,
+		&i.PartSize,
+		&i.VerifyStartedAt,
 	)
+	return i, err
+}
+
+const deleteUnreferencedAttachment = `-- name: DeleteUnreferencedAttachment :one
+DELETE FROM attachments a
+WHERE a.id = $1
+    AND a.updated_at < $2
+    AND a.cover_status <> 'processing'
+    AND NOT EXISTS (SELECT 1 FROM moment_attachments ma WHERE ma.attachment_id = a.id)
+    AND NOT EXISTS (
+        SELECT 1 FROM moment_revisions mr
+        WHERE mr.attachments @> jsonb_build_array(jsonb_build_object('attachment_id', a.id::text))
+    )
+RETURNING a.id, a.object_key, a.original_name, a.cover_object_key, a.mime_type, a.md5, a.cover_md5, a.file_size, a.status, a.created_at, a.updated_at, a.user_id, a.cover_status, a.cover_sizes, a.cover_attempts, a.sha256, a.upload_id, a.part_size, a.verify_started_at
+`
+
+type DeleteUnreferencedAttachmentParams struct {
+	ID            pgtype.UUID        `json:"id"`
+	UpdatedBefore pgtype.Timestamptz `json:"updated_before"`
+}
+
+// 删除前再次检查引用计数，期间被引用或更新过的附件不删除（不返回记录）
+func (q *Queries) DeleteUnreferencedAttachment(ctx context.Context, arg DeleteUnreferencedAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRow(ctx, deleteUnreferencedAttachment, arg.ID, arg.UpdatedBefore)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.ObjectKey,
+		&i.OriginalName,
+		&i.CoverObjectKey,
+		&i.MimeType,
+		&i.Md5,
+		&i.CoverMd5,
+		&i.FileSize,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.CoverStatus,
+		&i.CoverSizes,
+		&i.CoverAttempts,
+		&i.Sha256,
+		&i.UploadID,
+		&i.PartSize,
+		&i.VerifyStartedAt,
+	)
+	return i, err
+}
+
+const deleteUploadingAttachment = `-- name: DeleteUploadingAttachment :one
+DELETE FROM attachments
+WHERE id = $1 AND user_id = $2 AND status IN ('uploading', 'failed')
+    AND NOT EXISTS (SELECT 1 FROM moment_attachments ma WHERE ma.attachment_id = attachments.id)
+RETURNING id, object_key, original_name, cover_object_key, mime_type, md5, cover_md5, file_size, status, created_at, updated_at, user_id, cover_status, cover_sizes, cover_attempts, sha256, upload_id, part_size, verify_started_at
+`
+
+type DeleteUploadingAttachmentParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID int64       `json:"user_id"`
+}
+
+// 取消未完成或校验失败的上传
+func (q *Queries) DeleteUploadingAttachment(ctx context.Context, arg DeleteUploadingAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRow(ctx, deleteUploadingAttachment, arg.ID, arg.UserID)
 	var i Attachment
 	err := row.Scan(
 		&i.ID,
@@ -59,18 +359,43 @@ func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentPara
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
+		&i.CoverStatus,
+		&i.CoverSizes,
+		&i.CoverAttempts,
+		&i.Sha256,
+		&i.UploadID,
+		&i.PartSize,
+		&i.VerifyStartedAt,
 	)
 	return i, err
 }
 
+const failAttachmentVerification = `-- name: FailAttachmentVerification :exec
+UPDATE attachments
+SET status = 'failed'
+WHERE id = $1 AND status = 'verifying'
+`
+
+// 合并后的文件与声明的 SHA-256 或大小不一致，需要取消后重新上传
+func (q *Queries) FailAttachmentVerification(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, failAttachmentVerification, id)
+	return err
+}
+
 const findCompletedAttachmentByMD5 = `-- name: FindCompletedAttachmentByMD5 :one
-SELECT id, object_key, original_name, cover_object_key, mime_type, md5, cover_md5, file_size, status, created_at, updated_at FROM attachments
-WHERE md5 = $1 AND status = 'completed'
+SELECT id, object_key, original_name, cover_object_key, mime_type, md5, cover_md5, file_size, status, created_at, updated_at, user_id, cover_status, cover_sizes, cover_attempts, sha256, upload_id, part_size, verify_started_at FROM attachments
+WHERE md5 = $1 AND user_id = $2 AND status = 'completed'
 LIMIT 1
 `
 
-func (q *Queries) FindCompletedAttachmentByMD5(ctx context.Context, md5 string) (Attachment, error) {
-	row := q.db.QueryRow(ctx, findCompletedAttachmentByMD5, md5)
+type FindCompletedAttachmentByMD5Params struct {
+	Md5    string `json:"md5"`
+	UserID int64  `json:"user_id"`
+}
+
+func (q *Queries) FindCompletedAttachmentByMD5(ctx context.Context, arg FindCompletedAttachmentByMD5Params) (Attachment, error) {
+	row := q.db.QueryRow(ctx, findCompletedAttachmentByMD5, arg.Md5, arg.UserID)
 	var i Attachment
 	err := row.Scan(
 		&i.ID,
@@ -84,17 +409,68 @@ func (q *Queries) FindCompletedAttachmentByMD5(ctx context.Context, md5 string)
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
+		&i.CoverStatus,
+		&i.CoverSizes,
+		&i.CoverAttempts,
+		&i.Sha256,
+		&i.UploadID,
+		&i.PartSize,
+		&i.VerifyStartedAt,
+	)
+	return i, err
+}
+
+const findCompletedAttachmentBySHA256 = `-- name: FindCompletedAttachmentBySHA256 :one
+SELECT id, object_key, original_name, cover_object_key, mime_type, md5, cover_md5, file_size, status, created_at, updated_at, user_id, cover_status, cover_sizes, cover_attempts, sha256, upload_id, part_size, verify_started_at FROM attachments
+WHERE user_id = $1 AND sha256 = $2 AND status = 'completed'
+LIMIT 1
+`
+
+type FindCompletedAttachmentBySHA256Params struct {
+	UserID int64  `json:"user_id"`
+	Sha256 string `json:"sha256"`
+}
+
+func (q *Queries) FindCompletedAttachmentBySHA256(ctx context.Context, arg FindCompletedAttachmentBySHA256Params) (Attachment, error) {
+	row := q.db.QueryRow(ctx, findCompletedAttachmentBySHA256, arg.UserID, arg.Sha256)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.ObjectKey,
+		&i.OriginalName,
+		&i.CoverObjectKey,
+		&i.MimeType,
+		&i.Md5,
+		&i.CoverMd5,
+		&i.FileSize,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.CoverStatus,
+		&i.CoverSizes,
+		&i.CoverAttempts,
+		&i.Sha256,
+		&i.UploadID,
+		&i.PartSize,
+		&i.VerifyStartedAt,
 	)
 	return i, err
 }
 
 const getAttachmentById = `-- name: GetAttachmentById :one
-SELECT id, object_key, original_name, cover_object_key, mime_type, md5, cover_md5, file_size, status, created_at, updated_at FROM attachments
-WHERE id = $1
+SELECT id, object_key, original_name, cover_object_key, mime_type, md5, cover_md5, file_size, status, created_at, updated_at, user_id, cover_status, cover_sizes, cover_attempts, sha256, upload_id, part_size, verify_started_at FROM attachments
+WHERE id = $1 AND user_id = $2
 `
 
-func (q *Queries) GetAttachmentById(ctx context.Context, id pgtype.UUID) (Attachment, error) {
-	row := q.db.QueryRow(ctx, getAttachmentById, id)
+type GetAttachmentByIdParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID int64       `json:"user_id"`
+}
+
+func (q *Queries) GetAttachmentById(ctx context.Context, arg GetAttachmentByIdParams) (Attachment, error) {
+	row := q.db.QueryRow(ctx, getAttachmentById, arg.ID, arg.UserID)
 	var i Attachment
 	err := row.Scan(
 		&i.ID,
@@ -108,17 +484,30 @@ func (q *Queries) GetAttachmentById(ctx context.Context, id pgtype.UUID) (Attach
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
+		&i.CoverStatus,
+		&i.CoverSizes,
+		&i.CoverAttempts,
+		&i.Sha256,
+		&i.UploadID,
+		&i.PartSize,
+		&i.VerifyStartedAt,
 	)
 	return i, err
 }
 
 const getCompletedAttachmentCoverObjectKey = `-- name: GetCompletedAttachmentCoverObjectKey :one
 SELECT cover_object_key FROM attachments
-WHERE id = $1 AND status = 'completed'
+WHERE id = $1 AND user_id = $2 AND status = 'completed'
 `
 
-func (q *Queries) GetCompletedAttachmentCoverObjectKey(ctx context.Context, id pgtype.UUID) (string, error) {
-	row := q.db.QueryRow(ctx, getCompletedAttachmentCoverObjectKey, id)
+type GetCompletedAttachmentCoverObjectKeyParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID int64       `json:"user_id"`
+}
+
+func (q *Queries) GetCompletedAttachmentCoverObjectKey(ctx context.Context, arg GetCompletedAttachmentCoverObjectKeyParams) (string, error) {
+	row := q.db.QueryRow(ctx, getCompletedAttachmentCoverObjectKey, arg.ID, arg.UserID)
 	var cover_object_key string
 	err := row.Scan(&cover_object_key)
 	return cover_object_key, err
@@ -126,28 +515,289 @@ func (q *Queries) GetCompletedAttachmentCoverObjectKey(ctx context.Context, id p
 
 const getCompletedAttachmentObjectKey = `-- name: GetCompletedAttachmentObjectKey :one
 SELECT object_key FROM attachments
-WHERE id = $1 AND status = 'completed'
+WHERE id = $1 AND user_id = $2 AND status = 'completed'
 `
 
-func (q *Queries) GetCompletedAttachmentObjectKey(ctx context.Context, id pgtype.UUID) (string, error) {
-	row := q.db.QueryRow(ctx, getCompletedAttachmentObjectKey, id)
+type GetCompletedAttachmentObjectKeyParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID int64       `json:"user_id"`
+}
+
+func (q *Queries) GetCompletedAttachmentObjectKey(ctx context.Context, arg GetCompletedAttachmentObjectKeyParams) (string, error) {
+	row := q.db.QueryRow(ctx, getCompletedAttachmentObjectKey, arg.ID, arg.UserID)
 	var object_key string
 	err := row.Scan(&object_key)
 	return object_key, err
 }
 
+const listAttachmentObjectKeys = `-- name: ListAttachmentObjectKeys :many
+SELECT object_key, cover_object_key, cover_sizes FROM attachments
+`
+
+type ListAttachmentObjectKeysRow struct {
+	ObjectKey      string  `json:"object_key"`
+	CoverObjectKey string  `json:"cover_object_key"`
+	CoverSizes     []int32 `json:"cover_sizes"`
+}
+
+// 所有附件使用的对象键，服务端生成的封面键由 object_key 和 cover_sizes 推导
+func (q *Queries) ListAttachmentObjectKeys(ctx context.Context) ([]ListAttachmentObjectKeysRow, error) {
+	rows, err := q.db.Query(ctx, listAttachmentObjectKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAttachmentObjectKeysRow
+	for rows.Next() {
+		var i ListAttachmentObjectKeysRow
+		if err := rows.Scan(&i.ObjectKey, &i.CoverObjectKey, &i.CoverSizes); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGarbageAttachments = `-- name: ListGarbageAttachments :many
+SELECT a.id, a.object_key, a.original_name, a.cover_object_key, a.mime_type, a.md5, a.cover_md5, a.file_size, a.status, a.created_at, a.updated_at, a.user_id, a.cover_status, a.cover_sizes, a.cover_attempts, a.sha256, a.upload_id, a.part_size, a.verify_started_at FROM attachments a
+WHERE a.updated_at < $1
+    AND a.cover_status <> 'processing'
+    AND NOT EXISTS (SELECT 1 FROM moment_attachments ma WHERE ma.attachment_id = a.id)
+    AND NOT EXISTS (
+        SELECT 1 FROM moment_revisions mr
+        WHERE mr.attachments @> jsonb_build_array(jsonb_build_object('attachment_id', a.id::text))
+    )
+ORDER BY a.updated_at
+LIMIT $2
+`
+
+type ListGarbageAttachmentsParams struct {
+	UpdatedBefore pgtype.Timestamptz `json:"updated_before"`
+	BatchSize     int32              `json:"batch_size"`
+}
+
+// 列出超过宽限期且引用计数为 0 的附件：既没有被 moment 引用，也不在任何历史版本中
+// 包括未完成的上传和已完成但不再使用的附件，正在生成封面的附件留到下次
+func (q *Queries) ListGarbageAttachments(ctx context.Context, arg ListGarbageAttachmentsParams) ([]Attachment, error) {
+	rows, err := q.db.Query(ctx, listGarbageAttachments, arg.UpdatedBefore, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attachment
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.ObjectKey,
+			&i.OriginalName,
+			&i.CoverObjectKey,
+			&i.MimeType,
+			&i.Md5,
+			&i.CoverMd5,
+			&i.FileSize,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.CoverStatus,
+			&i.CoverSizes,
+			&i.CoverAttempts,
+			&i.Sha256,
+			&i.UploadID,
+			&i.PartSize,
+			&i.VerifyStartedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserAvatarKeys = `-- name: ListUserAvatarKeys :many
+SELECT avatar_key FROM users
+WHERE avatar_key <> ''
+`
+
+func (q *Queries) ListUserAvatarKeys(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, listUserAvatarKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var avatar_key string
+		if err := rows.Scan(&avatar_key); err != nil {
+			return nil, err
+		}
+		items = append(items, avatar_key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserGarbageAttachments = `-- name: ListUserGarbageAttachments :many
+SELECT a.id, a.object_key, a.original_name, a.cover_object_key, a.mime_type, a.md5, a.cover_md5, a.file_size, a.status, a.created_at, a.updated_at, a.user_id, a.cover_status, a.cover_sizes, a.cover_attempts, a.sha256, a.upload_id, a.part_size, a.verify_started_at FROM attachments a
+WHERE a.user_id = $1
+    AND a.updated_at < $2
+    AND a.cover_status <> 'processing'
+    AND NOT EXISTS (SELECT 1 FROM moment_attachments ma WHERE ma.attachment_id = a.id)
+    AND NOT EXISTS (
+        SELECT 1 FROM moment_revisions mr
+        WHERE mr.attachments @> jsonb_build_array(jsonb_build_object('attachment_id', a.id::text))
+    )
+ORDER BY a.updated_at
+`
+
+type ListUserGarbageAttachmentsParams struct {
+	UserID        int64              `json:"user_id"`
+	UpdatedBefore pgtype.Timestamptz `json:"updated_before"`
+}
+
+// 与 ListGarbageAttachments 条件相同，只列出一位用户的附件，用于预演报告
+func (q *Queries) ListUserGarbageAttachments(ctx context.Context, arg ListUserGarbageAttachmentsParams) ([]Attachment, error) {
+	rows, err := q.db.Query(ctx, listUserGarbageAttachments, arg.UserID, arg.UpdatedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attachment
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.ObjectKey,
+			&i.OriginalName,
+			&i.CoverObjectKey,
+			&i.MimeType,
+			&i.Md5,
+			&i.CoverMd5,
+			&i.FileSize,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.CoverStatus,
+			&i.CoverSizes,
+			&i.CoverAttempts,
+			&i.Sha256,
+			&i.UploadID,
+			&i.PartSize,
+			&i.VerifyStartedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markAttachmentVerifying = `-- name: MarkAttachmentVerifying :exec
+UPDATE attachments
+SET status = 'verifying',
+    upload_id = '',
+    verify_started_at = NULL
+WHERE id = $1 AND status = 'uploading'
+`
+
+// 分片合并后清空 upload_id 并等待后台任务校验
+func (q *Queries) MarkAttachmentVerifying(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markAttachmentVerifying, id)
+	return err
+}
+
+const markCoverReady = `-- name: MarkCoverReady :exec
+UPDATE attachments
+SET cover_status = 'ready',
+    cover_object_key = $1,
+    cover_md5 = $2,
+    cover_sizes = $3
+WHERE id = $4
+`
+
+type MarkCoverReadyParams struct {
+	CoverObjectKey string      `json:"cover_object_key"`
+	CoverMd5       string      `json:"cover_md5"`
+	CoverSizes     []int32     `json:"cover_sizes"`
+	ID             pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) MarkCoverReady(ctx context.Context, arg MarkCoverReadyParams) error {
+	_, err := q.db.Exec(ctx, markCoverReady,
+		arg.CoverObjectKey,
+		arg.CoverMd5,
+		arg.CoverSizes,
+		arg.ID,
+	)
+	return err
+}
+
+const releaseVerifyingAttachment = `-- name: ReleaseVerifyingAttachment :exec
+UPDATE attachments
+SET verify_started_at = NULL
+WHERE id = $1 AND status = 'verifying'
+`
+
+// 读取对象失败时放回等待领取，下次轮询重试
+func (q *Queries) ReleaseVerifyingAttachment(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, releaseVerifyingAttachment, id)
+	return err
+}
+
+const setCoverStatus = `-- name: SetCoverStatus :exec
+UPDATE attachments
+SET cover_status = $1
+WHERE id = $2
+`
+
+type SetCoverStatusParams struct {
+	CoverStatus string      `json:"cover_status"`
+	ID          pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) SetCoverStatus(ctx context.Context, arg SetCoverStatusParams) error {
+	_, err := q.db.Exec(ctx, setCoverStatus, arg.CoverStatus, arg.ID)
+	return err
+}
+
+const touchAttachment = `-- name: TouchAttachment :exec
+UPDATE attachments
+SET updated_at = NOW()
+WHERE id = $1
+`
+
+// 刷新 updated_at，推迟垃圾回收：去重命中的附件即将被新的 moment 引用，分片上传仍在进行
+func (q *Queries) TouchAttachment(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, touchAttachment, id)
+	return err
+}
+
 const updateAttachmentStatus = `-- name: UpdateAttachmentStatus :exec
 UPDATE attachments
 SET status = $1
-WHERE id = $2
+WHERE id = $2 AND user_id = $3
 `
 
 type UpdateAttachmentStatusParams struct {
 	Status string      `json:"status"`
 	ID     pgtype.UUID `json:"id"`
+	UserID int64       `json:"user_id"`
 }
 
 func (q *Queries) UpdateAttachmentStatus(ctx context.Context, arg UpdateAttachmentStatusParams) error {
-	_, err := q.db.Exec(ctx, updateAttachmentStatus, arg.Status, arg.ID)
+	_, err := q.db.Exec(ctx, updateAttachmentStatus, arg.Status, arg.ID, arg.UserID)
 	return err
 }
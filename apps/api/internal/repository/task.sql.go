@@ -12,19 +12,25 @@ import (
 )
 
 const createTask = `-- name: CreateTask :one
-INSERT INTO tasks (group_id, content, deadline)
-VALUES ($1, $2, $3)
-RETURNING id, group_id, content, status, deadline, created_at, updated_at
+INSERT INTO tasks (group_id, content, deadline, user_id)
+VALUES ($1, $2, $3, $4)
+RETURNING id, group_id, content, status, deadline, created_at, updated_at, user_id
 `
 
 type CreateTaskParams struct {
 	GroupID  int64              `json:"group_id"`
 	Content  string             `json:"content"`
 	Deadline pgtype.Timestamptz `json:"deadline"`
+	UserID   int64              `json:"user_id"`
 }
 
 func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error) {
-	row := q.db.QueryRow(ctx, createTask, arg.GroupID, arg.Content, arg.Deadline)
+	row := q.db.QueryRow(ctx, createTask,
+		arg.GroupID,
+		arg.Content,
+		arg.Deadline,
+		arg.UserID,
+	)
 	var i Task
 	err := row.Scan(
 		&i.ID,
@@ -34,26 +40,37 @@ func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, e
 		&i.Deadline,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
 	)
 	return i, err
 }
 
 const deleteTaskById = `-- name: DeleteTaskById :exec
 DELETE FROM tasks
-WHERE id = $1
+WHERE id = $1 AND user_id = $2
 `
 
-func (q *Queries) DeleteTaskById(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, deleteTaskById, id)
+type DeleteTaskByIdParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteTaskById(ctx context.Context, arg DeleteTaskByIdParams) error {
+	_, err := q.db.Exec(ctx, deleteTaskById, arg.ID, arg.UserID)
 	return err
 }
 
 const getTaskById = `-- name: GetTaskById :one
-SELECT id, group_id, content, status, deadline, created_at, updated_at FROM tasks WHERE id = $1
+SELECT id, group_id, content, status, deadline, created_at, updated_at, user_id FROM tasks WHERE id = $1 AND user_id = $2
 `
 
-func (q *Queries) GetTaskById(ctx context.Context, id int64) (Task, error) {
-	row := q.db.QueryRow(ctx, getTaskById, id)
+type GetTaskByIdParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) GetTaskById(ctx context.Context, arg GetTaskByIdParams) (Task, error) {
+	row := q.db.QueryRow(ctx, getTaskById, arg.ID, arg.UserID)
 	var i Task
 	err := row.Scan(
 		&i.ID,
@@ -63,21 +80,27 @@ func (q *Queries) GetTaskById(ctx context.Context, id int64) (Task, error) {
 		&i.Deadline,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
 	)
 	return i, err
 }
 
 const getTasksByGroupId = `-- name: GetTasksByGroupId :many
-SELECT id, group_id, content, status, deadline, created_at, updated_at FROM tasks
-WHERE group_id = $1
+SELECT id, group_id, content, status, deadline, created_at, updated_at, user_id FROM tasks
+WHERE group_id = $1 AND user_id = $2
 ORDER BY
     CASE WHEN deadline IS NULL THEN 1 ELSE 0 END,
     CASE WHEN deadline < NOW() THEN 0 ELSE 1 END,
     deadline ASC
 `
 
-func (q *Queries) GetTasksByGroupId(ctx context.Context, groupID int64) ([]Task, error) {
-	rows, err := q.db.Query(ctx, getTasksByGroupId, groupID)
+type GetTasksByGroupIdParams struct {
+	GroupID int64 `json:"group_id"`
+	UserID  int64 `json:"user_id"`
+}
+
+func (q *Queries) GetTasksByGroupId(ctx context.Context, arg GetTasksByGroupIdParams) ([]Task, error) {
+	rows, err := q.db.Query(ctx, getTasksByGroupId, arg.GroupID, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -93,6 +116,7 @@ func (q *Queries) GetTasksByGroupId(ctx context.Context, groupID int64) ([]Task,
 			&i.Deadline,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.UserID,
 		); err != nil {
 			return nil, err
 		}
@@ -106,12 +130,17 @@ func (q *Queries) GetTasksByGroupId(ctx context.Context, groupID int64) ([]Task,
 
 const taskExists = `-- name: TaskExists :one
 SELECT EXISTS(
-    SELECT 1 FROM tasks WHERE id = $1
+    SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2
 ) AS exists
 `
 
-func (q *Queries) TaskExists(ctx context.Context, id int64) (bool, error) {
-	row := q.db.QueryRow(ctx, taskExists, id)
+type TaskExistsParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) TaskExists(ctx context.Context, arg TaskExistsParams) (bool, error) {
+	row := q.db.QueryRow(ctx, taskExists, arg.ID, arg.UserID)
 	var exists bool
 	err := row.Scan(&exists)
 	return exists, err
@@ -124,8 +153,8 @@ SET
     deadline = $3,
     status = $4
 WHERE
-    id = $1
-RETURNING id, group_id, content, status, deadline, created_at, updated_at
+    id = $1 AND user_id = $5
+RETURNING id, group_id, content, status, deadline, created_at, updated_at, user_id
 `
 
 type UpdateTaskByIdParams struct {
@@ -133,6 +162,7 @@ type UpdateTaskByIdParams struct {
 	Content  string             `json:"content"`
 	Deadline pgtype.Timestamptz `json:"deadline"`
 	Status   TaskStatus         `json:"status"`
+	UserID   int64              `json:"user_id"`
 }
 
 func (q *Queries) UpdateTaskById(ctx context.Context, arg UpdateTaskByIdParams) (Task, error) {
@@ -141,6 +171,7 @@ func (q *Queries) UpdateTaskById(ctx context.Context, arg UpdateTaskByIdParams)
 		arg.Content,
 		arg.Deadline,
 		arg.Status,
+		arg.UserID,
 	)
 	var i Task
 	err := row.Scan(
@@ -151,6 +182,7 @@ func (q *Queries) UpdateTaskById(ctx context.Context, arg UpdateTaskByIdParams)
 		&i.Deadline,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
 	)
 	return i, err
 }
@@ -10,19 +10,25 @@ import (
 )
 
 const createTaskGroup = `-- name: CreateTaskGroup :one
-INSERT INTO task_groups (name, description, type)
-VALUES ($1, $2, $3)
-RETURNING id, name, description, type, created_at, updated_at
+INSERT INTO task_groups (name, description, type, user_id)
+VALUES ($1, $2, $3, $4)
+RETURNING id, name, description, type, created_at, updated_at, user_id
 `
 
 type CreateTaskGroupParams struct {
 	Name        string        `json:"name"`
 	Description string        `json:"description"`
 	Type        TaskGroupType `json:"type"`
+	UserID      int64         `json:"user_id"`
 }
 
 func (q *Queries) CreateTaskGroup(ctx context.Context, arg CreateTaskGroupParams) (TaskGroup, error) {
-	row := q.db.QueryRow(ctx, createTaskGroup, arg.Name, arg.Description, arg.Type)
+	row := q.db.QueryRow(ctx, createTaskGroup,
+		arg.Name,
+		arg.Description,
+		arg.Type,
+		arg.UserID,
+	)
 	var i TaskGroup
 	err := row.Scan(
 		&i.ID,
@@ -31,26 +37,32 @@ func (q *Queries) CreateTaskGroup(ctx context.Context, arg CreateTaskGroupParams
 		&i.Type,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
 	)
 	return i, err
 }
 
 const deleteTaskGroupById = `-- name: DeleteTaskGroupById :exec
 DELETE FROM task_groups
-WHERE id = $1
+WHERE id = $1 AND user_id = $2
 `
 
-func (q *Queries) DeleteTaskGroupById(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, deleteTaskGroupById, id)
+type DeleteTaskGroupByIdParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteTaskGroupById(ctx context.Context, arg DeleteTaskGroupByIdParams) error {
+	_, err := q.db.Exec(ctx, deleteTaskGroupById, arg.ID, arg.UserID)
 	return err
 }
 
 const getAllTaskGroups = `-- name: GetAllTaskGroups :many
-SELECT id, name, description, type, created_at, updated_at FROM task_groups ORDER BY updated_at DESC
+SELECT id, name, description, type, created_at, updated_at, user_id FROM task_groups WHERE user_id = $1 ORDER BY updated_at DESC
 `
 
-func (q *Queries) GetAllTaskGroups(ctx context.Context) ([]TaskGroup, error) {
-	rows, err := q.db.Query(ctx, getAllTaskGroups)
+func (q *Queries) GetAllTaskGroups(ctx context.Context, userID int64) ([]TaskGroup, error) {
+	rows, err := q.db.Query(ctx, getAllTaskGroups, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -65,6 +77,7 @@ func (q *Queries) GetAllTaskGroups(ctx context.Context) ([]TaskGroup, error) {
 			&i.Type,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.UserID,
 		); err != nil {
 			return nil, err
 		}
@@ -77,11 +90,16 @@ func (q *Queries) GetAllTaskGroups(ctx context.Context) ([]TaskGroup, error) {
 }
 
 const getTaskGroupById = `-- name: GetTaskGroupById :one
-SELECT id, name, description, type, created_at, updated_at FROM task_groups WHERE id = $1
+SELECT id, name, description, type, created_at, updated_at, user_id FROM task_groups WHERE id = $1 AND user_id = $2
 `
 
-func (q *Queries) GetTaskGroupById(ctx context.Context, id int64) (TaskGroup, error) {
-	row := q.db.QueryRow(ctx, getTaskGroupById, id)
+type GetTaskGroupByIdParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) GetTaskGroupById(ctx context.Context, arg GetTaskGroupByIdParams) (TaskGroup, error) {
+	row := q.db.QueryRow(ctx, getTaskGroupById, arg.ID, arg.UserID)
 	var i TaskGroup
 	err := row.Scan(
 		&i.ID,
@@ -90,16 +108,22 @@ func (q *Queries) GetTaskGroupById(ctx context.Context, id int64) (TaskGroup, er
 		&i.Type,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
 	)
 	return i, err
 }
 
 const getTaskGroupByName = `-- name: GetTaskGroupByName :one
-SELECT id, name, description, type, created_at, updated_at FROM task_groups WHERE name = $1
+SELECT id, name, description, type, created_at, updated_at, user_id FROM task_groups WHERE name = $1 AND user_id = $2
 `
 
-func (q *Queries) GetTaskGroupByName(ctx context.Context, name string) (TaskGroup, error) {
-	row := q.db.QueryRow(ctx, getTaskGroupByName, name)
+type GetTaskGroupByNameParams struct {
+	Name   string `json:"name"`
+	UserID int64  `json:"user_id"`
+}
+
+func (q *Queries) GetTaskGroupByName(ctx context.Context, arg GetTaskGroupByNameParams) (TaskGroup, error) {
+	row := q.db.QueryRow(ctx, getTaskGroupByName, arg.Name, arg.UserID)
 	var i TaskGroup
 	err := row.Scan(
 		&i.ID,
@@ -108,16 +132,22 @@ func (q *Queries) GetTaskGroupByName(ctx context.Context, name string) (TaskGrou
 		&i.Type,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
 	)
 	return i, err
 }
 
 const getTaskGroupsByType = `-- name: GetTaskGroupsByType :many
-SELECT id, name, description, type, created_at, updated_at FROM task_groups WHERE type = $1 ORDER BY updated_at DESC
+SELECT id, name, description, type, created_at, updated_at, user_id FROM task_groups WHERE type = $1 AND user_id = $2 ORDER BY updated_at DESC
 `
 
-func (q *Queries) GetTaskGroupsByType(ctx context.Context, type_ TaskGroupType) ([]TaskGroup, error) {
-	rows, err := q.db.Query(ctx, getTaskGroupsByType, type_)
+type GetTaskGroupsByTypeParams struct {
+	Type   TaskGroupType `json:"type"`
+	UserID int64         `json:"user_id"`
+}
+
+func (q *Queries) GetTaskGroupsByType(ctx context.Context, arg GetTaskGroupsByTypeParams) ([]TaskGroup, error) {
+	rows, err := q.db.Query(ctx, getTaskGroupsByType, arg.Type, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -132,6 +162,7 @@ func (q *Queries) GetTaskGroupsByType(ctx context.Context, type_ TaskGroupType)
 			&i.Type,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.UserID,
 		); err != nil {
 			return nil, err
 		}
@@ -145,12 +176,17 @@ func (q *Queries) GetTaskGroupsByType(ctx context.Context, type_ TaskGroupType)
 
 const taskGroupExists = `-- name: TaskGroupExists :one
 SELECT EXISTS(
-    SELECT 1 FROM task_groups WHERE id = $1
+    SELECT 1 FROM task_groups WHERE id = $1 AND user_id = $2
 ) AS exists
 `
 
-func (q *Queries) TaskGroupExists(ctx context.Context, id int64) (bool, error) {
-	row := q.db.QueryRow(ctx, taskGroupExists, id)
+type TaskGroupExistsParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) TaskGroupExists(ctx context.Context, arg TaskGroupExistsParams) (bool, error) {
+	row := q.db.QueryRow(ctx, taskGroupExists, arg.ID, arg.UserID)
 	var exists bool
 	err := row.Scan(&exists)
 	return exists, err
@@ -163,8 +199,8 @@ SET
     description = $2,
     type = $3
 WHERE
-    id = $4
-RETURNING id, name, description, type, created_at, updated_at
+    id = $4 AND user_id = $5
+RETURNING id, name, description, type, created_at, updated_at, user_id
 `
 
 type UpdateTaskGroupByIdParams struct {
@@ -172,6 +208,7 @@ type UpdateTaskGroupByIdParams struct {
 	Description string        `json:"description"`
 	Type        TaskGroupType `json:"type"`
 	ID          int64         `json:"id"`
+	UserID      int64         `json:"user_id"`
 }
 
 func (q *Queries) UpdateTaskGroupById(ctx context.Context, arg UpdateTaskGroupByIdParams) (TaskGroup, error) {
@@ -180,6 +217,7 @@ func (q *Queries) UpdateTaskGroupById(ctx context.Context, arg UpdateTaskGroupBy
 		arg.Description,
 		arg.Type,
 		arg.ID,
+		arg.UserID,
 	)
 	var i TaskGroup
 	err := row.Scan(
@@ -189,6 +227,7 @@ func (q *Queries) UpdateTaskGroupById(ctx context.Context, arg UpdateTaskGroupBy
 		&i.Type,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
 	)
 	return i, err
 }
@@ -23,9 +23,9 @@ func (q *Queries) CheckUserExists(ctx context.Context) (bool, error) {
 }
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (email, name, password_hash, birthday, avatar_base64, bio)
+INSERT INTO users (email, name, password_hash, birthday, bio, timezone)
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, email, name, password_hash, birthday, avatar_base64, bio, created_at, updated_at
+RETURNING id, email, name, password_hash, birthday, avatar_base64, bio, timezone, created_at, updated_at, email_verified_at, avatar_key
 `
 
 type CreateUserParams struct {
@@ -33,8 +33,8 @@ type CreateUserParams struct {
 	Name         string      `json:"name"`
 	PasswordHash string      `json:"password_hash"`
 	Birthday     pgtype.Date `json:"birthday"`
-	AvatarBase64 string      `json:"avatar_base64"`
 	Bio          string      `json:"bio"`
+	Timezone     string      `json:"timezone"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -43,8 +43,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.Name,
 		arg.PasswordHash,
 		arg.Birthday,
-		arg.AvatarBase64,
 		arg.Bio,
+		arg.Timezone,
 	)
 	var i User
 	err := row.Scan(
@@ -55,8 +55,11 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Birthday,
 		&i.AvatarBase64,
 		&i.Bio,
+		&i.Timezone,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
+		&i.AvatarKey,
 	)
 	return i, err
 }
@@ -70,12 +73,12 @@ func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
 	return err
 }
 
-const getUser = `-- name: GetUser :one
-SELECT id, email, name, password_hash, birthday, avatar_base64, bio, created_at, updated_at FROM users LIMIT 1
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, email, name, password_hash, birthday, avatar_base64, bio, timezone, created_at, updated_at, email_verified_at, avatar_key FROM users WHERE email = $1 LIMIT 1
 `
 
-func (q *Queries) GetUser(ctx context.Context) (User, error) {
-	row := q.db.QueryRow(ctx, getUser)
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -85,18 +88,21 @@ func (q *Queries) GetUser(ctx context.Context) (User, error) {
 		&i.Birthday,
 		&i.AvatarBase64,
 		&i.Bio,
+		&i.Timezone,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
+		&i.AvatarKey,
 	)
 	return i, err
 }
 
-const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, name, password_hash, birthday, avatar_base64, bio, created_at, updated_at FROM users WHERE email = $1 LIMIT 1
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, email, name, password_hash, birthday, avatar_base64, bio, timezone, created_at, updated_at, email_verified_at, avatar_key FROM users WHERE id = $1 LIMIT 1
 `
 
-func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByEmail, email)
+func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByID, id)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -106,8 +112,11 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Birthday,
 		&i.AvatarBase64,
 		&i.Bio,
+		&i.Timezone,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
+		&i.AvatarKey,
 	)
 	return i, err
 }
@@ -123,35 +132,109 @@ func (q *Queries) GetUserCount(ctx context.Context) (int64, error) {
 	return count, err
 }
 
-const updateUser = `-- name: UpdateUser :one
+const getUserTimezone = `-- name: GetUserTimezone :one
+SELECT timezone FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserTimezone(ctx context.Context, id int64) (string, error) {
+	row := q.db.QueryRow(ctx, getUserTimezone, id)
+	var timezone string
+	err := row.Scan(&timezone)
+	return timezone, err
+}
+
+const listUsersWithInlineAvatar = `-- name: ListUsersWithInlineAvatar :many
+SELECT id, avatar_base64 FROM users
+WHERE avatar_base64 <> '' AND avatar_key = ''
+ORDER BY id
+`
+
+type ListUsersWithInlineAvatarRow struct {
+	ID           int64  `json:"id"`
+	AvatarBase64 string `json:"avatar_base64"`
+}
+
+// 仍使用内联头像、需要迁移到对象存储的用户
+func (q *Queries) ListUsersWithInlineAvatar(ctx context.Context) ([]ListUsersWithInlineAvatarRow, error) {
+	rows, err := q.db.Query(ctx, listUsersWithInlineAvatar)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersWithInlineAvatarRow
+	for rows.Next() {
+		var i ListUsersWithInlineAvatarRow
+		if err := rows.Scan(&i.ID, &i.AvatarBase64); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateUserAvatar = `-- name: UpdateUserAvatar :one
+UPDATE users AS u
+SET avatar_key = $2, avatar_base64 = ''
+FROM users AS old
+WHERE u.id = $1 AND old.id = u.id
+RETURNING old.avatar_key
+`
+
+type UpdateUserAvatarParams struct {
+	ID        int64  `json:"id"`
+	AvatarKey string `json:"avatar_key"`
+}
+
+// 设置头像前缀并清空旧版内联头像，返回原来的前缀用于删除旧头像对象
+func (q *Queries) UpdateUserAvatar(ctx context.Context, arg UpdateUserAvatarParams) (string, error) {
+	row := q.db.QueryRow(ctx, updateUserAvatar, arg.ID, arg.AvatarKey)
+	var avatar_key string
+	err := row.Scan(&avatar_key)
+	return avatar_key, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID           int64  `json:"id"`
+	PasswordHash string `json:"password_hash"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+	return err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
 UPDATE users
 SET
-    email = $2,
-    name = $3,
-    birthday = $4,
-    avatar_base64 = $5,
-    bio = $6
+    name = $2,
+    birthday = $3,
+    bio = $4
 WHERE
     id = $1
-RETURNING id, email, name, password_hash, birthday, avatar_base64, bio, created_at, updated_at
+RETURNING id, email, name, password_hash, birthday, avatar_base64, bio, timezone, created_at, updated_at, email_verified_at, avatar_key
 `
 
-type UpdateUserParams struct {
-	ID           int64       `json:"id"`
-	Email        string      `json:"email"`
-	Name         string      `json:"name"`
-	Birthday     pgtype.Date `json:"birthday"`
-	AvatarBase64 string      `json:"avatar_base64"`
-	Bio          string      `json:"bio"`
+type UpdateUserProfileParams struct {
+	ID       int64       `json:"id"`
+	Name     string      `json:"name"`
+	Birthday pgtype.Date `json:"birthday"`
+	Bio      string      `json:"bio"`
 }
 
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRow(ctx, updateUser,
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserProfile,
 		arg.ID,
-		arg.Email,
 		arg.Name,
 		arg.Birthday,
-		arg.AvatarBase64,
 		arg.Bio,
 	)
 	var i User
@@ -163,24 +246,76 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Birthday,
 		&i.AvatarBase64,
 		&i.Bio,
+		&i.Timezone,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
+		&i.AvatarKey,
 	)
 	return i, err
 }
 
-const updateUserPassword = `-- name: UpdateUserPassword :exec
+const updateUserTimezone = `-- name: UpdateUserTimezone :one
 UPDATE users
-SET password_hash = $2
+SET timezone = $2
 WHERE id = $1
+RETURNING id, email, name, password_hash, birthday, avatar_base64, bio, timezone, created_at, updated_at, email_verified_at, avatar_key
 `
 
-type UpdateUserPasswordParams struct {
-	ID           int64  `json:"id"`
-	PasswordHash string `json:"password_hash"`
+type UpdateUserTimezoneParams struct {
+	ID       int64  `json:"id"`
+	Timezone string `json:"timezone"`
 }
 
-func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
-	_, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
-	return err
+func (q *Queries) UpdateUserTimezone(ctx context.Context, arg UpdateUserTimezoneParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserTimezone, arg.ID, arg.Timezone)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.PasswordHash,
+		&i.Birthday,
+		&i.AvatarBase64,
+		&i.Bio,
+		&i.Timezone,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
+		&i.AvatarKey,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+SET email = $2, email_verified_at = NOW()
+WHERE id = $1
+RETURNING id, email, name, password_hash, birthday, avatar_base64, bio, timezone, created_at, updated_at, email_verified_at, avatar_key
+`
+
+type VerifyUserEmailParams struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+}
+
+// 邮箱验证通过，修改邮箱时同时更新为新地址
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, verifyUserEmail, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.PasswordHash,
+		&i.Birthday,
+		&i.AvatarBase64,
+		&i.Bio,
+		&i.Timezone,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EmailVerifiedAt,
+		&i.AvatarKey,
+	)
+	return i, err
 }
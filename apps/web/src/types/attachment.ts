@@ -3,13 +3,16 @@ export type PresignedUploadResponse = {
     upload_url?: string;
     cover_upload_url?: string;
     is_duplicate: boolean;
+    cover_status: CoverStatus;
 };
 
+export type CoverStatus = "pending" | "processing" | "ready" | "failed" | "unsupported";
+
 export type PresignedUploadBody = {
     file_name: string;
     mime_type: string;
-    cover_ext: string;
+    cover_ext?: string;
     file_size: number;
-    cover_md5: string;
+    cover_md5?: string;
     md5: string;
 };
@@ -1,3 +1,5 @@
+import type { CoverStatus } from "@/types/attachment";
+
 export type Moment = {
     id: number;
     content: string;
@@ -13,6 +15,9 @@ export type MomentAttachment = {
     mime_type: string;
     file_size: number;
     position: number;
+    cover_url?: string;
+    cover_urls?: Record<string, string>;
+    cover_status: CoverStatus;
 };
 
 export type MomentCreate = {
@@ -11,8 +11,11 @@ export interface User {
     email: string;
     name: string;
     birthday: string;
-    avatar_base64: string;
+    avatar_url: string;
+    avatar_urls?: Record<string, string>;
+    email_verified: boolean;
     bio: string;
+    timezone: string;
     created_at: string;
     updated_at: string;
 }
@@ -6,7 +6,7 @@ export const genNameFromType = (type: TaskGroupType) => {
         case "day":
             return format(new Date(), "yyyy-MM-dd");
         case "week":
-            return format(new Date(), "yyyy-'W'ww");
+            return format(new Date(), "RRRR-'W'II");
         case "month":
             return format(new Date(), "yyyy-MM");
         case "year":